@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/mdns"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// advertiseRefresh is how often the advertised allocations are re-read.
+const advertiseRefresh = 30 * time.Second
+
+// advertiseType is the DNS-SD service type of advertised allocations.
+const advertiseType = "_http._tcp"
+
+// runAdvertise announces the listening allocations whose names are in advertiseNames
+// via mDNS/DNS-SD until interrupted.
+func runAdvertise(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(cfg.AdvertiseNames) == 0 {
+		return fmt.Errorf("nothing to advertise: list allocation names in advertiseNames (e.g., advertiseNames: [web])")
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	host, err := localHost()
+	if err != nil {
+		return err
+	}
+	owner := currentUserName()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var last string
+	list := func() []mdns.Service {
+		store, err := allocations.Load(configDir)
+		if err != nil {
+			stderrf("warning: failed to load allocations: %v\n", err)
+			return nil
+		}
+		services := advertisedServices(store, cfg.AdvertiseNames, owner, port.IsPortFree)
+		if summary := describeAdvertised(host, services); summary != last {
+			fmt.Print(summary)
+			last = summary
+		}
+		return services
+	}
+
+	stderrf("Advertising %s on %s.local via mDNS, Ctrl+C to stop\n", strings.Join(cfg.AdvertiseNames, ", "), host.Name)
+	return mdns.Advertise(ctx, host, advertiseRefresh, list)
+}
+
+// advertisedServices returns the listening allocations whose names are in names,
+// as DNS-SD services named "<owner>'s <project> (<name>)". External allocations
+// are never advertised: they belong to other programs.
+func advertisedServices(store *allocations.Store, names []string, owner string, isPortFree allocations.PortChecker) []mdns.Service {
+	allowed := make(map[string]bool, len(names))
+	for _, n := range names {
+		allowed[n] = true
+	}
+
+	var services []mdns.Service
+	for _, a := range store.SortedByPort() {
+		if !allowed[a.Name] || a.Status == allocations.StatusExternal || isPortFree(a.Port) {
+			continue
+		}
+		project := filepath.Base(a.Directory)
+		services = append(services, mdns.Service{
+			Instance: fmt.Sprintf("%s's %s (%s)", owner, project, a.Name),
+			Type:     advertiseType,
+			Port:     a.Port,
+			TXT:      []string{"user=" + owner, "project=" + project, "name=" + a.Name},
+		})
+	}
+	return services
+}
+
+// describeAdvertised lists the advertised services, one per line.
+func describeAdvertised(host mdns.Host, services []mdns.Service) string {
+	if len(services) == 0 {
+		return "No listening allocations to advertise\n"
+	}
+	addr := host.Name + ".local"
+	if len(host.IPs) > 0 {
+		addr = host.IPs[0].String()
+	}
+	var b strings.Builder
+	for _, s := range services {
+		fmt.Fprintf(&b, "%s is on %s:%d\n", s.Instance, addr, s.Port)
+	}
+	return b.String()
+}
+
+// localHost returns the short host name and the non-loopback IPv4 addresses of this machine.
+func localHost() (mdns.Host, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return mdns.Host{}, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	name, _, _ = strings.Cut(name, ".")
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return mdns.Host{}, fmt.Errorf("failed to list network addresses: %w", err)
+	}
+	host := mdns.Host{Name: name}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			host.IPs = append(host.IPs, ipnet.IP.To4())
+		}
+	}
+	if len(host.IPs) == 0 {
+		return mdns.Host{}, fmt.Errorf("no IPv4 network address to advertise on")
+	}
+	debug.Printf("main", "advertising as %s.local on %v", host.Name, host.IPs)
+	return host, nil
+}
+
+// currentUserName returns the login name shown in advertised instance names.
+func currentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		_, name, _ := strings.Cut(u.Username, `\`) // DOMAIN\user on Windows
+		if name != "" {
+			return name
+		}
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestAdvertisedServices(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/code/shop-api", 3014, "web")
+	store.SetAllocationWithName("/code/shop-api", 3015, "db")
+	store.SetAllocationWithName("/code/blog", 3016, "web")
+	store.SetExternalAllocation(3017, 99, "bob", "nginx", "/srv")
+	isPortFree := func(p int) bool { return p == 3016 }
+
+	services := advertisedServices(store, []string{"web", "main"}, "alice", isPortFree)
+	if len(services) != 1 {
+		t.Fatalf("advertisedServices() = %+v, want only the listening web allocation", services)
+	}
+	s := services[0]
+	if s.Instance != "alice's shop-api (web)" || s.Port != 3014 || s.Type != advertiseType {
+		t.Errorf("service = %+v", s)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// aliasPattern matches valid aliases (used as @alias in place of a directory).
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// validateAlias checks that alias can be referenced as @alias.
+func validateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("invalid alias %q (use letters, digits, '.', '_' and '-')", alias)
+	}
+	return nil
+}
+
+// dirResolver returns a function that turns a directory argument into an absolute path.
+// "@alias" is looked up in the allocations of configDir; "~" is expanded.
+func dirResolver(configDir string) func(string) (string, error) {
+	var store *allocations.Store
+	return func(arg string) (string, error) {
+		alias, isAlias := strings.CutPrefix(arg, "@")
+		if !isAlias {
+			dir, err := filepath.Abs(pathutil.ExpandHome(arg))
+			if err != nil {
+				return "", err
+			}
+			return normalizeDir(dir), nil
+		}
+		if store == nil {
+			var err error
+			if store, err = allocations.Load(configDir); err != nil {
+				return "", err
+			}
+		}
+		dir := store.FindDirectoryByAlias(alias)
+		if dir == "" {
+			return "", fmt.Errorf("unknown alias @%s (see 'port-selector alias list')", alias)
+		}
+		return dir, nil
+	}
+}
+
+// runAlias manages directory aliases: set NAME, clear, list.
+func runAlias(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	sub, rest := args[0], args[1:]
+	switch {
+	case sub == "set" && len(rest) == 1:
+	case (sub == "clear" || sub == "list") && len(rest) == 0:
+	default:
+		return fmt.Errorf("usage: port-selector alias set NAME | clear | list")
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	if sub == "list" {
+		return listAliases(configDir)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	alias := ""
+	if sub == "set" {
+		alias = strings.TrimPrefix(rest[0], "@")
+		if err := validateAlias(alias); err != nil {
+			return err
+		}
+	}
+
+	var count int
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		if alias != "" {
+			if other := store.FindDirectoryByAlias(alias); other != "" && other != cwd {
+				return fmt.Errorf("alias @%s is already used by %s", alias, pathutil.ShortenHomePath(other))
+			}
+		}
+		if len(store.GetAllocatedPortsForDirectory(cwd)) == 0 {
+			return fmt.Errorf("no allocations for %s (run port-selector first)", pathutil.ShortenHomePath(cwd))
+		}
+		count = store.SetAlias(cwd, alias)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if alias == "" {
+		fmt.Printf("Cleared alias for %s\n", pathutil.ShortenHomePath(cwd))
+		return nil
+	}
+	fmt.Printf("Alias @%s -> %s (%d allocation(s) updated)\n", alias, pathutil.ShortenHomePath(cwd), count)
+	return nil
+}
+
+// listAliases prints all aliases with their directories.
+func listAliases(configDir string) error {
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+
+	dirs := make(map[string]string)
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Alias != "" {
+			dirs[alloc.Alias] = alloc.Directory
+		}
+	}
+	if len(dirs) == 0 {
+		fmt.Println("No aliases defined.")
+		return nil
+	}
+
+	aliases := make([]string, 0, len(dirs))
+	for a := range dirs {
+		aliases = append(aliases, a)
+	}
+	sort.Strings(aliases)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALIAS\tDIRECTORY")
+	for _, a := range aliases {
+		fmt.Fprintf(w, "@%s\t%s\n", a, pathutil.ShortenHomePath(dirs[a]))
+	}
+	return w.Flush()
+}
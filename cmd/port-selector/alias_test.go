@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestValidateAlias(t *testing.T) {
+	for _, alias := range []string{"myshop", "shop-2", "a.b_c"} {
+		if err := validateAlias(alias); err != nil {
+			t.Errorf("validateAlias(%q) error = %v", alias, err)
+		}
+	}
+	for _, alias := range []string{"", "-x", "my shop", "a/b", "@x"} {
+		if err := validateAlias(alias); err == nil {
+			t.Errorf("validateAlias(%q) expected error", alias)
+		}
+	}
+}
+
+func TestDirResolver(t *testing.T) {
+	configDir := t.TempDir()
+	store := allocations.NewStore()
+	store.SetAllocation("/code/shop", 3000)
+	store.SetAlias("/code/shop", "myshop")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := dirResolver(configDir)
+	if dir, err := resolve("@myshop"); err != nil || dir != "/code/shop" {
+		t.Errorf("resolve(@myshop) = %q, %v", dir, err)
+	}
+	if dir, err := resolve("/tmp/x/../y"); err != nil || dir != "/tmp/y" {
+		t.Errorf("resolve(path) = %q, %v", dir, err)
+	}
+	if _, err := resolve("@missing"); err == nil {
+		t.Error("expected error for unknown alias")
+	}
+}
+
+func TestAlias(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	workDir := filepath.Join(tmpDir, "very", "long", "worktree", "path")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("portStart: 3970\nportEnd: 3979\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("alias", "set", "myshop"); err == nil {
+		t.Errorf("expected alias without allocations to fail, got: %s", output)
+	}
+	if output, err := run(); err != nil {
+		t.Fatalf("allocation failed: %v\n%s", err, output)
+	}
+	if output, err := run("alias", "set", "myshop"); err != nil || !strings.Contains(output, "@myshop") {
+		t.Fatalf("alias set = %q, %v", output, err)
+	}
+
+	output, err := run("--list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "ALIAS") || !strings.Contains(output, "@myshop") {
+		t.Errorf("expected alias in --list:\n%s", output)
+	}
+
+	if output, err := run("alias", "list"); err != nil || !strings.Contains(output, "@myshop") {
+		t.Errorf("alias list = %q, %v", output, err)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest describes the services of a project to allocate ports for.
+//
+//	services:
+//	  - name: web
+//	    lock: true
+//	    labels:
+//	      team: payments
+//	  - name: api
+type manifest struct {
+	Services []manifestService `yaml:"services"`
+}
+
+// manifestService is a single named allocation in a manifest.
+type manifestService struct {
+	Name   string            `yaml:"name"`
+	Lock   bool              `yaml:"lock,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// appliedService is the result of applying a single manifest service.
+type appliedService struct {
+	Name   string
+	Port   int
+	Locked bool
+}
+
+// loadManifest reads and validates a manifest file.
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if len(m.Services) == 0 {
+		return nil, fmt.Errorf("manifest %s has no services", path)
+	}
+
+	seen := make(map[string]bool)
+	for i, svc := range m.Services {
+		if svc.Name == "" {
+			return nil, fmt.Errorf("manifest service #%d has no name", i+1)
+		}
+		if seen[svc.Name] {
+			return nil, fmt.Errorf("manifest service %q is listed twice", svc.Name)
+		}
+		seen[svc.Name] = true
+		for k, v := range svc.Labels {
+			if _, _, _, err := allocations.ParseLabel(k + "=" + v); err != nil {
+				return nil, fmt.Errorf("manifest service %q: %w", svc.Name, err)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// dotenvKey converts a service name to an environment variable name (web-api -> WEB_API_PORT).
+func dotenvKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String() + "_PORT"
+}
+
+// runApply allocates (and optionally locks) all services from a manifest in one transaction.
+func runApply(args []string) error {
+	var manifestPath string
+	format := "summary"
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires a value")
+			}
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown option: %s", arg)
+		case manifestPath == "":
+			manifestPath = arg
+		default:
+			return fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if manifestPath == "" {
+		return fmt.Errorf("apply requires a manifest file (e.g., port-selector apply services.yaml)")
+	}
+	if format != "summary" && format != "dotenv" {
+		return fmt.Errorf("invalid format %q (use summary or dotenv)", format)
+	}
+
+	m, err := loadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	debug.Printf("main", "applying manifest %s with %d services", manifestPath, len(m.Services))
+
+	results, err := applyServices(configDir, cfg, cwd, m.Services)
+	if err != nil {
+		return err
+	}
+
+	if format == "dotenv" {
+		for _, r := range results {
+			fmt.Printf("%s=%d\n", dotenvKey(r.Name), r.Port)
+		}
+		return nil
+	}
+	return printApplied(results)
+}
+
+// applyServices allocates (and optionally locks and labels) services for cwd in one transaction.
+func applyServices(configDir string, cfg *config.Config, cwd string, services []manifestService) ([]appliedService, error) {
+	var results []appliedService
+	err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+		results = nil
+
+		if removed := store.RemoveExpired(cfg.GetAllocationTTL()); removed > 0 {
+			debug.Printf("main", "removed %d expired allocations", removed)
+		}
+
+		for _, svc := range services {
+			p, err := allocatePort(store, cfg, cwd, svc.Name)
+			if err != nil {
+				return fmt.Errorf("service %s: %w", svc.Name, err)
+			}
+
+			alloc := store.FindByPort(p)
+			locked := alloc != nil && alloc.Locked
+			if svc.Lock && !locked {
+				if !store.SetLockedByPort(p, true) {
+					return fmt.Errorf("internal error: failed to lock port %d for service %s", p, svc.Name)
+				}
+				store.UnlockOtherLockedPorts(cwd, svc.Name, p)
+				locked = true
+			}
+
+			if len(svc.Labels) > 0 {
+				store.SetLabels(p, svc.Labels)
+			}
+
+			results = append(results, appliedService{Name: svc.Name, Port: p, Locked: locked})
+		}
+		return nil
+	})
+	return results, err
+}
+
+// printApplied prints the NAME/PORT/LOCKED table of applied services.
+func printApplied(results []appliedService) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPORT\tLOCKED")
+	for _, r := range results {
+		locked := ""
+		if r.Locked {
+			locked = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", r.Name, r.Port, locked)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+		want    int
+	}{
+		{
+			name:    "valid manifest",
+			content: "services:\n  - name: web\n    lock: true\n  - name: api\n",
+			want:    2,
+		},
+		{
+			name:    "no services",
+			content: "services: []\n",
+			wantErr: "has no services",
+		},
+		{
+			name:    "missing name",
+			content: "services:\n  - lock: true\n",
+			wantErr: "has no name",
+		},
+		{
+			name:    "duplicate name",
+			content: "services:\n  - name: web\n  - name: web\n",
+			wantErr: "listed twice",
+		},
+		{
+			name:    "invalid yaml",
+			content: "services: [",
+			wantErr: "failed to parse manifest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "services.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := loadManifest(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(m.Services) != tt.want {
+				t.Errorf("expected %d services, got %d", tt.want, len(m.Services))
+			}
+		})
+	}
+}
+
+func TestDotenvKey(t *testing.T) {
+	tests := map[string]string{
+		"web":      "WEB_PORT",
+		"web-api":  "WEB_API_PORT",
+		"worker.1": "WORKER_1_PORT",
+	}
+	for name, want := range tests {
+		if got := dotenvKey(name); got != want {
+			t.Errorf("dotenvKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestApply_AllocatesAndLocksServices(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifestPath := filepath.Join(workDir, "services.yaml")
+	manifest := "services:\n  - name: web\n    lock: true\n  - name: api\n"
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "apply", "services.yaml", "--format", "dotenv")
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if !strings.Contains(string(output), "WEB_PORT=") || !strings.Contains(string(output), "API_PORT=") {
+		t.Errorf("expected dotenv output, got: %s", output)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := store.FindByDirectoryAndName(workDir, "web")
+	api := store.FindByDirectoryAndName(workDir, "api")
+	if web == nil || api == nil {
+		t.Fatalf("expected web and api allocations, got %v", store.SortedByPort())
+	}
+	if !web.Locked {
+		t.Error("expected web to be locked")
+	}
+	if api.Locked {
+		t.Error("expected api to stay unlocked")
+	}
+	if web.Port == api.Port {
+		t.Errorf("expected different ports, both got %d", web.Port)
+	}
+}
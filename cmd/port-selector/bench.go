@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+// Defaults of `bench`.
+const (
+	defaultBenchParallel   = 10
+	defaultBenchIterations = 20
+)
+
+// benchResult summarizes a bench run.
+type benchResult struct {
+	ops     int             // successful allocations
+	failed  int             // allocations that returned an error
+	elapsed time.Duration   // wall time of the whole run
+	waits   []time.Duration // lock wait of every locked operation, sorted
+	final   int             // allocations in the store at the end
+	lastErr error           // last allocation error, if any
+}
+
+// runBench exercises concurrent allocation against a temporary store with the
+// configured backend and range, and reports lock wait times and throughput.
+func runBench(args []string) error {
+	parallel, iterations := defaultBenchParallel, defaultBenchIterations
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var target *int
+		switch {
+		case arg == "--parallel" || strings.HasPrefix(arg, "--parallel="):
+			target = &parallel
+		case arg == "--iterations" || strings.HasPrefix(arg, "--iterations="):
+			target = &iterations
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+		flag, value, found := strings.Cut(arg, "=")
+		if !found {
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a number", flag)
+			}
+			i++
+			value = args[i]
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid %s value: %s", flag, value)
+		}
+		*target = n
+	}
+
+	if allocations.IsDryRun() || allocations.IsReadOnly() {
+		return fmt.Errorf("bench writes to a temporary store and cannot run with --dry-run or --read-only")
+	}
+
+	// The user's log is left alone: bench changes only its own temporary store
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Store == allocations.BackendRemote {
+		return fmt.Errorf("bench does not support store %s", allocations.BackendRemote)
+	}
+	if err := allocations.SetBackend(cfg.Store); err != nil {
+		return err
+	}
+	if size := cfg.PortEnd - cfg.PortStart + 1; size < parallel {
+		return fmt.Errorf("port range %d-%d is too small for %d workers", cfg.PortStart, cfg.PortEnd, parallel)
+	}
+
+	dir, err := os.MkdirTemp("", "port-selector-bench-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary store: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	allocations.SetStoreFile("")
+	allocations.SetHost("")
+
+	backend := cfg.Store
+	if backend == "" {
+		backend = allocations.BackendYAML
+	}
+	fmt.Printf("Bench: %d workers x %d allocations (%s store, range %d-%d)\n",
+		parallel, iterations, backend, cfg.PortStart, cfg.PortEnd)
+
+	res, err := benchAllocations(dir, cfg, parallel, iterations)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  operations:  %d ok, %d failed in %s (%.1f ops/s)\n",
+		res.ops, res.failed, res.elapsed.Round(time.Millisecond), float64(res.ops)/res.elapsed.Seconds())
+	fmt.Printf("  lock wait:   p50 %s  p95 %s  p99 %s  max %s\n",
+		percentile(res.waits, 50), percentile(res.waits, 95), percentile(res.waits, 99), percentile(res.waits, 100))
+	if res.lastErr != nil {
+		fmt.Printf("  last error:  %v\n", res.lastErr)
+	}
+	if res.final != parallel {
+		return fmt.Errorf("store holds %d allocations, expected %d (lost updates)", res.final, parallel)
+	}
+	fmt.Printf("  consistency: %d allocations, as expected\n", res.final)
+	if res.failed > 0 {
+		return fmt.Errorf("%d of %d allocations failed", res.failed, parallel*iterations)
+	}
+	return nil
+}
+
+// benchAllocations runs parallel workers against the store in configDir. Each
+// iteration is one locked transaction that drops the worker's allocation and
+// allocates a new port, so the store ends with exactly one allocation per worker
+// unless updates were lost.
+func benchAllocations(configDir string, cfg *config.Config, parallel, iterations int) (*benchResult, error) {
+	var mu sync.Mutex
+	res := &benchResult{}
+	allocations.SetLockWaitObserver(func(wait time.Duration) {
+		mu.Lock()
+		res.waits = append(res.waits, wait)
+		mu.Unlock()
+	})
+	defer allocations.SetLockWaitObserver(nil)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			dir := fmt.Sprintf("/port-selector-bench/worker-%d", w)
+			for i := 0; i < iterations; i++ {
+				err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+					store.RemoveByDirectoryAndName(dir, "main")
+					_, err := allocatePort(store, cfg, dir, "main")
+					return err
+				})
+				mu.Lock()
+				if err != nil {
+					res.failed++
+					res.lastErr = err
+				} else {
+					res.ops++
+				}
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	res.elapsed = time.Since(start)
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bench store: %w", err)
+	}
+	res.final = store.Count()
+	sort.Slice(res.waits, func(i, j int) bool { return res.waits[i] < res.waits[j] })
+	return res, nil
+}
+
+// percentile returns the p-th percentile of sorted durations (nearest rank).
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].Round(time.Microsecond)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestBenchAllocations(t *testing.T) {
+	cfg := &config.Config{PortStart: 47400, PortEnd: 47499}
+	res, err := benchAllocations(t.TempDir(), cfg, 4, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ops != 20 || res.failed != 0 {
+		t.Errorf("ops = %d, failed = %d (%v); want 20, 0", res.ops, res.failed, res.lastErr)
+	}
+	if res.final != 4 {
+		t.Errorf("final = %d, want one allocation per worker", res.final)
+	}
+	if len(res.waits) != 20 {
+		t.Errorf("recorded %d lock waits, want 20", len(res.waits))
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var waits []time.Duration
+	for i := 1; i <= 100; i++ {
+		waits = append(waits, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[int]time.Duration{50: 50 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(waits, p); got != want {
+			t.Errorf("percentile(%d) = %s, want %s", p, got, want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile(empty) = %s, want 0", got)
+	}
+}
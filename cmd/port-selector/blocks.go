@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// composeFiles are the file names that make a directory a compose project.
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// isComposeProject reports whether dir contains a compose file.
+func isComposeProject(dir string) bool {
+	for _, name := range composeFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findPortWithBlocks finds a free port for dir. Compose projects get their ports from
+// the project's block (reserving a new block for the first one); everyone else avoids
+// other projects' blocks while the rest of the range has free ports.
+// blockEnd is 0 when the port is not part of a block.
+func findPortWithBlocks(store *allocations.Store, cfg *config.Config, dir string, lastUsed int, excluded map[int]bool) (p, blockStart, blockEnd int, err error) {
+	reserved := store.ReservedBlockPorts(dir)
+	avoid := make(map[int]bool, len(excluded)+len(reserved))
+	for p := range excluded {
+		avoid[p] = true
+	}
+	for p := range reserved {
+		avoid[p] = true
+	}
+
+	if size := cfg.GetComposeBlockSize(); size > 1 && isComposeProject(dir) {
+		if start, end := store.BlockFor(dir); end > 0 {
+			p, err := port.FindFreePortWithExclusions(start, end, start-1, excluded)
+			switch {
+			case err == nil:
+				return p, start, end, nil
+			case errors.Is(err, port.ErrSearchTimeout):
+				return 0, 0, 0, err
+			}
+			debug.Printf("main", "block %d-%d of %s is full", start, end, dir)
+		} else {
+			start, err := port.FindFreeBlock(cfg.PortStart, cfg.PortEnd, lastUsed, size, avoid)
+			switch {
+			case err == nil:
+				debug.Printf("main", "reserving block %d-%d for compose project %s", start, start+size-1, dir)
+				return start, start, start + size - 1, nil
+			case errors.Is(err, port.ErrSearchTimeout):
+				return 0, 0, 0, err
+			}
+		}
+	}
+
+	if len(reserved) > 0 {
+		p, err := port.FindFreePortWithExclusions(cfg.PortStart, cfg.PortEnd, lastUsed, avoid)
+		switch {
+		case err == nil:
+			return p, 0, 0, nil
+		case errors.Is(err, port.ErrSearchTimeout):
+			return 0, 0, 0, err
+		}
+		debug.Printf("main", "only ports in other projects' blocks are free")
+	}
+	p, err = port.FindFreePortWithExclusions(cfg.PortStart, cfg.PortEnd, lastUsed, excluded)
+	return p, 0, 0, err
+}
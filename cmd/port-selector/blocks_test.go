@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestAllocatePort_ComposeBlock(t *testing.T) {
+	tmpDir := t.TempDir()
+	project := filepath.Join(tmpDir, "shop")
+	other := filepath.Join(tmpDir, "blog")
+	for _, dir := range []string{project, other} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(project, "compose.yaml"), []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{PortStart: 47100, PortEnd: 47130, ComposeBlockSize: 5}
+	store := allocations.NewStore()
+
+	web, err := allocatePort(store, cfg, project, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := store.BlockFor(project)
+	if start != web || end != web+4 {
+		t.Fatalf("block = %d-%d, want %d-%d", start, end, web, web+4)
+	}
+
+	blog, err := allocatePort(store, cfg, other, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blog >= start && blog <= end {
+		t.Errorf("other project got port %d inside block %d-%d", blog, start, end)
+	}
+
+	api, err := allocatePort(store, cfg, project, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api < start || api > end {
+		t.Errorf("api port %d outside block %d-%d", api, start, end)
+	}
+	if a := store.FindByPort(api); a.BlockStart != start || a.BlockEnd != end {
+		t.Errorf("api allocation block = %d-%d, want %d-%d", a.BlockStart, a.BlockEnd, start, end)
+	}
+}
+
+func TestAllocatePort_Preferred(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte("preferred:\n  web: 47155\n  api: 47156\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{PortStart: 47140, PortEnd: 47160}
+	store := allocations.NewStore()
+	store.SetAllocationWithName(filepath.Join(t.TempDir(), "other"), 47156, "main")
+
+	if p, err := allocatePort(store, cfg, dir, "web"); err != nil || p != 47155 {
+		t.Errorf("allocatePort(web) = %d, %v; want preferred 47155", p, err)
+	}
+	// 47156 is allocated elsewhere: fall back to the search
+	if p, err := allocatePort(store, cfg, dir, "api"); err != nil || p == 47156 {
+		t.Errorf("allocatePort(api) = %d, %v; want a port other than 47156", p, err)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// exitCheckFailed is the exit code returned by --check when the allocation
+// exists but is not served by the directory's process.
+const exitCheckFailed = 2
+
+// errCheckFailed is returned by runCheck when the health check does not pass.
+var errCheckFailed = errors.New("check failed")
+
+// checkResult is the --check outcome, printed as JSON with --json.
+type checkResult struct {
+	Directory     string `json:"directory"`
+	Name          string `json:"name"`
+	Port          int    `json:"port,omitempty"`
+	Allocated     bool   `json:"allocated"`
+	Listening     bool   `json:"listening"`
+	PID           int    `json:"pid,omitempty"`
+	Process       string `json:"process,omitempty"`
+	ProcessCwd    string `json:"process_cwd,omitempty"`
+	OwnerVerified bool   `json:"owner_verified"` // false if the listener's cwd could not be read
+	HealthPath    string `json:"health_path,omitempty"`
+	HTTPStatus    int    `json:"http_status,omitempty"` // status of GET HealthPath
+	Healthy       bool   `json:"healthy"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// checkAllocation evaluates the allocation of (cwd, name) against the process listening on it.
+// A listener whose cwd cannot be determined (e.g., another user's process) is not
+// treated as a mismatch, but OwnerVerified is false. An allocation with a health
+// path is healthy only if probe returns 2xx or 3xx.
+func checkAllocation(alloc *allocations.Allocation, isPortFree allocations.PortChecker, getProcess func(int) *port.ProcessInfo, probe healthProbe) checkResult {
+	var r checkResult
+	if alloc == nil {
+		r.Reason = "no allocation"
+		return r
+	}
+	r.Allocated = true
+	r.Directory = alloc.Directory
+	r.Name = alloc.Name
+	r.Port = alloc.Port
+	r.HealthPath = alloc.HealthPath
+
+	if isPortFree(alloc.Port) {
+		r.Reason = "port is not listening"
+		return r
+	}
+	r.Listening = true
+
+	if info := getProcess(alloc.Port); info != nil {
+		r.PID = info.PID
+		r.Process = info.Name
+		r.ProcessCwd = info.Cwd
+	}
+	if r.ProcessCwd != "" {
+		r.OwnerVerified = true
+		if !pathutil.IsWithin(r.ProcessCwd, alloc.Directory) {
+			r.Reason = fmt.Sprintf("port is used by a process in %s", pathutil.ShortenHomePath(r.ProcessCwd))
+			return r
+		}
+	}
+
+	if alloc.HealthPath != "" {
+		status, err := probe(alloc.Port, alloc.HealthPath)
+		r.HTTPStatus = status
+		if err != nil || !healthOK(status) {
+			r.Reason = describeHealthFailure(alloc.HealthPath, status, err)
+			return r
+		}
+	}
+
+	r.Healthy = true
+	if !r.OwnerVerified {
+		r.Reason = "listener's working directory is unknown"
+	}
+	return r
+}
+
+// runCheck verifies that the allocation for (cwd, name) is listening and served
+// from the directory. Returns errCheckFailed if not.
+func runCheck(name string, args []string) error {
+	jsonOutput := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			jsonOutput = true
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	// Offline every port looks free, which would pass for "not listening"
+	if err := requireChecks("--check"); err != nil {
+		return err
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+
+	r := checkAllocation(store.FindByDirectoryAndName(cwd, name), port.IsPortFree, port.GetPortProcess, probeHealth)
+	if !r.Allocated {
+		r.Directory = cwd
+		r.Name = name
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	} else if r.Healthy {
+		fmt.Printf("ok: port %d ('%s') is listening", r.Port, r.Name)
+		if r.Process != "" {
+			fmt.Printf(" (%s)", r.Process)
+		}
+		if r.HTTPStatus != 0 {
+			fmt.Printf(", GET %s: %d", r.HealthPath, r.HTTPStatus)
+		}
+		fmt.Println()
+	}
+
+	if !r.Healthy {
+		if !r.Allocated {
+			return fmt.Errorf("%w: no allocation for %s with name '%s'", errCheckFailed, pathutil.ShortenHomePath(cwd), name)
+		}
+		return fmt.Errorf("%w: port %d ('%s'): %s", errCheckFailed, r.Port, r.Name, r.Reason)
+	}
+	return nil
+}
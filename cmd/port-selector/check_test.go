@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+func TestCheckAllocation(t *testing.T) {
+	alloc := &allocations.Allocation{Port: 3000, Directory: "/code/shop", Name: "web"}
+	free := func(int) bool { return true }
+	busy := func(int) bool { return false }
+	process := func(cwd string) func(int) *port.ProcessInfo {
+		return func(int) *port.ProcessInfo {
+			return &port.ProcessInfo{PID: 42, Name: "node", Cwd: cwd}
+		}
+	}
+
+	tests := []struct {
+		name          string
+		alloc         *allocations.Allocation
+		isPortFree    allocations.PortChecker
+		getProcess    func(int) *port.ProcessInfo
+		wantHealthy   bool
+		wantVerified  bool
+		wantListening bool
+	}{
+		{"no allocation", nil, busy, process("/code/shop"), false, false, false},
+		{"not listening", alloc, free, process("/code/shop"), false, false, false},
+		{"served from directory", alloc, busy, process("/code/shop/web"), true, true, true},
+		{"served from other directory", alloc, busy, process("/code/other"), false, true, true},
+		{"unknown owner", alloc, busy, process(""), true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := checkAllocation(tt.alloc, tt.isPortFree, tt.getProcess, nil)
+			if r.Healthy != tt.wantHealthy || r.OwnerVerified != tt.wantVerified || r.Listening != tt.wantListening {
+				t.Errorf("checkAllocation() = %+v, want healthy=%v verified=%v listening=%v",
+					r, tt.wantHealthy, tt.wantVerified, tt.wantListening)
+			}
+		})
+	}
+}
+
+func TestCheckAllocation_HealthPath(t *testing.T) {
+	alloc := &allocations.Allocation{Port: 3000, Directory: "/code/shop", Name: "web", HealthPath: "/healthz"}
+	busy := func(int) bool { return false }
+	process := func(int) *port.ProcessInfo { return &port.ProcessInfo{PID: 42, Name: "node", Cwd: "/code/shop"} }
+	respond := func(status int, err error) healthProbe {
+		return func(p int, path string) (int, error) {
+			if p != 3000 || path != "/healthz" {
+				t.Errorf("probe(%d, %q), want (3000, /healthz)", p, path)
+			}
+			return status, err
+		}
+	}
+
+	if r := checkAllocation(alloc, busy, process, respond(200, nil)); !r.Healthy || r.HTTPStatus != 200 {
+		t.Errorf("200: %+v, want healthy", r)
+	}
+	r := checkAllocation(alloc, busy, process, respond(503, nil))
+	if r.Healthy || r.HTTPStatus != 503 || !strings.Contains(r.Reason, "503") {
+		t.Errorf("503: %+v, want unhealthy with status in reason", r)
+	}
+	if r := checkAllocation(alloc, busy, process, respond(0, errors.New("connection reset"))); r.Healthy {
+		t.Errorf("probe error: %+v, want unhealthy", r)
+	}
+}
+
+func TestCheck_ExitCodes(t *testing.T) {
+	binary := buildBinary(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	listenPort := ln.Addr().(*net.TCPAddr).Port
+
+	// The listener is this test process, so its cwd is the package directory
+	pkgDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	otherDir := filepath.Join(tmpDir, "other")
+	if err := os.MkdirAll(otherDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocationWithName(pkgDir, listenPort, "main")
+	store.SetAllocationWithName(otherDir, listenPort+1, "main")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	t.Run("healthy", func(t *testing.T) {
+		cmd := exec.Command(binary, "--check", "--json")
+		cmd.Dir = pkgDir
+		cmd.Env = env
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("expected exit 0, got %v\n%s", err, output)
+		}
+		var r checkResult
+		if err := json.Unmarshal(output, &r); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, output)
+		}
+		if !r.Healthy || r.Port != listenPort {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	})
+
+	t.Run("not listening", func(t *testing.T) {
+		cmd := exec.Command(binary, "--check")
+		cmd.Dir = otherDir
+		cmd.Env = env
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitCheckFailed {
+			t.Errorf("expected exit code %d, got %v", exitCheckFailed, err)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/i18n"
+)
+
+// noColor is set by --no-color.
+var noColor bool
+
+// ANSI foreground colors. Every code has two digits, so wrapping the cells of a
+// column in any of them grows each cell by the same number of bytes and keeps
+// tabwriter alignment.
+const (
+	colorRed     = "31"
+	colorGreen   = "32"
+	colorYellow  = "33"
+	colorMagenta = "35"
+	colorDefault = "39"
+)
+
+// Colors of the SOURCE, STATUS, LOCKED and EXPIRES cells of --list.
+var (
+	sourceColors  = map[string]string{"external": colorMagenta, "lock": colorYellow}
+	statusColors  = map[string]string{"free": colorGreen, "busy": colorRed}
+	lockedColors  = map[string]string{"yes": colorYellow}
+	expiresColors = map[string]string{"expired": colorRed}
+)
+
+// isTerminal reports whether f is attached to a terminal.
+// /dev/null is a character device too, so it is excluded explicitly.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(fi, null)
+}
+
+// colorEnabled reports whether output to f is colored: f must be a terminal,
+// and neither --no-color, NO_COLOR (any value) nor TERM=dumb may be set.
+func colorEnabled(f *os.File) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && isTerminal(f)
+}
+
+// colorize wraps s in the ANSI color code.
+func colorize(code, s string) string {
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// colorCell colorizes a table cell when enabled. A cell without a color of
+// its own gets the default color, so all cells of the column stay aligned.
+func colorCell(enabled bool, code, s string) string {
+	if !enabled {
+		return s
+	}
+	if code == "" {
+		code = colorDefault
+	}
+	return colorize(code, s)
+}
+
+// stderrf prints a localized message to stderr. On a terminal, the leading
+// "error:" label is red and "warning:" is yellow.
+func stderrf(format string, args ...any) {
+	msg := i18n.Sprintf(format, args...)
+	if colorEnabled(os.Stderr) {
+		code := ""
+		switch {
+		case strings.HasPrefix(format, "error:"):
+			code = colorRed
+		case strings.HasPrefix(format, "warning:"):
+			code = colorYellow
+		}
+		if label, rest, ok := strings.Cut(msg, ":"); ok && code != "" {
+			msg = colorize(code, label+":") + rest
+		}
+	}
+	fmt.Fprint(os.Stderr, msg)
+}
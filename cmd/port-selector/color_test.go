@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"text/tabwriter"
+)
+
+func TestColorCell_KeepsAlignment(t *testing.T) {
+	rows := [][2]string{{"PORT", "STATUS"}, {"3000", "free"}, {"3001", "busy"}, {"3002", "-"}}
+	render := func(color bool) string {
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		for i, r := range rows {
+			status := colorCell(color, statusColors[r[1]], r[1])
+			if i == 0 {
+				status = colorCell(color, "", r[1])
+			}
+			fmt.Fprintf(w, "%s\t%s\tnext\n", r[0], status)
+		}
+		w.Flush()
+		return buf.String()
+	}
+
+	plain := render(false)
+	colored := render(true)
+	if plain == colored {
+		t.Fatal("expected colored output")
+	}
+	if stripped := regexp.MustCompile("\x1b\\[[0-9]+m").ReplaceAllString(colored, ""); stripped != plain {
+		t.Errorf("colored table is misaligned:\n%s\nwant:\n%s", stripped, plain)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if colorEnabled(f) {
+		t.Error("colorEnabled() = true for a regular file")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(os.Stdout) {
+		t.Error("colorEnabled() = true with NO_COLOR set")
+	}
+}
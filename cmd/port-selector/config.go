@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/logger"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// defaultEditor is used by `config edit` when neither $VISUAL nor $EDITOR is set.
+const defaultEditor = "vi"
+
+// runConfig manages the config file: get KEY, set KEY VALUE, edit, validate, path.
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: port-selector config get KEY | set KEY VALUE | edit | validate | path")
+	}
+	sub, rest := args[0], args[1:]
+
+	wantArgs := map[string]int{"get": 1, "set": 2, "edit": 0, "validate": 0, "path": 0}
+	n, ok := wantArgs[sub]
+	if !ok {
+		return fmt.Errorf("unknown config command: %s (use get, set, edit, validate or path)", sub)
+	}
+	if len(rest) != n {
+		return fmt.Errorf("config %s takes %d argument(s), got %d", sub, n, len(rest))
+	}
+
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "path":
+		fmt.Println(path)
+		return nil
+	case "validate":
+		if err := config.ValidateFile(path); err != nil {
+			return fmt.Errorf("%s: %w", pathutil.ShortenHomePath(path), err)
+		}
+		fmt.Printf("%s is valid\n", pathutil.ShortenHomePath(path))
+		return nil
+	}
+
+	// Load creates the default config file if it doesn't exist yet
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	switch sub {
+	case "get":
+		value, err := cfg.Get(rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	case "set":
+		return configSet(path, rest[0], rest[1])
+	default: // edit
+		return configEdit(path)
+	}
+}
+
+// configSet rewrites a single key in the config file, keeping comments intact.
+func configSet(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	updated, name, err := config.SetValue(data, key, value)
+	if err != nil {
+		return err
+	}
+	if allocations.IsDryRun() {
+		stderrf("dry-run: would set %s = %s\n", name, value)
+		return nil
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	logger.Log(logger.ConfigSet, logger.Field("key", name), logger.Field("value", value))
+	fmt.Printf("%s = %s\n", name, value)
+	return nil
+}
+
+// configEdit opens the config file in $VISUAL/$EDITOR and validates it afterwards.
+func configEdit(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	// $EDITOR may include arguments (e.g., "code --wait")
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", parts[0], err)
+	}
+
+	if err := config.ValidateFile(path); err != nil {
+		return fmt.Errorf("%s is invalid: %w (run 'port-selector config edit' to fix)", pathutil.ShortenHomePath(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigCommand(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	configPath := filepath.Join(configDir, "config.yaml")
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"), "EDITOR=true", "VISUAL=")
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, append([]string{"config"}, args...)...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	// get creates the default config
+	if output, err := run("get", "portEnd"); err != nil || strings.TrimSpace(output) != "4000" {
+		t.Fatalf("config get portEnd = %q, %v", output, err)
+	}
+
+	if output, err := run("set", "freezePeriod", "12h"); err != nil {
+		t.Fatalf("config set failed: %v\n%s", err, output)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "freezePeriod: 12h\n") || !strings.Contains(string(data), "# Time to avoid reusing") {
+		t.Errorf("expected updated value with comments preserved:\n%s", data)
+	}
+
+	if output, err := run("set", "portEnd", "10"); err == nil {
+		t.Errorf("expected invalid value to be rejected, got: %s", output)
+	}
+
+	if output, err := run("validate"); err != nil || !strings.Contains(output, "is valid") {
+		t.Errorf("config validate = %q, %v", output, err)
+	}
+	if output, err := run("edit"); err != nil {
+		t.Errorf("config edit with EDITOR=true failed: %v\n%s", err, output)
+	}
+
+	if err := os.WriteFile(configPath, append(data, "portStrat: 1\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := run("validate"); err == nil || !strings.Contains(output, "portStrat") {
+		t.Errorf("expected unknown key to fail validation, got %q, %v", output, err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+// confirmInput, confirmOutput and confirmIsTerminal are replaced in tests.
+var (
+	confirmInput      io.Reader = os.Stdin
+	confirmOutput     io.Writer = os.Stderr
+	confirmIsTerminal           = stdinIsTerminal
+)
+
+// stdinIsTerminal reports whether stdin is attached to a terminal.
+func stdinIsTerminal() bool {
+	return isTerminal(os.Stdin)
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+// It returns true without asking if yes is set or in dry-run mode (nothing is
+// written then). Without a terminal it refuses, so scripts must pass --yes.
+func confirm(question string, yes bool) (bool, error) {
+	if yes || allocations.IsDryRun() {
+		return true, nil
+	}
+	if !confirmIsTerminal() {
+		return false, fmt.Errorf("confirmation required; re-run with --yes")
+	}
+	fmt.Fprintf(confirmOutput, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(confirmInput).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// errPortConflict is returned with onConflict: fail when the port of an existing
+// allocation is taken by a process outside its directory.
+var errPortConflict = errors.New("port conflict")
+
+// isForeignHolder reports whether the process on the allocation's port runs outside
+// the allocation's directory (an unidentified process counts as foreign).
+func isForeignHolder(alloc *allocations.Allocation, procInfo *port.ProcessInfo) bool {
+	if procInfo != nil && procInfo.Cwd != "" && pathutil.IsWithin(procInfo.Cwd, alloc.Directory) {
+		debug.Printf("main", "port %d is held by a process in %s, not a conflict", alloc.Port, procInfo.Cwd)
+		return false
+	}
+	return true
+}
+
+// describeHolder names the process on a busy port for messages.
+func describeHolder(procInfo *port.ProcessInfo) string {
+	holder := "another process"
+	if procInfo != nil && procInfo.Name != "" {
+		holder = procInfo.Name
+		if procInfo.Cwd != "" {
+			holder += " in " + pathutil.ShortenHomePath(procInfo.Cwd)
+		}
+	}
+	return holder
+}
+
+// resolveConflict applies the onConflict policy to an existing allocation whose port
+// is taken by a foreign process. Returns reallocate=true if the allocation was dropped
+// and a new port must be allocated.
+func resolveConflict(store *allocations.Store, policy string, alloc *allocations.Allocation, procInfo *port.ProcessInfo) (reallocate bool, err error) {
+	switch policy {
+	case config.ConflictFail:
+		return false, fmt.Errorf("%w: port %d of %s ('%s') is taken by %s (onConflict: fail)",
+			errPortConflict, alloc.Port, pathutil.ShortenHomePath(alloc.Directory), alloc.Name, describeHolder(procInfo))
+	case config.ConflictReallocate:
+		stderrf("warning: port %d is taken by %s; allocating a new port\n", alloc.Port, describeHolder(procInfo))
+		store.RemoveByDirectoryAndName(alloc.Directory, alloc.Name)
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestParseAllocOptions_OnConflict(t *testing.T) {
+	opts, _, err := parseAllocOptions([]string{"--on-conflict", "fail"})
+	if err != nil || opts.onConflict != config.ConflictFail {
+		t.Errorf("parseAllocOptions(--on-conflict fail) = %+v, %v", opts, err)
+	}
+	for _, bad := range [][]string{{"--on-conflict"}, {"--on-conflict=ignore"}} {
+		if _, _, err := parseAllocOptions(bad); err == nil {
+			t.Errorf("parseAllocOptions(%v) expected error", bad)
+		}
+	}
+}
+
+func TestAllocatePort_OnConflict(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+	dir := filepath.Join(t.TempDir(), "project") // the test process runs elsewhere
+
+	tests := []struct {
+		policy  string
+		wantErr bool
+		newPort bool
+	}{
+		{config.ConflictReuse, false, false},
+		{config.ConflictFail, true, false},
+		{config.ConflictReallocate, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			store := allocations.NewStore()
+			store.SetAllocationWithName(dir, busy, "main")
+			cfg := &config.Config{PortStart: 47170, PortEnd: 47190, OnConflict: tt.policy}
+
+			p, err := allocatePort(store, cfg, dir, "main")
+			if tt.wantErr {
+				if !errors.Is(err, errPortConflict) {
+					t.Fatalf("expected errPortConflict, got %d, %v", p, err)
+				}
+				if store.FindByPort(busy) == nil {
+					t.Error("expected the allocation to be kept")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.newPort == (p == busy) {
+				t.Errorf("allocatePort() = %d (busy port %d), want new port: %v", p, busy, tt.newPort)
+			}
+		})
+	}
+
+	// Locked ports are reallocated only with verifyOwner, and stay locked
+	store := allocations.NewStore()
+	store.SetAllocationWithName(dir, busy, "main")
+	store.SetLockedByPort(busy, true)
+	cfg := &config.Config{PortStart: 47170, PortEnd: 47190, OnConflict: config.ConflictReallocate}
+	if p, err := allocatePort(store, cfg, dir, "main"); err != nil || p != busy {
+		t.Errorf("allocatePort(locked) = %d, %v; want %d", p, err, busy)
+	}
+	cfg.VerifyOwner = true
+	p, err := allocatePort(store, cfg, dir, "main")
+	if err != nil || p == busy {
+		t.Fatalf("allocatePort(locked, verifyOwner) = %d, %v; want a new port", p, err)
+	}
+	if a := store.FindByPort(p); a == nil || !a.Locked {
+		t.Errorf("expected new port %d to be locked, got %v", p, a)
+	}
+}
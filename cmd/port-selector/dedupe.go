@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runDedupe stores the directories of all allocations the way the working
+// directory is stored now (symlinks resolved unless symlinks: keep), and
+// merges the allocations that turn out to be duplicates. Allocations made
+// before normalization existed are migrated this way.
+func runDedupe(args []string) error {
+	// --dry-run is a global flag extracted by parseArgs
+	dryRun := allocations.IsDryRun()
+	for _, arg := range args {
+		if arg != "-n" {
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+		dryRun = true
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	var changes []allocations.DirectoryChange
+	if dryRun {
+		store, err := allocations.Load(configDir)
+		if err != nil {
+			return err
+		}
+		changes = store.NormalizeDirectories(normalizeDir)
+	} else {
+		err = allocations.WithJournal(configDir, "dedupe", func(store *allocations.Store) error {
+			changes = store.NormalizeDirectories(normalizeDir)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	moved, removed := "Moved", "Removed"
+	if dryRun {
+		moved, removed = "Would move", "Would remove"
+	}
+	movedCount, merged := 0, 0
+	for _, c := range changes {
+		if c.DuplicateOf > 0 {
+			merged++
+			fmt.Printf("%s port %d (%s, '%s'): duplicate of port %d\n",
+				removed, c.Port, pathutil.ShortenHomePath(c.Directory), c.Name, c.DuplicateOf)
+			continue
+		}
+		if c.To != c.Directory {
+			movedCount++
+			fmt.Printf("%s port %d ('%s'): %s -> %s\n",
+				moved, c.Port, c.Name, pathutil.ShortenHomePath(c.Directory), pathutil.ShortenHomePath(c.To))
+		}
+		if c.LockedDuplicateOf > 0 {
+			stderrf("warning: ports %d and %d are both locked for %s ('%s'); both are kept, forget one with 'port-selector --forget %d'\n",
+				c.LockedDuplicateOf, c.Port, pathutil.ShortenHomePath(c.To), c.Name, c.Port)
+		}
+	}
+
+	switch {
+	case len(changes) == 0:
+		fmt.Println("No duplicate directories found.")
+	case dryRun:
+		fmt.Printf("%d allocation(s) would be moved, %d merged.\n", movedCount, merged)
+	default:
+		fmt.Printf("Moved %d allocation(s), merged %d.\n", movedCount, merged)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// devcontainerConfig is the part of devcontainer.json that forwards ports.
+// Codespaces and the Dev Containers extension forward forwardPorts and show
+// the portsAttributes label in the Ports view.
+type devcontainerConfig struct {
+	ForwardPorts    []int                            `json:"forwardPorts"`
+	PortsAttributes map[string]devcontainerPortAttrs `json:"portsAttributes"`
+}
+
+// devcontainerPortAttrs is one entry of portsAttributes.
+type devcontainerPortAttrs struct {
+	Label         string `json:"label"`
+	OnAutoForward string `json:"onAutoForward,omitempty"`
+}
+
+// devcontainerConfigFor returns the forwarding settings for the project's
+// named allocations, labeled by name.
+func devcontainerConfigFor(project vscodeProject, onAutoForward string) devcontainerConfig {
+	cfg := devcontainerConfig{
+		ForwardPorts:    []int{},
+		PortsAttributes: make(map[string]devcontainerPortAttrs),
+	}
+	for _, a := range project.Allocations {
+		cfg.ForwardPorts = append(cfg.ForwardPorts, a.Port)
+		cfg.PortsAttributes[strconv.Itoa(a.Port)] = devcontainerPortAttrs{Label: a.Name, OnAutoForward: onAutoForward}
+	}
+	return cfg
+}
+
+// validAutoForward reports whether action is a devcontainer onAutoForward value
+// ("" leaves the editor default).
+func validAutoForward(action string) bool {
+	switch action {
+	case "", "notify", "openBrowser", "openPreview", "silent", "ignore":
+		return true
+	}
+	return false
+}
+
+// runDevcontainer prints forwardPorts and portsAttributes for the current
+// directory's allocations, to paste into devcontainer.json or merge from a
+// devcontainer feature's lifecycle script.
+func runDevcontainer(args []string) error {
+	var onAutoForward string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--on-auto-forward":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--on-auto-forward requires a value")
+			}
+			onAutoForward = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--on-auto-forward="):
+			onAutoForward = strings.TrimPrefix(arg, "--on-auto-forward=")
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+	if !validAutoForward(onAutoForward) {
+		return fmt.Errorf("invalid --on-auto-forward %q (use notify, openBrowser, openPreview, silent or ignore)", onAutoForward)
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	project := vscodeProjectFor(store, cwd)
+	if len(project.Allocations) == 0 {
+		return fmt.Errorf("no allocations for %s (run port-selector first)", pathutil.ShortenHomePath(cwd))
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(devcontainerConfigFor(project, onAutoForward))
+}
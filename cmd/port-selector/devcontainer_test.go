@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDevcontainerConfigFor(t *testing.T) {
+	project := vscodeProject{Allocations: []vscodeAllocation{{Name: "web", Port: 3010}, {Name: "api", Port: 3011}}}
+	out, err := json.Marshal(devcontainerConfigFor(project, "silent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"forwardPorts":[3010,3011],"portsAttributes":{"3010":{"label":"web","onAutoForward":"silent"},"3011":{"label":"api","onAutoForward":"silent"}}}`
+	if string(out) != want {
+		t.Errorf("devcontainerConfigFor() = %s, want %s", out, want)
+	}
+
+	out, err = json.Marshal(devcontainerConfigFor(vscodeProject{Allocations: project.Allocations[:1]}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"forwardPorts":[3010],"portsAttributes":{"3010":{"label":"web"}}}`; string(out) != want {
+		t.Errorf("devcontainerConfigFor() without onAutoForward = %s, want %s", out, want)
+	}
+
+	if validAutoForward("open-browser") || !validAutoForward("openBrowser") {
+		t.Error("validAutoForward() does not match the devcontainer values")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/docker"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// dockerWatchRetry is how long `gc --watch-docker` waits before reconnecting to the event stream.
+const dockerWatchRetry = 5 * time.Second
+
+// registerContainer records the published ports of c as external allocations
+// tagged with the container ID. Ports held by regular allocations are left alone.
+// Returns the registered ports.
+func registerContainer(store *allocations.Store, c docker.Container) []int {
+	var ports []int
+	for _, p := range c.Ports {
+		if alloc := store.FindByPort(p); alloc != nil {
+			if alloc.Status != allocations.StatusExternal || alloc.ContainerID == c.ID {
+				continue
+			}
+		}
+		store.SetExternalAllocation(p, 0, "", c.Name, c.ProjectDir)
+		store.SetContainerID(p, c.ID)
+		if c.Service != "" {
+			store.SetComposeService(p, c.Service)
+		}
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// unregisterContainer removes the external allocations registered for containerID.
+// Returns the removed allocations.
+func unregisterContainer(store *allocations.Store, containerID string) []allocations.Allocation {
+	var removed []allocations.Allocation
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Status == allocations.StatusExternal && alloc.ContainerID == containerID {
+			store.RemoveByPort(alloc.Port)
+			removed = append(removed, alloc)
+		}
+	}
+	return removed
+}
+
+// syncContainers registers the running containers and removes the external
+// allocations of containers that are no longer running.
+func syncContainers(store *allocations.Store, running []docker.Container) {
+	ids := make(map[string]bool, len(running))
+	for _, c := range running {
+		ids[c.ID] = true
+		printRegistered(c, registerContainer(store, c))
+	}
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Status == allocations.StatusExternal && alloc.ContainerID != "" && !ids[alloc.ContainerID] {
+			printUnregistered(unregisterContainer(store, alloc.ContainerID))
+		}
+	}
+}
+
+func printRegistered(c docker.Container, ports []int) {
+	dir := c.ProjectDir
+	if dir == "" {
+		dir = "unknown directory"
+	}
+	for _, p := range ports {
+		fmt.Printf("Registered port %d (%s, %s)\n", p, c.Name, pathutil.ShortenHomePath(dir))
+	}
+}
+
+func printUnregistered(removed []allocations.Allocation) {
+	for _, alloc := range removed {
+		fmt.Printf("Removed port %d (%s, container stopped)\n", alloc.Port, alloc.ExternalProcessName)
+	}
+}
+
+// handleContainerEvent updates the store for a container start or stop.
+func handleContainerEvent(configDir string, e docker.ContainerEvent) error {
+	var running []docker.Container
+	if e.Action == docker.EventStart {
+		var err error
+		if running, err = docker.Containers(); err != nil {
+			return err
+		}
+	}
+	return allocations.WithStore(configDir, func(store *allocations.Store) error {
+		if e.Action == docker.EventDie {
+			printUnregistered(unregisterContainer(store, e.ContainerID))
+			return nil
+		}
+		for _, c := range running {
+			if c.ID == e.ContainerID {
+				printRegistered(c, registerContainer(store, c))
+			}
+		}
+		return nil
+	})
+}
+
+// watchDocker keeps the external allocations in sync with running containers:
+// a full sync at startup (and after reconnecting), then one update per container
+// start or stop, until interrupted.
+func watchDocker(configDir string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	synced := false
+	for {
+		err := func() error {
+			running, err := docker.Containers()
+			if err != nil {
+				return err
+			}
+			err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+				syncContainers(store, running)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			synced = true
+			stderrf("Watching container events (%d running with published ports), Ctrl+C to stop\n", len(running))
+			return docker.WatchContainers(ctx, func(e docker.ContainerEvent) {
+				if err := handleContainerEvent(configDir, e); err != nil {
+					stderrf("warning: %s %s: %v\n", e.Action, e.ContainerID, err)
+				}
+			})
+		}()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !synced {
+			return err // no API to watch at all
+		}
+		stderrf("warning: %v; retrying in %s\n", err, dockerWatchRetry)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(dockerWatchRetry):
+		}
+	}
+}
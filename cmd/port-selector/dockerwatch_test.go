@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/docker"
+)
+
+func TestSyncContainers(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocation("/home/user/shop", 3000)                     // regular allocation, never touched
+	store.SetExternalAllocation(3005, 0, "", "old-db-1", "/srv/old") // container that is gone
+	store.SetContainerID(3005, "deadbeef0000")
+	store.SetExternalAllocation(3006, 77, "bob", "nginx", "/srv") // external, not a container
+
+	running := []docker.Container{
+		{ID: "0123456789ab", Name: "shop-web-1", ProjectDir: "/home/user/shop", Ports: []int{3000, 3001}},
+	}
+	syncContainers(store, running)
+
+	if a := store.FindByPort(3000); a.Status == allocations.StatusExternal || a.ContainerID != "" {
+		t.Errorf("regular allocation was modified: %+v", a)
+	}
+	a := store.FindByPort(3001)
+	if a == nil || a.Status != allocations.StatusExternal || a.ContainerID != "0123456789ab" ||
+		a.Directory != "/home/user/shop" || a.ExternalProcessName != "shop-web-1" {
+		t.Errorf("container port not registered: %+v", a)
+	}
+	if store.FindByPort(3005) != nil {
+		t.Error("allocation of stopped container was not removed")
+	}
+	if store.FindByPort(3006) == nil {
+		t.Error("non-container external allocation was removed")
+	}
+
+	if removed := unregisterContainer(store, "0123456789ab"); len(removed) != 1 || removed[0].Port != 3001 {
+		t.Errorf("unregisterContainer() = %+v", removed)
+	}
+	if store.FindByPort(3000) == nil {
+		t.Error("regular allocation removed with the container")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// portEnvVar is the environment variable honored by --respect-env.
+const portEnvVar = "PORT"
+
+// portFromEnv returns the port from $PORT.
+// Returns false if the variable is unset or empty, error if it is not a valid port.
+func portFromEnv() (int, bool, error) {
+	value := strings.TrimSpace(os.Getenv(portEnvVar))
+	if value == "" {
+		return 0, false, nil
+	}
+	p, err := strconv.Atoi(value)
+	if err != nil || p < 1 || p > 65535 {
+		return 0, false, fmt.Errorf("invalid $%s value: %s (must be 1-65535)", portEnvVar, value)
+	}
+	return p, true, nil
+}
+
+// runRegisterEnvPort registers the port from $PORT for (cwd, name) and prints it.
+// If the port is already allocated to another directory or name, a warning is printed
+// and the allocation is left untouched; the port from the environment is still returned.
+func runRegisterEnvPort(cfg *config.Config, configDir, cwd, name string, envPort int) error {
+	debug.Printf("main", "respecting $%s=%d for name %s", portEnvVar, envPort, name)
+
+	if envPort < cfg.PortStart || envPort > cfg.PortEnd {
+		debug.Printf("main", "$%s=%d is outside configured range %d-%d", portEnvVar, envPort, cfg.PortStart, cfg.PortEnd)
+	}
+
+	var conflict *allocations.Allocation
+	err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+		conflict = nil
+
+		if removed := store.RemoveExpired(cfg.GetAllocationTTL()); removed > 0 {
+			debug.Printf("main", "removed %d expired allocations", removed)
+		}
+
+		if alloc := store.FindByPort(envPort); alloc != nil {
+			if alloc.Directory != cwd || alloc.Name != name {
+				conflict = alloc
+				return nil
+			}
+			store.UpdateLastUsedByPort(envPort)
+			return nil
+		}
+
+		store.SetAllocationWithName(cwd, envPort, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if conflict != nil {
+		state := ""
+		if conflict.Locked {
+			state = ", locked"
+		} else if conflict.Status == allocations.StatusExternal {
+			state = ", external"
+		}
+		stderrf("warning: $%s=%d is allocated to %s ('%s'%s); not registering it for %s\n",
+			portEnvVar, envPort, pathutil.ShortenHomePath(conflict.Directory), conflict.Name, state, pathutil.ShortenHomePath(cwd))
+	}
+
+	fmt.Println(envPort)
+	return nil
+}
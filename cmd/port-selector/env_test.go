@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestPortFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantOK  bool
+		wantErr bool
+	}{
+		{"unset", "", 0, false, false},
+		{"valid", "3500", 3500, true, false},
+		{"whitespace", " 3500 ", 3500, true, false},
+		{"non-numeric", "abc", 0, false, true},
+		{"out of range", "70000", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(portEnvVar, tt.value)
+			got, ok, err := portFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("portFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("portFromEnv() = %d, %v; want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRespectEnv(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation("/other/project", 3601)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	t.Run("registers free port", func(t *testing.T) {
+		cmd := exec.Command(binary, "--respect-env")
+		cmd.Dir = workDir
+		cmd.Env = append(env, "PORT=3600")
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.TrimSpace(string(output)) != "3600" {
+			t.Errorf("expected 3600, got %s", output)
+		}
+
+		loaded, err := allocations.Load(configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alloc := loaded.FindByDirectoryAndName(workDir, "main"); alloc == nil || alloc.Port != 3600 {
+			t.Errorf("expected 3600 to be registered for %s, got %+v", workDir, alloc)
+		}
+	})
+
+	t.Run("warns on conflict", func(t *testing.T) {
+		cmd := exec.Command(binary, "--respect-env", "--name", "web")
+		cmd.Dir = workDir
+		cmd.Env = append(env, "PORT=3601")
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.TrimSpace(string(output)) != "3601" {
+			t.Errorf("expected 3601, got %s", output)
+		}
+		if !strings.Contains(stderr.String(), "is allocated to /other/project") {
+			t.Errorf("expected conflict warning, got: %s", stderr.String())
+		}
+
+		loaded, err := allocations.Load(configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alloc := loaded.FindByPort(3601); alloc == nil || alloc.Directory != "/other/project" {
+			t.Errorf("expected conflicting allocation to be left untouched, got %+v", alloc)
+		}
+	})
+}
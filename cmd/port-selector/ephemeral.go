@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// ephemeralAttempts bounds how often the kernel is asked again when it hands out
+// a port that is already recorded in the store.
+const ephemeralAttempts = 10
+
+// allocateEphemeral returns the port of (cwd, name) or, if there is none, records a
+// port assigned by the kernel (outside the configured range) for it.
+func allocateEphemeral(store *allocations.Store, cwd, name string, ephemeralPort func() (int, error)) (int, error) {
+	if existing := store.FindByDirectoryAndName(cwd, name); existing != nil {
+		debug.Printf("main", "found existing allocation for name %s: port %d", name, existing.Port)
+		store.UpdateLastUsedByPort(existing.Port)
+		return existing.Port, nil
+	}
+
+	for i := 0; i < ephemeralAttempts; i++ {
+		p, err := ephemeralPort()
+		if err != nil {
+			return 0, err
+		}
+		if store.FindByPort(p) != nil {
+			debug.Printf("main", "ephemeral port %d is already allocated, asking again", p)
+			continue
+		}
+		store.SetAllocationWithName(cwd, p, name)
+		return p, nil
+	}
+	return 0, fmt.Errorf("kernel kept assigning allocated ports (%d attempts)", ephemeralAttempts)
+}
+
+// kernelEphemeralRange reads the kernel's ephemeral port range (variable for tests).
+var kernelEphemeralRange = port.KernelEphemeralRange
+
+// ephemeralWarning makes checkEphemeralOverlap warn only once per run (apply, bench).
+var ephemeralWarning sync.Once
+
+// rangeOverlap is the part of the configured range inside the kernel's ephemeral range.
+type rangeOverlap struct {
+	Start, End             int // overlapping ports
+	KernelStart, KernelEnd int // ip_local_port_range
+}
+
+func (o *rangeOverlap) String() string {
+	return fmt.Sprintf("ports %d-%d of the range are in the kernel's ephemeral range %d-%d", o.Start, o.End, o.KernelStart, o.KernelEnd)
+}
+
+// ephemeralOverlap returns the overlap of the configured range with the kernel's
+// ephemeral range, or nil if there is none or the kernel range can't be read.
+func ephemeralOverlap(cfg *config.Config) *rangeOverlap {
+	kStart, kEnd, err := kernelEphemeralRange()
+	if err != nil {
+		debug.Printf("port", "skipping ephemeral range check: %v", err)
+		return nil
+	}
+	o := &rangeOverlap{Start: max(cfg.PortStart, kStart), End: min(cfg.PortEnd, kEnd), KernelStart: kStart, KernelEnd: kEnd}
+	if o.Start > o.End {
+		return nil
+	}
+	return o
+}
+
+// checkEphemeralOverlap applies ephemeralOverlap before a new port is searched:
+// outbound connections take source ports from the ephemeral range, so allocated
+// ports there can be busy now and then. Returns an error with ephemeralOverlap: fail.
+func checkEphemeralOverlap(cfg *config.Config) error {
+	mode := cfg.GetEphemeralOverlap()
+	if mode == config.OverlapIgnore {
+		return nil
+	}
+	o := ephemeralOverlap(cfg)
+	if o == nil {
+		return nil
+	}
+	if mode == config.OverlapFail {
+		return fmt.Errorf("%s, where outbound connections take ports transiently; move portStart/portEnd out of it (or set ephemeralOverlap: warn)", o)
+	}
+	ephemeralWarning.Do(func() {
+		stderrf("warning: %s; outbound connections may take them transiently (set ephemeralOverlap: ignore to silence)\n", o)
+	})
+	return nil
+}
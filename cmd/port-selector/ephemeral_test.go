@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestAllocateEphemeral(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/other", 45001, "main")
+
+	assigned := []int{45001, 45002}
+	next := func() (int, error) {
+		p := assigned[0]
+		assigned = assigned[1:]
+		return p, nil
+	}
+
+	// 45001 is taken by another directory, so the kernel is asked again
+	p, err := allocateEphemeral(store, "/tool", "main", next)
+	if err != nil || p != 45002 {
+		t.Fatalf("allocateEphemeral() = %d, %v; want 45002", p, err)
+	}
+	if a := store.FindByPort(45002); a == nil || a.Directory != "/tool" {
+		t.Errorf("expected 45002 recorded for /tool, got %v", a)
+	}
+
+	// Remembered: the same port comes back without asking the kernel
+	p, err = allocateEphemeral(store, "/tool", "main", func() (int, error) {
+		t.Fatal("kernel should not be asked for an existing allocation")
+		return 0, nil
+	})
+	if err != nil || p != 45002 {
+		t.Errorf("allocateEphemeral() again = %d, %v; want 45002", p, err)
+	}
+}
+
+func TestCheckEphemeralOverlap(t *testing.T) {
+	old := kernelEphemeralRange
+	kernelEphemeralRange = func() (int, int, error) { return 32768, 60999, nil }
+	t.Cleanup(func() { kernelEphemeralRange = old })
+
+	if o := ephemeralOverlap(&config.Config{PortStart: 3000, PortEnd: 4000}); o != nil {
+		t.Errorf("ephemeralOverlap(3000-4000) = %v, want nil", o)
+	}
+	o := ephemeralOverlap(&config.Config{PortStart: 30000, PortEnd: 40000})
+	if o == nil || o.Start != 32768 || o.End != 40000 {
+		t.Fatalf("ephemeralOverlap(30000-40000) = %+v, want 32768-40000", o)
+	}
+
+	cfg := &config.Config{PortStart: 30000, PortEnd: 40000, EphemeralOverlap: config.OverlapFail}
+	if err := checkEphemeralOverlap(cfg); err == nil || !strings.Contains(err.Error(), "32768-40000") {
+		t.Errorf("checkEphemeralOverlap(fail) = %v, want overlap error", err)
+	}
+	for _, mode := range []string{"", config.OverlapWarn, config.OverlapIgnore} {
+		cfg.EphemeralOverlap = mode
+		if err := checkEphemeralOverlap(cfg); err != nil {
+			t.Errorf("checkEphemeralOverlap(%q) = %v, want nil", mode, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// defaultEventsInterval is how often `events --follow` checks the store for changes.
+const defaultEventsInterval = 500 * time.Millisecond
+
+// Event types emitted by `events`.
+const (
+	eventSnapshot = "snapshot" // existing allocation at startup
+	eventAllocate = "allocate"
+	eventRelease  = "release"
+	eventLock     = "lock"
+	eventUnlock   = "unlock"
+	eventUpdate   = "update"
+)
+
+// allocationEvent is one JSON line of `events` output.
+type allocationEvent struct {
+	Time    time.Time         `json:"ts"`
+	Event   string            `json:"event"`
+	Port    int               `json:"port"`
+	Dir     string            `json:"dir"`
+	Name    string            `json:"name"`
+	Locked  bool              `json:"locked"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Changes []string          `json:"changes,omitempty"`
+}
+
+// runEvents prints the allocations as JSON events and, with --follow, keeps
+// printing one event per change until interrupted.
+func runEvents(args []string) error {
+	follow := false
+	interval := defaultEventsInterval
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--follow" || arg == "-f":
+			follow = true
+		case arg == "--interval" || strings.HasPrefix(arg, "--interval="):
+			value, hasValue := strings.CutPrefix(arg, "--interval=")
+			if !hasValue {
+				if i+1 >= len(args) {
+					return fmt.Errorf("--interval requires a duration")
+				}
+				i++
+				value = args[i]
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid --interval %q (e.g., 500ms, 2s)", value)
+			}
+			interval = d
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	if err := writeEvents(enc, snapshotEvents(store, time.Now())); err != nil {
+		return err
+	}
+	if !follow {
+		return nil
+	}
+
+	path := allocations.StorePath(configDir)
+	stamp := fileStamp(path)
+	for {
+		time.Sleep(interval)
+		s := fileStamp(path)
+		if s == stamp {
+			continue
+		}
+		stamp = s
+
+		current, err := allocations.Load(configDir)
+		if err != nil {
+			// Keep following: the file may be fixed or restored later
+			stderrf("warning: %v\n", err)
+			continue
+		}
+		events := changeEvents(allocations.Diff(store, current), time.Now())
+		debug.Printf("events", "store changed: %d event(s)", len(events))
+		if err := writeEvents(enc, events); err != nil {
+			return err
+		}
+		store = current
+	}
+}
+
+// fileStamp identifies a version of the file by modification time and size.
+func fileStamp(path string) string {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", fi.ModTime().UnixNano(), fi.Size())
+}
+
+// snapshotEvents returns one snapshot event per allocation, ordered by port.
+func snapshotEvents(store *allocations.Store, now time.Time) []allocationEvent {
+	var events []allocationEvent
+	for _, a := range store.SortedByPort() {
+		events = append(events, allocationEvent{Time: now, Event: eventSnapshot, Port: a.Port, Dir: a.Directory, Name: a.Name, Locked: a.Locked, Labels: a.Labels})
+	}
+	return events
+}
+
+// changeEvents converts store changes into events.
+func changeEvents(changes []allocations.Change, now time.Time) []allocationEvent {
+	var events []allocationEvent
+	for _, c := range changes {
+		e := allocationEvent{Time: now, Port: c.Port}
+		info := c.After
+		switch {
+		case c.Before == nil:
+			e.Event = eventAllocate
+		case c.After == nil:
+			e.Event = eventRelease
+			info = c.Before
+		case c.Before.Locked != c.After.Locked:
+			e.Event = eventUnlock
+			if c.After.Locked {
+				e.Event = eventLock
+			}
+			e.Changes = c.Fields
+		default:
+			e.Event = eventUpdate
+			e.Changes = c.Fields
+		}
+		e.Dir, e.Name, e.Locked, e.Labels = info.Directory, info.Name, info.Locked, info.Labels
+		events = append(events, e)
+	}
+	return events
+}
+
+// writeEvents encodes events as JSON lines.
+func writeEvents(enc *json.Encoder, events []allocationEvent) error {
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
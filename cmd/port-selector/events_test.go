@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestChangeEvents(t *testing.T) {
+	before := allocations.NewStore()
+	before.SetAllocationWithName("/a", 3000, "main")
+	before.SetAllocationWithName("/b", 3001, "main")
+	after := allocations.NewStore()
+	after.Allocations[3000] = &allocations.AllocationInfo{}
+	*after.Allocations[3000] = *before.Allocations[3000]
+	after.Allocations[3000].Locked = true
+	after.SetAllocationWithName("/c", 3002, "web")
+
+	events := changeEvents(allocations.Diff(before, after), time.Now())
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	want := []struct {
+		event string
+		port  int
+		dir   string
+	}{{eventLock, 3000, "/a"}, {eventRelease, 3001, "/b"}, {eventAllocate, 3002, "/c"}}
+	for i, w := range want {
+		if events[i].Event != w.event || events[i].Port != w.port || events[i].Dir != w.dir {
+			t.Errorf("event %d = %+v, want %s %d %s", i, events[i], w.event, w.port, w.dir)
+		}
+	}
+	if len(events[0].Changes) != 1 || events[0].Changes[0] != "locked: - -> true" {
+		t.Errorf("unexpected lock changes: %v", events[0].Changes)
+	}
+}
+
+func TestEvents_Follow(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	store := allocations.NewStore()
+	store.SetAllocation(filepath.Join(tmpDir, "a"), 3920)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "events", "--follow", "--interval", "20ms")
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	lines := make(chan allocationEvent)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			var e allocationEvent
+			if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+				lines <- e
+			}
+		}
+		close(lines)
+	}()
+	next := func() allocationEvent {
+		t.Helper()
+		select {
+		case e, ok := <-lines:
+			if !ok {
+				t.Fatal("events exited early")
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+		return allocationEvent{}
+	}
+
+	if e := next(); e.Event != eventSnapshot || e.Port != 3920 {
+		t.Errorf("expected snapshot of 3920, got %+v", e)
+	}
+
+	err = allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetLockedByPort(3920, true)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e.Event != eventLock || e.Port != 3920 || !e.Locked {
+		t.Errorf("expected lock event for 3920, got %+v", e)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+// rangeExhaustion explains why no port of the range could be allocated.
+// Each port is counted once, in the first matching category.
+type rangeExhaustion struct {
+	PortStart     int      `json:"port_start"`
+	PortEnd       int      `json:"port_end"`
+	Total         int      `json:"total"`
+	External      int      `json:"external"`       // recorded external processes
+	Locked        int      `json:"locked"`         // locked by other directories
+	SameDirectory int      `json:"same_directory"` // other names of the current directory
+	Frozen        int      `json:"frozen"`         // used within the freeze period
+	Excluded      int      `json:"excluded"`       // excludedPorts and excludedRanges
+	Busy          int      `json:"busy"`           // in use by some process
+	Suggestions   []string `json:"suggestions"`
+}
+
+// errRangeExhausted is returned by allocatePort when every port of the range is taken.
+type errRangeExhausted struct {
+	rangeExhaustion
+}
+
+func (e *errRangeExhausted) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "all ports in range %d-%d are busy or frozen (%d ports):\n", e.PortStart, e.PortEnd, e.Total)
+	for _, row := range []struct {
+		label string
+		count int
+	}{
+		{"busy (in use)", e.Busy},
+		{"frozen (recently used)", e.Frozen},
+		{"locked by other directories", e.Locked},
+		{"external processes", e.External},
+		{"other names of this directory", e.SameDirectory},
+		{"excluded in config", e.Excluded},
+	} {
+		if row.count > 0 {
+			fmt.Fprintf(&b, "  %-30s %d\n", row.label+":", row.count)
+		}
+	}
+	for _, s := range e.Suggestions {
+		fmt.Fprintf(&b, "hint: %s\n", s)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// diagnoseExhaustion classifies every port of the configured range for (cwd, name).
+func diagnoseExhaustion(store *allocations.Store, cfg *config.Config, cwd, name string, isPortFree allocations.PortChecker) *errRangeExhausted {
+	frozen := store.GetFrozenPortsWithPolicy(cfg.FreezePeriodFor)
+	locked := store.GetLockedPortsForExclusion(cwd)
+	excluded := cfg.ExcludedPortSet()
+
+	e := &errRangeExhausted{rangeExhaustion{PortStart: cfg.PortStart, PortEnd: cfg.PortEnd}}
+	for p := cfg.PortStart; p <= cfg.PortEnd; p++ {
+		e.Total++
+		info := store.Allocations[p]
+		switch {
+		case info != nil && info.Status == allocations.StatusExternal:
+			e.External++
+		case locked[p]:
+			e.Locked++
+		case info != nil && info.Directory == cwd && info.Name != name:
+			e.SameDirectory++
+		case frozen[p]:
+			e.Frozen++
+		case excluded[p]:
+			e.Excluded++
+		case !isPortFree(p):
+			e.Busy++
+		}
+	}
+
+	if e.Frozen > 0 {
+		e.Suggestions = append(e.Suggestions, fmt.Sprintf(
+			"lower freezePeriod (now %s) to reuse released ports sooner: port-selector config set freezePeriod 1h", cfg.GetFreezePeriod()))
+	}
+	if e.External > 0 || e.Frozen > 0 {
+		e.Suggestions = append(e.Suggestions, "drop expired and stale allocations: port-selector gc")
+	}
+	if e.Locked > 0 {
+		e.Suggestions = append(e.Suggestions, "unlock ports that are no longer needed (see LOCKED in port-selector --list)")
+	}
+	e.Suggestions = append(e.Suggestions, fmt.Sprintf(
+		"increase portEnd to widen the range: port-selector config set portEnd %d", cfg.PortEnd+e.Total))
+	return e
+}
+
+// allocationResult is the output of an allocation with --json.
+type allocationResult struct {
+	Port       int              `json:"port,omitempty"`
+	Directory  string           `json:"directory"`
+	Name       string           `json:"name"`
+	Error      string           `json:"error,omitempty"`
+	Exhaustion *rangeExhaustion `json:"exhaustion,omitempty"`
+}
+
+// printAllocationJSON prints the allocation outcome; a range exhaustion includes its breakdown.
+// allocErr is returned so that the caller still reports it and exits non-zero.
+func printAllocationJSON(p int, cwd, name string, allocErr error) error {
+	result := allocationResult{Port: p, Directory: cwd, Name: name}
+	if allocErr != nil {
+		result.Error = allocErr.Error()
+		if e, ok := allocErr.(*errRangeExhausted); ok {
+			result.Error = fmt.Sprintf("all ports in range %d-%d are busy or frozen", e.PortStart, e.PortEnd)
+			result.Exhaustion = &e.rangeExhaustion
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return err
+	}
+	return allocErr
+}
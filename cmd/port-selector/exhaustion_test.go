@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestDiagnoseExhaustion(t *testing.T) {
+	cfg := &config.Config{PortStart: 3000, PortEnd: 3005, FreezePeriod: "24h"}
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/home/user/other", 3000, "main") // frozen (just used)
+	store.SetAllocationWithName("/home/user/locked", 3001, "main")
+	store.SetLockedByPort(3001, true)
+	store.SetExternalAllocation(3002, 42, "user", "postgres", "/")
+	store.SetAllocationWithName("/home/user/app", 3003, "web")
+	busy := map[int]bool{3004: true, 3005: true}
+
+	e := diagnoseExhaustion(store, cfg, "/home/user/app", "main", func(p int) bool { return !busy[p] })
+	got := e.rangeExhaustion
+	if got.Total != 6 || got.Frozen != 1 || got.Locked != 1 || got.External != 1 || got.SameDirectory != 1 || got.Busy != 2 {
+		t.Errorf("unexpected breakdown: %+v", got)
+	}
+
+	msg := e.Error()
+	for _, want := range []string{"3000-3005", "busy (in use):", "freezePeriod", "portEnd 3011"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Error() missing %q:\n%s", want, msg)
+		}
+	}
+}
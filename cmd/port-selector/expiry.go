@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/i18n"
+)
+
+// allocationExpiry returns when an unlocked allocation expires by TTL or lease,
+// whichever comes first, or the zero time if it never expires.
+func allocationExpiry(a *allocations.Allocation, ttl time.Duration) time.Time {
+	if a.Locked {
+		return time.Time{}
+	}
+	var expiry time.Time
+	if ttl > 0 {
+		used := a.LastUsedAt
+		if used.IsZero() {
+			used = a.AssignedAt
+		}
+		expiry = used.Add(ttl)
+	}
+	if a.Lease > 0 && !a.LeaseExpiresAt.IsZero() && (expiry.IsZero() || a.LeaseExpiresAt.Before(expiry)) {
+		expiry = a.LeaseExpiresAt
+	}
+	return expiry
+}
+
+// expiryWarnings returns a warning for every allocation of cwd, except the one on
+// returned (it was just renewed), that expires within the expiryWarning window.
+// Returning one port does not renew the other names of the directory, so a
+// long-running service that is rarely requested would otherwise expire silently.
+func expiryWarnings(store *allocations.Store, cfg *config.Config, cwd string, returned int, now time.Time) []string {
+	window := cfg.GetExpiryWarning()
+	if window <= 0 {
+		return nil
+	}
+	ttl := cfg.GetAllocationTTL()
+
+	var warnings []string
+	for _, a := range store.SortedByPort() {
+		if a.Directory != cwd || a.Port == returned || a.Status == allocations.StatusExternal {
+			continue
+		}
+		expiry := allocationExpiry(&a, ttl)
+		if expiry.IsZero() || expiry.Before(now) || expiry.Sub(now) > window {
+			continue
+		}
+		warnings = append(warnings, i18n.Sprintf("allocation for '%s' (port %d) expires in %s; run 'port-selector --name %s' to renew it or --lock to keep it",
+			a.Name, a.Port, formatRemaining(expiry.Sub(now)), a.Name))
+	}
+	return warnings
+}
+
+// printExpiryWarnings writes expiry warnings, one per line.
+func printExpiryWarnings(w io.Writer, warnings []string) {
+	for _, msg := range warnings {
+		i18n.Fprintf(w, "warning: %s\n", msg)
+	}
+}
+
+// formatRemaining formats a time span coarsely: days from 2 days up, then hours, then minutes.
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	default:
+		return "less than a minute"
+	}
+}
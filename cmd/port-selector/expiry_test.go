@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestExpiryWarnings(t *testing.T) {
+	now := time.Now()
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/code/shop", 3000, "main")
+	store.SetAllocationWithName("/code/shop", 3001, "web")
+	store.SetAllocationWithName("/code/shop", 3002, "api")
+	store.SetAllocationWithName("/code/shop", 3003, "db")
+	store.SetLockedByPort(3003, true)
+	store.SetAllocationWithName("/code/shop", 3004, "worker")
+	store.SetLease(3004, 5*time.Hour)
+	store.SetAllocationWithName("/code/blog", 3005, "web")
+	for _, p := range []int{3000, 3001, 3003, 3005} {
+		store.Allocations[p].LastUsedAt = now.Add(-28 * 24 * time.Hour)
+	}
+
+	cfg := &config.Config{AllocationTTL: "30d"}
+	warnings := expiryWarnings(store, cfg, "/code/shop", 3000, now)
+	if len(warnings) != 2 {
+		t.Fatalf("expiryWarnings() = %q, want web (TTL) and worker (lease)", warnings)
+	}
+	if !strings.Contains(warnings[0], "'web' (port 3001) expires in 2d") {
+		t.Errorf("warning = %q", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "'worker' (port 3004) expires in 5h") {
+		t.Errorf("warning = %q", warnings[1])
+	}
+
+	cfg.ExpiryWarning = "0"
+	if warnings := expiryWarnings(store, cfg, "/code/shop", 3000, now); warnings != nil {
+		t.Errorf("expiryWarnings() with expiryWarning: 0 = %q", warnings)
+	}
+}
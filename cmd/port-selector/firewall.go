@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// Firewall tools supported by the firewall command.
+const (
+	firewallUFW       = "ufw"
+	firewallFirewalld = "firewalld"
+)
+
+// firewallRule opens a TCP port to a source network.
+type firewallRule struct {
+	Tool   string
+	Source string // CIDR, e.g. 192.168.1.0/24
+	Port   int
+}
+
+// addCommands returns the commands that create the rule. firewalld gets the rule
+// both at runtime and permanently, which avoids a reload.
+func (r firewallRule) addCommands() [][]string {
+	if r.Tool == firewallFirewalld {
+		return [][]string{
+			{"firewall-cmd", "--add-rich-rule=" + r.richRule()},
+			{"firewall-cmd", "--permanent", "--add-rich-rule=" + r.richRule()},
+		}
+	}
+	return [][]string{{"ufw", "allow", "from", r.Source, "to", "any", "port", strconv.Itoa(r.Port), "proto", "tcp", "comment", "port-selector"}}
+}
+
+// deleteCommands returns the commands that remove the rule.
+func (r firewallRule) deleteCommands() [][]string {
+	if r.Tool == firewallFirewalld {
+		return [][]string{
+			{"firewall-cmd", "--remove-rich-rule=" + r.richRule()},
+			{"firewall-cmd", "--permanent", "--remove-rich-rule=" + r.richRule()},
+		}
+	}
+	return [][]string{{"ufw", "delete", "allow", "from", r.Source, "to", "any", "port", strconv.Itoa(r.Port), "proto", "tcp"}}
+}
+
+// richRule returns the firewalld rich rule.
+func (r firewallRule) richRule() string {
+	return fmt.Sprintf(`rule family="ipv4" source address="%s" port port="%d" protocol="tcp" accept`, r.Source, r.Port)
+}
+
+// record returns the rule as stored on its allocation: "TOOL:SOURCE"
+// (e.g., "ufw:192.168.1.0/24"), so that the rule can be removed later.
+func (r firewallRule) record() string {
+	return r.Tool + ":" + r.Source
+}
+
+// recordedRule returns the rule recorded on an allocation, if any.
+func recordedRule(alloc allocations.Allocation) (firewallRule, bool) {
+	tool, source, ok := strings.Cut(alloc.Firewall, ":")
+	if !ok || (tool != firewallUFW && tool != firewallFirewalld) || source == "" {
+		return firewallRule{}, false
+	}
+	return firewallRule{Tool: tool, Source: source, Port: alloc.Port}, true
+}
+
+// runPrivileged runs a firewall command, through sudo unless already root (variable for tests).
+var runPrivileged = func(argv []string) error {
+	if os.Geteuid() != 0 {
+		argv = append([]string{"sudo"}, argv...)
+	}
+	debug.Printf("main", "running %s", strings.Join(argv, " "))
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	return cmd.Run()
+}
+
+// formatCommand renders argv as a shell command line, quoting arguments where needed.
+func formatCommand(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if strings.ContainsAny(arg, " \"'$`\\*?;&|<>()") {
+			arg = shellQuote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// detectFirewall returns the firewall tool installed on this machine.
+// The tools usually live in /usr/sbin, which is not always in PATH.
+func detectFirewall() (string, error) {
+	for _, tool := range []struct{ name, binary string }{
+		{firewallUFW, "ufw"},
+		{firewallFirewalld, "firewall-cmd"},
+	} {
+		if _, err := exec.LookPath(tool.binary); err == nil {
+			return tool.name, nil
+		}
+		if _, err := os.Stat("/usr/sbin/" + tool.binary); err == nil {
+			return tool.name, nil
+		}
+	}
+	return "", fmt.Errorf("neither ufw nor firewalld found (use --tool ufw|firewalld)")
+}
+
+// lanNetwork returns the first private IPv4 network this machine is connected to.
+func lanNetwork() (*net.IPNet, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network addresses: %w", err)
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil && ipnet.IP.IsPrivate() {
+			return ipnet, nil
+		}
+	}
+	return nil, fmt.Errorf("no private IPv4 network found")
+}
+
+// runFirewall prints the firewall rules that open the directory's allocated ports
+// to the local network. --allow-lan applies them with sudo and records them on the
+// allocations; --remove deletes the recorded rules.
+func runFirewall(args []string) error {
+	var tool, source string
+	apply, remove := false, false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--allow-lan":
+			apply = true
+		case arg == "--remove":
+			remove = true
+		case arg == "--tool" || arg == "--source":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", arg)
+			}
+			if arg == "--tool" {
+				tool = args[i+1]
+			} else {
+				source = args[i+1]
+			}
+			i++
+		case strings.HasPrefix(arg, "--tool="):
+			tool = strings.TrimPrefix(arg, "--tool=")
+		case strings.HasPrefix(arg, "--source="):
+			source = strings.TrimPrefix(arg, "--source=")
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+	if apply && remove {
+		return fmt.Errorf("--allow-lan cannot be combined with --remove")
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	var allocs []allocations.Allocation
+	for _, a := range store.SortedByPort() {
+		if a.Directory == cwd && a.Status != allocations.StatusExternal {
+			allocs = append(allocs, a)
+		}
+	}
+
+	if remove {
+		recorded := false
+		for _, a := range allocs {
+			if _, ok := recordedRule(a); ok {
+				recorded = true
+			}
+		}
+		if !recorded {
+			fmt.Printf("No firewall rules recorded for %s\n", pathutil.ShortenHomePath(cwd))
+			return nil
+		}
+		if closed := closeFirewallRules(allocs); len(closed) > 0 {
+			return clearFirewallRecords(configDir, closed)
+		}
+		return nil
+	}
+
+	if len(allocs) == 0 {
+		return fmt.Errorf("no allocations for %s (run port-selector first)", pathutil.ShortenHomePath(cwd))
+	}
+	rules, err := firewallRules(allocs, tool, source)
+	if err != nil {
+		return err
+	}
+
+	if !apply || allocations.IsDryRun() {
+		fmt.Printf("# Open the ports of %s to %s (%s)\n", pathutil.ShortenHomePath(cwd), rules[0].Source, rules[0].Tool)
+		for _, r := range rules {
+			for _, argv := range r.addCommands() {
+				fmt.Println("sudo " + formatCommand(argv))
+			}
+		}
+		return nil
+	}
+
+	// Record the rules opened before a failure too, so that --remove finds them
+	opened := make(map[int]string)
+	var applyErr error
+	for i, r := range rules {
+		for _, argv := range r.addCommands() {
+			if applyErr = runPrivileged(argv); applyErr != nil {
+				applyErr = fmt.Errorf("%s failed: %w", argv[0], applyErr)
+				break
+			}
+		}
+		if applyErr != nil {
+			break
+		}
+		opened[r.Port] = r.record()
+		fmt.Printf("Opened port %d ('%s') to %s (%s)\n", r.Port, allocs[i].Name, r.Source, r.Tool)
+	}
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		for p, rule := range opened {
+			store.SetFirewall(p, rule)
+		}
+		return nil
+	})
+	if applyErr != nil {
+		return applyErr
+	}
+	return err
+}
+
+// firewallRules returns one rule per allocation, detecting the tool and the local
+// network unless given.
+func firewallRules(allocs []allocations.Allocation, tool, source string) ([]firewallRule, error) {
+	switch tool {
+	case "":
+		detected, err := detectFirewall()
+		if err != nil {
+			return nil, err
+		}
+		tool = detected
+	case firewallUFW, firewallFirewalld:
+	default:
+		return nil, fmt.Errorf("unknown firewall tool %q (use ufw or firewalld)", tool)
+	}
+
+	if source == "" {
+		lan, err := lanNetwork()
+		if err != nil {
+			return nil, fmt.Errorf("%w (use --source CIDR)", err)
+		}
+		source = (&net.IPNet{IP: lan.IP.Mask(lan.Mask), Mask: lan.Mask}).String()
+	} else if _, _, err := net.ParseCIDR(source); err != nil {
+		return nil, fmt.Errorf("invalid --source %q (expected a CIDR such as 192.168.1.0/24)", source)
+	}
+
+	rules := make([]firewallRule, len(allocs))
+	for i, a := range allocs {
+		rules[i] = firewallRule{Tool: tool, Source: source, Port: a.Port}
+	}
+	return rules, nil
+}
+
+// closeFirewallRules deletes the firewall rules recorded on allocs and returns the
+// ports whose rules are gone. It is also the allocations firewall closer, called
+// for every allocation removed with a rule. Failures are reported as warnings: the
+// allocation may already be removed, and the rule can still be deleted by hand.
+func closeFirewallRules(allocs []allocations.Allocation) []int {
+	var closed []int
+	for _, a := range allocs {
+		r, ok := recordedRule(a)
+		if !ok {
+			continue
+		}
+		if allocations.IsDryRun() {
+			for _, argv := range r.deleteCommands() {
+				stderrf("dry-run: would run sudo %s\n", formatCommand(argv))
+			}
+			continue
+		}
+		failed := false
+		for _, argv := range r.deleteCommands() {
+			if err := runPrivileged(argv); err != nil {
+				stderrf("warning: failed to remove the firewall rule for port %d (%s): %v\n", a.Port, formatCommand(argv), err)
+				failed = true
+				break
+			}
+		}
+		if !failed {
+			fmt.Printf("Closed port %d to %s (%s)\n", a.Port, r.Source, r.Tool)
+			closed = append(closed, a.Port)
+		}
+	}
+	return closed
+}
+
+// clearFirewallRecords drops the recorded rules of ports whose rules were removed.
+func clearFirewallRecords(configDir string, ports []int) error {
+	return allocations.WithStore(configDir, func(store *allocations.Store) error {
+		for _, p := range ports {
+			store.SetFirewall(p, "")
+		}
+		return nil
+	})
+}
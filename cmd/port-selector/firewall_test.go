@@ -0,0 +1,137 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestFirewallRuleCommands(t *testing.T) {
+	ufw := firewallRule{Tool: firewallUFW, Source: "192.168.1.0/24", Port: 3000}
+	if got := formatCommand(ufw.addCommands()[0]); got != "ufw allow from 192.168.1.0/24 to any port 3000 proto tcp comment port-selector" {
+		t.Errorf("ufw add = %q", got)
+	}
+	if got := formatCommand(ufw.deleteCommands()[0]); got != "ufw delete allow from 192.168.1.0/24 to any port 3000 proto tcp" {
+		t.Errorf("ufw delete = %q", got)
+	}
+
+	firewalld := firewallRule{Tool: firewallFirewalld, Source: "10.0.0.0/8", Port: 3001}
+	add := firewalld.addCommands()
+	if len(add) != 2 || add[1][1] != "--permanent" {
+		t.Fatalf("firewalld add = %v, want runtime and permanent commands", add)
+	}
+	want := `firewall-cmd '--add-rich-rule=rule family="ipv4" source address="10.0.0.0/8" port port="3001" protocol="tcp" accept'`
+	if got := formatCommand(add[0]); got != want {
+		t.Errorf("firewalld add = %q, want %q", got, want)
+	}
+
+	r, ok := recordedRule(allocations.Allocation{Port: 3000, Firewall: ufw.record()})
+	if !ok || r != ufw {
+		t.Errorf("recordedRule() = %+v, %v, want %+v", r, ok, ufw)
+	}
+	if _, ok := recordedRule(allocations.Allocation{Port: 3000, Firewall: "iptables:any"}); ok {
+		t.Error("recordedRule() accepted an unknown tool")
+	}
+	if _, ok := recordedRule(allocations.Allocation{Port: 3000, Labels: map[string]string{"firewall": ufw.record()}}); ok {
+		t.Error("recordedRule() accepted a rule from a label")
+	}
+}
+
+func TestFirewallRules_Source(t *testing.T) {
+	allocs := []allocations.Allocation{{Port: 3000}, {Port: 3001}}
+	rules, err := firewallRules(allocs, firewallUFW, "192.168.50.0/24")
+	if err != nil || len(rules) != 2 || rules[1].Port != 3001 || rules[1].Source != "192.168.50.0/24" {
+		t.Errorf("firewallRules() = %+v, %v", rules, err)
+	}
+	for _, tc := range []struct{ tool, source string }{{"iptables", "192.168.50.0/24"}, {firewallUFW, "lan"}} {
+		if _, err := firewallRules(allocs, tc.tool, tc.source); err == nil {
+			t.Errorf("firewallRules(%q, %q) expected error", tc.tool, tc.source)
+		}
+	}
+}
+
+// stubFirewall records the firewall commands instead of running them and
+// installs closeFirewallRules as the allocations firewall closer.
+func stubFirewall(t *testing.T) *[]string {
+	t.Helper()
+	var ran []string
+	old := runPrivileged
+	runPrivileged = func(argv []string) error {
+		ran = append(ran, strings.Join(argv, " "))
+		return nil
+	}
+	allocations.SetFirewallCloser(func(removed []allocations.Allocation) { closeFirewallRules(removed) })
+	t.Cleanup(func() {
+		runPrivileged = old
+		allocations.SetFirewallCloser(nil)
+	})
+	return &ran
+}
+
+func TestForgetPort_ClosesFirewallRule(t *testing.T) {
+	ran := stubFirewall(t)
+
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/shop", 3000, "main")
+		s.SetFirewall(3000, "ufw:192.168.1.0/24")
+		s.SetAllocationWithName("/shop", 3001, "web")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := forgetPort(configDir, 3001); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("forgetting a port without a rule ran %v", *ran)
+	}
+	if err := forgetPort(configDir, 3000); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 1 || (*ran)[0] != "ufw delete allow from 192.168.1.0/24 to any port 3000 proto tcp" {
+		t.Errorf("forgetPort() ran %v, want the ufw delete command", *ran)
+	}
+}
+
+func TestForgetAllAndRelease_CloseFirewallRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/shop", 3000, "main")
+		s.SetFirewall(3000, "ufw:192.168.1.0/24")
+		s.SetAllocationWithName("/shop", 3001, "web")
+		s.SetFirewall(3001, "firewalld:10.0.0.0/8")
+		// A label is not a rule record and can't forge one
+		s.SetAllocationWithName("/blog", 3002, "main")
+		s.SetLabels(3002, map[string]string{"firewall": "ufw:192.168.1.0/24"})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ran := stubFirewall(t)
+
+	if err := releaseAllocation(configDir, "/shop", "web", func(int) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 2 || !strings.Contains((*ran)[0], "--remove-rich-rule=") || !strings.Contains((*ran)[0], `port="3001"`) {
+		t.Errorf("release ran %v, want the firewalld commands for port 3001", *ran)
+	}
+
+	*ran = nil
+	if err := runForgetAll([]string{"--yes"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 1 || (*ran)[0] != "ufw delete allow from 192.168.1.0/24 to any port 3000 proto tcp" {
+		t.Errorf("--forget-all ran %v, want only the ufw delete command for port 3000", *ran)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// dirMatcher returns a function reporting whether an allocation directory is
+// selected by --forget-glob PATTERN or --forget-prefix DIR.
+func dirMatcher(option, arg string) (func(dir string) bool, error) {
+	pattern, err := filepath.Abs(pathutil.ExpandHome(arg))
+	if err != nil {
+		return nil, err
+	}
+	if option == "--forget-prefix" {
+		return func(dir string) bool {
+			return dir == pattern || strings.HasPrefix(dir, strings.TrimSuffix(pattern, "/")+"/")
+		}, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", arg, err)
+	}
+	return func(dir string) bool {
+		ok, _ := filepath.Match(pattern, dir)
+		return ok
+	}, nil
+}
+
+// runForgetMatching removes all allocations whose directory matches a glob
+// (--forget-glob) or lies under a prefix (--forget-prefix), after confirmation.
+func runForgetMatching(option string, args []string) error {
+	var arg string
+	yes := false
+	for _, a := range args {
+		switch {
+		case a == "--yes" || a == "-y":
+			yes = true
+		case strings.HasPrefix(a, "-"):
+			return fmt.Errorf("unknown option: %s", a)
+		case arg != "":
+			return fmt.Errorf("unknown arguments: %v", args)
+		default:
+			arg = a
+		}
+	}
+	if arg == "" {
+		return fmt.Errorf("%s requires a pattern", option)
+	}
+
+	match, err := dirMatcher(option, arg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	var matched []allocations.Allocation
+	for _, alloc := range store.SortedByPort() {
+		if match(alloc.Directory) {
+			matched = append(matched, alloc)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Printf("No allocations match %s\n", arg)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, alloc := range matched {
+		fmt.Fprintf(w, "  %d\t%s\t%s\n", alloc.Port, pathutil.ShortenHomePath(alloc.Directory), alloc.Name)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	ok, err := confirm(fmt.Sprintf("Forget %d allocation(s)?", len(matched)), yes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	// Re-match under the lock: the store may have changed while we were asking
+	var count int
+	var backupPath string
+	err = allocations.WithJournal(configDir, option, func(store *allocations.Store) error {
+		count = 0
+		path, err := allocations.Backup(configDir)
+		if err != nil {
+			return err
+		}
+		backupPath = path
+		for _, alloc := range store.SortedByPort() {
+			if match(alloc.Directory) && store.RemoveByPort(alloc.Port) {
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %d allocation(s) matching %s\n", clearedVerb(), count, arg)
+	printBackupPath(backupPath)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestDirMatcher(t *testing.T) {
+	tests := []struct {
+		option, arg, dir string
+		want             bool
+	}{
+		{"--forget-glob", "/code/worktrees/*", "/code/worktrees/feature-a", true},
+		{"--forget-glob", "/code/worktrees/*", "/code/worktrees/feature-a/sub", false},
+		{"--forget-glob", "/code/worktrees/*", "/code/worktrees", false},
+		{"--forget-glob", "/code/*/app", "/code/x/app", true},
+		{"--forget-prefix", "/code/worktrees", "/code/worktrees", true},
+		{"--forget-prefix", "/code/worktrees", "/code/worktrees/a/b", true},
+		{"--forget-prefix", "/code/worktrees/", "/code/worktrees/a", true},
+		{"--forget-prefix", "/code/worktrees", "/code/worktrees-old", false},
+	}
+	for _, tt := range tests {
+		match, err := dirMatcher(tt.option, tt.arg)
+		if err != nil {
+			t.Fatalf("dirMatcher(%s, %s): %v", tt.option, tt.arg, err)
+		}
+		if got := match(tt.dir); got != tt.want {
+			t.Errorf("%s %s matches %s = %v, want %v", tt.option, tt.arg, tt.dir, got, tt.want)
+		}
+	}
+
+	if _, err := dirMatcher("--forget-glob", "/code/[x"); err == nil {
+		t.Error("expected error for malformed glob")
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	oldInput, oldOutput, oldIsTerminal := confirmInput, confirmOutput, confirmIsTerminal
+	t.Cleanup(func() { confirmInput, confirmOutput, confirmIsTerminal = oldInput, oldOutput, oldIsTerminal })
+	confirmOutput = io.Discard
+
+	confirmIsTerminal = func() bool { return false }
+	if ok, err := confirm("Continue?", true); err != nil || !ok {
+		t.Errorf("confirm with yes = %v, %v; want true", ok, err)
+	}
+	if _, err := confirm("Continue?", false); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("expected --yes hint without a terminal, got %v", err)
+	}
+
+	confirmIsTerminal = func() bool { return true }
+	for answer, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		confirmInput = strings.NewReader(answer)
+		if ok, err := confirm("Continue?", false); err != nil || ok != want {
+			t.Errorf("confirm(%q) = %v, %v; want %v", answer, ok, err, want)
+		}
+	}
+}
+
+func TestForgetGlob(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees := filepath.Join(tmpDir, "worktrees")
+	store := allocations.NewStore()
+	store.SetAllocation(filepath.Join(worktrees, "a"), 3880)
+	store.SetAllocation(filepath.Join(worktrees, "b"), 3881)
+	store.SetAllocation(filepath.Join(tmpDir, "main-repo"), 3882)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	// stdin is not a terminal: refuse without --yes
+	cmd := exec.Command(binary, "--forget-glob", filepath.Join(worktrees, "*"))
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "--yes") {
+		t.Fatalf("expected confirmation error, got: %v\n%s", err, output)
+	}
+
+	cmd = exec.Command(binary, "--forget-glob", filepath.Join(worktrees, "*"), "--yes")
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("--forget-glob --yes failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "Cleared 2 allocation(s)") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Count() != 1 || loaded.FindByPort(3882) == nil {
+		t.Errorf("expected only port 3882 to remain, got %d allocations", loaded.Count())
+	}
+
+	cmd = exec.Command(binary, "--forget-prefix", tmpDir, "-y")
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(output), "Cleared 1 allocation(s)") {
+		t.Errorf("--forget-prefix: %v\n%s", err, output)
+	}
+}
+
+func TestForgetAll_Backup(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(filepath.Join(tmpDir, "a"), 3890)
+	store.SetAllocation(filepath.Join(tmpDir, "b"), 3891)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a terminal --forget-all doesn't ask, for compatibility with scripts
+	cmd := exec.Command(binary, "--forget-all")
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("--forget-all failed: %v\n%s", err, output)
+	}
+
+	backupPath := filepath.Join(configDir, "allocations.yaml.bak")
+	if !strings.Contains(string(output), "Cleared 2 allocation(s)") || !strings.Contains(string(output), "Backup saved to "+backupPath) {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	backup, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(backup), "3890") || !strings.Contains(string(backup), "3891") {
+		t.Errorf("backup doesn't contain the removed allocations:\n%s", backup)
+	}
+}
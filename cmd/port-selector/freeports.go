@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// runFreePorts prints the ports in the configured range that a new allocation could
+// take right now, one per line, without allocating them. --count N stops after N ports.
+func runFreePorts(args []string) error {
+	count := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg != "--count" && !strings.HasPrefix(arg, "--count=") {
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+		value := strings.TrimPrefix(arg, "--count=")
+		if arg == "--count" {
+			if i+1 >= len(args) {
+				return fmt.Errorf("--count requires a number")
+			}
+			i++
+			value = args[i]
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid --count value: %s", value)
+		}
+		count = n
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load allocations: %w", err)
+	}
+
+	ports := freePorts(store, cfg, count, port.IsPortFree)
+	if len(ports) == 0 {
+		return fmt.Errorf("no free ports in range %d-%d", cfg.PortStart, cfg.PortEnd)
+	}
+	for _, p := range ports {
+		fmt.Println(p)
+	}
+	return nil
+}
+
+// freePorts returns up to count (0 for all) ports of the range in ascending order
+// that are not external, locked, frozen or excluded and can be bound now.
+func freePorts(store *allocations.Store, cfg *config.Config, count int, isPortFree allocations.PortChecker) []int {
+	frozen := store.GetFrozenPortsWithPolicy(cfg.FreezePeriodFor)
+	excluded := cfg.ExcludedPortSet()
+
+	var ports []int
+	for p := cfg.PortStart; p <= cfg.PortEnd; p++ {
+		if info := store.Allocations[p]; info != nil && (info.Status == allocations.StatusExternal || info.Locked) {
+			continue
+		}
+		if frozen[p] || excluded[p] || !isPortFree(p) {
+			continue
+		}
+		ports = append(ports, p)
+		if count > 0 && len(ports) == count {
+			break
+		}
+	}
+	return ports
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestFreePorts(t *testing.T) {
+	cfg := &config.Config{PortStart: 3000, PortEnd: 3009, FreezePeriod: "1h", ExcludedPorts: []int{3008}}
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/frozen", 3001, "main") // recently used: frozen
+	store.SetAllocationWithName("/locked", 3002, "main")
+	store.SetLockedByPort(3002, true)
+	store.SetExternalAllocation(3003, 1234, "root", "nginx", "/srv") // external
+	busy := map[int]bool{3005: true}
+	isFree := func(p int) bool { return !busy[p] }
+
+	if got, want := freePorts(store, cfg, 0, isFree), []int{3000, 3004, 3006, 3007, 3009}; !reflect.DeepEqual(got, want) {
+		t.Errorf("freePorts() = %v, want %v", got, want)
+	}
+	if got, want := freePorts(store, cfg, 2, isFree), []int{3000, 3004}; !reflect.DeepEqual(got, want) {
+		t.Errorf("freePorts(count 2) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// runGC removes expired, stale external, and orphaned allocations in one pass.
+// Designed to run unattended from cron or a systemd timer.
+func runGC(args []string) error {
+	// --dry-run is a global flag extracted by parseArgs
+	dryRun := allocations.IsDryRun()
+	watch := false
+	for _, arg := range args {
+		switch arg {
+		case "-n":
+			dryRun = true
+		case "--watch-docker":
+			watch = true
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	opts := allocations.GCOptions{
+		TTL:        cfg.GetAllocationTTL(),
+		IsPortFree: port.IsPortFree,
+		DirExists:  allocations.DirExists,
+		OwnerAlive: port.ProcessAlive,
+		// A listening port counts as use, like a request for it
+		TouchListening: true,
+	}
+	if port.IsOffline() {
+		// A free-looking port proves nothing offline: keep external and --pid allocations
+		opts.IsPortFree, opts.OwnerAlive, opts.TouchListening = nil, nil, false
+		if watch {
+			return requireChecks("gc --watch-docker")
+		}
+	}
+	debug.Printf("main", "gc: ttl=%v, dry-run=%v", opts.TTL, dryRun)
+
+	var removed []allocations.GCCandidate
+	touched := 0
+	if dryRun {
+		store, err := allocations.Load(configDir)
+		if err != nil {
+			return err
+		}
+		removed = store.FindGarbage(opts)
+	} else {
+		// WithStore also merges leftover legacy freeze history into the store
+		err = allocations.WithJournal(configDir, "gc", func(store *allocations.Store) error {
+			removed = store.CollectGarbage(opts)
+			touched = store.TouchListening(opts)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	for _, c := range removed {
+		fmt.Printf("%s port %d (%s, '%s'): %s\n", verb, c.Port, pathutil.ShortenHomePath(c.Directory), c.Name, c.Reason)
+	}
+
+	if touched > 0 {
+		debug.Printf("main", "gc: refreshed last use of %d listening allocation(s)", touched)
+	}
+
+	switch {
+	case len(removed) == 0:
+		fmt.Println("Nothing to clean up.")
+	case dryRun:
+		fmt.Printf("%d allocation(s) would be removed.\n", len(removed))
+	default:
+		fmt.Printf("Removed %d allocation(s).\n", len(removed))
+	}
+
+	if watch {
+		if dryRun {
+			return fmt.Errorf("--watch-docker cannot be combined with --dry-run")
+		}
+		return watchDocker(configDir)
+	}
+	return nil
+}
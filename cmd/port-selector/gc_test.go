@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestGC(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(workDir, 3800)
+	store.SetAllocation(filepath.Join(tmpDir, "deleted"), 3801)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	t.Run("dry run", func(t *testing.T) {
+		cmd := exec.Command(binary, "gc", "--dry-run")
+		cmd.Env = env
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(output), "Would remove port 3801") {
+			t.Errorf("expected dry-run report for 3801, got: %s", output)
+		}
+
+		loaded, err := allocations.Load(configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.FindByPort(3801) == nil {
+			t.Error("dry run must not remove allocations")
+		}
+	})
+
+	t.Run("removes orphaned allocation", func(t *testing.T) {
+		cmd := exec.Command(binary, "gc")
+		cmd.Env = env
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(output), "Removed 1 allocation(s)") {
+			t.Errorf("unexpected output: %s", output)
+		}
+
+		loaded, err := allocations.Load(configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.FindByPort(3801) != nil {
+			t.Error("expected allocation for deleted directory to be removed")
+		}
+		if loaded.FindByPort(3800) == nil {
+			t.Error("expected allocation for existing directory to be kept")
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// groupLabel is the label that ties allocations created with --group together.
+const groupLabel = "group"
+
+// parseGroupFromArgs extracts --group NAME and returns the group ("" if absent)
+// and the remaining arguments.
+func parseGroupFromArgs(args []string) (string, []string, error) {
+	var group string
+	var remaining []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value, hasValue := strings.CutPrefix(arg, "--group=")
+		if !hasValue {
+			if arg != "--group" {
+				remaining = append(remaining, arg)
+				continue
+			}
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("--group requires a name")
+			}
+			i++
+			value = args[i]
+		}
+		if err := validateLabelValue(groupLabel, value); err != nil {
+			return "", nil, err
+		}
+		group = value
+	}
+	return group, remaining, nil
+}
+
+// validateLabelValue checks that value is non-empty and can be stored as the
+// value of label key (group or session).
+func validateLabelValue(key, value string) error {
+	if _, _, _, err := allocations.ParseLabel(key + "=" + value); err != nil || value == "" {
+		return fmt.Errorf("invalid %s name: %q", key, value)
+	}
+	return nil
+}
+
+// labelFlag returns the label set by arg if it is --group or --session (with
+// the value either attached or in the next argument), or "".
+func labelFlag(arg string) string {
+	for _, key := range []string{groupLabel, sessionLabel} {
+		if arg == "--"+key || strings.HasPrefix(arg, "--"+key+"=") {
+			return key
+		}
+	}
+	return ""
+}
+
+// labeledAllocations returns the allocations whose label key equals value, in
+// any directory, sorted by port.
+func labeledAllocations(store *allocations.Store, key, value string) []allocations.Allocation {
+	var members []allocations.Allocation
+	for _, a := range store.SortedByPort() {
+		if a.Labels[key] == value {
+			members = append(members, a)
+		}
+	}
+	return members
+}
+
+// runGroupLock locks or unlocks every allocation of group in one transaction.
+func runGroupLock(group string, locked bool, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("--group cannot be combined with %v", args)
+	}
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	var members []allocations.Allocation
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		members = labeledAllocations(store, groupLabel, group)
+		if len(members) == 0 {
+			return fmt.Errorf("no allocations in group '%s'", group)
+		}
+		for _, a := range members {
+			store.SetLockedByPort(a.Port, locked)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "Locked"
+	if !locked {
+		verb = "Unlocked"
+	}
+	fmt.Printf("%s %d allocation(s) of group '%s'\n", verb, len(members), group)
+	return nil
+}
+
+// runForgetGroup removes every allocation of group in one journaled transaction.
+func runForgetGroup(group string, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("--group cannot be combined with %v", args)
+	}
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	removed, err := forgetLabeled(configDir, groupLabel, group, "--forget --group "+group)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Printf("No allocations in group '%s'\n", group)
+		return nil
+	}
+	fmt.Printf("%s %d allocation(s) of group '%s'\n", clearedVerb(), len(removed), group)
+	return nil
+}
+
+// forgetLabeled removes every allocation whose label key equals value in one
+// journaled transaction and returns the removed allocations.
+func forgetLabeled(configDir, key, value, command string) ([]allocations.Allocation, error) {
+	var removed []allocations.Allocation
+	err := allocations.WithJournal(configDir, command, func(store *allocations.Store) error {
+		removed = labeledAllocations(store, key, value)
+		for _, a := range removed {
+			store.RemoveByPort(a.Port)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// runGroup prints the allocations of a group (group NAME [--format table|dotenv|json]).
+func runGroup(args []string) error {
+	var group string
+	format := "table"
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires a value")
+			}
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown option: %s", arg)
+		case group == "":
+			group = arg
+		default:
+			return fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if group == "" {
+		return fmt.Errorf("usage: port-selector group NAME [--format table|dotenv|json]")
+	}
+	if format != "table" && format != "dotenv" && format != "json" {
+		return fmt.Errorf("invalid format %q (use table, dotenv or json)", format)
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	members := labeledAllocations(store, groupLabel, group)
+	if len(members) == 0 {
+		return fmt.Errorf("no allocations in group '%s'", group)
+	}
+	return printGroup(members, format)
+}
+
+// groupEntry is one allocation in `group --format json`.
+type groupEntry struct {
+	Name      string `json:"name"`
+	Port      int    `json:"port"`
+	Directory string `json:"directory"`
+	Locked    bool   `json:"locked"`
+}
+
+// printGroup prints group members in the given format.
+func printGroup(members []allocations.Allocation, format string) error {
+	switch format {
+	case "dotenv":
+		for _, a := range members {
+			fmt.Printf("%s=%d\n", dotenvKey(a.Name), a.Port)
+		}
+		return nil
+	case "json":
+		entries := make([]groupEntry, len(members))
+		for i, a := range members {
+			entries[i] = groupEntry{Name: a.Name, Port: a.Port, Directory: a.Directory, Locked: a.Locked}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPORT\tLOCKED\tDIRECTORY")
+	for _, a := range members {
+		locked := ""
+		if a.Locked {
+			locked = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", a.Name, a.Port, locked, pathutil.ShortenHomePath(a.Directory))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestParseGroupFromArgs(t *testing.T) {
+	tests := []struct {
+		args      []string
+		group     string
+		remaining []string
+		wantErr   bool
+	}{
+		{args: []string{"--group", "ci-run-42"}, group: "ci-run-42"},
+		{args: []string{"--group=ci-run-42", "web"}, group: "ci-run-42", remaining: []string{"web"}},
+		{args: []string{"web"}, remaining: []string{"web"}},
+		{args: []string{"--group"}, wantErr: true},
+		{args: []string{"--group="}, wantErr: true},
+		{args: []string{"--group", "a,b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		group, remaining, err := parseGroupFromArgs(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseGroupFromArgs(%v) expected error", tt.args)
+			}
+			continue
+		}
+		if err != nil || group != tt.group || !reflect.DeepEqual(remaining, tt.remaining) {
+			t.Errorf("parseGroupFromArgs(%v) = %q, %v, %v; want %q, %v", tt.args, group, remaining, err, tt.group, tt.remaining)
+		}
+	}
+}
+
+func TestForgetLabeled_Group(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/shop", 3000, "main")
+		s.SetLabels(3000, map[string]string{groupLabel: "ci-run-42"})
+		s.SetAllocationWithName("/billing", 3001, "main")
+		s.SetLabels(3001, map[string]string{groupLabel: "ci-run-42"})
+		s.SetAllocationWithName("/shop", 3002, "web")
+		s.SetLabels(3002, map[string]string{groupLabel: "ci-run-43"})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := forgetLabeled(configDir, groupLabel, "ci-run-42", "--forget --group ci-run-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0].Port != 3000 || removed[1].Port != 3001 {
+		t.Errorf("forgetLabeled() removed %+v, want ports 3000 and 3001", removed)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.FindByPort(3000) != nil || store.FindByPort(3001) != nil {
+		t.Error("group members are still allocated")
+	}
+	if store.FindByPort(3002) == nil {
+		t.Error("allocation of another group was removed")
+	}
+}
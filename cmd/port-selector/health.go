@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+// healthTimeout bounds a single health-check request.
+const healthTimeout = 2 * time.Second
+
+// healthProbe performs the HTTP health check of a port and returns the status code.
+type healthProbe func(port int, path string) (int, error)
+
+// probeHealth sends GET http://127.0.0.1:PORT/PATH. Redirects are not followed.
+func probeHealth(port int, path string) (int, error) {
+	client := &http.Client{
+		Timeout: healthTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get("http://127.0.0.1:" + strconv.Itoa(port) + path)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// healthOK reports whether a health-check status code means healthy (2xx or 3xx).
+func healthOK(status int) bool {
+	return status >= 200 && status < 400
+}
+
+// describeHealthFailure explains a failed health check for --check and status.
+func describeHealthFailure(path string, status int, err error) string {
+	if err != nil {
+		return fmt.Sprintf("GET %s failed: %v", path, err)
+	}
+	return fmt.Sprintf("GET %s returned %d %s", path, status, http.StatusText(status))
+}
+
+// healthSummary probes every listening allocation with a health path and returns
+// a one-line summary for status, or "" if no allocation has a health path.
+func healthSummary(allocs []allocations.Allocation, isPortFree allocations.PortChecker, probe healthProbe) string {
+	var total, healthy int
+	var failing []string
+	for _, a := range allocs {
+		if a.HealthPath == "" {
+			continue
+		}
+		total++
+		if isPortFree(a.Port) {
+			failing = append(failing, fmt.Sprintf("%d (not listening)", a.Port))
+			continue
+		}
+		status, err := probe(a.Port, a.HealthPath)
+		if err != nil || !healthOK(status) {
+			if err != nil {
+				failing = append(failing, fmt.Sprintf("%d (no response)", a.Port))
+			} else {
+				failing = append(failing, fmt.Sprintf("%d (%d)", a.Port, status))
+			}
+			continue
+		}
+		healthy++
+	}
+	if total == 0 {
+		return ""
+	}
+	s := fmt.Sprintf("%d/%d healthy", healthy, total)
+	if len(failing) > 0 {
+		s += ", failing: " + strings.Join(failing, ", ")
+	}
+	return s
+}
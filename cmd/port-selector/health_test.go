@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestProbeHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/missing", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	_, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.Atoi(portStr)
+
+	for path, want := range map[string]int{"/healthz": 200, "/moved": 302, "/missing": 503} {
+		if status, err := probeHealth(p, path); err != nil || status != want {
+			t.Errorf("probeHealth(%s) = %d, %v, want %d", path, status, err, want)
+		}
+	}
+
+	allocs := []allocations.Allocation{
+		{Port: p, HealthPath: "/healthz"},
+		{Port: p, HealthPath: "/missing"},
+		{Port: 1, HealthPath: "/healthz"},
+		{Port: 2},
+	}
+	isPortFree := func(port int) bool { return port != p }
+	want := "1/3 healthy, failing: " + portStr + " (503), 1 (not listening)"
+	if got := healthSummary(allocs, isPortFree, probeHealth); got != want {
+		t.Errorf("healthSummary() = %q, want %q", got, want)
+	}
+	if got := healthSummary(allocs[3:], isPortFree, probeHealth); got != "" {
+		t.Errorf("healthSummary() without health paths = %q, want empty", got)
+	}
+}
+
+func TestParseAllocOptions_Health(t *testing.T) {
+	opts, _, err := parseAllocOptions([]string{"--health=/healthz"})
+	if err != nil || !opts.healthSet || opts.health != "/healthz" {
+		t.Errorf("parseAllocOptions(--health=/healthz) = %+v, %v", opts, err)
+	}
+	if _, _, err := parseAllocOptions([]string{"--health", "healthz"}); err == nil {
+		t.Error("expected error for a path without leading slash")
+	}
+}
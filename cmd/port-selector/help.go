@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// helpEntry documents a command, an option or a config key. The same entries
+// produce --help, --help-full and the man page (--man), so they can't drift apart.
+type helpEntry struct {
+	usage string // e.g. "--forget DIR|@ALIAS"
+	short string // one line for --help; further lines continue the description
+	long  string // extra detail for --help-full and the man page (optional)
+}
+
+// helpTopic is a free-form section (examples, notes) after the command and option lists.
+type helpTopic struct {
+	title    string
+	body     string // preformatted, indented by two spaces in --help
+	fullOnly bool   // shown only in --help-full and the man page
+}
+
+// helpColumn is the column where descriptions start in --help.
+const helpColumn = 23
+
+const helpSummary = `Finds and returns a free port from configured range.
+Remembers which port was assigned to which directory.`
+
+var commandHelp = []helpEntry{
+	{"apply FILE [--format summary|dotenv]", "Allocate all services from a manifest in one step",
+		"The manifest lists services with an optional name and lock flag;\nall of them are allocated in one transaction."},
+	{"init rails|nextjs|django [--force]", "Allocate the conventional named ports of a framework\nand write .port-selector.yaml documenting them",
+		"The allocated ports become the preferred ports of the project.\n--force overwrites an existing .port-selector.yaml."},
+	{"advertise", "Announce listening allocations named in advertiseNames\non the LAN via mDNS/DNS-SD until interrupted",
+		"Opt-in: nothing is announced unless advertiseNames is set in the config."},
+	{"tunnel user@host [--name NAME] [--print]", "Allocate a port on user@host with its port-selector and forward it\nto the local allocation with ssh -R until interrupted",
+		"Both ends are recorded as external allocations pointing at the other end.\n--print prints the ssh command instead of running it.\ntunnel --accept HOST:PORT --name NAME is the remote half, run over ssh."},
+	{"firewall [--allow-lan] [--remove]", "Print ufw/firewalld rules opening the directory's ports to the LAN;\n--allow-lan applies them with sudo, --remove deletes them",
+		"--tool ufw|firewalld and --source CIDR override detection.\nApplied rules are also removed by --forget."},
+	{"group NAME", "List the allocations of a group (--format table|dotenv|json)", ""},
+	{"session end ID", "Free every allocation tagged with --session ID, in any directory", "session list shows the sessions that still hold allocations."},
+	{"devcontainer [--on-auto-forward A]", "Print forwardPorts and portsAttributes JSON for the current directory's\nallocations, for devcontainer.json and Codespaces", ""},
+	{"dedupe [--dry-run]", "Store directories normalized (symlinks resolved, case as on disk) and merge\nduplicate allocations (migrates allocations made through symlinks)", ""},
+	{"here", "Show the current directory's allocations: name, port, status, locked, age", ""},
+	{"status", "Show range utilization (locked, external, frozen, busy, free),\nthe oldest allocation and store file stats", ""},
+	{"swap PORT1 PORT2", "Exchange the directories and names of two allocations",
+		"Locked, external and listening ports are refused."},
+	{"gc [--dry-run] [--watch-docker]", "Remove expired, stale external and orphaned allocations\n(for cron or a systemd timer)",
+		"--watch-docker keeps running and registers published container ports as external\nallocations on container start, removing them on stop (Docker or Podman API socket)."},
+	{"bench [--parallel N] [--iterations N]", "Run concurrent allocations against a temporary store\nand report lock wait times and throughput",
+		"Uses the configured backend and port range; the real store and log are not touched.\nFails if the store ends up with lost updates."},
+	{"history [--port N] [--dir PATH|@ALIAS] [--since 7d]", "Show allocation lifecycle events from the log",
+		"Requires the log option in the config."},
+	{"undo [--list] [--force]", "Revert the last --forget*, --lock PORT or gc",
+		"The last 10 operations are kept in undo-journal.yaml. --force\noverwrites ports that changed after the operation."},
+	{"restore [--from N|FILE]", "Replace the store with a backup (lists backups without --from)",
+		"N selects allocations.yaml.bak.N (see the backups option); works even\nif the current store is corrupted."},
+	{"repair", "Salvage readable entries of a corrupted allocations file",
+		"The broken file is kept as allocations.yaml.corrupt-<time>."},
+	{"events [--follow] [--interval D]", "Print allocations as JSON lines; --follow streams changes",
+		"Event types: snapshot, allocate, release, lock, unlock, update.\nThe store is checked for changes every 500ms by default."},
+	{"prompt", "Print the current directory's ports on one line for shell prompts (web:3010* api:3011)",
+		"* marks locked ports. Reads the store without locking and never probes ports;\nerrors print an empty line (see --verbose)."},
+	{"vscode [--print | --json]", "Add the ports of the current directory as inputs to .vscode/tasks.json",
+		"Inputs are named port-selector.<name>; use ${input:port-selector.web} in tasks and launch configurations.\n--print shows the updated file, --json prints the ports for the editor extension."},
+	{"config get KEY | set KEY VALUE | edit | validate | path", "Read or change the config file (comments are preserved)", ""},
+	{"profiles", "List profiles (independent port pools, see --profile)", ""},
+	{"alias set NAME | clear | list", "Name the current directory's allocations; use @NAME for --dir", ""},
+	{"show [PORT | --name NAME] [--json]", "Print every stored field of an allocation as YAML (or JSON)", "Without PORT, shows the allocation of the current directory (default name: main)."},
+	{"note PORT [TEXT]", "Show or set a free-text note for an allocation (empty TEXT clears it)", "The note is shown (truncated) in --list."},
+	{"systemd [--name NAME] [--exec CMD] [--unit UNIT] [--output DIR]", "Generate a systemd user service + socket for the port", ""},
+	{"proxy [--format caddy|nginx|traefik]", "Print reverse proxy config for <dir>.localhost hostnames", ""},
+	{"hostname [HOST] [--name NAME] [--clear]", "Record a hostname (default <dir>.local) for the allocation", ""},
+	{"hosts [--write [FILE]]", "Print (or write into /etc/hosts) entries for recorded hostnames", ""},
+	{"open [--name NAME] [--path /PATH] [--print]", "Open http://localhost:PORT/PATH in the default browser", ""},
+	{"url [--name NAME] [--path /PATH] [--lan] [--qr]", "Print the URL of the allocation; --lan uses the LAN address,\n--qr also renders a terminal QR code (implies --lan)", ""},
+}
+
+var optionHelp = []helpEntry{
+	{"-h, --help", "Show this help message", ""},
+	{"--help-full", "Show this help with detailed descriptions", ""},
+	{"--man", "Print the man page (roff)", "Install with: port-selector --man > ~/.local/share/man/man1/port-selector.1"},
+	{"-v, --version", "Show version", ""},
+	{"-l, --list [--label KEY[=VALUE]] [--k8s] [--all-hosts] [--wide] [--columns LIST] [--format FORMAT] [--group-by dir] [--stale[=AGE]]", "List all port allocations",
+		"With --k8s, show only kubectl port-forwards recorded by --scan.\nWith --all-hosts (perHost: true), list the stores of all machines.\n--wide shows full directories, processes and notes.\n--columns picks columns in order: port, host, dir, alias, name, hostname, source,\nstatus, locked, user, pid, process, assigned, age, expires, owner, labels, note.\n--format prints the same rows as table (default), csv, markdown or json.\n--group-by dir prints each directory once with its allocations beneath.\n--stale shows only expired allocations and those unused for AGE (default 30d)."},
+	{"--check [--json]", "Exit 0 if the allocation is listening from this directory (2 if not)", ""},
+	{"-c, --lock [PORT]", "Lock port for current directory and name (or specified port)",
+		"With PORT, allocates and locks that port in one step (see Port Locking)."},
+	{"-u, --unlock [PORT]", "Unlock port for current directory and name (or specified port)", ""},
+	{"--sticky [PORT]", "Prefer PORT (or the allocated port) for current directory and name",
+		"Unlike a lock, other directories may take a sticky port while it is free;\nit is given back on the next request once it is free again."},
+	{"--unsticky", "Remove the sticky port of current directory and name", ""},
+	{"--force, -f", "Force lock a busy port or locked port from another directory", ""},
+	{"--forget", "Clear all port allocations for current directory", ""},
+	{"--forget --name NAME", "Clear port allocation for current directory with specific name", ""},
+	{"--forget DIR|@ALIAS", "Clear port allocations for another directory (may be deleted)", ""},
+	{"--forget PORT", "Clear the allocation of a specific port", ""},
+	{"--forget-glob GLOB", "Clear allocations whose directory matches GLOB (asks; --yes to skip)", ""},
+	{"--forget-prefix DIR", "Clear allocations for DIR and everything under it (asks; --yes to skip)", ""},
+	{"--release", "Clear allocation only if its port is free and unlocked (exit 3 if refused)", ""},
+	{"--renew [--lease D]", "Extend the lease of the allocation (--lease D changes its length)", ""},
+	{"--rename OLD NEW", "Rename an allocation of the current directory, keeping its port and lock", ""},
+	{"--move [PORT] DIR", "Move an allocation (PORT, or --name NAME of the current directory) to DIR",
+		"The port, name, lock and timestamps are kept."},
+	{"--forget-all [--yes]", "Clear all port allocations (asks on a terminal, backs up the store)",
+		"The store is copied to allocations.yaml.bak first."},
+	{"--free [--count N]", "Print free ports in the range without allocating them",
+		"Free means not locked, not external, not frozen, not excluded and not listening;\nthe command fails if there are none."},
+	{"--scan", "Scan port range and record busy ports with their directories",
+		"kubectl port-forwards are recorded under (k8s:CONTEXT/NAMESPACE) with the forwarded target as NAME."},
+	{"--refresh", "Refresh external port allocations (remove stale entries)", ""},
+	{"--convert-store FMT", "Copy allocations into another store backend (yaml or remote)", ""},
+	{"--schema", "Print the JSON Schema of the --json outputs (one $defs entry per output)", ""},
+	{"--name NAME", `Use named allocation (default: .port-selector-name, else "main")`, ""},
+	{"--respect-env", "Register $PORT for current directory instead of allocating", ""},
+	{"--no-freeze", "Don't freeze the port after use (for throwaway allocations)", ""},
+	{"--lease D", "Expire the allocation after D (e.g., 2h) unless it is reissued or renewed",
+		"Independent of allocationTTL. Every reissue in the directory renews the lease."},
+	{"--ephemeral", "Take a port assigned by the kernel (outside the range) and remember it", ""},
+	{"--on-conflict P", "What to do when the existing port is taken by another directory's process:\nreuse, fail or reallocate (overrides onConflict)", ""},
+	{"--verify-owner", "Check that a busy locked port is held by a process in its directory;\na foreign holder is reported and handled by onConflict", ""},
+	{"--pid PID", "Bind the allocation to the process that will use the port;\ngc frees it as soon as the process exits", ""},
+	{"--label KEY=VALUE", "Set a label on the allocation (repeatable; KEY= removes it)",
+		"With --list, show only allocations with the label (KEY alone matches any value)."},
+	{"--group NAME", "Add the allocation to a group (stored as the group label)",
+		"With --list, --lock, --unlock or --forget, act on every allocation of the group in any directory."},
+	{"--session ID", "Tag the allocation with a session token (e.g., $CI_JOB_ID) for session end", ""},
+	{"--health PATH", "Store an HTTP health-check path (e.g., /healthz) on the allocation;\n--check and status send GET to it (empty PATH removes it)", ""},
+	{"--json", "Print the allocation as JSON (with a breakdown of the range when it is exhausted)", ""},
+	{"--hold", "Keep the port bound after printing it until stdin closes or SIGUSR1",
+		"Closes the race between allocation and service start: release the port right before the service binds it."},
+	{"--wait [--timeout D]", "Block until the port is listening (default timeout 30s)", ""},
+	{"--wait --free", "Block until the port is free", ""},
+	{"--verbose[=MODULES]", "Enable debug output (can be combined with other flags);\nMODULES limits it, e.g. --verbose=allocations,port", ""},
+	{"--debug-json", "Print debug output as JSON lines (implies --verbose)", ""},
+	{"--dry-run", "Print what would change in the allocations without saving\n(can be combined with other commands)", ""},
+	{"--read-only", "Fail any command that would change the allocations;\nlookups of existing allocations still work", ""},
+	{"--offline", "Skip all liveness checks (no bind, /proc or container runtime);\nallocation relies on the store alone", ""},
+	{"--no-color", "Disable colors (also NO_COLOR=1); colors are used only on a terminal", ""},
+	{"--config DIR", "Use DIR instead of ~/.config/port-selector (config, allocations, log)", ""},
+	{"--store FILE", "Read and write allocations in FILE (lock and undo journal next to it)", ""},
+	{"--profile NAME", "Use an independent port pool (config + allocations) named NAME\n(also $PORT_SELECTOR_PROFILE)", ""},
+}
+
+// configHelp documents the config.yaml keys (every key of config.Keys must be listed).
+var configHelp = []helpEntry{
+	{"portStart: 3000", "Start of port range", ""},
+	{"portEnd: 4000", "End of port range", ""},
+	{"freezePeriod: 24h", "How long to avoid reusing a port (e.g., 24h, 30m, 0 to disable)", ""},
+	{"allocationTTL: 30d", "Auto-expire allocations (e.g., 30d, 720h, 0 to disable)", ""},
+	{"expiryWarning: 3d", "Warn on stderr when another allocation of the directory expires\n(TTL or lease) within this time (default 3d, 0 to disable)", ""},
+	{"log: ~/.config/port-selector/port-selector.log", "Log file path (optional)", ""},
+	{"logFormat: text", "Log line format: text or json", ""},
+	{"logTarget: journald", "Where allocation events go: file (default), syslog or journald\n(journald gets every field as PORT_SELECTOR_* metadata)", ""},
+	{"store: yaml", "Storage backend: yaml or remote", ""},
+	{"remoteURL: URL", "HTTP(S) URL of the shared store for store: remote ($PORT_SELECTOR_REMOTE_TOKEN is sent as a bearer token)", ""},
+	{"notify: true", "Desktop notification when an allocated port is taken", ""},
+	{"verifyOwner: true", "Always check who holds a busy locked port (same as --verify-owner)", ""},
+	{"onConflict: fail", "When an unlocked allocated port is taken by another directory's process: reuse (default, warn), fail or reallocate", ""},
+	{"ephemeralOverlap: fail", "When the range overlaps the kernel's ephemeral range: warn (default), fail (refuse new allocations) or ignore", ""},
+	{"perHost: true", "Keep a separate store per hostname for synced config directories ($PORT_SELECTOR_HOST overrides the hostname)", ""},
+	{"readOnly: true", "Never change the allocations store (same as --read-only)", ""},
+	{"backups: 5", "Keep N copies of the store, taken before each change", ""},
+	{"updateCheck: true", "Check for a new release once a day (notice on stderr)", ""},
+	{"containerRuntime: podman", "Container runtime for attributing published ports: auto (default), docker, podman, nerdctl", ""},
+	{"composeBlockSize: 5", "Adjacent ports reserved for the named ports of a compose project (default 10, 1 disables)", ""},
+	{"portCheck: strict", "Port availability check: bind (default) or strict (127.0.0.1, 0.0.0.0 and :: without SO_REUSEADDR)",
+		"strict reports ports in TIME_WAIT and ports bound to a single interface as busy."},
+	{"checkTimeoutMs: 250", "A port check slower than this reports the port busy (default 1000)",
+		"A whole port search gives up after 10s with an error instead of hanging."},
+	{"checks: off", "Turn liveness checks off (same as --offline): the store alone decides", ""},
+	{"rootDetection: git", "Resolve subdirectories to the project root: none (default), git (nearest .git)\nor config (nearest .port-selector.yaml)", ""},
+	{"symlinks: keep", "Directories reached through a symlink: resolve (default, share the real path's\nallocations) or keep", ""},
+	{"pathCase: insensitive", "Directories that differ only in case: auto (default; the same on macOS\nand Windows), sensitive or insensitive", ""},
+	{"language: ru", "Language of errors and warnings on stderr: auto (default, from LANG), en or ru", ""},
+	{"socketSource: proc", "How listening sockets are read: auto (default, sock_diag netlink with /proc fallback), netlink, proc", ""},
+	{"freezeRules:", "Per-name/directory freeze overrides (first match wins)", ""},
+	{"excludedPorts: [3306, 5432]", "Ports that are never allocated, even when free", ""},
+	{"excludedRanges: [\"6000-6063\"]", "Port ranges that are never allocated, even when free", ""},
+	{"advertiseNames: [web, api]", "Allocation names announced on the LAN by advertise (opt-in)", ""},
+}
+
+// configRulesExample follows the freezeRules entry in the Configuration section.
+const configRulesExample = `- name: tmp
+  freezePeriod: 0`
+
+var helpTopics = []helpTopic{
+	{title: "Named Allocations", body: `--name <name> creates a stable, per-directory named allocation.
+The same directory can have multiple named allocations (web/api/db/etc.).
+Default name is "main" when --name is not provided, unless the directory sets
+another one in .port-selector-name or the name key of .port-selector.yaml.`},
+	{title: "Examples", body: `port-selector                    # Use default name "main"
+port-selector --name postgres    # Named allocation for postgres
+port-selector --name web         # Named allocation for web
+port-selector --list             # Show all allocations with NAME column
+port-selector --lock             # Lock "main" allocation
+port-selector --lock --name web  # Lock "web" allocation
+port-selector --unlock --name db # Unlock "db" allocation
+port-selector --forget           # Forget all allocations for directory
+port-selector --forget --name api # Forget only "api" allocation
+port-selector --release --name web # Forget "web" only if it is not in use
+port-selector --refresh          # Remove stale external port allocations
+port-selector --check --name web # Readiness check for scripts and Makefiles
+port-selector apply services.yaml --format dotenv > .env
+PORT=3100 port-selector --respect-env  # Register port injected by CI`},
+	{title: "Port Locking", body: `Locked ports are reserved and won't be allocated to other directories.
+Use this for long-running services.
+
+Using --lock with a port number will allocate AND lock that port
+to the current directory/name in one step.
+
+When --lock PORT targets another directory's port:
+- Free + unlocked: reassigned without --force (abandoned allocation)
+- Free + locked: requires --force to reassign
+- Busy (any): blocked completely — stop the service first
+
+When --lock PORT targets a busy unallocated port:
+- Requires --force (you take responsibility for the conflict)
+
+If the port is already in use by another directory, it will be
+registered as an external allocation instead of failing.`},
+	{title: "Exit Status", fullOnly: true, body: `0  success
+1  error
+2  --check: the allocation is not listening from this directory
+3  --release: the port is locked or in use`},
+	{title: "Files", fullOnly: true, body: `~/.config/port-selector/config.yaml        configuration
+~/.config/port-selector/allocations.yaml   allocations
+~/.config/port-selector/undo-journal.yaml  operations that can be undone
+~/.config/port-selector/allocations.yaml.bak*  store backups
+~/.config/port-selector/profiles/NAME/     profiles (--profile NAME)`},
+	{title: "Environment", fullOnly: true, body: `PORT_SELECTOR_PROFILE  profile to use when --profile is not given
+PORT_SELECTOR_DEBUG    debug output like --verbose=VALUE (1, MODULES, json)
+PORT                   port registered by --respect-env
+XDG_CONFIG_HOME        base of the config directory (default ~/.config)
+XDG_STATE_HOME         base of the store directory when the config directory is read-only (default ~/.local/state)
+VISUAL, EDITOR         editor for 'config edit'`},
+}
+
+// printHelp prints the short help to stdout.
+func printHelp() {
+	writeHelp(os.Stdout, false)
+}
+
+// writeHelp renders the help text; full adds detailed descriptions and extra topics.
+func writeHelp(w io.Writer, full bool) {
+	fmt.Fprintln(w, "Usage: port-selector [options]\n       port-selector <command> [args]")
+	fmt.Fprintf(w, "\n%s\n", helpSummary)
+
+	fmt.Fprintln(w, "\nCommands:")
+	writeEntries(w, commandHelp, "  ", full)
+	fmt.Fprintln(w, "\nOptions:")
+	writeEntries(w, optionHelp, "  ", full)
+
+	for _, t := range helpTopics {
+		if t.fullOnly && !full {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n%s\n", t.title, indent(t.body, "  "))
+	}
+
+	fmt.Fprintln(w, "\nConfiguration:\n  ~/.config/port-selector/config.yaml\n\n  Available options:")
+	writeConfigEntries(w)
+	fmt.Fprintln(w, "\nSource code:\n  https://github.com/dapi/port-selector")
+}
+
+// writeEntries prints entries as "usage  description", moving the description
+// to the next line when the usage doesn't fit before helpColumn.
+func writeEntries(w io.Writer, entries []helpEntry, prefix string, full bool) {
+	pad := strings.Repeat(" ", helpColumn)
+	for _, e := range entries {
+		lines := strings.Split(e.short, "\n")
+		if full && e.long != "" {
+			lines = append(lines, strings.Split(e.long, "\n")...)
+		}
+		width := helpColumn - len(prefix) - 1
+		if len(e.usage) <= width {
+			fmt.Fprintf(w, "%s%-*s %s\n", prefix, width, e.usage, lines[0])
+		} else {
+			fmt.Fprintf(w, "%s%s\n%s%s\n", prefix, e.usage, pad, lines[0])
+		}
+		for _, l := range lines[1:] {
+			fmt.Fprintf(w, "%s%s\n", pad, l)
+		}
+	}
+}
+
+// writeConfigEntries prints the config keys as an annotated YAML snippet.
+func writeConfigEntries(w io.Writer) {
+	for _, e := range configHelp {
+		line := "    " + e.usage
+		if len(line) < 26 {
+			line += strings.Repeat(" ", 26-len(line))
+		} else {
+			line += "  "
+		}
+		fmt.Fprintf(w, "%s# %s\n", line, e.short)
+	}
+	fmt.Fprintln(w, indent(configRulesExample, "      "))
+}
+
+// indent prefixes every non-empty line of s.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeMan renders the man page in roff format.
+func writeMan(w io.Writer, date time.Time) {
+	fmt.Fprintf(w, ".TH PORT-SELECTOR 1 %q %q \"User Commands\"\n", date.Format("2006-01-02"), "port-selector "+version)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `port-selector \- allocate stable free ports per project directory`)
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, `.B port-selector
+[\fIoptions\fR]
+.br
+.B port-selector
+\fIcommand\fR [\fIargs\fR]`)
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, roffEscape(helpSummary))
+
+	writeManEntries(w, "COMMANDS", commandHelp)
+	writeManEntries(w, "OPTIONS", optionHelp)
+	for _, t := range helpTopics {
+		fmt.Fprintf(w, ".SH %s\n.nf\n%s\n.fi\n", strings.ToUpper(t.title), roffEscape(t.body))
+	}
+
+	fmt.Fprintln(w, ".SH CONFIGURATION")
+	fmt.Fprintln(w, roffEscape("~/.config/port-selector/config.yaml (created on first run):"))
+	for _, e := range configHelp {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roffEscape(e.usage), roffEscape(e.short))
+	}
+	fmt.Fprintln(w, ".SH SEE ALSO")
+	fmt.Fprintln(w, roffEscape("https://github.com/dapi/port-selector"))
+}
+
+// writeManEntries renders entries as a tagged paragraph list.
+func writeManEntries(w io.Writer, title string, entries []helpEntry) {
+	fmt.Fprintf(w, ".SH %s\n", title)
+	for _, e := range entries {
+		text := roffEscape(strings.ReplaceAll(e.short, "\n", " "))
+		if e.long != "" {
+			text += "\n.br\n" + roffEscape(strings.ReplaceAll(e.long, "\n", " "))
+		}
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roffEscape(e.usage), text)
+	}
+}
+
+// roffEscape escapes backslashes and dashes, and protects lines that would
+// otherwise start a roff request.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestWriteHelp(t *testing.T) {
+	var short, full bytes.Buffer
+	writeHelp(&short, false)
+	writeHelp(&full, true)
+
+	for _, want := range []string{
+		"  -h, --help           Show this help message\n",
+		"  --forget-all [--yes] Clear all port allocations",
+		"  apply FILE [--format summary|dotenv]\n                       Allocate all services",
+		"    portStart: 3000       # Start of port range\n",
+		"      - name: tmp\n        freezePeriod: 0\n",
+	} {
+		if !strings.Contains(short.String(), want) {
+			t.Errorf("help doesn't contain %q", want)
+		}
+	}
+
+	if strings.Contains(short.String(), "Exit Status:") {
+		t.Error("short help shouldn't contain full-only topics")
+	}
+	for _, want := range []string{"Exit Status:", "                       The store is copied to allocations.yaml.bak first.\n"} {
+		if !strings.Contains(full.String(), want) {
+			t.Errorf("full help doesn't contain %q", want)
+		}
+	}
+}
+
+func TestConfigHelp_CoversAllKeys(t *testing.T) {
+	documented := make(map[string]bool)
+	for _, e := range configHelp {
+		key, _, _ := strings.Cut(e.usage, ":")
+		documented[key] = true
+	}
+	for _, key := range config.Keys() {
+		if !documented[key] {
+			t.Errorf("config key %q is missing from configHelp", key)
+		}
+	}
+}
+
+func TestWriteMan(t *testing.T) {
+	var buf bytes.Buffer
+	writeMan(&buf, time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC))
+	man := buf.String()
+
+	for _, want := range []string{
+		`.TH PORT-SELECTOR 1 "2026-01-10"`,
+		".SH OPTIONS\n",
+		".B \\-\\-forget\\-all [\\-\\-yes]\n",
+		".SH EXIT STATUS\n",
+	} {
+		if !strings.Contains(man, want) {
+			t.Errorf("man page doesn't contain %q", want)
+		}
+	}
+	for _, line := range strings.Split(man, "\n") {
+		if strings.HasPrefix(line, ".") && !strings.HasPrefix(line, ".TH") && !strings.HasPrefix(line, ".SH") &&
+			!strings.HasPrefix(line, ".TP") && !strings.HasPrefix(line, ".B") && !strings.HasPrefix(line, ".nf") &&
+			!strings.HasPrefix(line, ".fi") && !strings.HasPrefix(line, ".br") {
+			t.Errorf("unexpected roff request: %q", line)
+		}
+	}
+}
+
+func TestRoffEscape(t *testing.T) {
+	if got := roffEscape(`a-b \n`); got != `a\-b \en` {
+		t.Errorf("roffEscape() = %q", got)
+	}
+	if got := roffEscape("x\n.hidden"); got != "x\n\\&.hidden" {
+		t.Errorf("roffEscape() = %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// hereColumns are the columns of the here command.
+var hereColumns = []string{"name", "port", "status", "locked", "age"}
+
+// runHere prints the allocations of the current directory as a compact
+// --list table: name, port, live status, lock and age.
+func runHere(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load allocations: %w", err)
+	}
+	allocs, sticky := hereAllocations(store, cwd)
+	if len(allocs) == 0 {
+		fmt.Printf("No allocations for %s (run port-selector first).\n", pathutil.ShortenHomePath(cwd))
+		return nil
+	}
+
+	rows, _ := buildListRows(allocs, port.NewSnapshot(), sticky, listOptions{})
+	return writeListTable(os.Stdout, rows, hereColumns, colorEnabled(os.Stdout))
+}
+
+// hereAllocations returns the allocations of dir sorted by port, and which of
+// their ports are sticky.
+func hereAllocations(store *allocations.Store, dir string) ([]allocations.Allocation, map[int]bool) {
+	var allocs []allocations.Allocation
+	sticky := make(map[int]bool)
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Directory != dir {
+			continue
+		}
+		allocs = append(allocs, alloc)
+		if store.IsSticky(alloc.Port) {
+			sticky[alloc.Port] = true
+		}
+	}
+	return allocs, sticky
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+func TestHereAllocations(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/srv/shop", 3001, "web")
+	store.SetAllocationWithName("/srv/blog", 3002, "main")
+	store.SetAllocationWithName("/srv/shop", 3000, "main")
+	store.SetLockedByPort(3000, true)
+	store.SetSticky(3001, "/srv/shop", "web")
+
+	allocs, sticky := hereAllocations(store, "/srv/shop")
+	if len(allocs) != 2 || allocs[0].Port != 3000 || allocs[1].Port != 3001 {
+		t.Fatalf("hereAllocations() = %+v, want ports 3000 and 3001", allocs)
+	}
+	if !sticky[3001] || len(sticky) != 1 {
+		t.Errorf("sticky = %v, want only 3001", sticky)
+	}
+
+	rows, _ := buildListRows(allocs, port.NewSnapshot(), sticky, listOptions{})
+	var buf bytes.Buffer
+	if err := writeListTable(&buf, rows, hereColumns, false); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 || strings.Join(strings.Fields(lines[0]), " ") != "NAME PORT STATUS LOCKED AGE" ||
+		!strings.HasPrefix(strings.Join(strings.Fields(lines[1]), " "), "main 3000 ") ||
+		!strings.Contains(lines[2], "sticky") {
+		t.Errorf("unexpected here table:\n%s", buf.String())
+	}
+}
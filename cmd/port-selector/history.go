@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/logger"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// historyFilter selects log entries shown by the history command.
+type historyFilter struct {
+	port  int       // 0 = any
+	dir   string    // absolute path; matches the directory and its subdirectories ("" = any)
+	since time.Time // zero = no limit
+}
+
+// matches reports whether the entry passes the filter.
+func (f historyFilter) matches(e logger.Entry) bool {
+	if f.port != 0 && e.Fields["port"] != strconv.Itoa(f.port) {
+		return false
+	}
+	if f.dir != "" && (e.Fields["dir"] == "" || !pathutil.IsWithin(e.Fields["dir"], f.dir)) {
+		return false
+	}
+	if !f.since.IsZero() && e.Time.Before(f.since) {
+		return false
+	}
+	return true
+}
+
+// historyDetails formats the fields not shown in dedicated columns.
+func historyDetails(fields map[string]string) string {
+	var keys []string
+	for k := range fields {
+		switch k {
+		case "port", "dir", "name", "by":
+		default:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, logger.Field(k, fields[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseHistoryArgs parses history command flags. resolveDir turns the --dir value
+// (a path or @alias) into an absolute directory.
+func parseHistoryArgs(args []string, now time.Time, resolveDir func(string) (string, error)) (historyFilter, error) {
+	var f historyFilter
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg != "--port" && arg != "--dir" && arg != "--since" {
+			return f, fmt.Errorf("unknown option: %s", arg)
+		}
+		if i+1 >= len(args) {
+			return f, fmt.Errorf("%s requires a value", arg)
+		}
+		value := args[i+1]
+		i++
+
+		switch arg {
+		case "--port":
+			p, err := strconv.Atoi(value)
+			if err != nil || p < 1 || p > 65535 {
+				return f, fmt.Errorf("invalid port: %s (must be 1-65535)", value)
+			}
+			f.port = p
+		case "--dir":
+			dir, err := resolveDir(value)
+			if err != nil {
+				return f, fmt.Errorf("invalid directory %s: %w", value, err)
+			}
+			f.dir = dir
+		case "--since":
+			d, err := config.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return f, fmt.Errorf("invalid --since value: %s (e.g., 7d, 12h)", value)
+			}
+			f.since = now.Add(-d)
+		}
+	}
+	return f, nil
+}
+
+// runHistory prints the allocation lifecycle recorded in the log file.
+func runHistory(args []string) error {
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	filter, err := parseHistoryArgs(args, time.Now(), dirResolver(configDir))
+	if err != nil {
+		return err
+	}
+	if target := cfg.GetLogTarget(); target != logger.TargetFile {
+		return fmt.Errorf("history reads the log file, but events go to %s (logTarget); use journalctl or your syslog instead", target)
+	}
+	if cfg.Log == "" {
+		return fmt.Errorf("logging is disabled; set 'log' in the config to record history")
+	}
+
+	logPath, err := logger.ExpandPath(cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	entries, skipped, err := logger.ReadEntries(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("No history recorded yet.")
+			return nil
+		}
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	if skipped > 0 {
+		stderrf("warning: skipped %d unparsable line(s) in %s\n", skipped, pathutil.ShortenHomePath(logPath))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	shown := 0
+	for _, e := range entries {
+		if !filter.matches(e) {
+			continue
+		}
+		if shown == 0 {
+			fmt.Fprintln(w, "TIME\tEVENT\tPORT\tDIRECTORY\tNAME\tBY\tDETAILS")
+		}
+		shown++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.Event,
+			valueOrDash(e.Fields["port"]),
+			valueOrDash(pathutil.ShortenHomePath(e.Fields["dir"])),
+			valueOrDash(e.Fields["name"]),
+			valueOrDash(e.Fields["by"]),
+			historyDetails(e.Fields))
+	}
+	if shown == 0 {
+		fmt.Println("No matching events.")
+		return nil
+	}
+	return w.Flush()
+}
+
+// valueOrDash returns s, or "-" if s is empty.
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+func TestHistoryFilter(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	filter, err := parseHistoryArgs([]string{"--port", "3000", "--dir", "/code/shop", "--since", "7d"}, now, filepath.Abs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry := func(ts time.Time, port, dir string) logger.Entry {
+		return logger.Entry{Time: ts, Event: logger.AllocAdd, Fields: map[string]string{"port": port, "dir": dir}}
+	}
+
+	tests := []struct {
+		name  string
+		entry logger.Entry
+		want  bool
+	}{
+		{"match", entry(now.Add(-time.Hour), "3000", "/code/shop"), true},
+		{"subdirectory", entry(now.Add(-time.Hour), "3000", "/code/shop/web"), true},
+		{"other port", entry(now.Add(-time.Hour), "3001", "/code/shop"), false},
+		{"other directory", entry(now.Add(-time.Hour), "3000", "/code/shop2"), false},
+		{"too old", entry(now.Add(-8*24*time.Hour), "3000", "/code/shop"), false},
+		{"no directory", entry(now.Add(-time.Hour), "3000", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.matches(tt.entry); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHistoryArgs_Invalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"--port", "abc"},
+		{"--since", "soon"},
+		{"--port"},
+		{"--verbose-history"},
+	} {
+		if _, err := parseHistoryArgs(args, time.Now(), filepath.Abs); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestHistoryDetails(t *testing.T) {
+	got := historyDetails(map[string]string{"port": "3000", "dir": "/x", "by": "alice", "reason": "stale external", "locked": "true"})
+	if got != `locked=true reason="stale external"` {
+		t.Errorf("unexpected details: %s", got)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(tmpDir, "port-selector.log")
+	cfg := "portStart: 3950\nportEnd: 3999\nlog: " + logPath + "\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	for _, args := range [][]string{{}, {"--lock"}} {
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	cmd := exec.Command(binary, "history", "--dir", workDir)
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	for _, want := range []string{"ALLOC_ADD", "ALLOC_LOCK", "locked=true"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("expected %q in history output:\n%s", want, output)
+		}
+	}
+}
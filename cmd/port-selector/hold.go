@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// holdPort listens on p, prints it and keeps the listener open until stdin is closed
+// or a release signal (SIGUSR1, SIGINT, SIGTERM) arrives. No other process can take
+// the port in the meantime; the caller releases it right before starting the service.
+func holdPort(p int, stdin io.Reader) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", p))
+	if err != nil {
+		return fmt.Errorf("cannot hold port %d: %w", p, err)
+	}
+	defer ln.Close()
+
+	fmt.Println(p)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, append(releaseSignals, os.Interrupt, syscall.SIGTERM)...)
+	defer signal.Stop(signals)
+
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, stdin)
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		debug.Printf("main", "stdin closed, releasing port %d", p)
+	case sig := <-signals:
+		debug.Printf("main", "got %s, releasing port %d", sig, p)
+	}
+	return nil
+}
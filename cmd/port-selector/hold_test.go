@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/port"
+)
+
+func TestHoldPort(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	stdin, release := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- holdPort(p, stdin) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for port.IsPortFree(p) {
+		if time.Now().After(deadline) {
+			t.Fatalf("port %d was not held", p)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	release.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("holdPort() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("holdPort() did not return after stdin was closed")
+	}
+	if !port.IsPortFree(p) {
+		t.Errorf("port %d still busy after release", p)
+	}
+}
+
+func TestParseAllocOptions_HoldWithWait(t *testing.T) {
+	if _, _, err := parseAllocOptions([]string{"--hold", "--wait"}); err == nil {
+		t.Error("expected error for --hold with --wait")
+	}
+	opts, _, err := parseAllocOptions([]string{"--hold"})
+	if err != nil || !opts.hold {
+		t.Errorf("parseAllocOptions(--hold) = %+v, %v", opts, err)
+	}
+}
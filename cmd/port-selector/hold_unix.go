@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// releaseSignals tell --hold that the caller is ready to bind the port.
+var releaseSignals = []os.Signal{syscall.SIGUSR1}
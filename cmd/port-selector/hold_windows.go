@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// releaseSignals is empty on Windows (no SIGUSR1): close stdin to release the port.
+var releaseSignals []os.Signal
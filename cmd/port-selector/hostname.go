@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// Markers delimiting the block managed by `hosts --write`.
+const (
+	hostsBlockBegin = "# BEGIN port-selector"
+	hostsBlockEnd   = "# END port-selector"
+)
+
+// defaultHostsFile is the file updated by `hosts --write` without an argument.
+const defaultHostsFile = "/etc/hosts"
+
+// defaultHostname returns the hostname suggested for an allocation:
+// <dirname>.local for "main", <name>-<dirname>.local otherwise.
+func defaultHostname(dir, name string) string {
+	label := hostLabel(filepath.Base(dir))
+	if name != "" && name != "main" {
+		label = hostLabel(name) + "-" + label
+	}
+	return label + ".local"
+}
+
+// validateHostname checks that hostname consists of valid DNS labels.
+func validateHostname(hostname string) error {
+	if hostname == "" || len(hostname) > 253 {
+		return fmt.Errorf("invalid hostname %q", hostname)
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if label == "" || len(label) > 63 || label != hostLabel(label) {
+			return fmt.Errorf("invalid hostname %q (use letters, digits and dashes separated by dots)", hostname)
+		}
+	}
+	return nil
+}
+
+// runHostname records (or clears) a hostname for the allocation of (cwd, name),
+// allocating a port if needed.
+func runHostname(name string, args []string) error {
+	var hostname string
+	clearHostname := false
+	for _, arg := range args {
+		switch {
+		case arg == "--clear":
+			clearHostname = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown option: %s", arg)
+		case hostname == "":
+			hostname = strings.ToLower(arg)
+		default:
+			return fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if clearHostname && hostname != "" {
+		return fmt.Errorf("--clear does not take a hostname")
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if !clearHostname {
+		if hostname == "" {
+			hostname = defaultHostname(cwd, name)
+		}
+		if err := validateHostname(hostname); err != nil {
+			return err
+		}
+	}
+
+	var resultPort int
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		if clearHostname {
+			alloc := store.FindByDirectoryAndName(cwd, name)
+			if alloc == nil {
+				return fmt.Errorf("no allocation found for %s with name '%s'", pathutil.ShortenHomePath(cwd), name)
+			}
+			resultPort = alloc.Port
+			store.SetHostname(alloc.Port, "")
+			return nil
+		}
+
+		p, err := allocatePort(store, cfg, cwd, name)
+		if err != nil {
+			return err
+		}
+		if other := store.FindByHostname(hostname); other != nil && other.Port != p {
+			return fmt.Errorf("hostname %s is already used by %s ('%s', port %d)",
+				hostname, pathutil.ShortenHomePath(other.Directory), other.Name, other.Port)
+		}
+		resultPort = p
+		store.SetHostname(p, hostname)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if clearHostname {
+		fmt.Printf("Cleared hostname for '%s' (port %d)\n", name, resultPort)
+		return nil
+	}
+	debug.Printf("main", "hostname %s recorded for port %d", hostname, resultPort)
+	fmt.Printf("Hostname %s -> 127.0.0.1:%d for '%s'\n", hostname, resultPort, name)
+	return nil
+}
+
+// hostsBlock renders the managed /etc/hosts block for all recorded hostnames.
+func hostsBlock(store *allocations.Store) string {
+	var hostnames []string
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Hostname != "" {
+			hostnames = append(hostnames, alloc.Hostname)
+		}
+	}
+	sort.Strings(hostnames)
+
+	var b strings.Builder
+	b.WriteString(hostsBlockBegin + "\n")
+	for _, h := range hostnames {
+		fmt.Fprintf(&b, "127.0.0.1\t%s\n", h)
+	}
+	b.WriteString(hostsBlockEnd + "\n")
+	return b.String()
+}
+
+// replaceHostsBlock replaces the managed block in content, or appends it if absent.
+func replaceHostsBlock(content, block string) string {
+	begin := strings.Index(content, hostsBlockBegin)
+	end := strings.Index(content, hostsBlockEnd)
+	if begin >= 0 && end > begin {
+		end += len(hostsBlockEnd)
+		if end < len(content) && content[end] == '\n' {
+			end++
+		}
+		return content[:begin] + block + content[end:]
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + block
+}
+
+// runHosts prints the hosts block for recorded hostnames, or writes it into a hosts file.
+func runHosts(args []string) error {
+	write := false
+	path := defaultHostsFile
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--write":
+			write = true
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				path = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--write="):
+			write = true
+			path = strings.TrimPrefix(arg, "--write=")
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	block := hostsBlock(store)
+
+	if !write {
+		fmt.Print(block)
+		return nil
+	}
+
+	// Replace the target of a symlinked hosts file (e.g. NixOS), not the link
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	updated := replaceHostsBlock(string(data), block)
+
+	if allocations.IsDryRun() {
+		stderrf("dry-run: would write %s\n", path)
+		fmt.Print(block)
+		return nil
+	}
+
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := pathutil.WriteFileAtomic(path, []byte(updated), mode); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("cannot write %s: permission denied (try: sudo --preserve-env=HOME port-selector hosts --write)", path)
+		}
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("Updated %s\n", path)
+	return nil
+}
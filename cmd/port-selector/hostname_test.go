@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestDefaultHostname(t *testing.T) {
+	tests := []struct {
+		dir  string
+		name string
+		want string
+	}{
+		{"/home/user/code/shop", "main", "shop.local"},
+		{"/home/user/code/shop", "api", "api-shop.local"},
+		{"/home/user/code/My Shop", "main", "my-shop.local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := defaultHostname(tt.dir, tt.name); got != tt.want {
+				t.Errorf("defaultHostname(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+			}
+			if err := validateHostname(tt.want); err != nil {
+				t.Errorf("default hostname should be valid: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	for _, h := range []string{"", "shop..local", "-shop.local", "shop_1.local", "Shop.local"} {
+		if err := validateHostname(h); err == nil {
+			t.Errorf("expected %q to be invalid", h)
+		}
+	}
+}
+
+func TestReplaceHostsBlock(t *testing.T) {
+	block := hostsBlockBegin + "\n127.0.0.1\tshop.local\n" + hostsBlockEnd + "\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"append", "127.0.0.1\tlocalhost", "127.0.0.1\tlocalhost\n" + block},
+		{"replace", "127.0.0.1\tlocalhost\n" + hostsBlockBegin + "\n127.0.0.1\told.local\n" + hostsBlockEnd + "\n::1\tlocalhost\n",
+			"127.0.0.1\tlocalhost\n" + block + "::1\tlocalhost\n"},
+		{"empty", "", block},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceHostsBlock(tt.content, block); got != tt.want {
+				t.Errorf("replaceHostsBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostnameAndHosts(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	workDir := filepath.Join(tmpDir, "shop")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	cmd := exec.Command(binary, "hostname")
+	cmd.Dir = workDir
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("hostname failed: %v\n%s", err, output)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alloc := store.FindByDirectoryAndName(workDir, "main"); alloc == nil || alloc.Hostname != "shop.local" {
+		t.Fatalf("expected hostname shop.local to be recorded, got %+v", alloc)
+	}
+
+	hostsFile := filepath.Join(tmpDir, "hosts")
+	if err := os.WriteFile(hostsFile, []byte("127.0.0.1\tlocalhost\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// --dry-run prints the block and leaves the file alone
+	cmd = exec.Command(binary, "--dry-run", "hosts", "--write", hostsFile)
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("--dry-run hosts --write failed: %v", err)
+	}
+	if !strings.Contains(string(output), "shop.local") {
+		t.Errorf("--dry-run output = %q, want the hosts block", output)
+	}
+	if data, _ := os.ReadFile(hostsFile); string(data) != "127.0.0.1\tlocalhost\n" {
+		t.Errorf("--dry-run changed the hosts file:\n%s", data)
+	}
+
+	cmd = exec.Command(binary, "hosts", "--write", hostsFile)
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("hosts --write failed: %v\n%s", err, output)
+	}
+	data, err := os.ReadFile(hostsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "127.0.0.1\tshop.local") || !strings.HasPrefix(string(data), "127.0.0.1\tlocalhost\n") {
+		t.Errorf("unexpected hosts file:\n%s", data)
+	}
+	if fi, err := os.Stat(hostsFile); err != nil {
+		t.Fatal(err)
+	} else if fi.Mode().Perm() != 0600 {
+		t.Errorf("hosts file mode = %v, want the original 0600", fi.Mode().Perm())
+	}
+
+	cmd = exec.Command(binary, "--list")
+	cmd.Env = env
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "HOSTNAME") || !strings.Contains(string(output), "shop.local") {
+		t.Errorf("expected hostname in --list output:\n%s", output)
+	}
+}
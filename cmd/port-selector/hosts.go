@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+// hostEnvVar overrides the hostname that namespaces the store with perHost: true
+// (useful when the system hostname changes between networks).
+const hostEnvVar = "PORT_SELECTOR_HOST"
+
+// currentHostname returns the name of this machine's store with perHost: true.
+// Returns "" (the shared store) if the hostname is unknown.
+func currentHostname() string {
+	if host := allocations.SanitizeHost(os.Getenv(hostEnvVar)); host != "" {
+		return host
+	}
+	host, err := os.Hostname()
+	if err != nil || allocations.SanitizeHost(host) == "" {
+		stderrf("warning: cannot determine hostname (set %s), using the shared store\n", hostEnvVar)
+		return ""
+	}
+	return allocations.SanitizeHost(host)
+}
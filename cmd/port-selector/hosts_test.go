@@ -0,0 +1,10 @@
+package main
+
+import "testing"
+
+func TestCurrentHostname_EnvOverride(t *testing.T) {
+	t.Setenv(hostEnvVar, "Laptop.example.com")
+	if got := currentHostname(); got != "laptop" {
+		t.Errorf("currentHostname() = %q, want laptop", got)
+	}
+}
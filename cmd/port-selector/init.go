@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// initService is a conventional named allocation of a framework template.
+type initService struct {
+	Name        string
+	Description string
+}
+
+// initTemplates lists the named allocations created by init for each framework.
+var initTemplates = map[string][]initService{
+	"rails": {
+		{"web", "Rails server: bin/rails server -p $(port-selector --name web)"},
+		{"webpack", "webpack-dev-server or Vite dev server"},
+		{"sidekiq", "Sidekiq Web UI"},
+	},
+	"nextjs": {
+		{"web", "next dev -p $(port-selector --name web)"},
+		{"storybook", "Storybook: storybook dev -p $(port-selector --name storybook)"},
+	},
+	"django": {
+		{"web", "python manage.py runserver $(port-selector --name web)"},
+		{"flower", "Celery Flower: celery flower --port=$(port-selector --name flower)"},
+	},
+}
+
+// initTemplateNames returns the framework names accepted by init, sorted.
+func initTemplateNames() []string {
+	names := make([]string, 0, len(initTemplates))
+	for name := range initTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runInit allocates the conventional named ports of a framework for the current
+// directory and writes .port-selector.yaml documenting them.
+func runInit(args []string) error {
+	var framework string
+	force := false
+	for _, arg := range args {
+		switch {
+		case arg == "--force":
+			force = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown option: %s", arg)
+		case framework == "":
+			framework = arg
+		default:
+			return fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	available := strings.Join(initTemplateNames(), ", ")
+	if framework == "" {
+		return fmt.Errorf("init requires a framework (%s)", available)
+	}
+	services, ok := initTemplates[framework]
+	if !ok {
+		return fmt.Errorf("unknown framework %q (available: %s)", framework, available)
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	projectPath := filepath.Join(cwd, config.ProjectFileName)
+	if _, err := os.Stat(projectPath); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", pathutil.ShortenHomePath(projectPath))
+	}
+
+	manifestServices := make([]manifestService, len(services))
+	for i, svc := range services {
+		manifestServices[i] = manifestService{Name: svc.Name}
+	}
+	results, err := applyServices(configDir, cfg, cwd, manifestServices)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(projectPath, []byte(renderInitProject(framework, services, results)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", projectPath, err)
+	}
+
+	if err := printApplied(results); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", pathutil.ShortenHomePath(projectPath))
+	return nil
+}
+
+// renderInitProject returns the .port-selector.yaml written by init: the allocated
+// ports become the preferred ports of the project, each documented by a comment.
+func renderInitProject(framework string, services []initService, results []appliedService) string {
+	ports := make(map[string]int, len(results))
+	for _, r := range results {
+		ports[r.Name] = r.Port
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Created by 'port-selector init %s'.\n", framework)
+	b.WriteString("# Named allocations of this project (port-selector --name NAME).\n")
+	b.WriteString("# Preferred ports are tried first for a name that has no allocation yet.\n")
+	b.WriteString("preferred:\n")
+	for _, svc := range services {
+		fmt.Fprintf(&b, "  # %s\n", svc.Description)
+		fmt.Fprintf(&b, "  %s: %d\n", svc.Name, ports[svc.Name])
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestInit_CreatesTemplateAllocations(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(tmpDir, "shop")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	if out, err := run("init", "rails"); err != nil {
+		t.Fatalf("init rails failed: %v\n%s", err, out)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := config.LoadProject(workDir)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	for _, svc := range initTemplates["rails"] {
+		alloc := store.FindByDirectoryAndName(workDir, svc.Name)
+		if alloc == nil {
+			t.Fatalf("no allocation for %s", svc.Name)
+		}
+		if project.Preferred[svc.Name] != alloc.Port {
+			t.Errorf("preferred[%s] = %d, want %d", svc.Name, project.Preferred[svc.Name], alloc.Port)
+		}
+	}
+
+	if out, err := run("init", "rails"); err == nil || !strings.Contains(out, "already exists") {
+		t.Errorf("second init: %v\n%s, want 'already exists'", err, out)
+	}
+	if out, err := run("init", "rails", "--force"); err != nil {
+		t.Errorf("init --force failed: %v\n%s", err, out)
+	}
+	if out, err := run("init", "cobol"); err == nil || !strings.Contains(out, "unknown framework") {
+		t.Errorf("init cobol: %v\n%s, want 'unknown framework'", err, out)
+	}
+}
@@ -0,0 +1,676 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// maxDirWidth caps the DIRECTORY column of --list unless --wide is given.
+const maxDirWidth = 40
+
+// defaultStaleAge is the age from which --stale lists an allocation that has
+// not expired.
+const defaultStaleAge = 30 * 24 * time.Hour
+
+// listColumn is a column of --list, selected by key in --columns.
+type listColumn struct {
+	key    string
+	header string
+}
+
+// listColumns are all --list columns in their default order.
+var listColumns = []listColumn{
+	{"port", "PORT"},
+	{"host", "HOST"},
+	{"dir", "DIRECTORY"},
+	{"alias", "ALIAS"},
+	{"name", "NAME"},
+	{"hostname", "HOSTNAME"},
+	{"source", "SOURCE"},
+	{"status", "STATUS"},
+	{"locked", "LOCKED"},
+	{"user", "USER"},
+	{"pid", "PID"},
+	{"process", "PROCESS"},
+	{"assigned", "ASSIGNED"},
+	{"age", "AGE"},
+	{"expires", "EXPIRES"},
+	{"owner", "OWNER"},
+	{"labels", "LABELS"},
+	{"note", "NOTE"},
+}
+
+// listColumnAliases are accepted in --columns besides the keys.
+var listColumnAliases = map[string]string{"directory": "dir"}
+
+// listRow is one allocation of --list, with a value for every column key.
+type listRow struct {
+	alloc allocations.Allocation
+	cells map[string]string
+}
+
+// listOptions are the options of --list.
+type listOptions struct {
+	onlyKube bool          // --k8s: only kubectl port-forwards recorded by --scan
+	allHosts bool          // --all-hosts: the stores of all machines with perHost: true
+	wide     bool          // --wide: no truncation of directories, processes and notes
+	columns  []string      // --columns: column keys to show, in order (nil for the default)
+	format   string        // --format: table (default), csv, markdown or json
+	groupBy  string        // --group-by: "dir" nests rows under a line per directory
+	staleAge time.Duration // --stale[=AGE]: only expired allocations and those unused for AGE (0 for all)
+	ttl      time.Duration // allocationTTL of the config, for AGE and EXPIRES
+}
+
+// listFormats are the values of --list --format.
+var listFormats = []string{"table", "csv", "markdown", "json"}
+
+// parseListOptions parses --list options; the remaining arguments are label filters.
+func parseListOptions(args []string) (listOptions, []string, error) {
+	opts := listOptions{format: "table"}
+	var filterArgs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--k8s":
+			opts.onlyKube = true
+		case arg == "--all-hosts":
+			opts.allHosts = true
+		case arg == "--wide":
+			opts.wide = true
+		case arg == "--columns" || strings.HasPrefix(arg, "--columns="):
+			value := strings.TrimPrefix(arg, "--columns=")
+			if arg == "--columns" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--columns requires a list (e.g., port,name,dir,status)")
+				}
+				value = args[i+1]
+				i++
+			}
+			columns, err := parseListColumns(value)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.columns = columns
+		case arg == "--format" || strings.HasPrefix(arg, "--format="):
+			value := strings.TrimPrefix(arg, "--format=")
+			if arg == "--format" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--format requires a value (%s)", strings.Join(listFormats, ", "))
+				}
+				value = args[i+1]
+				i++
+			}
+			if !slices.Contains(listFormats, value) {
+				return opts, nil, fmt.Errorf("invalid format %q (use %s)", value, strings.Join(listFormats, ", "))
+			}
+			opts.format = value
+		case arg == "--group-by" || strings.HasPrefix(arg, "--group-by="):
+			value := strings.TrimPrefix(arg, "--group-by=")
+			if arg == "--group-by" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--group-by requires a value (dir)")
+				}
+				value = args[i+1]
+				i++
+			}
+			if alias, ok := listColumnAliases[value]; ok {
+				value = alias
+			}
+			if value != "dir" {
+				return opts, nil, fmt.Errorf("invalid --group-by %q (use dir)", value)
+			}
+			opts.groupBy = value
+		case arg == "--stale" || strings.HasPrefix(arg, "--stale="):
+			opts.staleAge = defaultStaleAge
+			if value, ok := strings.CutPrefix(arg, "--stale="); ok {
+				age, err := config.ParseDuration(value)
+				if err != nil || age <= 0 {
+					return opts, nil, fmt.Errorf("invalid --stale age %q (use a duration like 7d or 12h)", value)
+				}
+				opts.staleAge = age
+			}
+		default:
+			filterArgs = append(filterArgs, arg)
+		}
+	}
+	if opts.groupBy != "" && opts.format != "table" {
+		return opts, nil, fmt.Errorf("--group-by only applies to the table format")
+	}
+	return opts, filterArgs, nil
+}
+
+// parseListColumns parses a comma-separated list of column keys.
+func parseListColumns(value string) ([]string, error) {
+	known := make(map[string]bool, len(listColumns))
+	keys := make([]string, len(listColumns))
+	for i, c := range listColumns {
+		known[c.key] = true
+		keys[i] = c.key
+	}
+
+	var columns []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if alias, ok := listColumnAliases[key]; ok {
+			key = alias
+		}
+		if !known[key] {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", key, strings.Join(keys, ","))
+		}
+		columns = append(columns, key)
+	}
+	return columns, nil
+}
+
+// defaultListColumns returns the columns shown without --columns: HOST only with
+// --all-hosts, ALIAS, HOSTNAME, OWNER, LABELS and NOTE only when some row has
+// one, and AGE and EXPIRES only when some row can expire (allocationTTL or a lease).
+func defaultListColumns(rows []listRow, allHosts bool) []string {
+	optional := map[string]bool{"host": allHosts, "age": false, "expires": false}
+	for _, r := range rows {
+		expires := r.cells["expires"] != "" && r.cells["expires"] != "-"
+		optional["age"] = optional["age"] || expires
+		optional["expires"] = optional["expires"] || expires
+		optional["alias"] = optional["alias"] || r.alloc.Alias != ""
+		optional["hostname"] = optional["hostname"] || r.alloc.Hostname != ""
+		optional["owner"] = optional["owner"] || r.alloc.OwnerPID > 0
+		optional["labels"] = optional["labels"] || len(r.alloc.Labels) > 0
+		optional["note"] = optional["note"] || r.alloc.Note != ""
+	}
+
+	var columns []string
+	for _, c := range listColumns {
+		if show, ok := optional[c.key]; ok && !show {
+			continue
+		}
+		columns = append(columns, c.key)
+	}
+	return columns
+}
+
+func runList(args []string) error {
+	opts, filterArgs, err := parseListOptions(args)
+	if err != nil {
+		return err
+	}
+	labelValues, labelKeys, err := parseLabelFilter(filterArgs)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	opts.ttl = cfg.GetAllocationTTL()
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	// Load without locking - this is read-only and Save() uses atomic writes
+	// (temp file + rename), so the file is always in a consistent state.
+	var allAllocs []allocations.Allocation
+	sticky := make(map[int]bool) // ports held by the owner of their sticky port
+	if opts.allHosts {
+		if allAllocs, err = allocations.LoadAllHosts(configDir); err != nil {
+			return fmt.Errorf("failed to load allocations: %w", err)
+		}
+	} else {
+		store, err := allocations.Load(configDir)
+		if err != nil {
+			return fmt.Errorf("failed to load allocations: %w", err)
+		}
+		allAllocs = store.SortedByPort()
+		for p := range store.Sticky {
+			if store.IsSticky(p) {
+				sticky[p] = true
+			}
+		}
+	}
+	if len(allAllocs) == 0 {
+		if opts.allHosts {
+			fmt.Println("No port allocations found in per-host stores (see perHost in config).")
+		} else {
+			fmt.Println("No port allocations found.")
+		}
+		return nil
+	}
+
+	if opts.onlyKube {
+		filtered := allAllocs[:0]
+		for _, alloc := range allAllocs {
+			if alloc.IsKubeForward() {
+				filtered = append(filtered, alloc)
+			}
+		}
+		allAllocs = filtered
+		if len(allAllocs) == 0 {
+			fmt.Println("No kubectl port-forwards recorded (run port-selector --scan).")
+			return nil
+		}
+	}
+
+	if len(labelValues) > 0 || len(labelKeys) > 0 {
+		filtered := allAllocs[:0]
+		for _, alloc := range allAllocs {
+			matches := alloc.HasLabels(labelValues)
+			for k := range labelKeys {
+				if _, ok := alloc.Labels[k]; !ok {
+					matches = false
+				}
+			}
+			if matches {
+				filtered = append(filtered, alloc)
+			}
+		}
+		allAllocs = filtered
+		if len(allAllocs) == 0 {
+			fmt.Println("No port allocations match the labels.")
+			return nil
+		}
+	}
+
+	if opts.staleAge > 0 {
+		now := time.Now()
+		filtered := allAllocs[:0]
+		for _, alloc := range allAllocs {
+			if isStale(&alloc, opts.ttl, opts.staleAge, now) {
+				filtered = append(filtered, alloc)
+			}
+		}
+		allAllocs = filtered
+		if len(allAllocs) == 0 {
+			fmt.Println("No expired or stale allocations.")
+			return nil
+		}
+	}
+
+	rows, hasIncompleteInfo := buildListRows(allAllocs, port.NewSnapshot(), sticky, opts)
+	columns := opts.columns
+	if columns == nil {
+		columns = defaultListColumns(rows, opts.allHosts)
+	}
+	if opts.groupBy == "dir" {
+		err = writeListGrouped(os.Stdout, rows, columns, colorEnabled(os.Stdout))
+	} else {
+		err = writeList(os.Stdout, rows, columns, opts.format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if hasIncompleteInfo {
+		stderrf("\nTip: Run with sudo for full process info: sudo port-selector --list\n")
+	}
+
+	return nil
+}
+
+// lastUsed returns when the allocation was last used, or assigned if never.
+func lastUsed(a *allocations.Allocation) time.Time {
+	if a.LastUsedAt.IsZero() {
+		return a.AssignedAt
+	}
+	return a.LastUsedAt
+}
+
+// isStale reports whether --stale lists the allocation: it has expired by TTL
+// or lease, or was last used at least age ago.
+func isStale(a *allocations.Allocation, ttl, age time.Duration, now time.Time) bool {
+	if expiry := allocationExpiry(a, ttl); !expiry.IsZero() && !expiry.After(now) {
+		return true
+	}
+	return now.Sub(lastUsed(a)) >= age
+}
+
+// formatAge formats the time since the allocation was last used, e.g. "3h".
+func formatAge(a *allocations.Allocation, now time.Time) string {
+	d := now.Sub(lastUsed(a))
+	if d < time.Minute {
+		return "<1m"
+	}
+	return formatRemaining(d)
+}
+
+// formatExpires formats when the allocation expires: "-" for never, "expired"
+// once past, or the time left, e.g. "6d".
+func formatExpires(a *allocations.Allocation, ttl time.Duration, now time.Time) string {
+	expiry := allocationExpiry(a, ttl)
+	switch {
+	case expiry.IsZero():
+		return "-"
+	case !expiry.After(now):
+		return "expired"
+	case expiry.Sub(now) < time.Minute:
+		return "<1m"
+	}
+	return formatRemaining(expiry.Sub(now))
+}
+
+// buildListRows computes the cells of every allocation, reading the live state
+// of its port from procs. It also reports whether some busy port's process
+// could not be identified (usually for lack of root).
+func buildListRows(allocs []allocations.Allocation, procs *port.Snapshot, sticky map[int]bool, opts listOptions) ([]listRow, bool) {
+	hasIncompleteInfo := false
+	now := time.Now()
+	thisHost := allocations.CurrentHost()
+	processName := truncateProcessName
+	if opts.wide {
+		processName = func(name string) string { return name }
+	}
+
+	rows := make([]listRow, len(allocs))
+	for i, alloc := range allocs {
+		// The live state of another machine's ports is unknown here
+		otherHost := opts.allHosts && alloc.Host != thisHost
+		status := "free"
+		if otherHost {
+			status = "-"
+		}
+		username := "-"
+		pid := "-"
+		process := "-"
+
+		// Determine SOURCE and use saved external info for external allocations
+		source := "free"
+		if alloc.Status == allocations.StatusExternal {
+			source = "external"
+			// For external allocations, use saved process info
+			if alloc.ExternalUser != "" {
+				username = alloc.ExternalUser
+			}
+			if alloc.ExternalPID > 0 {
+				pid = strconv.Itoa(alloc.ExternalPID)
+			}
+			if alloc.ExternalProcessName != "" {
+				process = processName(alloc.ExternalProcessName)
+			}
+			status = "busy" // External ports are always busy
+		} else if alloc.Locked {
+			source = "lock"
+			// Use saved process name from allocation if available
+			if alloc.ProcessName != "" {
+				process = processName(alloc.ProcessName)
+			}
+		} else {
+			// Normal allocation - use saved process name if available
+			if alloc.ProcessName != "" {
+				process = processName(alloc.ProcessName)
+			}
+		}
+
+		// Compose service names say more than "docker-proxy"
+		service := alloc.ComposeService
+
+		// For non-external allocations, check live port status
+		if alloc.Status != allocations.StatusExternal && !otherHost && procs.IsListening(alloc.Port) {
+			status = "busy"
+			if procInfo := procs.GetPortProcess(alloc.Port); procInfo != nil {
+				if procInfo.Service != "" {
+					service = procInfo.Service
+				}
+				if procInfo.User != "" {
+					username = procInfo.User
+				}
+				if procInfo.PID > 0 {
+					pid = strconv.Itoa(procInfo.PID)
+					// Override with current process name if available
+					if procInfo.Name != "" {
+						process = processName(procInfo.Name)
+					}
+				} else if procInfo.ContainerID != "" {
+					// Docker container detected via fallback
+					process = "docker-proxy"
+				} else {
+					// Have user but no PID and no Docker - mark incomplete only if no saved name
+					if alloc.ProcessName == "" {
+						hasIncompleteInfo = true
+					}
+				}
+			}
+		}
+
+		if service != "" {
+			process = processName("compose:" + service)
+		}
+
+		locked := ""
+		if alloc.Locked {
+			locked = "yes"
+		} else if sticky[alloc.Port] {
+			locked = "sticky"
+		}
+
+		dir := pathutil.ShortenHomePath(alloc.Directory)
+		if !opts.wide && len(dir) > maxDirWidth {
+			dir = truncateDirectoryPath(dir, maxDirWidth)
+		}
+
+		owner := formatOwner(alloc)
+		if otherHost && alloc.OwnerPID > 0 {
+			owner = strconv.Itoa(alloc.OwnerPID)
+		}
+
+		note := "-"
+		if alloc.Note != "" {
+			note = alloc.Note
+			if !opts.wide {
+				note = truncateNote(note)
+			}
+		}
+
+		rows[i] = listRow{alloc: alloc, cells: map[string]string{
+			"port":     strconv.Itoa(alloc.Port),
+			"host":     alloc.Host,
+			"dir":      dir,
+			"alias":    dashIfEmpty(prefixIfSet("@", alloc.Alias)),
+			"name":     alloc.Name, // Always show the name (even "main")
+			"hostname": dashIfEmpty(alloc.Hostname),
+			"source":   source,
+			"status":   status,
+			"locked":   locked,
+			"user":     username,
+			"pid":      pid,
+			"process":  process,
+			"assigned": alloc.AssignedAt.Local().Format("2006-01-02 15:04"),
+			"age":      formatAge(&alloc, now),
+			"expires":  formatExpires(&alloc, opts.ttl, now),
+			"owner":    owner,
+			"labels":   dashIfEmpty(allocations.FormatLabels(alloc.Labels)),
+			"note":     note,
+		}}
+	}
+	return rows, hasIncompleteInfo
+}
+
+// writeList prints rows in the --format of --list. Every format shows the same
+// cells; only the table is colored.
+func writeList(out io.Writer, rows []listRow, columns []string, format string) error {
+	switch format {
+	case "csv":
+		return writeListCSV(out, rows, columns)
+	case "markdown":
+		return writeListMarkdown(out, rows, columns)
+	case "json":
+		return writeListJSON(out, rows, columns)
+	}
+	return writeListTable(out, rows, columns, out == os.Stdout && colorEnabled(os.Stdout))
+}
+
+// listHeaders returns the header of each column.
+func listHeaders(columns []string) []string {
+	headers := make([]string, len(columns))
+	for i, key := range columns {
+		for _, c := range listColumns {
+			if c.key == key {
+				headers[i] = c.header
+			}
+		}
+	}
+	return headers
+}
+
+// writeListCSV prints rows as CSV (RFC 4180) with a header line.
+func writeListCSV(out io.Writer, rows []listRow, columns []string) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(listHeaders(columns)); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, r := range rows {
+		for i, key := range columns {
+			record[i] = r.cells[key]
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeListMarkdown prints rows as a GitHub-flavored Markdown table.
+func writeListMarkdown(out io.Writer, rows []listRow, columns []string) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	line := func(cells []string) string {
+		for i, c := range cells {
+			cells[i] = escape.Replace(c)
+		}
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(line(listHeaders(columns)))
+	separator := make([]string, len(columns))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	b.WriteString(line(separator))
+	for _, r := range rows {
+		cells := make([]string, len(columns))
+		for i, key := range columns {
+			cells[i] = r.cells[key]
+		}
+		b.WriteString(line(cells))
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// writeListJSON prints rows as a JSON array of objects keyed by column.
+func writeListJSON(out io.Writer, rows []listRow, columns []string) error {
+	entries := make([]map[string]string, len(rows))
+	for i, r := range rows {
+		entries[i] = make(map[string]string, len(columns))
+		for _, key := range columns {
+			entries[i][key] = r.cells[key]
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// writeListTable prints rows as an aligned table of the given columns.
+// With color, SOURCE, STATUS, LOCKED and EXPIRES cells are colored.
+func writeListTable(out io.Writer, rows []listRow, columns []string, color bool) error {
+	colors := map[string]map[string]string{"source": sourceColors, "status": statusColors, "locked": lockedColors, "expires": expiresColors}
+	headers := make(map[string]string, len(listColumns))
+	for _, c := range listColumns {
+		headers[c.key] = c.header
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	cells := make([]string, len(columns))
+	for i, key := range columns {
+		cells[i] = headers[key]
+		if colors[key] != nil {
+			cells[i] = colorCell(color, "", cells[i])
+		}
+	}
+	fmt.Fprintln(w, strings.Join(cells, "\t"))
+
+	for _, r := range rows {
+		for i, key := range columns {
+			cells[i] = r.cells[key]
+			if colors[key] != nil {
+				cells[i] = colorCell(color, colors[key][cells[i]], cells[i])
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// listGroupIndent indents the rows of --group-by under their directory line.
+const listGroupIndent = "    "
+
+// writeListGrouped prints the table of --group-by dir: a line with the full
+// directory, then its rows indented beneath it without the DIRECTORY column.
+// Directories keep the order of their lowest port; all groups share one
+// alignment.
+func writeListGrouped(out io.Writer, rows []listRow, columns []string, color bool) error {
+	var dirs []string
+	groups := make(map[string][]listRow)
+	for _, r := range rows {
+		if _, ok := groups[r.alloc.Directory]; !ok {
+			dirs = append(dirs, r.alloc.Directory)
+		}
+		groups[r.alloc.Directory] = append(groups[r.alloc.Directory], r)
+	}
+	ordered := make([]listRow, 0, len(rows))
+	for _, dir := range dirs {
+		ordered = append(ordered, groups[dir]...)
+	}
+
+	var table strings.Builder
+	columns = slices.DeleteFunc(slices.Clone(columns), func(key string) bool { return key == "dir" })
+	if err := writeListTable(&table, ordered, columns, color); err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+
+	var b strings.Builder
+	b.WriteString(listGroupIndent + lines[0] + "\n")
+	lines = lines[1:]
+	for _, dir := range dirs {
+		b.WriteString(pathutil.ShortenHomePath(dir) + "\n")
+		for _, line := range lines[:len(groups[dir])] {
+			b.WriteString(listGroupIndent + line + "\n")
+		}
+		lines = lines[len(groups[dir]):]
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// dashIfEmpty returns "-" for an empty cell.
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// prefixIfSet prepends prefix to a non-empty s.
+func prefixIfSet(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
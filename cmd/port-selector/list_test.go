@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+func TestParseListOptions(t *testing.T) {
+	opts, rest, err := parseListOptions([]string{"--wide", "--columns", "port,Name,directory,status", "--label", "env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.wide || !reflect.DeepEqual(opts.columns, []string{"port", "name", "dir", "status"}) {
+		t.Errorf("parseListOptions() = %+v", opts)
+	}
+	if !reflect.DeepEqual(rest, []string{"--label", "env"}) {
+		t.Errorf("remaining = %v, want the label filter", rest)
+	}
+
+	for _, bad := range [][]string{{"--columns"}, {"--columns=port,size"}, {"--columns="}} {
+		if _, _, err := parseListOptions(bad); err == nil {
+			t.Errorf("parseListOptions(%v) expected error", bad)
+		}
+	}
+}
+
+func TestDefaultListColumns(t *testing.T) {
+	rows := []listRow{{alloc: allocations.Allocation{Note: "staging"}}}
+	got := defaultListColumns(rows, false)
+	want := []string{"port", "dir", "name", "source", "status", "locked", "user", "pid", "process", "assigned", "note"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("defaultListColumns() = %v, want %v", got, want)
+	}
+}
+
+func TestListRows_WideAndColumns(t *testing.T) {
+	dir := "/srv/worktrees/port-selector/feature-very-long-branch-name-for-issue-4629"
+	allocs := []allocations.Allocation{{Port: 3000, Directory: dir, Name: "web", Locked: true}}
+
+	rows, _ := buildListRows(allocs, port.NewSnapshot(), nil, listOptions{})
+	if cell := rows[0].cells["dir"]; cell == dir || len(cell) > maxDirWidth {
+		t.Errorf("dir cell without --wide = %q, want it truncated to %d characters", cell, maxDirWidth)
+	}
+	rows, _ = buildListRows(allocs, port.NewSnapshot(), nil, listOptions{wide: true})
+	if cell := rows[0].cells["dir"]; cell != dir {
+		t.Errorf("dir cell with --wide = %q, want %q", cell, dir)
+	}
+
+	var buf bytes.Buffer
+	if err := writeListTable(&buf, rows, []string{"port", "name", "locked", "dir"}, false); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || strings.Join(strings.Fields(lines[0]), " ") != "PORT NAME LOCKED DIRECTORY" ||
+		strings.Join(strings.Fields(lines[1]), " ") != "3000 web yes "+dir {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestWriteList_Formats(t *testing.T) {
+	rows := []listRow{{cells: map[string]string{"port": "3000", "name": "web", "note": `a|b, "c"`}}}
+	columns := []string{"port", "name", "note"}
+
+	for _, tc := range []struct {
+		format, want string
+	}{
+		{"csv", "PORT,NAME,NOTE\n3000,web,\"a|b, \"\"c\"\"\"\n"},
+		{"markdown", "| PORT | NAME | NOTE |\n| --- | --- | --- |\n| 3000 | web | a\\|b, \"c\" |\n"},
+		{"json", "[\n  {\n    \"name\": \"web\",\n    \"note\": \"a|b, \\\"c\\\"\",\n    \"port\": \"3000\"\n  }\n]\n"},
+	} {
+		var buf bytes.Buffer
+		if err := writeList(&buf, rows, columns, tc.format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", tc.format, buf.String(), tc.want)
+		}
+	}
+
+	if _, _, err := parseListOptions([]string{"--format", "xml"}); err == nil {
+		t.Error("parseListOptions(--format xml) expected error")
+	}
+}
+
+func TestWriteListGrouped(t *testing.T) {
+	row := func(port int, dir, name string) listRow {
+		return listRow{alloc: allocations.Allocation{Port: port, Directory: dir, Name: name},
+			cells: map[string]string{"port": strconv.Itoa(port), "dir": dir, "name": name}}
+	}
+	rows := []listRow{row(3000, "/srv/shop", "web"), row(3001, "/srv/blog", "main"), row(3002, "/srv/shop", "api")}
+
+	var buf bytes.Buffer
+	if err := writeListGrouped(&buf, rows, []string{"port", "dir", "name"}, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "    PORT  NAME\n" +
+		"/srv/shop\n" +
+		"    3000  web\n" +
+		"    3002  api\n" +
+		"/srv/blog\n" +
+		"    3001  main\n"
+	if buf.String() != want {
+		t.Errorf("grouped output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	for _, bad := range [][]string{{"--group-by", "name"}, {"--group-by"}, {"--group-by=dir", "--format", "csv"}} {
+		if _, _, err := parseListOptions(bad); err == nil {
+			t.Errorf("parseListOptions(%v) expected error", bad)
+		}
+	}
+}
+
+func TestListAgeAndExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ttl := 7 * 24 * time.Hour
+	for _, tc := range []struct {
+		name          string
+		alloc         allocations.Allocation
+		age, expires  string
+		stale, stale2 bool // with --stale and --stale=2d
+	}{
+		{"fresh", allocations.Allocation{LastUsedAt: now.Add(-3 * time.Hour)}, "3h", "6d", false, false},
+		{"never used", allocations.Allocation{AssignedAt: now.Add(-3 * 24 * time.Hour)}, "3d", "4d", false, true},
+		{"expired", allocations.Allocation{LastUsedAt: now.Add(-8 * 24 * time.Hour)}, "8d", "expired", true, true},
+		{"locked", allocations.Allocation{LastUsedAt: now.Add(-40 * 24 * time.Hour), Locked: true}, "40d", "-", true, true},
+		{"lease", allocations.Allocation{LastUsedAt: now, Lease: time.Hour, LeaseExpiresAt: now.Add(-time.Minute)}, "<1m", "expired", true, true},
+	} {
+		if got := formatAge(&tc.alloc, now); got != tc.age {
+			t.Errorf("%s: formatAge() = %q, want %q", tc.name, got, tc.age)
+		}
+		if got := formatExpires(&tc.alloc, ttl, now); got != tc.expires {
+			t.Errorf("%s: formatExpires() = %q, want %q", tc.name, got, tc.expires)
+		}
+		if got := isStale(&tc.alloc, ttl, defaultStaleAge, now); got != tc.stale {
+			t.Errorf("%s: isStale(30d) = %v, want %v", tc.name, got, tc.stale)
+		}
+		if got := isStale(&tc.alloc, ttl, 2*24*time.Hour, now); got != tc.stale2 {
+			t.Errorf("%s: isStale(2d) = %v, want %v", tc.name, got, tc.stale2)
+		}
+	}
+
+	rows := []listRow{{cells: map[string]string{"expires": "6d"}}}
+	if columns := defaultListColumns(rows, false); !slices.Contains(columns, "age") || !slices.Contains(columns, "expires") {
+		t.Errorf("defaultListColumns() = %v, want AGE and EXPIRES when a row expires", columns)
+	}
+
+	opts, _, err := parseListOptions([]string{"--stale=12h"})
+	if err != nil || opts.staleAge != 12*time.Hour {
+		t.Errorf("parseListOptions(--stale=12h) = %+v, %v", opts, err)
+	}
+	if _, _, err := parseListOptions([]string{"--stale=soon"}); err == nil {
+		t.Error("parseListOptions(--stale=soon) expected error")
+	}
+}
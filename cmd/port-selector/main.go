@@ -5,76 +5,196 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
-	"text/tabwriter"
+	"time"
 
 	"github.com/dapi/port-selector/internal/allocations"
 	"github.com/dapi/port-selector/internal/config"
 	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/docker"
+	"github.com/dapi/port-selector/internal/i18n"
 	"github.com/dapi/port-selector/internal/logger"
+	"github.com/dapi/port-selector/internal/notify"
 	"github.com/dapi/port-selector/internal/pathutil"
 	"github.com/dapi/port-selector/internal/port"
 )
 
 var version = "dev"
 
-// initLoggerFromConfig initializes the logger using the provided config's Log path.
+// lastUsedRefreshInterval is how stale LastUsedAt may become before the default
+// allocation path takes the store lock to refresh it. Batching the update keeps
+// repeated calls (e.g., from shell prompts) on the lock-free read path.
+const lastUsedRefreshInterval = time.Minute
+
+// initLoggerFromConfig initializes the logger using the provided config's Log path,
+// or the system logger selected by logTarget.
 // Logs a warning to stderr if initialization fails.
 func initLoggerFromConfig(cfg *config.Config) {
-	if cfg.Log != "" {
-		if err := logger.Init(cfg.Log); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to initialize logger: %v\n", err)
-		}
+	target := cfg.GetLogTarget()
+	if target == logger.TargetFile && cfg.Log == "" {
+		return
+	}
+	var err error
+	if target == logger.TargetFile {
+		err = logger.Init(cfg.Log)
+	} else {
+		err = logger.InitTarget(target)
+	}
+	if err != nil {
+		stderrf("warning: failed to initialize logger: %v\n", err)
+	}
+	if err := logger.SetFormat(cfg.LogFormat); err != nil {
+		stderrf("warning: %v\n", err)
 	}
 }
 
-// loadConfigAndInitLogger loads config and initializes logger.
+// loadConfigAndInitLogger loads config, initializes logger and selects the store backend,
+// its directory and backup retention.
+// Logging is skipped in dry-run and read-only modes, since nothing is changed.
 // Returns the loaded config and any error.
 func loadConfigAndInitLogger() (*config.Config, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, err
 	}
-	initLoggerFromConfig(cfg)
+	if cfg.ReadOnly {
+		allocations.SetReadOnly(true)
+	}
+	if !allocations.IsDryRun() && !allocations.IsReadOnly() {
+		initLoggerFromConfig(cfg)
+	}
+	allocations.SetRemoteURL(cfg.RemoteURL)
+	if err := allocations.SetBackend(cfg.Store); err != nil {
+		return nil, err
+	}
+	if cfg.PerHost {
+		allocations.SetHost(currentHostname())
+	}
+	allocations.SetBackupCount(cfg.Backups)
+	useStateDir(false)
+	if err := docker.SetRuntime(cfg.ContainerRuntime); err != nil {
+		return nil, err
+	}
+	if err := port.SetSocketSource(cfg.SocketSource); err != nil {
+		return nil, err
+	}
+	if err := port.SetCheckStrategy(cfg.PortCheck); err != nil {
+		return nil, err
+	}
+	port.SetCheckTimeout(time.Duration(cfg.CheckTimeoutMs) * time.Millisecond)
+	if cfg.Checks == "off" {
+		port.SetOffline(true)
+	}
+	if cfg.Language != "" && cfg.Language != "auto" {
+		i18n.SetLanguage(cfg.Language)
+	}
+	if cfg.RootDetection != "" {
+		rootDetection = cfg.RootDetection
+	}
+	keepSymlinks = cfg.Symlinks == "keep"
+	caseInsensitivePaths = cfg.CaseInsensitivePaths()
+	allocations.SetDirectoryNormalizer(normalizeDir)
+	allocations.SetFirewallCloser(func(removed []allocations.Allocation) { closeFirewallRules(removed) })
 	return cfg, nil
 }
 
-// parseArgs extracts --verbose flag and returns remaining arguments.
-func parseArgs() []string {
+// profileEnvVar selects a profile when --profile is not given.
+const profileEnvVar = "PORT_SELECTOR_PROFILE"
+
+// parseArgs extracts global flags (--verbose[=MODULES], --debug-json, --dry-run,
+// --read-only, --offline, --no-color, --config DIR, --store FILE, --profile NAME) and returns remaining arguments.
+func parseArgs(osArgs []string) ([]string, error) {
 	var args []string
-	for _, arg := range os.Args[1:] {
-		if arg == "--verbose" {
+	profile := os.Getenv(profileEnvVar)
+	configSet := false
+	if value := os.Getenv(debug.EnvVar); value != "" {
+		debug.Configure(value)
+	}
+	for i := 0; i < len(osArgs); i++ {
+		arg := osArgs[i]
+		flag, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case arg == "--verbose":
 			debug.SetEnabled(true)
-		} else {
+		case flag == "--verbose" && hasValue:
+			debug.Configure(value)
+		case arg == "--debug-json":
+			debug.SetJSON(true)
+			debug.SetEnabled(true)
+		case arg == "--dry-run":
+			allocations.SetDryRun(true)
+		case arg == "--read-only":
+			allocations.SetReadOnly(true)
+		case arg == "--offline":
+			port.SetOffline(true)
+		case arg == "--no-color":
+			noColor = true
+		case flag == "--config" || flag == "--store" || flag == "--profile":
+			if !hasValue {
+				if i+1 >= len(osArgs) {
+					return nil, fmt.Errorf("%s requires a value", flag)
+				}
+				value = osArgs[i+1]
+				i++
+			}
+			if value == "" {
+				return nil, fmt.Errorf("%s requires a value", flag)
+			}
+			if flag == "--profile" {
+				profile = value
+				continue
+			}
+			abs, err := filepath.Abs(pathutil.ExpandHome(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s path %s: %w", flag, value, err)
+			}
+			if flag == "--config" {
+				config.SetDir(abs)
+				configSet = true
+			} else {
+				allocations.SetStoreFile(abs)
+			}
+		default:
 			args = append(args, arg)
 		}
 	}
-	return args
+
+	if profile != "" {
+		if configSet {
+			return nil, fmt.Errorf("--profile cannot be combined with --config")
+		}
+		if err := config.SetProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
 }
 
-// parseNameFromArgs extracts --name flag and returns the name and remaining arguments.
-// Returns "main" as default if --name is not provided.
+// parseNameFromArgs extracts --name flag and returns the name, whether --name was
+// given and the remaining arguments. Without --name, returns the directory's
+// default name (see defaultName).
 // Returns error if --name is provided with empty value.
-func parseNameFromArgs(args []string) (string, []string, error) {
-	name := "main"
+func parseNameFromArgs(args []string) (string, bool, []string, error) {
+	name := ""
 	var remaining []string
 	i := 0
 	for i < len(args) {
 		arg := args[i]
 		if arg == "--name" {
 			if i+1 >= len(args) {
-				return "", nil, fmt.Errorf("--name requires a value")
+				return "", false, nil, fmt.Errorf("--name requires a value")
 			}
 			name = args[i+1]
 			if name == "" {
-				return "", nil, fmt.Errorf("--name cannot be empty")
+				return "", false, nil, fmt.Errorf("--name cannot be empty")
 			}
 			i += 2 // skip --name and its value
 		} else if strings.HasPrefix(arg, "--name=") {
 			name = strings.TrimPrefix(arg, "--name=")
 			if name == "" {
-				return "", nil, fmt.Errorf("--name cannot be empty")
+				return "", false, nil, fmt.Errorf("--name cannot be empty")
 			}
 			i++ // skip this arg
 		} else {
@@ -82,7 +202,35 @@ func parseNameFromArgs(args []string) (string, []string, error) {
 			i++
 		}
 	}
-	return name, remaining, nil
+	if name != "" {
+		return name, true, remaining, nil
+	}
+	name, err := defaultName()
+	if err != nil {
+		return "", false, nil, err
+	}
+	return name, false, remaining, nil
+}
+
+// defaultName returns the allocation name used without --name: the one set for
+// the working directory by .port-selector-name or .port-selector.yaml, else by
+// the monorepo patterns of a parent project, or "main".
+func defaultName() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "main", nil
+	}
+	name, err := config.DefaultName(cwd)
+	if err != nil {
+		return "main", err
+	}
+	if name == "" {
+		_, name = config.MonorepoMatch(cwd)
+	}
+	if name == "" {
+		return "main", nil
+	}
+	return name, nil
 }
 
 // parseForceFromArgs extracts --force flag and returns whether it was present and remaining arguments.
@@ -99,6 +247,175 @@ func parseForceFromArgs(args []string) (bool, []string) {
 	return force, remaining
 }
 
+// allocOptions holds flags that modify the default port allocation.
+type allocOptions struct {
+	respectEnv  bool              // use $PORT from the environment instead of allocating (--respect-env)
+	noFreeze    bool              // don't freeze the port after use (--no-freeze)
+	wait        bool              // block until the port is listening (--wait)
+	waitFree    bool              // with --wait, block until the port is free instead (--free)
+	waitTimeout time.Duration     // give up waiting after this duration (--timeout)
+	labels      map[string]string // labels to set on the allocation; empty value removes (--label)
+	hold        bool              // keep the port bound until the caller is ready (--hold)
+	json        bool              // print the result (or the range exhaustion breakdown) as JSON (--json)
+	lease       time.Duration     // expire the allocation after this long unless reissued or renewed (--lease)
+	pid         int               // process that will use the port; gc frees it when the process exits (--pid)
+	ephemeral   bool              // take a port from the kernel instead of the configured range (--ephemeral)
+	onConflict  string            // overrides the onConflict config policy for this call (--on-conflict)
+	verifyOwner bool              // check who holds a busy locked port too (--verify-owner)
+	health      string            // HTTP path probed by --check and status; empty clears (--health)
+	healthSet   bool              // --health was given
+}
+
+// parseAllocOptions extracts allocation flags and returns the options and remaining arguments.
+func parseAllocOptions(args []string) (allocOptions, []string, error) {
+	opts := allocOptions{waitTimeout: defaultWaitTimeout}
+	var remaining []string
+	timeoutSet := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--respect-env":
+			opts.respectEnv = true
+		case arg == "--no-freeze":
+			opts.noFreeze = true
+		case arg == "--label" || strings.HasPrefix(arg, "--label="):
+			value, hasValue := strings.CutPrefix(arg, "--label=")
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--label requires key=value")
+				}
+				i++
+				value = args[i]
+			}
+			key, labelValue, hasEq, err := allocations.ParseLabel(value)
+			if err != nil {
+				return opts, nil, err
+			}
+			if !hasEq {
+				return opts, nil, fmt.Errorf("--label requires key=value (use key= to remove a label)")
+			}
+			if opts.labels == nil {
+				opts.labels = make(map[string]string)
+			}
+			opts.labels[key] = labelValue
+		case labelFlag(arg) != "":
+			key := labelFlag(arg)
+			value := strings.TrimPrefix(arg, "--"+key+"=")
+			if arg == "--"+key {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--%s requires a name", key)
+				}
+				value = args[i+1]
+				i++
+			}
+			if err := validateLabelValue(key, value); err != nil {
+				return opts, nil, err
+			}
+			if opts.labels == nil {
+				opts.labels = make(map[string]string)
+			}
+			opts.labels[key] = value
+		case arg == "--lease" || strings.HasPrefix(arg, "--lease="):
+			value := strings.TrimPrefix(arg, "--lease=")
+			if arg == "--lease" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--lease requires a duration (e.g., 2h)")
+				}
+				value = args[i+1]
+				i++
+			}
+			d, err := config.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return opts, nil, fmt.Errorf("invalid --lease value: %s", value)
+			}
+			opts.lease = d
+		case arg == "--pid" || strings.HasPrefix(arg, "--pid="):
+			value := strings.TrimPrefix(arg, "--pid=")
+			if arg == "--pid" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--pid requires a process ID")
+				}
+				value = args[i+1]
+				i++
+			}
+			pid, err := strconv.Atoi(value)
+			if err != nil || pid <= 0 {
+				return opts, nil, fmt.Errorf("invalid --pid value: %s", value)
+			}
+			opts.pid = pid
+		case arg == "--on-conflict" || strings.HasPrefix(arg, "--on-conflict="):
+			value := strings.TrimPrefix(arg, "--on-conflict=")
+			if arg == "--on-conflict" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--on-conflict requires reuse, fail or reallocate")
+				}
+				value = args[i+1]
+				i++
+			}
+			if value == "" {
+				return opts, nil, fmt.Errorf("--on-conflict requires reuse, fail or reallocate")
+			}
+			if err := config.ValidateConflictPolicy(value); err != nil {
+				return opts, nil, err
+			}
+			opts.onConflict = value
+		case arg == "--verify-owner":
+			opts.verifyOwner = true
+		case arg == "--health" || strings.HasPrefix(arg, "--health="):
+			value := strings.TrimPrefix(arg, "--health=")
+			if arg == "--health" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--health requires a path (e.g., /healthz)")
+				}
+				value = args[i+1]
+				i++
+			}
+			if value != "" && !strings.HasPrefix(value, "/") {
+				return opts, nil, fmt.Errorf("invalid --health value: %s (must start with /)", value)
+			}
+			opts.health = value
+			opts.healthSet = true
+		case arg == "--ephemeral":
+			opts.ephemeral = true
+		case arg == "--hold":
+			opts.hold = true
+		case arg == "--json":
+			opts.json = true
+		case arg == "--wait":
+			opts.wait = true
+		case arg == "--free":
+			opts.waitFree = true
+		case arg == "--timeout" || strings.HasPrefix(arg, "--timeout="):
+			value := strings.TrimPrefix(arg, "--timeout=")
+			if arg == "--timeout" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("--timeout requires a value (e.g., 30s)")
+				}
+				value = args[i+1]
+				i++
+			}
+			d, err := config.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return opts, nil, fmt.Errorf("invalid --timeout value: %s", value)
+			}
+			opts.waitTimeout = d
+			timeoutSet = true
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	if (opts.waitFree || timeoutSet) && !opts.wait {
+		return opts, nil, fmt.Errorf("--free and --timeout require --wait")
+	}
+	if opts.hold && opts.wait {
+		return opts, nil, fmt.Errorf("--hold cannot be combined with --wait")
+	}
+	if opts.json && (opts.hold || opts.respectEnv) {
+		return opts, nil, fmt.Errorf("--json cannot be combined with --hold or --respect-env")
+	}
+	return opts, remaining, nil
+}
+
 // parseOptionalPortFromArgs parses an optional port number from args.
 // It looks for a port number at the end of the args array.
 // If a non-numeric argument is provided where a port is expected, returns an error.
@@ -226,239 +543,870 @@ func truncateDirectoryPath(path string, maxLen int) string {
 }
 
 func main() {
-	// Parse arguments, extracting --verbose flag
-	args := parseArgs()
+	// The config may override the locale's language once it is loaded
+	i18n.SetLanguage(i18n.Detect(os.Getenv))
+
+	// Parse arguments, extracting global flags
+	args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		stderrf("error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// --free alone lists free ports; with --wait it waits for the allocated port
+	if len(args) > 0 && args[0] == "--free" && !slices.Contains(args, "--wait") {
+		if err := runFreePorts(args[1:]); err != nil {
+			stderrf("error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if len(args) > 0 {
 		switch args[0] {
 		case "-h", "--help":
 			printHelp()
 			return
+		case "--help-full":
+			writeHelp(os.Stdout, true)
+			return
+		case "--man":
+			writeMan(os.Stdout, time.Now())
+			return
 		case "-v", "--version":
 			printVersion()
 			return
 		case "-l", "--list":
-			if err := runList(); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			if err := runList(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		case "--forget":
-			name, remainingArgs, err := parseNameFromArgs(args[1:])
+			group, remainingArgs, err := parseGroupFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if group != "" {
+				if err := runForgetGroup(group, remainingArgs); err != nil {
+					stderrf("error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+			name, explicitName, remainingArgs, err := parseNameFromArgs(remainingArgs)
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runForget(name, explicitName, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "--renew":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runRenew(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "--release":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runRelease(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				if errors.Is(err, errReleaseRefused) {
+					os.Exit(exitReleaseRefused)
+				}
+				os.Exit(1)
+			}
+			return
+		case "--check":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runCheck(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				if errors.Is(err, errCheckFailed) {
+					os.Exit(exitCheckFailed)
+				}
+				os.Exit(1)
+			}
+			return
+		case "--move":
+			name, explicitName, remainingArgs, err := parseNameFromArgs(args[1:])
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runMove(name, explicitName, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
-			if err := runForget(name, remainingArgs); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		case "--rename":
+			if err := runRename(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "--forget-glob", "--forget-prefix":
+			if err := runForgetMatching(args[0], args[1:]); err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		case "--forget-all":
-			if err := runForgetAll(); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			if err := runForgetAll(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		case "--scan":
 			if err := runScan(); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		case "--refresh":
 			if err := runRefresh(); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "apply":
+			if err := runApply(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "init":
+			if err := runInit(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "advertise":
+			if err := runAdvertise(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "gc":
+			if err := runGC(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "status":
+			if err := runStatus(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "swap":
+			if err := runSwap(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "bench":
+			if err := runBench(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "tunnel":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runTunnel(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "systemd":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runSystemd(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "proxy":
+			if err := runProxy(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "firewall":
+			if err := runFirewall(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "hostname":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runHostname(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "hosts":
+			if err := runHosts(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "open":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runOpen(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "url":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runURL(name, remainingArgs); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "history":
+			if err := runHistory(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "undo":
+			if err := runUndo(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "config":
+			if err := runConfig(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "profiles":
+			if err := runProfiles(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "alias":
+			if err := runAlias(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "restore":
+			if err := runRestore(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "repair":
+			if err := runRepair(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case updateCheckCommand:
+			if err := runUpdateCheck(args[1:]); err != nil {
+				debug.Printf("update", "update check failed: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "events":
+			if err := runEvents(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "vscode":
+			if err := runVSCode(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "prompt":
+			if err := runPrompt(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "note":
+			if err := runNote(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "show", "--show":
+			if err := runShow(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "group":
+			if err := runGroup(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "session":
+			if err := runSession(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "devcontainer":
+			if err := runDevcontainer(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "--schema":
+			if err := runSchema(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "here":
+			if err := runHere(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "dedupe":
+			if err := runDedupe(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "--convert-store":
+			if err := runConvertStore(args[1:]); err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		case "-c", "--lock":
-			name, remainingArgs, err := parseNameFromArgs(args[1:])
+			group, remainingArgs, err := parseGroupFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if group != "" {
+				if err := runGroupLock(group, true, remainingArgs); err != nil {
+					stderrf("error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+			name, _, remainingArgs, err := parseNameFromArgs(remainingArgs)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			force, remainingArgs := parseForceFromArgs(remainingArgs)
 			portArg, err := parseOptionalPortFromArgs(remainingArgs)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			if err := runSetLocked(name, portArg, true, force); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "--sticky", "--unsticky":
+			name, _, remainingArgs, err := parseNameFromArgs(args[1:])
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(remainingArgs) > 1 || (args[0] == "--unsticky" && len(remainingArgs) > 0) {
+				stderrf("error: unknown arguments: %v\n", remainingArgs)
+				os.Exit(1)
+			}
+			portArg, err := parseOptionalPortFromArgs(remainingArgs)
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := runSetSticky(name, portArg, args[0] == "--sticky"); err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		case "-u", "--unlock":
-			name, remainingArgs, err := parseNameFromArgs(args[1:])
+			group, remainingArgs, err := parseGroupFromArgs(args[1:])
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if group != "" {
+				if err := runGroupLock(group, false, remainingArgs); err != nil {
+					stderrf("error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+			name, _, remainingArgs, err := parseNameFromArgs(remainingArgs)
+			if err != nil {
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			force, remainingArgs := parseForceFromArgs(remainingArgs)
 			portArg, err := parseOptionalPortFromArgs(remainingArgs)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			if err := runSetLocked(name, portArg, false, force); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				stderrf("error: %v\n", err)
 				os.Exit(1)
 			}
 			return
 		default:
-			// Check if the first arg is a --name flag
-			if strings.HasPrefix(args[0], "--name") {
-				name, remainingArgs, err := parseNameFromArgs(args)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "error: %v\n", err)
-					os.Exit(1)
-				}
-				if len(remainingArgs) > 0 {
-					fmt.Fprintf(os.Stderr, "error: unknown option: %s\n", remainingArgs[0])
-					printHelp()
-					os.Exit(1)
-				}
-				if err := runWithName(name); err != nil {
-					fmt.Fprintf(os.Stderr, "error: %v\n", err)
-					os.Exit(1)
-				}
-				return
+			// Allocation with flags (--name, --respect-env, ...)
+			name, _, remainingArgs, err := parseNameFromArgs(args)
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
 			}
-			fmt.Fprintf(os.Stderr, "error: unknown option: %s\n", args[0])
-			printHelp()
-			os.Exit(1)
+			opts, remainingArgs, err := parseAllocOptions(remainingArgs)
+			if err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(remainingArgs) > 0 {
+				stderrf("error: unknown option: %s\n", remainingArgs[0])
+				printHelp()
+				os.Exit(1)
+			}
+			if err := runWithName(name, opts); err != nil {
+				stderrf("error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	// No args - run with the directory's default name ("main" unless set)
+	name, err := defaultName()
+	if err != nil {
+		stderrf("error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := runWithName(name, allocOptions{}); err != nil {
+		stderrf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runWithName runs port selection with the given name.
+func runWithName(name string, opts allocOptions) error {
+	debug.Printf("main", "starting port selection with name=%s", name)
+
+	// Load configuration and initialize logger
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	debug.Printf("main", "config loaded: portStart=%d, portEnd=%d, freezePeriod=%s",
+		cfg.PortStart, cfg.PortEnd, cfg.GetFreezePeriod())
+
+	// Get config directory for allocations
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+	debug.Printf("main", "config dir: %s", configDir)
+
+	// Get current working directory
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	debug.Printf("main", "current directory: %s", cwd)
+
+	if opts.respectEnv {
+		if envPort, ok, err := portFromEnv(); err != nil {
+			return err
+		} else if ok {
+			return runRegisterEnvPort(cfg, configDir, cwd, name, envPort)
+		}
+		debug.Printf("main", "$PORT is not set, allocating as usual")
+	}
+	if opts.wait {
+		if err := requireChecks("--wait"); err != nil {
+			return err
+		}
+	}
+
+	resultPort, err := obtainPort(cfg, configDir, cwd, name, opts)
+	if err != nil {
+		if opts.json {
+			return printAllocationJSON(0, cwd, name, err)
+		}
+		return err
+	}
+
+	if opts.wait {
+		if err := waitForPort(resultPort, !opts.waitFree, opts.waitTimeout, port.IsPortFree); err != nil {
+			return err
+		}
+	}
+
+	if opts.hold {
+		return holdPort(resultPort, os.Stdin)
+	}
+
+	// Output the port
+	if opts.json {
+		if err := printAllocationJSON(resultPort, cwd, name, nil); err != nil {
+			return err
 		}
+	} else {
+		fmt.Println(resultPort)
 	}
 
-	// No args - run with default name "main"
-	if err := runWithName("main"); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+	if cfg.UpdateCheck {
+		checkForUpdate(allocations.StateDir(configDir))
 	}
+	return nil
 }
 
-// runWithName runs port selection with the given name.
-func runWithName(name string) error {
-	debug.Printf("main", "starting port selection with name=%s", name)
-
-	// Load configuration and initialize logger
-	cfg, err := loadConfigAndInitLogger()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+// obtainPort returns the port for (cwd, name), allocating one if needed.
+func obtainPort(cfg *config.Config, configDir, cwd, name string, opts allocOptions) (int, error) {
+	// Per-call flags override the config
+	if opts.onConflict != "" || opts.verifyOwner {
+		override := *cfg
+		if opts.onConflict != "" {
+			override.OnConflict = opts.onConflict
+		}
+		override.VerifyOwner = override.VerifyOwner || opts.verifyOwner
+		cfg = &override
 	}
-	debug.Printf("main", "config loaded: portStart=%d, portEnd=%d, freezePeriod=%s",
-		cfg.PortStart, cfg.PortEnd, cfg.GetFreezePeriod())
+	opts.verifyOwner = cfg.VerifyOwner
 
-	// Get config directory for allocations
-	configDir, err := config.ConfigDir()
-	if err != nil {
-		return fmt.Errorf("failed to get config dir: %w", err)
+	// Fast path: answer from a lock-free read when no write is needed
+	if p, store, ok := lookupWithoutLock(configDir, cwd, name, opts); ok {
+		printExpiryWarnings(os.Stderr, expiryWarnings(store, cfg, cwd, p, time.Now()))
+		return p, nil
 	}
-	debug.Printf("main", "config dir: %s", configDir)
 
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+	// The start time tells the owner apart from a later process with the same PID
+	var ownerStart uint64
+	if opts.pid > 0 {
+		if !port.ProcessAlive(opts.pid, 0) {
+			return 0, fmt.Errorf("process %d is not running", opts.pid)
+		}
+		ownerStart, _ = port.ProcessStartTime(opts.pid)
 	}
-	debug.Printf("main", "current directory: %s", cwd)
 
 	// Use WithStore for atomic operations
 	var resultPort int
-	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
-		// Auto-cleanup expired allocations
-		ttl := cfg.GetAllocationTTL()
-		if ttl > 0 {
-			if removed := store.RemoveExpired(ttl); removed > 0 {
-				debug.Printf("main", "removed %d expired allocations", removed)
-			}
+	var warnings []string
+	err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+		// Auto-cleanup expired allocations and leases
+		if removed := store.RemoveExpired(cfg.GetAllocationTTL()); removed > 0 {
+			debug.Printf("main", "removed %d expired allocations", removed)
+		}
+
+		var allocErr error
+		if opts.ephemeral {
+			resultPort, allocErr = allocateEphemeral(store, cwd, name, port.EphemeralPort)
+		} else {
+			resultPort, allocErr = allocatePort(store, cfg, cwd, name)
+		}
+		if allocErr != nil {
+			return allocErr
 		}
+		if opts.noFreeze {
+			store.SetNoFreeze(resultPort, true)
+		}
+		if opts.pid > 0 {
+			store.SetOwner(resultPort, opts.pid, ownerStart)
+		}
+		// Reissuing renews the lease; --lease starts a new one
+		if opts.lease > 0 {
+			store.SetLease(resultPort, opts.lease)
+		} else {
+			store.RenewLease(resultPort)
+		}
+		if len(opts.labels) > 0 {
+			store.SetLabels(resultPort, opts.labels)
+		}
+		if opts.healthSet {
+			store.SetHealthPath(resultPort, opts.health)
+		}
+		warnings = expiryWarnings(store, cfg, cwd, resultPort, time.Now())
+		return nil
+	})
 
-		// Check if current directory already has an allocated port for this name
-		// ALWAYS return the same port for (directory, name) - port is stable per directory
-		if existing := store.FindByDirectoryAndName(cwd, name); existing != nil {
-			debug.Printf("main", "found existing allocation for name %s: port %d (locked=%v)", name, existing.Port, existing.Locked)
+	if err != nil {
+		return 0, err
+	}
+	printExpiryWarnings(os.Stderr, warnings)
+	return resultPort, nil
+}
 
-			// Warn if the port is busy (occupied by another process)
-			if !port.IsPortFree(existing.Port) {
-				procInfo := port.GetPortProcess(existing.Port)
-				if procInfo != nil && procInfo.Name != "" {
-					fmt.Fprintf(os.Stderr, "warning: port %d is busy (%s); use --forget to get a new port\n", existing.Port, procInfo.Name)
-				} else {
-					fmt.Fprintf(os.Stderr, "warning: port %d is busy; use --forget to get a new port\n", existing.Port)
+// notifyPortConflict sends a desktop notification when the allocation's port is held
+// by a process outside the allocation's directory (or by an unidentified process).
+func notifyPortConflict(alloc *allocations.Allocation, procInfo *port.ProcessInfo) {
+	if !isForeignHolder(alloc, procInfo) {
+		return
+	}
+	message := fmt.Sprintf("Port %d of %s ('%s') is taken by %s", alloc.Port, pathutil.ShortenHomePath(alloc.Directory), alloc.Name, describeHolder(procInfo))
+	if err := notify.Send("port-selector: port conflict", message); err != nil {
+		debug.Printf("main", "notification failed: %v", err)
+	}
+}
+
+// preferredPortAvailable reports whether a preferred port can be allocated: it is not
+// excluded (frozen, locked or used by another name), not allocated and free.
+func preferredPortAvailable(store *allocations.Store, p int, excluded map[int]bool) bool {
+	return !excluded[p] && store.FindByPort(p) == nil && port.IsPortFree(p)
+}
+
+// allocatePort returns the port for (cwd, name), allocating a new one if needed.
+// Must be called inside WithStore.
+func allocatePort(store *allocations.Store, cfg *config.Config, cwd, name string) (int, error) {
+	// A sticky port wins over the current allocation whenever it can be taken;
+	// a locked allocation stays where it is
+	if existing := store.FindByDirectoryAndName(cwd, name); existing == nil || !existing.Locked {
+		if sticky := claimStickyPort(store, cwd, name, port.IsPortFree); sticky != 0 {
+			debug.Printf("main", "using sticky port %d for name %s", sticky, name)
+			return sticky, nil
+		}
+	}
+
+	// Check if current directory already has an allocated port for this name
+	// ALWAYS return the same port for (directory, name) - port is stable per directory
+	if existing := store.FindByDirectoryAndName(cwd, name); existing != nil {
+		debug.Printf("main", "found existing allocation for name %s: port %d (locked=%v)", name, existing.Port, existing.Locked)
+
+		// Warn if the port is busy (occupied by another process); an unlocked port taken
+		// by a foreign process follows the onConflict policy, and so does a locked one
+		// with verifyOwner
+		reallocate := false
+		if !port.IsPortFree(existing.Port) {
+			procInfo := port.GetPortProcess(existing.Port)
+			policy := cfg.GetOnConflict()
+			if cfg.Notify {
+				notifyPortConflict(existing, procInfo)
+			}
+			foreign := (!existing.Locked || cfg.VerifyOwner) && isForeignHolder(existing, procInfo)
+			switch {
+			case foreign && policy != config.ConflictReuse:
+				var err error
+				if reallocate, err = resolveConflict(store, policy, existing, procInfo); err != nil {
+					return 0, err
 				}
+			case foreign && existing.Locked:
+				stderrf("warning: locked port %d is held by %s, not by a process in %s\n",
+					existing.Port, describeHolder(procInfo), pathutil.ShortenHomePath(existing.Directory))
+			case procInfo != nil && procInfo.Name != "":
+				stderrf("warning: port %d is busy (%s); use --forget to get a new port\n", existing.Port, procInfo.Name)
+			default:
+				stderrf("warning: port %d is busy; use --forget to get a new port\n", existing.Port)
 			}
+		}
 
+		if !reallocate {
 			// Update last_used timestamp for the specific port being issued
 			if !store.UpdateLastUsedByPort(existing.Port) {
 				debug.Printf("main", "warning: UpdateLastUsedByPort failed for port %d", existing.Port)
-				fmt.Fprintf(os.Stderr, "warning: failed to update timestamp for port %d\n", existing.Port)
+				stderrf("warning: failed to update timestamp for port %d\n", existing.Port)
 			}
-			resultPort = existing.Port
-			return nil
+			return existing.Port, nil
 		}
 
-		// Get last used port for round-robin behavior
-		lastUsed := store.GetLastIssuedPort()
-		debug.Printf("main", "last issued port: %d", lastUsed)
+		// A reallocated locked port stays locked
+		if existing.Locked {
+			newPort, err := allocatePort(store, cfg, cwd, name)
+			if err == nil {
+				store.SetLockedByPort(newPort, true)
+			}
+			return newPort, err
+		}
+	}
+
+	// A new port is needed: check the range against the kernel's ephemeral range
+	if err := checkEphemeralOverlap(cfg); err != nil {
+		return 0, err
+	}
 
-		// Get frozen ports (recently used)
-		frozenPorts := store.GetFrozenPorts(cfg.GetFreezePeriod())
-		debug.Printf("main", "frozen ports: %d", len(frozenPorts))
+	// Get last used port for round-robin behavior
+	lastUsed := store.GetLastIssuedPort()
+	debug.Printf("main", "last issued port: %d", lastUsed)
 
-		// Add locked ports from other directories to the exclusion set
-		lockedPorts := store.GetLockedPortsForExclusion(cwd)
-		debug.Printf("main", "locked ports from other directories: %d", len(lockedPorts))
-		for p := range lockedPorts {
-			frozenPorts[p] = true
-		}
+	// Get frozen ports (recently used)
+	frozenPorts := store.GetFrozenPortsWithPolicy(cfg.FreezePeriodFor)
+	debug.Printf("main", "frozen ports: %d", len(frozenPorts))
 
-		// Add ports allocated to other names in the same directory to the exclusion set
-		otherNamesPorts := make(map[int]bool)
-		for port, info := range store.Allocations {
-			if info != nil && info.Directory == cwd && info.Name != name {
-				otherNamesPorts[port] = true
-			}
+	// Add locked ports from other directories to the exclusion set
+	lockedPorts := store.GetLockedPortsForExclusion(cwd)
+	debug.Printf("main", "locked ports from other directories: %d", len(lockedPorts))
+	for p := range lockedPorts {
+		frozenPorts[p] = true
+	}
+
+	// Ports of excludedPorts/excludedRanges are never handed out
+	for p := range cfg.ExcludedPortSet() {
+		frozenPorts[p] = true
+	}
+
+	// Add ports allocated to other names in the same directory to the exclusion set
+	otherNamesPorts := make(map[int]bool)
+	for port, info := range store.Allocations {
+		if info != nil && info.Directory == cwd && info.Name != name {
+			otherNamesPorts[port] = true
 		}
-		debug.Printf("main", "ports for other names in same directory: %d", len(otherNamesPorts))
-		for p := range otherNamesPorts {
-			frozenPorts[p] = true
+	}
+	debug.Printf("main", "ports for other names in same directory: %d", len(otherNamesPorts))
+	for p := range otherNamesPorts {
+		frozenPorts[p] = true
+	}
+
+	// Try the port preferred for this name in .port-selector.yaml
+	project, err := config.LoadProject(cwd)
+	if err != nil {
+		return 0, err
+	}
+	if preferred, ok := project.Preferred[name]; ok {
+		if preferredPortAvailable(store, preferred, frozenPorts) {
+			debug.Printf("main", "using preferred port %d for name %s", preferred, name)
+			store.SetAllocationWithName(cwd, preferred, name)
+			return preferred, nil
 		}
+		debug.Printf("main", "preferred port %d for name %s is taken, searching", preferred, name)
+	}
 
-		// Find a free port (excluding frozen and locked ones)
-		debug.Printf("main", "searching for free port in range %d-%d, starting after %d",
-			cfg.PortStart, cfg.PortEnd, lastUsed)
-		freePort, err := port.FindFreePortWithExclusions(cfg.PortStart, cfg.PortEnd, lastUsed, frozenPorts)
-		if err != nil {
-			if errors.Is(err, port.ErrAllPortsBusy) {
-				return fmt.Errorf("all ports in range %d-%d are busy or frozen", cfg.PortStart, cfg.PortEnd)
-			}
-			return fmt.Errorf("failed to find free port: %w", err)
+	// Find a free port (excluding frozen and locked ones)
+	debug.Printf("main", "searching for free port in range %d-%d, starting after %d",
+		cfg.PortStart, cfg.PortEnd, lastUsed)
+	freePort, blockStart, blockEnd, err := findPortWithBlocks(store, cfg, cwd, lastUsed, frozenPorts)
+	if err != nil {
+		if errors.Is(err, port.ErrAllPortsBusy) {
+			return 0, diagnoseExhaustion(store, cfg, cwd, name, port.IsPortFree)
 		}
-		debug.Printf("main", "found free port: %d", freePort)
+		return 0, fmt.Errorf("failed to find free port: %w", err)
+	}
+	debug.Printf("main", "found free port: %d", freePort)
 
-		// Save allocation for this directory and name (with safe cleanup of old ports for this name)
-		store.SetAllocationWithName(cwd, freePort, name)
+	// Save allocation for this directory and name (with safe cleanup of old ports for this name)
+	store.SetAllocationWithName(cwd, freePort, name)
+	if blockEnd > 0 {
+		store.SetBlock(freePort, blockStart, blockEnd)
+	}
 
-		// Update last issued port
-		store.SetLastIssuedPort(freePort)
+	// Update last issued port
+	store.SetLastIssuedPort(freePort)
 
-		resultPort = freePort
-		return nil
-	})
+	return freePort, nil
+}
 
+// lookupWithoutLock returns the existing port for (cwd, name) if it can be answered
+// from a lock-free read: the allocation exists, its port is free or locked (and
+// the holder of a locked port need not be verified), and
+// LastUsedAt is recent enough that refreshing it can be deferred. The loaded store
+// is returned too. Returns false if the caller must fall back to the locked path.
+func lookupWithoutLock(configDir, cwd, name string, opts allocOptions) (int, *allocations.Store, bool) {
+	store, err := allocations.Load(configDir)
 	if err != nil {
-		return err
+		debug.Printf("main", "fast path: load failed, falling back to locked path: %v", err)
+		return 0, nil, false
 	}
 
-	// Output the port
-	fmt.Println(resultPort)
-	return nil
+	existing := store.FindByDirectoryAndName(cwd, name)
+	if existing == nil {
+		debug.Printf("main", "fast path: no allocation for name %s", name)
+		return 0, nil, false
+	}
+
+	if opts.noFreeze && !existing.NoFreeze {
+		debug.Printf("main", "fast path: port %d needs no_freeze flag", existing.Port)
+		return 0, nil, false
+	}
+
+	for k, v := range opts.labels {
+		if existing.Labels[k] != v {
+			debug.Printf("main", "fast path: port %d needs label %s=%s", existing.Port, k, v)
+			return 0, nil, false
+		}
+	}
+
+	if opts.healthSet && existing.HealthPath != opts.health {
+		debug.Printf("main", "fast path: port %d needs health path %q", existing.Port, opts.health)
+		return 0, nil, false
+	}
+
+	if opts.pid > 0 && existing.OwnerPID != opts.pid {
+		debug.Printf("main", "fast path: port %d needs owner pid %d", existing.Port, opts.pid)
+		return 0, nil, false
+	}
+
+	if opts.lease > 0 || existing.Lease > 0 {
+		debug.Printf("main", "fast path: lease of port %d needs renewal", existing.Port)
+		return 0, nil, false
+	}
+
+	if !existing.Locked && stickyPortAvailable(store, cwd, name, existing.Port) {
+		debug.Printf("main", "fast path: sticky port of name %s may be free", name)
+		return 0, nil, false
+	}
+
+	if time.Since(existing.LastUsedAt) >= lastUsedRefreshInterval {
+		debug.Printf("main", "fast path: last_used_at of port %d needs refresh", existing.Port)
+		return 0, nil, false
+	}
+
+	if (!existing.Locked || opts.verifyOwner) && !port.IsPortFree(existing.Port) {
+		debug.Printf("main", "fast path: port %d is busy (locked=%v)", existing.Port, existing.Locked)
+		return 0, nil, false
+	}
+
+	debug.Printf("main", "fast path: returning port %d without lock", existing.Port)
+	return existing.Port, store, true
 }
 
-func runForget(name string, remainingArgs []string) error {
-	if len(remainingArgs) > 0 {
+// runForget removes the allocations of the working directory (or of the directory
+// or port given as argument): all of them, or only name when --name was given.
+func runForget(name string, explicitName bool, remainingArgs []string) error {
+	if len(remainingArgs) > 1 {
 		return fmt.Errorf("unknown arguments: %v", remainingArgs)
 	}
 
@@ -471,19 +1419,38 @@ func runForget(name string, remainingArgs []string) error {
 		return fmt.Errorf("failed to get config dir: %w", err)
 	}
 
-	cwd, err := os.Getwd()
+	// An optional argument selects the target: a port number, or a directory
+	// (path or @alias) that doesn't have to exist anymore.
+	cwd, err := workingDir()
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
+	if len(remainingArgs) == 1 {
+		arg := remainingArgs[0]
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+		if portArg, convErr := strconv.Atoi(arg); convErr == nil {
+			if portArg < 1 || portArg > 65535 {
+				return fmt.Errorf("invalid port number: %s (must be 1-65535)", arg)
+			}
+			if explicitName {
+				return fmt.Errorf("--name cannot be combined with a port")
+			}
+			return forgetPort(configDir, portArg)
+		}
+		if cwd, err = dirResolver(configDir)(arg); err != nil {
+			return err
+		}
+	}
 
-	// If name is "main" and no --name flag was provided (remainingArgs is empty),
-	// remove all allocations for the directory.
-	// If --name was explicitly provided (even if it's "main"), remove only that name.
-	removeAll := (name == "main" && len(remainingArgs) == 0)
+	// Without --name, remove all allocations for the directory (a default name
+	// from .port-selector-name doesn't narrow it). Otherwise remove only that name.
+	removeAll := !explicitName
 
 	var removedPort int
 	var removedCount int
-	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+	err = allocations.WithJournal(configDir, "--forget", func(store *allocations.Store) error {
 		if removeAll {
 			// Remove all allocations for this directory
 			var removed []allocations.Allocation
@@ -536,19 +1503,53 @@ func runForget(name string, remainingArgs []string) error {
 
 	if removeAll {
 		if removedCount > 0 {
-			fmt.Printf("Cleared %d allocation(s) for %s (most recent was port %d)\n",
-				removedCount, pathutil.ShortenHomePath(cwd), removedPort)
+			fmt.Printf("%s %d allocation(s) for %s (most recent was port %d)\n",
+				clearedVerb(), removedCount, pathutil.ShortenHomePath(cwd), removedPort)
 		}
 	} else {
 		if removedPort > 0 {
-			fmt.Printf("Cleared allocation '%s' for %s (was port %d)\n",
-				name, pathutil.ShortenHomePath(cwd), removedPort)
+			fmt.Printf("%s allocation '%s' for %s (was port %d)\n",
+				clearedVerb(), name, pathutil.ShortenHomePath(cwd), removedPort)
 		}
 	}
 	return nil
 }
 
-func runForgetAll() error {
+// forgetPort removes the allocation for a single port, whatever its directory.
+func forgetPort(configDir string, portArg int) error {
+	var removed *allocations.AllocationInfo
+	err := allocations.WithJournal(configDir, "--forget", func(store *allocations.Store) error {
+		removed = nil
+		if info := store.Allocations[portArg]; info != nil {
+			copied := *info
+			removed = &copied
+			store.RemoveByPort(portArg)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if removed == nil {
+		fmt.Printf("No allocation found for port %d\n", portArg)
+		return nil
+	}
+	fmt.Printf("%s port %d (%s, '%s')\n", clearedVerb(), portArg, pathutil.ShortenHomePath(removed.Directory), removed.Name)
+	return nil
+}
+
+func runForgetAll(args []string) error {
+	yes := false
+	for _, arg := range args {
+		switch arg {
+		case "--yes", "-y":
+			yes = true
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
 	if _, err := loadConfigAndInitLogger(); err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -558,8 +1559,33 @@ func runForgetAll() error {
 		return fmt.Errorf("failed to get config dir: %w", err)
 	}
 
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	if store.Count() == 0 {
+		fmt.Println("No allocations found")
+		return nil
+	}
+
+	// Only ask on a terminal, so existing scripts keep working without --yes
+	ok, err := confirm(fmt.Sprintf("About to delete %d allocation(s), continue?", store.Count()), yes || !confirmIsTerminal())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
 	var count int
-	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+	var backupPath string
+	err = allocations.WithJournal(configDir, "--forget-all", func(store *allocations.Store) error {
+		path, err := allocations.Backup(configDir)
+		if err != nil {
+			return err
+		}
+		backupPath = path
 		count = store.RemoveAll()
 		return nil
 	})
@@ -571,11 +1597,28 @@ func runForgetAll() error {
 	if count == 0 {
 		fmt.Println("No allocations found")
 	} else {
-		fmt.Printf("Cleared %d allocation(s)\n", count)
+		fmt.Printf("%s %d allocation(s)\n", clearedVerb(), count)
 	}
+	printBackupPath(backupPath)
 	return nil
 }
 
+// clearedVerb starts the messages of the forget commands, which under --dry-run
+// report what would be cleared.
+func clearedVerb() string {
+	if allocations.IsDryRun() {
+		return "Would clear"
+	}
+	return "Cleared"
+}
+
+// printBackupPath tells the user where the store was backed up before a destructive change.
+func printBackupPath(path string) {
+	if path != "" {
+		fmt.Printf("Backup saved to %s\n", pathutil.ShortenHomePath(path))
+	}
+}
+
 func runSetLocked(name string, portArg int, locked bool, force bool) error {
 	if _, err := loadConfigAndInitLogger(); err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -586,7 +1629,7 @@ func runSetLocked(name string, portArg int, locked bool, force bool) error {
 		return fmt.Errorf("failed to get config dir: %w", err)
 	}
 
-	cwd, err := os.Getwd()
+	cwd, err := workingDir()
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
@@ -595,7 +1638,18 @@ func runSetLocked(name string, portArg int, locked bool, force bool) error {
 	var reassignedFrom string
 	var isExternal bool
 	var externalProcessName string
-	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+	// Locking a specific port may reassign it from another directory; journal it for undo
+	withStore := allocations.WithStore
+	if portArg > 0 {
+		operation := fmt.Sprintf("--lock %d", portArg)
+		if !locked {
+			operation = fmt.Sprintf("--unlock %d", portArg)
+		}
+		withStore = func(configDir string, fn func(*allocations.Store) error) error {
+			return allocations.WithJournal(configDir, operation, fn)
+		}
+	}
+	err = withStore(configDir, func(store *allocations.Store) error {
 		var lockErr error
 		if portArg > 0 {
 			targetPort, reassignedFrom, isExternal, lockErr = lockSpecificPort(store, name, portArg, cwd, locked, force)
@@ -627,7 +1681,7 @@ func runSetLocked(name string, portArg int, locked bool, force bool) error {
 
 	// Print warning if port was reassigned from another directory
 	if reassignedFrom != "" {
-		fmt.Fprintf(os.Stderr, "warning: port %d was allocated to %s\n", targetPort, pathutil.ShortenHomePath(reassignedFrom))
+		stderrf("warning: port %d was allocated to %s\n", targetPort, pathutil.ShortenHomePath(reassignedFrom))
 		fmt.Printf("Reassigned and locked port %d for '%s' in %s\n", targetPort, name, pathutil.ShortenHomePath(cwd))
 	} else {
 		action := "Locked"
@@ -768,217 +1822,61 @@ func lockCurrentDirectory(store *allocations.Store, name string, cwd string, loc
 	return alloc.Port, nil
 }
 
-func runList() error {
-	configDir, err := config.ConfigDir()
-	if err != nil {
-		return fmt.Errorf("failed to get config dir: %w", err)
-	}
-
-	// Load without locking - this is read-only and Save() uses atomic writes
-	// (temp file + rename), so the file is always in a consistent state.
-	store, err := allocations.Load(configDir)
-	if err != nil {
-		return fmt.Errorf("failed to load allocations: %w", err)
-	}
-	if store.Count() == 0 {
-		fmt.Println("No port allocations found.")
-		return nil
-	}
-
-	// Determine which directories have multiple names
-	dirsWithMultipleNames := make(map[string]bool)
-	dirNameCount := make(map[string]map[string]bool)
-	allAllocs := store.SortedByPort()
-
-	for _, alloc := range allAllocs {
-		if dirNameCount[alloc.Directory] == nil {
-			dirNameCount[alloc.Directory] = make(map[string]bool)
-		}
-		dirNameCount[alloc.Directory][alloc.Name] = true
-	}
-
-	for dir, names := range dirNameCount {
-		if len(names) > 1 {
-			dirsWithMultipleNames[dir] = true
-		}
-	}
-
-	// First pass: collect all directory paths and determine max width (up to 40 chars)
-	const maxDirWidth = 40
-	allDirectories := make([]string, len(allAllocs))
-	maxDirLen := 0
-
-	for i, alloc := range allAllocs {
-		shortDir := pathutil.ShortenHomePath(alloc.Directory)
-		allDirectories[i] = shortDir
-
-		if len(shortDir) > maxDirLen {
-			maxDirLen = len(shortDir)
-		}
-	}
-
-	// Second pass: format and print output
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "PORT\tDIRECTORY\tNAME\tSOURCE\tSTATUS\tLOCKED\tUSER\tPID\tPROCESS\tASSIGNED")
-
-	hasIncompleteInfo := false
-
-	for i, alloc := range allAllocs {
-		status := "free"
-		username := "-"
-		pid := "-"
-		process := "-"
-
-		// Determine SOURCE and use saved external info for external allocations
-		source := "free"
-		if alloc.Status == allocations.StatusExternal {
-			source = "external"
-			// For external allocations, use saved process info
-			if alloc.ExternalUser != "" {
-				username = alloc.ExternalUser
-			}
-			if alloc.ExternalPID > 0 {
-				pid = strconv.Itoa(alloc.ExternalPID)
-			}
-			if alloc.ExternalProcessName != "" {
-				process = truncateProcessName(alloc.ExternalProcessName)
-			}
-			status = "busy" // External ports are always busy
-		} else if alloc.Locked {
-			source = "lock"
-			// Use saved process name from allocation if available
-			if alloc.ProcessName != "" {
-				process = truncateProcessName(alloc.ProcessName)
+// parseLabelFilter parses --label KEY[=VALUE] arguments of --list.
+// A key without a value matches any allocation that has the key.
+func parseLabelFilter(args []string) (map[string]string, map[string]bool, error) {
+	values := make(map[string]string)
+	keys := make(map[string]bool)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if key := labelFlag(arg); key != "" {
+			value := strings.TrimPrefix(arg, "--"+key+"=")
+			if arg == "--"+key {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("--%s requires a name", key)
+				}
+				value = args[i+1]
+				i++
 			}
-		} else {
-			// Normal allocation - use saved process name if available
-			if alloc.ProcessName != "" {
-				process = truncateProcessName(alloc.ProcessName)
+			if err := validateLabelValue(key, value); err != nil {
+				return nil, nil, err
 			}
+			values[key] = value
+			continue
 		}
-
-		// For non-external allocations, check live port status
-		if alloc.Status != allocations.StatusExternal && !port.IsPortFree(alloc.Port) {
-			status = "busy"
-			if procInfo := port.GetPortProcess(alloc.Port); procInfo != nil {
-				if procInfo.User != "" {
-					username = procInfo.User
-				}
-				if procInfo.PID > 0 {
-					pid = strconv.Itoa(procInfo.PID)
-					// Override with current process name if available
-					if procInfo.Name != "" {
-						process = truncateProcessName(procInfo.Name)
-					}
-				} else if procInfo.ContainerID != "" {
-					// Docker container detected via fallback
-					process = "docker-proxy"
-				} else {
-					// Have user but no PID and no Docker - mark incomplete only if no saved name
-					if alloc.ProcessName == "" {
-						hasIncompleteInfo = true
-					}
-				}
+		value, hasValue := strings.CutPrefix(arg, "--label=")
+		if !hasValue {
+			if arg != "--label" {
+				return nil, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--label requires key=value")
 			}
+			i++
+			value = args[i]
 		}
-
-		locked := ""
-		if alloc.Locked {
-			locked = "yes"
+		key, labelValue, hasEq, err := allocations.ParseLabel(value)
+		if err != nil {
+			return nil, nil, err
 		}
-
-		// Always show the name (even "main")
-		nameStr := alloc.Name
-
-		timestamp := alloc.AssignedAt.Local().Format("2006-01-02 15:04")
-
-		// Get pre-calculated directory string and truncate if needed
-		shortDir := allDirectories[i]
-		// Cap at 40 characters maximum
-		if len(shortDir) > maxDirWidth {
-			shortDir = truncateDirectoryPath(shortDir, maxDirWidth)
+		if hasEq {
+			values[key] = labelValue
+		} else {
+			keys[key] = true
 		}
-
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", alloc.Port, shortDir, nameStr, source, status, locked, username, pid, process, timestamp)
-	}
-
-	w.Flush()
-
-	if hasIncompleteInfo {
-		fmt.Fprintln(os.Stderr, "\nTip: Run with sudo for full process info: sudo port-selector --list")
 	}
-
-	return nil
+	return values, keys, nil
 }
 
-func printHelp() {
-	fmt.Println(`Usage: port-selector [options]
-
-Finds and returns a free port from configured range.
-Remembers which port was assigned to which directory.
-
-Options:
-  -h, --help           Show this help message
-  -v, --version        Show version
-  -l, --list           List all port allocations
-  -c, --lock [PORT]    Lock port for current directory and name (or specified port)
-  -u, --unlock [PORT]  Unlock port for current directory and name (or specified port)
-  --force, -f          Force lock a busy port or locked port from another directory
-  --forget             Clear all port allocations for current directory
-  --forget --name NAME Clear port allocation for current directory with specific name
-  --forget-all         Clear all port allocations
-  --scan               Scan port range and record busy ports with their directories
-  --refresh            Refresh external port allocations (remove stale entries)
-  --name NAME          Use named allocation (default: "main")
-  --verbose            Enable debug output (can be combined with other flags)
-
-Named Allocations:
-  --name <name> creates a stable, per-directory named allocation.
-  The same directory can have multiple named allocations (web/api/db/etc.).
-  Default name is "main" when --name is not provided.
-
-Examples:
-  port-selector                    # Use default name "main"
-  port-selector --name postgres    # Named allocation for postgres
-  port-selector --name web         # Named allocation for web
-  port-selector --list             # Show all allocations with NAME column
-  port-selector --lock             # Lock "main" allocation
-  port-selector --lock --name web  # Lock "web" allocation
-  port-selector --unlock --name db # Unlock "db" allocation
-  port-selector --forget           # Forget all allocations for directory
-  port-selector --forget --name api # Forget only "api" allocation
-  port-selector --refresh          # Remove stale external port allocations
-
-Port Locking:
-  Locked ports are reserved and won't be allocated to other directories.
-  Use this for long-running services.
-
-  Using --lock with a port number will allocate AND lock that port
-  to the current directory/name in one step.
-
-  When --lock PORT targets another directory's port:
-  - Free + unlocked: reassigned without --force (abandoned allocation)
-  - Free + locked: requires --force to reassign
-  - Busy (any): blocked completely — stop the service first
-
-  When --lock PORT targets a busy unallocated port:
-  - Requires --force (you take responsibility for the conflict)
-
-  If the port is already in use by another directory, it will be
-  registered as an external allocation instead of failing.
-
-Configuration:
-  ~/.config/port-selector/config.yaml
-
-  Available options:
-    portStart: 3000       # Start of port range
-    portEnd: 4000         # End of port range
-    freezePeriod: 24h     # How long to avoid reusing a port (e.g., 24h, 30m, 0 to disable)
-    allocationTTL: 30d    # Auto-expire allocations (e.g., 30d, 720h, 0 to disable)
-    log: ~/.config/port-selector/port-selector.log  # Log file path (optional)
-
-Source code:
-  https://github.com/dapi/port-selector`)
+// formatOwner describes the owner process bound with --pid and whether it still runs.
+func formatOwner(alloc allocations.Allocation) string {
+	if alloc.OwnerPID <= 0 {
+		return "-"
+	}
+	if port.ProcessAlive(alloc.OwnerPID, alloc.OwnerStartTime) {
+		return fmt.Sprintf("%d alive", alloc.OwnerPID)
+	}
+	return fmt.Sprintf("%d exited", alloc.OwnerPID)
 }
 
 func printVersion() {
@@ -990,6 +1888,9 @@ func runScan() error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := requireChecks("--scan"); err != nil {
+		return err
+	}
 
 	configDir, err := config.ConfigDir()
 	if err != nil {
@@ -1000,13 +1901,21 @@ func runScan() error {
 
 	var discovered int
 	var hasIncompleteInfo bool
+	procs := port.NewSnapshot() // read /proc once for the whole range
 
+	excluded := cfg.ExcludedPortSet()
 	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
 		for p := cfg.PortStart; p <= cfg.PortEnd; p++ {
 			if port.IsPortFree(p) {
 				continue
 			}
 
+			// Excluded ports are never allocated, so there is nothing to record
+			if excluded[p] {
+				fmt.Printf("Port %d: excluded\n", p)
+				continue
+			}
+
 			// Skip if already allocated
 			if existing := store.FindByPort(p); existing != nil {
 				fmt.Printf("Port %d: already allocated to %s\n", p, pathutil.ShortenHomePath(existing.Directory))
@@ -1014,7 +1923,7 @@ func runScan() error {
 			}
 
 			// Port is busy - try to get process info
-			procInfo := port.GetPortProcess(p)
+			procInfo := procs.GetPortProcess(p)
 
 			// Determine process name for allocation
 			processName := ""
@@ -1026,9 +1935,21 @@ func runScan() error {
 				}
 			}
 
+			// kubectl port-forwards are attributed to their kubeconfig context and namespace
+			if procInfo != nil && procInfo.Kube != nil {
+				kf := procInfo.Kube
+				store.AddKubeForwardForScan(p, kf.Context, kf.Namespace, kf.Target)
+				discovered++
+				fmt.Printf("Port %d: used by kubectl port-forward %s (context=%s, namespace=%s)\n", p, kf.Target, kf.Context, kf.Namespace)
+				continue
+			}
+
 			// Add allocation for this port (don't replace existing ports for same directory)
 			if procInfo != nil && procInfo.Cwd != "" {
 				store.AddAllocationForScan(procInfo.Cwd, p, processName, procInfo.ContainerID)
+				if procInfo.Service != "" {
+					store.SetComposeService(p, procInfo.Service)
+				}
 			} else {
 				store.SetUnknownPortAllocation(p, processName)
 			}
@@ -1040,6 +1961,8 @@ func runScan() error {
 					cwdShort := pathutil.ShortenHomePath(procInfo.Cwd)
 					if procInfo.PID > 0 {
 						fmt.Printf("Port %d: used by %s (pid=%d, cwd=%s)\n", p, procInfo.Name, procInfo.PID, cwdShort)
+					} else if procInfo.ContainerID != "" && procInfo.Service != "" {
+						fmt.Printf("Port %d: used by docker-proxy (service=%s, container=%s, cwd=%s)\n", p, procInfo.Service, procInfo.ContainerID, cwdShort)
 					} else if procInfo.ContainerID != "" {
 						fmt.Printf("Port %d: used by docker-proxy (container=%s, cwd=%s)\n", p, procInfo.ContainerID, cwdShort)
 					} else if procInfo.User != "" {
@@ -1076,7 +1999,7 @@ func runScan() error {
 	}
 
 	if hasIncompleteInfo {
-		fmt.Fprintln(os.Stderr, "\nTip: Run with sudo for full process info: sudo port-selector --scan")
+		stderrf("\nTip: Run with sudo for full process info: sudo port-selector --scan\n")
 	}
 
 	return nil
@@ -1086,6 +2009,9 @@ func runRefresh() error {
 	if _, err := loadConfigAndInitLogger(); err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := requireChecks("--refresh"); err != nil {
+		return err
+	}
 
 	configDir, err := config.ConfigDir()
 	if err != nil {
@@ -1125,3 +2051,45 @@ func runRefresh() error {
 
 	return nil
 }
+
+// runConvertStore copies allocations from the configured backend into the target backend.
+func runConvertStore(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("--convert-store requires a target backend (%s or %s)", allocations.BackendYAML, allocations.BackendRemote)
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	from, err := allocations.NewBackend(cfg.Store)
+	if err != nil {
+		return err
+	}
+	to, err := allocations.NewBackend(args[0])
+	if err != nil {
+		return err
+	}
+	if from.Name() == to.Name() {
+		return fmt.Errorf("store is already %s", to.Name())
+	}
+
+	count, err := allocations.Convert(configDir, from, to)
+	if err != nil {
+		return err
+	}
+
+	dir := allocations.StoreDir(configDir)
+	fmt.Printf("Converted %d allocation(s) from %s to %s\n",
+		count, pathutil.ShortenHomePath(from.Path(dir)), pathutil.ShortenHomePath(to.Path(dir)))
+	if configPath, err := config.ConfigPath(); err == nil {
+		fmt.Printf("Set 'store: %s' in %s to use it\n", to.Name(), pathutil.ShortenHomePath(configPath))
+	}
+	return nil
+}
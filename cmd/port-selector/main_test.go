@@ -11,8 +11,19 @@ import (
 	"testing"
 
 	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/port"
 )
 
+// TestMain pins the locale so that the built binary prints English messages.
+func TestMain(m *testing.M) {
+	os.Unsetenv("LC_ALL")
+	os.Unsetenv("LC_MESSAGES")
+	os.Setenv("LANG", "C")
+	os.Exit(m.Run())
+}
+
 // buildBinary builds the port-selector binary for testing
 func buildBinary(t *testing.T) string {
 	t.Helper()
@@ -1432,3 +1443,674 @@ func TestList_ShowsSourceColumn(t *testing.T) {
 		t.Errorf("expected 'external' source for external allocation, got: %s", output)
 	}
 }
+
+func TestPortSelector_FastPathDoesNotRewriteStore(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	cmd := exec.Command(binary)
+	cmd.Dir = workDir
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to get port: %v", err)
+	}
+	expectedPort := strings.TrimSpace(string(output))
+
+	allocPath := filepath.Join(configDir, "allocations.yaml")
+	before, err := os.Stat(allocPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Second call within the refresh interval must not rewrite the store
+	cmd = exec.Command(binary)
+	cmd.Dir = workDir
+	cmd.Env = env
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("second call failed: %v", err)
+	}
+	if port := strings.TrimSpace(string(output)); port != expectedPort {
+		t.Errorf("expected port %s, got %s", expectedPort, port)
+	}
+
+	after, err := os.Stat(allocPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("expected allocations file to be left untouched on the fast path")
+	}
+}
+
+func TestDryRun_DoesNotModifyStore(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configContent := "portStart: 3960\nportEnd: 3969\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(filepath.Join(tmpDir, "other"), 3960)
+	store.SetLockedByPort(3960, true)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+
+	t.Run("allocate", func(t *testing.T) {
+		cmd := exec.Command(binary, "--dry-run")
+		cmd.Dir = workDir
+		cmd.Env = env
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, stderr.String())
+		}
+		if strings.TrimSpace(string(output)) != "3961" {
+			t.Errorf("expected would-be port 3961 on stdout, got %q", output)
+		}
+		if !strings.Contains(stderr.String(), "+ 3961") {
+			t.Errorf("expected added allocation in report, got: %s", stderr.String())
+		}
+	})
+
+	t.Run("forget-all", func(t *testing.T) {
+		cmd := exec.Command(binary, "--forget-all", "--dry-run")
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, output)
+		}
+		if !strings.Contains(string(output), "- 3960") {
+			t.Errorf("expected removed allocation in report, got: %s", output)
+		}
+		if !strings.Contains(string(output), "Would clear 1 allocation(s)") || strings.Contains(string(output), "Cleared") {
+			t.Errorf("expected 'Would clear' message, got: %s", output)
+		}
+	})
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Count() != 1 || loaded.FindByPort(3960) == nil {
+		t.Errorf("dry run must not modify the store, got %+v", loaded.SortedByPort())
+	}
+}
+
+func TestParseArgs_GlobalFlags(t *testing.T) {
+	t.Cleanup(func() {
+		config.SetDir("")
+		allocations.SetStoreFile("")
+		allocations.SetDryRun(false)
+		allocations.SetReadOnly(false)
+		port.SetOffline(false)
+		noColor = false
+	})
+
+	args, err := parseArgs([]string{"--config", "/tmp/ps-config", "--list", "--store=/tmp/ps.yaml", "--dry-run", "--read-only", "--offline", "--no-color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 1 || args[0] != "--list" {
+		t.Errorf("parseArgs() args = %v, want [--list]", args)
+	}
+	if dir, _ := config.ConfigDir(); dir != "/tmp/ps-config" {
+		t.Errorf("ConfigDir() = %q, want /tmp/ps-config", dir)
+	}
+	if !allocations.IsDryRun() {
+		t.Error("expected dry-run to be enabled")
+	}
+	if !allocations.IsReadOnly() {
+		t.Error("expected read-only to be enabled")
+	}
+	if !port.IsOffline() {
+		t.Error("expected offline to be enabled")
+	}
+	if !noColor {
+		t.Error("expected colors to be disabled")
+	}
+
+	for _, bad := range [][]string{{"--config"}, {"--store="}} {
+		if _, err := parseArgs(bad); err == nil {
+			t.Errorf("parseArgs(%v) expected error", bad)
+		}
+	}
+}
+
+func TestParseArgs_Verbose(t *testing.T) {
+	t.Setenv(debug.EnvVar, "")
+	t.Cleanup(func() {
+		debug.SetEnabled(false)
+		debug.SetJSON(false)
+		debug.SetComponents(nil)
+	})
+
+	args, err := parseArgs([]string{"--verbose=allocations", "--debug-json", "--list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 1 || args[0] != "--list" {
+		t.Errorf("parseArgs() args = %v, want [--list]", args)
+	}
+	if !debug.IsEnabled() {
+		t.Error("expected debug output to be enabled")
+	}
+}
+
+func TestConfigFlag_IsolatesState(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	xdgDir := filepath.Join(tmpDir, "xdg")
+	altDir := filepath.Join(tmpDir, "alt")
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+xdgDir)
+
+	cmd := exec.Command(binary, "--config", altDir)
+	cmd.Dir = workDir
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("allocation with --config failed: %v\n%s", err, output)
+	}
+
+	for _, name := range []string{"config.yaml", "allocations.yaml"} {
+		if _, err := os.Stat(filepath.Join(altDir, name)); err != nil {
+			t.Errorf("expected %s in --config directory: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(xdgDir, "port-selector")); !os.IsNotExist(err) {
+		t.Errorf("default config directory must not be touched, stat error: %v", err)
+	}
+
+	storeFile := filepath.Join(tmpDir, "store", "ports.yaml")
+	cmd = exec.Command(binary, "--config", altDir, "--store", storeFile, "--name", "web")
+	cmd.Dir = workDir
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("allocation with --store failed: %v\n%s", err, output)
+	}
+	if _, err := os.Stat(storeFile); err != nil {
+		t.Errorf("expected --store file to be written: %v", err)
+	}
+
+	cmd = exec.Command(binary, "--config", altDir, "--list")
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("--list failed: %v\n%s", err, output)
+	}
+	if strings.Contains(string(output), "web") {
+		t.Errorf("allocation written to --store must not appear in the default store:\n%s", output)
+	}
+}
+
+func TestProfiles_SeparatePools(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	baseDir := filepath.Join(tmpDir, ".config", "port-selector")
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"), "PORT_SELECTOR_PROFILE=")
+
+	run := func(extraEnv []string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(append([]string{}, env...), extraEnv...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	run(nil, "--profile", "work", "config", "set", "portEnd", "5100")
+	run(nil, "--profile", "work", "config", "set", "portStart", "5000")
+	if port := strings.TrimSpace(run(nil, "--profile", "work")); port != "5000" {
+		t.Errorf("expected port 5000 from the work profile, got %s", port)
+	}
+	if port := strings.TrimSpace(run([]string{"PORT_SELECTOR_PROFILE=work"}, "--name", "api")); port != "5001" {
+		t.Errorf("expected port 5001 via PORT_SELECTOR_PROFILE, got %s", port)
+	}
+
+	if _, err := os.Stat(filepath.Join(baseDir, "profiles", "work", "allocations.yaml")); err != nil {
+		t.Errorf("expected allocations inside the profile directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "allocations.yaml")); !os.IsNotExist(err) {
+		t.Errorf("default pool must stay untouched, stat error: %v", err)
+	}
+
+	if output := run(nil, "--profile", "work", "profiles"); !strings.Contains(output, "* work") {
+		t.Errorf("expected work profile to be marked current:\n%s", output)
+	}
+
+	cmd := exec.Command(binary, "--profile", "../evil")
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err == nil {
+		t.Errorf("expected invalid profile name to fail, got: %s", output)
+	}
+}
+
+func TestForget_DirectoryAndPortArgument(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directories don't exist: they may have been deleted already
+	gone := filepath.Join(tmpDir, "deleted-project")
+	other := filepath.Join(tmpDir, "other-project")
+	store := allocations.NewStore()
+	store.SetAllocationWithName(gone, 3870, "main")
+	store.SetAllocationWithName(gone, 3871, "web")
+	store.SetAllocationWithName(other, 3872, "main")
+	store.SetAllocationWithName(other, 3873, "db")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Env = env
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	if output := run("--forget", gone); !strings.Contains(output, "Cleared 2 allocation(s)") {
+		t.Errorf("unexpected output: %s", output)
+	}
+	if output := run("--forget", "3873"); !strings.Contains(output, "Cleared port 3873") {
+		t.Errorf("unexpected output: %s", output)
+	}
+	if output := run("--forget", "3873"); !strings.Contains(output, "No allocation found for port 3873") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Count() != 1 || loaded.FindByPort(3872) == nil {
+		t.Errorf("expected only port 3872 to remain, got %d allocations", loaded.Count())
+	}
+
+	cmd := exec.Command(binary, "--forget", "3872", "--name", "web")
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "--name cannot be combined") {
+		t.Errorf("expected error for --name with port, got: %v\n%s", err, output)
+	}
+}
+
+func TestLabels_AllocateAndFilter(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation("/tmp/unlabeled", 3710)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: expected success, got error: %v, output: %s", args, err, output)
+		}
+		return string(output)
+	}
+
+	port := strings.TrimSpace(run("--name", "web", "--label", "team=payments", "--label=env=dev"))
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alloc := loaded.FindByDirectoryAndName(workDir, "web")
+	if alloc == nil || fmt.Sprint(alloc.Port) != port {
+		t.Fatalf("expected allocation on port %s, got %+v", port, alloc)
+	}
+	if got := allocations.FormatLabels(alloc.Labels); got != "env=dev,team=payments" {
+		t.Errorf("labels = %q, want env=dev,team=payments", got)
+	}
+
+	output := run("--list", "--label", "team=payments")
+	if !strings.Contains(output, "LABELS") || !strings.Contains(output, port) || strings.Contains(output, "3710") {
+		t.Errorf("unexpected filtered list: %s", output)
+	}
+	if output := run("--list", "--label", "team=other"); !strings.Contains(output, "No port allocations match") {
+		t.Errorf("expected no matches, got: %s", output)
+	}
+
+	run("--name", "web", "--label", "env=")
+	if output := run("--list", "--label", "env"); !strings.Contains(output, "No port allocations match") {
+		t.Errorf("expected env label to be removed, got: %s", output)
+	}
+}
+
+func TestLanguage_Russian(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	run := func(env ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, "show", "3999")
+		cmd.Env = append(os.Environ(), append([]string{"XDG_CONFIG_HOME=" + tmpDir}, env...)...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err == nil {
+			t.Fatal("expected a missing allocation error")
+		}
+		return stderr.String()
+	}
+
+	if got := run("LANG=ru_RU.UTF-8"); !strings.HasPrefix(got, "ошибка: ") {
+		t.Errorf("stderr with LANG=ru_RU.UTF-8 = %q, want a Russian error prefix", got)
+	}
+	if got := run("LANG=ru_RU.UTF-8", "LC_ALL=C"); !strings.HasPrefix(got, "error: ") {
+		t.Errorf("stderr with LC_ALL=C = %q, want English", got)
+	}
+
+	configDir := filepath.Join(tmpDir, "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("portStart: 3000\nportEnd: 4000\nlanguage: en\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := run("LANG=ru_RU.UTF-8"); !strings.HasPrefix(got, "error: ") {
+		t.Errorf("stderr with language: en = %q, want English", got)
+	}
+}
+
+func TestDefaultName_File(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	workDir := filepath.Join(tmpDir, "api")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, config.NameFileName), []byte("api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+tmpDir)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	port := run()
+	if named := run("--name", "api"); named != port {
+		t.Errorf("port-selector = %s, --name api = %s; want the same allocation", port, named)
+	}
+	if main := run("--name", "main"); main == port {
+		t.Errorf("--name main = %s, want a separate allocation", main)
+	}
+}
+
+func TestDefaultName_ForgetAndMove(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	workDir := filepath.Join(tmpDir, "api")
+	target := filepath.Join(tmpDir, "target")
+	for _, dir := range []string{workDir, target} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(workDir, config.NameFileName), []byte("api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+tmpDir)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// The default name doesn't count as --name for a port argument
+	api := run()
+	web := run("--name", "web")
+	run("--forget", web)
+	run("--move", api, target)
+
+	// Bare --forget clears every allocation of the directory, not just the default name
+	run()
+	run("--name", "web")
+	if out := run("--forget"); !strings.Contains(out, "Cleared 2 allocation(s)") {
+		t.Errorf("--forget = %q, want both allocations cleared", out)
+	}
+}
+
+func TestSymlinkedDirectory_Upgrade(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	realDir := filepath.Join(tmpDir, "code", "shop")
+	if err := os.MkdirAll(realDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "shop")
+	if err := os.Symlink(realDir, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	// A store written by a version that kept symlinked paths
+	configDir := filepath.Join(tmpDir, "port-selector")
+	store := allocations.NewStore()
+	store.SetAllocationWithName(link, 3000, "main")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary)
+	cmd.Dir = link
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+tmpDir, "PWD="+link)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("port-selector failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "3000" {
+		t.Errorf("port after upgrade = %s, want the stored 3000", got)
+	}
+
+	store, err = allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Allocations) != 1 || store.Allocations[3000] == nil || store.Allocations[3000].Directory != realDir {
+		t.Errorf("store after upgrade = %+v, want port 3000 under %s", store.Allocations, realDir)
+	}
+}
+
+func TestRootDetection_Git(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, "port-selector")
+	root := filepath.Join(tmpDir, "shop")
+	frontend := filepath.Join(root, "frontend")
+	for _, dir := range []string{configDir, filepath.Join(root, ".git"), frontend} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("portStart: 3000\nportEnd: 4000\nrootDetection: git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(dir string) string {
+		t.Helper()
+		cmd := exec.Command(binary)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+tmpDir)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("port-selector in %s failed: %v", dir, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	if atRoot, inSub := run(root), run(frontend); atRoot != inSub {
+		t.Errorf("port at the root = %s, in frontend = %s; want the root's allocation", atRoot, inSub)
+	}
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(store.Allocations); n != 1 {
+		t.Errorf("store has %d allocations, want 1", n)
+	}
+}
+
+func TestMonorepo_PackageName(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "shop")
+	api := filepath.Join(root, "packages", "api")
+	if err := os.MkdirAll(api, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, config.ProjectFileName), []byte("monorepo:\n  packages/*: $(basename)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+tmpDir)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v in %s failed: %v", args, dir, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	inPackage := run(api)
+	if atRoot := run(root, "--name", "api"); atRoot != inPackage {
+		t.Errorf("port in packages/api = %s, --name api at the root = %s; want the same allocation", inPackage, atRoot)
+	}
+	store, err := allocations.Load(filepath.Join(tmpDir, "port-selector"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alloc := store.FindByDirectoryAndName(root, "api"); alloc == nil {
+		t.Errorf("no 'api' allocation at %s", root)
+	}
+}
+
+func TestSymlinkedDirectory_SharesAllocation(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	realDir := filepath.Join(tmpDir, "code", "shop")
+	if err := os.MkdirAll(realDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "shop")
+	if err := os.Symlink(realDir, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = dir
+		// Getwd trusts $PWD, which is how shells report a symlinked directory
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+tmpDir, "PWD="+dir)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v in %s failed: %v", args, dir, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	if viaLink, viaReal := run(link), run(realDir); viaLink != viaReal {
+		t.Errorf("port via symlink = %s, via real path = %s; want one allocation", viaLink, viaReal)
+	}
+
+	// An allocation added under the symlinked path after the migration is merged by dedupe
+	configDir := filepath.Join(tmpDir, "port-selector")
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName(link, 3999, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if out := run(realDir, "dedupe"); !strings.Contains(out, "port 3999") || !strings.Contains(out, "duplicate of") {
+		t.Errorf("dedupe output = %q, want port 3999 merged", out)
+	}
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Allocations) != 1 {
+		t.Errorf("store has %d allocations after dedupe, want 1", len(store.Allocations))
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runMove moves an allocation to another directory, keeping its port, name and lock:
+// --move PORT DIR moves the allocation of PORT, --move [--name NAME] DIR the
+// allocation NAME of the current directory.
+func runMove(name string, explicitName bool, remainingArgs []string) error {
+	if len(remainingArgs) < 1 || len(remainingArgs) > 2 {
+		return fmt.Errorf("usage: port-selector --move [PORT | --name NAME] DIR")
+	}
+	for _, arg := range remainingArgs {
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	portArg := 0
+	if len(remainingArgs) == 2 {
+		p, err := strconv.Atoi(remainingArgs[0])
+		if err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port number: %s (must be 1-65535)", remainingArgs[0])
+		}
+		if explicitName {
+			return fmt.Errorf("--name cannot be combined with a port")
+		}
+		portArg = p
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	target, err := dirResolver(configDir)(remainingArgs[len(remainingArgs)-1])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", pathutil.ShortenHomePath(target))
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	moved, err := moveAllocation(configDir, portArg, cwd, name, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved port %d ('%s') from %s to %s\n", moved.Port, moved.Name,
+		pathutil.ShortenHomePath(moved.Directory), pathutil.ShortenHomePath(target))
+	return nil
+}
+
+// moveAllocation implements runMove. It selects the allocation by port, or by
+// (dir, name) when port is 0, and returns it as it was before the move. It fails
+// if target already has an allocation with the same name.
+func moveAllocation(configDir string, port int, dir, name, target string) (*allocations.Allocation, error) {
+	var moved *allocations.Allocation
+	err := allocations.WithJournal(configDir, "--move", func(store *allocations.Store) error {
+		alloc := store.FindByPort(port)
+		if port == 0 {
+			alloc = store.FindByDirectoryAndName(dir, name)
+			if alloc == nil {
+				return fmt.Errorf("no allocation found for %s with name '%s'", pathutil.ShortenHomePath(dir), name)
+			}
+		} else if alloc == nil {
+			return fmt.Errorf("no allocation found for port %d", port)
+		}
+		if alloc.Status == allocations.StatusExternal {
+			return fmt.Errorf("port %d is an external allocation and cannot be moved", alloc.Port)
+		}
+		if existing := store.FindByDirectoryAndName(target, alloc.Name); existing != nil && existing.Port != alloc.Port {
+			return fmt.Errorf("%s already has an allocation named '%s' (port %d)",
+				pathutil.ShortenHomePath(target), alloc.Name, existing.Port)
+		}
+		moved = alloc
+		store.SetDirectory(alloc.Port, target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestMoveAllocation(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/old", 3000, "main")
+		s.SetAllocationWithName("/old", 3001, "web")
+		s.SetLockedByPort(3001, true)
+		s.SetAllocationWithName("/taken", 3002, "main")
+		s.SetExternalAllocation(3003, 1234, "user", "python", "/elsewhere")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := moveAllocation(configDir, 0, "/old", "web", "/new")
+	if err != nil || moved.Port != 3001 {
+		t.Fatalf("moveAllocation(--name web) = %+v, %v", moved, err)
+	}
+	moved, err = moveAllocation(configDir, 3000, "/ignored", "main", "/new")
+	if err != nil || moved.Directory != "/old" {
+		t.Fatalf("moveAllocation(3000) = %+v, %v", moved, err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := store.FindByDirectoryAndName("/new", "web"); a == nil || a.Port != 3001 || !a.Locked {
+		t.Errorf("moved allocation = %+v, want locked port 3001 in /new", a)
+	}
+	if a := store.FindByDirectoryAndName("/new", "main"); a == nil || a.Port != 3000 {
+		t.Errorf("moved allocation = %+v, want port 3000 in /new", a)
+	}
+	if len(store.GetAllocatedPortsForDirectory("/old")) != 0 {
+		t.Error("/old still has allocations")
+	}
+
+	if _, err := moveAllocation(configDir, 3000, "", "main", "/taken"); err == nil || !strings.Contains(err.Error(), "already has") {
+		t.Errorf("expected 'already has' error, got %v", err)
+	}
+	if _, err := moveAllocation(configDir, 3003, "", "main", "/new2"); err == nil || !strings.Contains(err.Error(), "external") {
+		t.Errorf("expected external error, got %v", err)
+	}
+	if _, err := moveAllocation(configDir, 3999, "", "main", "/new"); err == nil {
+		t.Error("expected error for an unallocated port")
+	}
+}
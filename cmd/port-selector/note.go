@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// maxNoteWidth is how many characters of a note --list shows.
+const maxNoteWidth = 30
+
+// truncateNote shortens a note for the --list table.
+func truncateNote(note string) string {
+	r := []rune(note)
+	if len(r) > maxNoteWidth {
+		return string(r[:maxNoteWidth-3]) + "..."
+	}
+	return note
+}
+
+// runNote prints (note PORT) or sets (note PORT TEXT) the note of an allocation.
+// An empty TEXT clears the note.
+func runNote(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: port-selector note PORT [TEXT]")
+	}
+	p, err := strconv.Atoi(args[0])
+	if err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port number: %s (must be 1-65535)", args[0])
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	if len(args) == 1 {
+		store, err := allocations.Load(configDir)
+		if err != nil {
+			return err
+		}
+		alloc := store.FindByPort(p)
+		if alloc == nil {
+			return fmt.Errorf("no allocation found for port %d", p)
+		}
+		if alloc.Note != "" {
+			fmt.Println(alloc.Note)
+		}
+		return nil
+	}
+
+	note := strings.TrimSpace(args[1])
+	if strings.ContainsAny(note, "\r\n") {
+		return fmt.Errorf("note must be a single line")
+	}
+
+	var alloc *allocations.Allocation
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		if !store.SetNote(p, note) {
+			return fmt.Errorf("no allocation found for port %d", p)
+		}
+		alloc = store.FindByPort(p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if note == "" {
+		fmt.Printf("Cleared note for port %d (%s, '%s')\n", p, pathutil.ShortenHomePath(alloc.Directory), alloc.Name)
+		return nil
+	}
+	fmt.Printf("Noted port %d (%s, '%s'): %s\n", p, pathutil.ShortenHomePath(alloc.Directory), alloc.Name, note)
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestTruncateNote(t *testing.T) {
+	if got := truncateNote("short"); got != "short" {
+		t.Errorf("truncateNote(short) = %q", got)
+	}
+	long := strings.Repeat("я", 40)
+	got := truncateNote(long)
+	if len([]rune(got)) != maxNoteWidth || !strings.HasSuffix(got, "...") {
+		t.Errorf("truncateNote(long) = %q", got)
+	}
+}
+
+func TestNote(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	store := allocations.NewStore()
+	store.SetAllocation("/tmp/shop-api", 3940)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, args...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	if out, err := run("note", "3940", "staging replica of shop-api"); err != nil || !strings.Contains(out, "Noted port 3940") {
+		t.Fatalf("note failed: %v\n%s", err, out)
+	}
+	if out, _ := run("note", "3940"); strings.TrimSpace(out) != "staging replica of shop-api" {
+		t.Errorf("note PORT = %q", out)
+	}
+	if out, _ := run("--list"); !strings.Contains(out, "NOTE") || !strings.Contains(out, "staging replica of shop-api") {
+		t.Errorf("expected note in --list, got:\n%s", out)
+	}
+	if _, err := run("note", "3941", "x"); err == nil {
+		t.Error("expected error for unallocated port")
+	}
+
+	if out, err := run("note", "3940", ""); err != nil || !strings.Contains(out, "Cleared note") {
+		t.Fatalf("clearing note failed: %v\n%s", err, out)
+	}
+	if out, _ := run("--list"); strings.Contains(out, "NOTE") {
+		t.Errorf("expected no NOTE column after clearing, got:\n%s", out)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// requireChecks fails commands that only make sense with liveness checks when
+// they are off (--offline or checks: off).
+func requireChecks(command string) error {
+	if port.IsOffline() {
+		return fmt.Errorf("%s needs liveness checks, which are off (--offline or checks: off)", command)
+	}
+	return nil
+}
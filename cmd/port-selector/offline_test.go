@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestOffline_RefusesLivenessCommands(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	workDir := filepath.Join(tmpDir, "project")
+	for _, dir := range []string{configDir, workDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(workDir, 3700)
+	store.SetAllocationWithName(workDir, 3701, "web")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"--check"},
+		{"--release"},
+		{"swap", "3700", "3701"},
+	} {
+		t.Run(args[0], func(t *testing.T) {
+			cmd := exec.Command(binary, append([]string{"--offline"}, args...)...)
+			cmd.Dir = workDir
+			cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+			output, err := cmd.CombinedOutput()
+			if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+				t.Fatalf("expected exit code 1, got %v\n%s", err, output)
+			}
+			if !strings.Contains(string(output), "needs liveness checks") {
+				t.Errorf("output = %q, want the liveness checks error", output)
+			}
+		})
+	}
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.FindByPort(3700); a == nil || a.Directory != workDir || a.Name != "main" {
+		t.Errorf("offline commands changed port 3700: %+v", a)
+	}
+	if a := loaded.FindByPort(3701); a == nil || a.Name != "web" {
+		t.Errorf("offline commands changed port 3701: %+v", a)
+	}
+}
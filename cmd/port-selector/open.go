@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// allocationURL returns http://localhost:PORT with the given path.
+func allocationURL(p int, path string) string {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("http://localhost:%d%s", p, path)
+}
+
+// browserCommand returns the command that opens url in the default browser.
+// $BROWSER takes precedence over the platform default.
+func browserCommand(goos, browser, url string) (string, []string) {
+	if browser != "" {
+		return browser, []string{url}
+	}
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	default:
+		return "xdg-open", []string{url}
+	}
+}
+
+// runOpen opens the allocation of (cwd, name) in the default browser.
+func runOpen(name string, args []string) error {
+	var path string
+	printOnly := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--path":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--path requires a value")
+			}
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--path="):
+			path = strings.TrimPrefix(arg, "--path=")
+		case arg == "--print":
+			printOnly = true
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	alloc := store.FindByDirectoryAndName(cwd, name)
+	if alloc == nil {
+		return fmt.Errorf("no allocation found for %s with name '%s'", pathutil.ShortenHomePath(cwd), name)
+	}
+
+	url := allocationURL(alloc.Port, path)
+	if printOnly {
+		fmt.Println(url)
+		return nil
+	}
+
+	browser, browserArgs := browserCommand(runtime.GOOS, os.Getenv("BROWSER"), url)
+	debug.Printf("main", "opening %s with %s", url, browser)
+	if err := exec.Command(browser, browserArgs...).Start(); err != nil {
+		return fmt.Errorf("failed to open browser (%s): %w", browser, err)
+	}
+	fmt.Println(url)
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestAllocationURL(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "http://localhost:3000"},
+		{"/admin", "http://localhost:3000/admin"},
+		{"admin?debug=1", "http://localhost:3000/admin?debug=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := allocationURL(3000, tt.path); got != tt.want {
+				t.Errorf("allocationURL(3000, %q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	tests := []struct {
+		goos    string
+		browser string
+		want    string
+	}{
+		{"linux", "", "xdg-open"},
+		{"darwin", "", "open"},
+		{"windows", "", "rundll32"},
+		{"linux", "firefox", "firefox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.want, func(t *testing.T) {
+			name, args := browserCommand(tt.goos, tt.browser, "http://localhost:3000")
+			if name != tt.want || args[len(args)-1] != "http://localhost:3000" {
+				t.Errorf("browserCommand(%q, %q) = %s %v", tt.goos, tt.browser, name, args)
+			}
+		})
+	}
+}
+
+func TestOpen_Print(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocationWithName(workDir, 3900, "web")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "open", "--name", "web", "--path", "/admin", "--print")
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "http://localhost:3900/admin" {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
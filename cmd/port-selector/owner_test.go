@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestParseAllocOptions_Pid(t *testing.T) {
+	opts, rest, err := parseAllocOptions([]string{"--pid", "1234", "--name", "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.pid != 1234 || len(rest) != 2 {
+		t.Errorf("pid = %d, rest = %v", opts.pid, rest)
+	}
+	for _, bad := range [][]string{{"--pid"}, {"--pid=0"}, {"--pid", "self"}} {
+		if _, _, err := parseAllocOptions(bad); err == nil {
+			t.Errorf("parseAllocOptions(%v) expected error", bad)
+		}
+	}
+}
+
+func TestFormatOwner(t *testing.T) {
+	if got := formatOwner(allocations.Allocation{}); got != "-" {
+		t.Errorf("formatOwner(no owner) = %q, want -", got)
+	}
+	if got := formatOwner(allocations.Allocation{OwnerPID: os.Getpid()}); !strings.HasSuffix(got, " alive") {
+		t.Errorf("formatOwner(self) = %q, want alive", got)
+	}
+	if _, err := os.Stat("/proc/self"); err == nil {
+		if got := formatOwner(allocations.Allocation{OwnerPID: 1 << 30}); !strings.HasSuffix(got, " exited") {
+			t.Errorf("formatOwner(missing pid) = %q, want exited", got)
+		}
+	}
+}
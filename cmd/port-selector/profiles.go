@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dapi/port-selector/internal/config"
+)
+
+// runProfiles lists existing profiles, marking the selected one.
+func runProfiles(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+
+	names, err := config.Profiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	current := config.Profile()
+	marker := func(name string) string {
+		if name == current {
+			return "* "
+		}
+		return "  "
+	}
+
+	fmt.Printf("%s(default)\n", marker(""))
+	for _, name := range names {
+		fmt.Printf("%s%s\n", marker(name), name)
+	}
+	if current != "" && !slices.Contains(names, current) {
+		fmt.Printf("%s%s (not created yet)\n", marker(current), current)
+	}
+	return nil
+}
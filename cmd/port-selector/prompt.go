@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// runPrompt prints a one-line summary of the current directory's allocations
+// for shell prompts and status bars (e.g., "web:3010* api:3011", * = locked).
+// It reads the store without locking and never probes ports, so it stays fast;
+// errors are reported with --verbose only and produce an empty line.
+func runPrompt(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	// The backend comes from config, but a broken config must not break the prompt
+	if cfg, err := config.Load(); err != nil {
+		debug.Printf("prompt", "failed to load config: %v", err)
+	} else {
+		allocations.SetRemoteURL(cfg.RemoteURL)
+		if err := allocations.SetBackend(cfg.Store); err != nil {
+			debug.Printf("prompt", "%v", err)
+		}
+		if cfg.PerHost {
+			allocations.SetHost(currentHostname())
+		}
+		if cfg.RootDetection != "" {
+			rootDetection = cfg.RootDetection
+		}
+		keepSymlinks = cfg.Symlinks == "keep"
+		caseInsensitivePaths = cfg.CaseInsensitivePaths()
+		allocations.SetDirectoryNormalizer(normalizeDir)
+	}
+	useStateDir(true)
+	// The prompt must not wait on the network: use the cached remote store
+	allocations.SetRemoteCacheOnly(true)
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		debug.Printf("prompt", "failed to load allocations: %v", err)
+		fmt.Println()
+		return nil
+	}
+	fmt.Println(promptSummary(store, cwd))
+	return nil
+}
+
+// promptSummary returns "name:port" pairs of dir's allocations sorted by port,
+// with "*" appended to locked ports. External allocations are skipped.
+func promptSummary(store *allocations.Store, dir string) string {
+	var parts []string
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Directory != dir || alloc.Status == allocations.StatusExternal {
+			continue
+		}
+		part := alloc.Name + ":" + strconv.Itoa(alloc.Port)
+		if alloc.Locked {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestPromptSummary(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/proj", 3011, "api")
+	store.SetAllocationWithName("/proj", 3010, "web")
+	store.SetLockedByPort(3010, true)
+	store.SetAllocationWithName("/other", 3012, "web")
+	store.SetExternalAllocation(3013, 42, "bob", "nginx", "/proj")
+
+	if got, want := promptSummary(store, "/proj"), "web:3010* api:3011"; got != want {
+		t.Errorf("promptSummary() = %q, want %q", got, want)
+	}
+	if got := promptSummary(store, "/none"); got != "" {
+		t.Errorf("promptSummary() for unknown dir = %q, want empty", got)
+	}
+}
+
+func TestPrompt_CorruptedStore(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "allocations.yaml"), []byte("allocations: [broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "prompt")
+	cmd.Dir = tmpDir
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("prompt failed: %v\n%s", err, out)
+	}
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("expected empty output for corrupted store, got %q", out)
+	}
+}
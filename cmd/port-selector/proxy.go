@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// proxyDomain is the TLD used for generated hostnames. *.localhost resolves to
+// the loopback address without any DNS setup in browsers and most resolvers.
+const proxyDomain = "localhost"
+
+// proxyRoute maps a hostname to an allocated port.
+type proxyRoute struct {
+	Host      string
+	Port      int
+	Directory string
+	Name      string
+}
+
+// hostLabel converts a string into a valid DNS label (lowercase, [a-z0-9-]).
+func hostLabel(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// proxyHost returns the hostname for an allocation: <dirname>.localhost for
+// "main", <name>.<dirname>.localhost for other names.
+func proxyHost(dir, name string) string {
+	host := hostLabel(filepath.Base(dir)) + "." + proxyDomain
+	if name != "" && name != "main" {
+		host = hostLabel(name) + "." + host
+	}
+	return host
+}
+
+// proxyRoutes builds routes for all directory allocations, sorted by port.
+// A hostname recorded with `port-selector hostname` takes precedence over the generated one.
+// External and unknown allocations are skipped. When two directories share a
+// basename, the later port is skipped with a warning.
+func proxyRoutes(store *allocations.Store) []proxyRoute {
+	var routes []proxyRoute
+	seen := make(map[string]proxyRoute)
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Status == allocations.StatusExternal || !filepath.IsAbs(alloc.Directory) {
+			continue
+		}
+		host := alloc.Hostname
+		if host == "" {
+			host = proxyHost(alloc.Directory, alloc.Name)
+		}
+		route := proxyRoute{Host: host, Port: alloc.Port, Directory: alloc.Directory, Name: alloc.Name}
+		if prev, ok := seen[route.Host]; ok {
+			stderrf("warning: %s is already routed to port %d (%s); skipping port %d (%s)\n",
+				route.Host, prev.Port, pathutil.ShortenHomePath(prev.Directory), route.Port, pathutil.ShortenHomePath(route.Directory))
+			continue
+		}
+		seen[route.Host] = route
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// renderCaddy renders a Caddyfile snippet.
+func renderCaddy(routes []proxyRoute) string {
+	var b strings.Builder
+	for i, r := range routes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s ('%s')\nhttp://%s {\n\treverse_proxy 127.0.0.1:%d\n}\n",
+			pathutil.ShortenHomePath(r.Directory), r.Name, r.Host, r.Port)
+	}
+	return b.String()
+}
+
+// renderNginx renders nginx server blocks.
+func renderNginx(routes []proxyRoute) string {
+	var b strings.Builder
+	for i, r := range routes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, `# %s ('%s')
+server {
+    listen 80;
+    server_name %s;
+    location / {
+        proxy_pass http://127.0.0.1:%d;
+        proxy_set_header Host $host;
+        proxy_set_header Upgrade $http_upgrade;
+        proxy_set_header Connection "upgrade";
+        proxy_http_version 1.1;
+    }
+}
+`, pathutil.ShortenHomePath(r.Directory), r.Name, r.Host, r.Port)
+	}
+	return b.String()
+}
+
+// renderTraefik renders a Traefik dynamic configuration (file provider).
+func renderTraefik(routes []proxyRoute) string {
+	var b strings.Builder
+	b.WriteString("http:\n  routers:\n")
+	for _, r := range routes {
+		id := hostLabel(r.Host)
+		fmt.Fprintf(&b, "    %s:\n      rule: \"Host(`%s`)\"\n      service: %s\n", id, r.Host, id)
+	}
+	b.WriteString("  services:\n")
+	for _, r := range routes {
+		fmt.Fprintf(&b, "    %s:\n      loadBalancer:\n        servers:\n          - url: \"http://127.0.0.1:%d\"\n", hostLabel(r.Host), r.Port)
+	}
+	return b.String()
+}
+
+// runProxy prints a reverse proxy config mapping hostnames to allocated ports.
+func runProxy(args []string) error {
+	format := "caddy"
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires a value")
+			}
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	renderers := map[string]func([]proxyRoute) string{
+		"caddy":   renderCaddy,
+		"nginx":   renderNginx,
+		"traefik": renderTraefik,
+	}
+	render, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("invalid format %q (use caddy, nginx or traefik)", format)
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+
+	routes := proxyRoutes(store)
+	if len(routes) == 0 {
+		stderrf("No allocations to route.\n")
+		return nil
+	}
+	fmt.Print(render(routes))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestProxyHost(t *testing.T) {
+	tests := []struct {
+		dir  string
+		name string
+		want string
+	}{
+		{"/home/user/code/shop", "main", "shop.localhost"},
+		{"/home/user/code/shop", "", "shop.localhost"},
+		{"/home/user/code/shop", "api", "api.shop.localhost"},
+		{"/home/user/code/My_Shop", "web_ui", "web-ui.my-shop.localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := proxyHost(tt.dir, tt.name); got != tt.want {
+				t.Errorf("proxyHost(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyRoutes(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/code/shop", 3000, "main")
+	store.SetAllocationWithName("/code/shop", 3001, "api")
+	store.SetAllocationWithName("/other/shop", 3002, "main") // duplicate hostname
+	store.SetExternalAllocation(3003, 1234, "user", "node", "/code/external")
+	store.SetUnknownPortAllocation(3004, "")
+
+	routes := proxyRoutes(store)
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", routes)
+	}
+	if routes[0].Host != "shop.localhost" || routes[0].Port != 3000 {
+		t.Errorf("unexpected first route: %+v", routes[0])
+	}
+	if routes[1].Host != "api.shop.localhost" || routes[1].Port != 3001 {
+		t.Errorf("unexpected second route: %+v", routes[1])
+	}
+}
+
+func TestProxyRender(t *testing.T) {
+	routes := []proxyRoute{{Host: "shop.localhost", Port: 3000, Directory: "/code/shop", Name: "main"}}
+
+	tests := []struct {
+		format string
+		render func([]proxyRoute) string
+		want   []string
+	}{
+		{"caddy", renderCaddy, []string{"http://shop.localhost {", "reverse_proxy 127.0.0.1:3000"}},
+		{"nginx", renderNginx, []string{"server_name shop.localhost;", "proxy_pass http://127.0.0.1:3000;"}},
+		{"traefik", renderTraefik, []string{"rule: \"Host(`shop.localhost`)\"", "url: \"http://127.0.0.1:3000\""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out := tt.render(routes)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("%s output missing %q:\n%s", tt.format, want, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// exitReleaseRefused is the exit code returned when --release refuses to remove
+// an allocation, so scripts can tell a refusal apart from other errors (exit 1).
+const exitReleaseRefused = 3
+
+// errReleaseRefused is returned by runRelease when the allocation is locked or in use.
+var errReleaseRefused = errors.New("release refused")
+
+// runRelease removes the allocation for (cwd, name) only if it is unlocked and
+// its port is free. Unlike --forget, it never removes an allocation that is in use.
+func runRelease(name string, remainingArgs []string) error {
+	if len(remainingArgs) > 0 {
+		return fmt.Errorf("unknown arguments: %v", remainingArgs)
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	// Offline every port looks free, which would pass for "not listening"
+	if err := requireChecks("--release"); err != nil {
+		return err
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	return releaseAllocation(configDir, cwd, name, port.IsPortFree)
+}
+
+// releaseAllocation implements runRelease with an injectable port checker.
+func releaseAllocation(configDir, cwd, name string, isPortFree allocations.PortChecker) error {
+	var released *allocations.Allocation
+	err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+		released = nil
+
+		alloc := store.FindByDirectoryAndName(cwd, name)
+		if alloc == nil {
+			return nil
+		}
+		if alloc.Locked {
+			return fmt.Errorf("%w: port %d ('%s') is locked (use --unlock first or --forget)", errReleaseRefused, alloc.Port, name)
+		}
+		if !isPortFree(alloc.Port) {
+			return fmt.Errorf("%w: port %d ('%s') is in use", errReleaseRefused, alloc.Port, name)
+		}
+
+		released, _ = store.RemoveByDirectoryAndName(cwd, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if released == nil {
+		fmt.Printf("No allocation found for %s with name '%s'\n", pathutil.ShortenHomePath(cwd), name)
+		return nil
+	}
+	fmt.Printf("Released allocation '%s' for %s (was port %d)\n", name, pathutil.ShortenHomePath(cwd), released.Port)
+	return nil
+}
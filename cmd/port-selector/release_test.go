@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestReleaseAllocation(t *testing.T) {
+	alwaysFree := func(int) bool { return true }
+	neverFree := func(int) bool { return false }
+
+	tests := []struct {
+		name        string
+		locked      bool
+		isPortFree  allocations.PortChecker
+		wantRefused bool
+		wantRemoved bool
+	}{
+		{"free and unlocked", false, alwaysFree, false, true},
+		{"locked", true, alwaysFree, true, false},
+		{"in use", false, neverFree, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configDir := t.TempDir()
+			store := allocations.NewStore()
+			store.SetAllocationWithName("/project", 3000, "web")
+			if tt.locked {
+				store.SetLockedByPort(3000, true)
+			}
+			if err := allocations.Save(configDir, store); err != nil {
+				t.Fatal(err)
+			}
+
+			err := releaseAllocation(configDir, "/project", "web", tt.isPortFree)
+			if got := errors.Is(err, errReleaseRefused); got != tt.wantRefused {
+				t.Fatalf("releaseAllocation() error = %v, wantRefused %v", err, tt.wantRefused)
+			}
+			if !tt.wantRefused && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			loaded, err := allocations.Load(configDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed := loaded.FindByPort(3000) == nil; removed != tt.wantRemoved {
+				t.Errorf("allocation removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestRelease_RefusedExitCode(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(workDir, 3700)
+	store.SetLockedByPort(3700, true)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "--release")
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitReleaseRefused {
+		t.Fatalf("expected exit code %d, got %v", exitReleaseRefused, err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runRename renames an allocation of the current directory (--rename OLD NEW),
+// keeping its port, lock and timestamps.
+func runRename(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: port-selector --rename OLD NEW")
+	}
+	oldName, newName := args[0], args[1]
+	for _, name := range args {
+		if name == "" {
+			return fmt.Errorf("allocation name cannot be empty")
+		}
+		if strings.HasPrefix(name, "-") {
+			return fmt.Errorf("unknown option: %s", name)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	port, err := renameAllocation(configDir, cwd, oldName, newName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Renamed '%s' to '%s' (port %d)\n", oldName, newName, port)
+	return nil
+}
+
+// renameAllocation implements runRename and returns the port of the renamed allocation.
+// It fails if the directory has no allocation OLD or already has one named NEW.
+func renameAllocation(configDir, dir, oldName, newName string) (int, error) {
+	var port int
+	err := allocations.WithJournal(configDir, "--rename", func(store *allocations.Store) error {
+		alloc := store.FindByDirectoryAndName(dir, oldName)
+		if alloc == nil {
+			return fmt.Errorf("no allocation found for %s with name '%s'", pathutil.ShortenHomePath(dir), oldName)
+		}
+		if existing := store.FindByDirectoryAndName(dir, newName); existing != nil {
+			return fmt.Errorf("%s already has an allocation named '%s' (port %d)",
+				pathutil.ShortenHomePath(dir), newName, existing.Port)
+		}
+		port = alloc.Port
+		store.SetName(port, newName)
+		return nil
+	})
+	return port, err
+}
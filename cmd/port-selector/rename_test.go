@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestRenameAllocation(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/project", 3000, "main")
+		s.SetAllocationWithName("/project", 3001, "web")
+		s.SetLockedByPort(3001, true)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := renameAllocation(configDir, "/project", "web", "frontend")
+	if err != nil || port != 3001 {
+		t.Fatalf("renameAllocation() = %d, %v", port, err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alloc := store.FindByDirectoryAndName("/project", "frontend")
+	if alloc == nil || alloc.Port != 3001 || !alloc.Locked {
+		t.Fatalf("renamed allocation = %+v, want locked port 3001", alloc)
+	}
+	if old := before.FindByPort(3001); !alloc.AssignedAt.Equal(old.AssignedAt) {
+		t.Errorf("AssignedAt = %v, want %v", alloc.AssignedAt, old.AssignedAt)
+	}
+	if store.FindByDirectoryAndName("/project", "web") != nil {
+		t.Error("old name still present")
+	}
+
+	if _, err := renameAllocation(configDir, "/project", "frontend", "main"); err == nil || !strings.Contains(err.Error(), "already has") {
+		t.Errorf("expected 'already has' error, got %v", err)
+	}
+	if _, err := renameAllocation(configDir, "/project", "web", "api"); err == nil {
+		t.Error("expected error for a missing allocation")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runRenew extends the lease of the allocation for (cwd, name). With --lease D
+// the lease length is changed (or a lease is added to an allocation without one).
+func runRenew(name string, remainingArgs []string) error {
+	var lease time.Duration
+	for i := 0; i < len(remainingArgs); i++ {
+		arg := remainingArgs[i]
+		if arg != "--lease" && !strings.HasPrefix(arg, "--lease=") {
+			return fmt.Errorf("unknown arguments: %v", remainingArgs[i:])
+		}
+		value := strings.TrimPrefix(arg, "--lease=")
+		if arg == "--lease" {
+			if i+1 >= len(remainingArgs) {
+				return fmt.Errorf("--lease requires a duration (e.g., 2h)")
+			}
+			i++
+			value = remainingArgs[i]
+		}
+		d, err := config.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid --lease value: %s", value)
+		}
+		lease = d
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	alloc, err := renewLease(configDir, cwd, name, lease)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Renewed lease on port %d ('%s') until %s\n",
+		alloc.Port, name, alloc.LeaseExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// renewLease implements runRenew. lease > 0 sets a new lease length.
+func renewLease(configDir, cwd, name string, lease time.Duration) (*allocations.Allocation, error) {
+	var renewed *allocations.Allocation
+	err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+		alloc := store.FindByDirectoryAndName(cwd, name)
+		if alloc == nil {
+			return fmt.Errorf("no allocation found for %s with name '%s'", pathutil.ShortenHomePath(cwd), name)
+		}
+		if lease > 0 {
+			store.SetLease(alloc.Port, lease)
+		} else if !store.RenewLease(alloc.Port) {
+			return fmt.Errorf("port %d ('%s') has no lease (use --renew --lease D to add one)", alloc.Port, name)
+		}
+		renewed = store.FindByPort(alloc.Port)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renewed, nil
+}
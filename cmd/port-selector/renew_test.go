@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestRenewLease(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/project", 3000, "main")
+		s.SetAllocationWithName("/project", 3001, "web")
+		s.SetLease(3000, time.Hour)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	alloc, err := renewLease(configDir, "/project", "main", 0)
+	if err != nil {
+		t.Fatalf("renewLease() error = %v", err)
+	}
+	if alloc.Lease != time.Hour || time.Until(alloc.LeaseExpiresAt) < 59*time.Minute {
+		t.Errorf("lease = %v until %v, want 1h from now", alloc.Lease, alloc.LeaseExpiresAt)
+	}
+
+	if _, err := renewLease(configDir, "/project", "web", 0); err == nil || !strings.Contains(err.Error(), "no lease") {
+		t.Errorf("expected 'no lease' error, got %v", err)
+	}
+	alloc, err = renewLease(configDir, "/project", "web", 2*time.Hour)
+	if err != nil || alloc.Lease != 2*time.Hour {
+		t.Errorf("renewLease(--lease 2h) = %+v, %v", alloc, err)
+	}
+
+	if _, err := renewLease(configDir, "/other", "main", 0); err == nil {
+		t.Error("expected error for a directory without allocation")
+	}
+}
+
+func TestParseAllocOptions_Lease(t *testing.T) {
+	opts, rest, err := parseAllocOptions([]string{"--lease", "2h", "--name", "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.lease != 2*time.Hour || len(rest) != 2 {
+		t.Errorf("lease = %v, rest = %v", opts.lease, rest)
+	}
+	for _, bad := range [][]string{{"--lease"}, {"--lease=0"}, {"--lease", "soon"}} {
+		if _, _, err := parseAllocOptions(bad); err == nil {
+			t.Errorf("parseAllocOptions(%v) expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runRepair salvages the parseable entries of a corrupted allocations file.
+func runRepair(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown arguments: %v", args)
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	result, err := allocations.Repair(configDir)
+	if err != nil {
+		if errors.Is(err, allocations.ErrNotCorrupted) {
+			fmt.Println("Allocations file is not corrupted, nothing to repair.")
+			return nil
+		}
+		return err
+	}
+
+	verb := "Recovered"
+	if allocations.IsDryRun() {
+		verb = "dry-run: would recover"
+	}
+	fmt.Printf("%s %d allocation(s), dropped %d\n", verb, result.Store.Count(), len(result.Dropped))
+	for _, d := range result.Dropped {
+		fmt.Printf("  %s\n", d)
+	}
+	if result.QuarantinePath != "" {
+		fmt.Printf("Corrupted file moved to %s\n", pathutil.ShortenHomePath(result.QuarantinePath))
+	}
+	return nil
+}
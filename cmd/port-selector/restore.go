@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runRestore replaces the store with a backup (--from N|FILE), or lists the backups.
+func runRestore(args []string) error {
+	from := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--from":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--from requires a backup number or file")
+			}
+			i++
+			from = args[i]
+		case strings.HasPrefix(arg, "--from="):
+			from = strings.TrimPrefix(arg, "--from=")
+		default:
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	if from == "" {
+		return listBackups(configDir)
+	}
+
+	path := allocations.BackupPath(configDir, pathutil.ExpandHome(from))
+	store, err := allocations.Restore(configDir, path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d allocation(s) from %s\n", store.Count(), pathutil.ShortenHomePath(path))
+	return nil
+}
+
+// listBackups prints the available backups, most recent rotated backup first.
+func listBackups(configDir string) error {
+	backups, err := allocations.Backups(configDir)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Println("No backups found (set 'backups' in config to enable them).")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BACKUP\tTIME\tALLOCATIONS\tFILE")
+	for _, b := range backups {
+		ref := "-"
+		if b.Index > 0 {
+			ref = fmt.Sprint(b.Index)
+		}
+		count := "corrupted"
+		if store, err := allocations.ReadBackup(b.Path); err == nil {
+			count = fmt.Sprint(store.Count())
+		} else if !errors.Is(err, allocations.ErrCorrupted) {
+			count = "unreadable"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ref, b.ModTime.Format("2006-01-02 15:04:05"), count, pathutil.ShortenHomePath(b.Path))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("\nRestore with: port-selector restore --from BACKUP (number or file)")
+	return nil
+}
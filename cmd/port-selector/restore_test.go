@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestRestore_FromBackup(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("portStart: 3900\nportEnd: 3950\nbackups: 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(filepath.Join(tmpDir, "a"), 3900)
+	store.SetAllocation(filepath.Join(tmpDir, "b"), 3901)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	if output := run("restore"); !strings.Contains(output, "No backups found") {
+		t.Errorf("expected no backups, got: %s", output)
+	}
+
+	run("--forget-all", "--yes")
+
+	output := run("restore")
+	if !strings.Contains(output, "allocations.yaml.bak.1") || !strings.Contains(output, "allocations.yaml.bak\n") {
+		t.Errorf("expected rotated and --forget-all backups in list, got: %s", output)
+	}
+
+	if output := run("restore", "--from", "1"); !strings.Contains(output, "Restored 2 allocation(s)") {
+		t.Errorf("unexpected restore output: %s", output)
+	}
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Count() != 2 {
+		t.Errorf("expected 2 allocations after restore, got %d", loaded.Count())
+	}
+}
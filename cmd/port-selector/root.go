@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// rootDetection is the rootDetection setting, applied by loadConfigAndInitLogger.
+var rootDetection = config.RootDetectionNone
+
+// keepSymlinks is set by symlinks: keep in the config.
+var keepSymlinks bool
+
+// caseInsensitivePaths follows pathCase in the config (the platform default until loaded).
+var caseInsensitivePaths = config.DefaultCaseInsensitivePaths()
+
+// normalizeDir returns the form in which dir is stored: with symlinks resolved
+// unless symlinks: keep, and spelled as on disk when paths are case-insensitive.
+func normalizeDir(dir string) string {
+	if !keepSymlinks {
+		dir = pathutil.ResolveSymlinks(dir)
+	}
+	if caseInsensitivePaths {
+		dir = pathutil.CanonicalCase(dir)
+	}
+	return dir
+}
+
+// workingDir returns the directory that allocations are made for: the root of
+// a monorepo whose patterns match the working directory, else the working
+// directory or its project root under rootDetection.
+func workingDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	cwd = normalizeDir(cwd)
+	if root, _ := config.MonorepoMatch(cwd); root != "" {
+		return root, nil
+	}
+	return config.ProjectRoot(cwd, rootDetection), nil
+}
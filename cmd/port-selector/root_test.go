@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDir(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(tmpDir, "Code", "Project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "project-link")
+	if err := os.Symlink(project, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	defer func(keep, insensitive bool) { keepSymlinks, caseInsensitivePaths = keep, insensitive }(keepSymlinks, caseInsensitivePaths)
+	for _, tc := range []struct {
+		keep, insensitive bool
+		dir, want         string
+	}{
+		{false, false, link, project},
+		{true, false, link, link},
+		{false, true, strings.ToLower(project), project},
+		{false, false, strings.ToLower(project), strings.ToLower(project)},
+	} {
+		keepSymlinks, caseInsensitivePaths = tc.keep, tc.insensitive
+		if got := normalizeDir(tc.dir); got != tc.want {
+			t.Errorf("normalizeDir(%s) with keep=%v insensitive=%v = %s, want %s", tc.dir, tc.keep, tc.insensitive, got, tc.want)
+		}
+	}
+}
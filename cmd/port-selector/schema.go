@@ -0,0 +1,22 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+)
+
+// outputSchema is the JSON Schema of the --json outputs, one $defs entry per
+// output. schema_test.go validates the real outputs against it.
+//
+//go:embed schema.json
+var outputSchema []byte
+
+// runSchema prints the JSON Schema of the --json outputs.
+func runSchema(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+	_, err := os.Stdout.Write(outputSchema)
+	return err
+}
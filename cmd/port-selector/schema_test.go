@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+// schemaValidator checks JSON documents against schema.json. It implements the
+// keywords the schema uses, and is strict: an object field that the schema
+// does not list is an error, so that new output fields must be documented.
+type schemaValidator struct {
+	defs map[string]any
+}
+
+func newSchemaValidator(t *testing.T) *schemaValidator {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(outputSchema, &doc); err != nil {
+		t.Fatalf("schema.json is not JSON: %v", err)
+	}
+	defs, ok := doc["$defs"].(map[string]any)
+	if !ok {
+		t.Fatal("schema.json has no $defs")
+	}
+	return &schemaValidator{defs: defs}
+}
+
+// validate decodes data and checks it against the definition def.
+func (v *schemaValidator) validate(def string, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("not JSON: %w", err)
+	}
+	return v.check(map[string]any{"$ref": "#/$defs/" + def}, value, "$")
+}
+
+func (v *schemaValidator) check(schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unknown $ref %s", path, ref)
+		}
+		return v.check(def, value, path)
+	}
+
+	if types, ok := schema["type"]; ok {
+		var allowed []any
+		if list, ok := types.([]any); ok {
+			allowed = list
+		} else {
+			allowed = []any{types}
+		}
+		matched := false
+		for _, typ := range allowed {
+			matched = matched || hasJSONType(value, typ.(string))
+		}
+		if !matched {
+			return fmt.Errorf("%s: %v is not of type %v", path, value, types)
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			found = found || fmt.Sprint(e) == fmt.Sprint(value)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && fmt.Sprint(c) != fmt.Sprint(value) {
+		return fmt.Errorf("%s: %v, want %v", path, value, c)
+	}
+	if n, ok := value.(json.Number); ok {
+		f, _ := n.Float64()
+		if min, ok := schema["minimum"].(float64); ok && f < min {
+			return fmt.Errorf("%s: %v is below %v", path, n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && f > max {
+			return fmt.Errorf("%s: %v is above %v", path, n, max)
+		}
+	}
+	if s, ok := value.(string); ok && schema["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return fmt.Errorf("%s: %q is not a date-time", path, s)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, r := range required {
+			if _, ok := value[r.(string)]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, r)
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, ok := props[k].(map[string]any)
+			if !ok {
+				sub, ok = schema["additionalProperties"].(map[string]any)
+			}
+			if !ok {
+				return fmt.Errorf("%s: field %q is not in the schema", path, k)
+			}
+			if err := v.check(sub, value[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				if err := v.check(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasJSONType(value any, typ string) bool {
+	switch value := value.(type) {
+	case map[string]any:
+		return typ == "object"
+	case []any:
+		return typ == "array"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	case nil:
+		return typ == "null"
+	case json.Number:
+		if typ == "number" {
+			return true
+		}
+		_, err := value.Int64()
+		return typ == "integer" && err == nil
+	}
+	return false
+}
+
+func TestSchemaValidator(t *testing.T) {
+	v := newSchemaValidator(t)
+	for _, tc := range []struct {
+		def, doc string
+		wantErr  bool
+	}{
+		{"group", `[{"name": "web", "port": 3000, "directory": "/shop", "locked": false}]`, false},
+		{"group", `[{"name": "web", "port": 70000, "directory": "/shop", "locked": false}]`, true},
+		{"group", `[{"name": "web", "port": 3000, "directory": "/shop"}]`, true},
+		{"group", `[{"name": "web", "port": 3000, "directory": "/shop", "locked": false, "extra": 1}]`, true},
+		{"group", `[{"name": "web", "port": 3000.5, "directory": "/shop", "locked": false}]`, true},
+		{"event", `{"ts": "yesterday", "event": "allocate", "port": 3000, "dir": "/shop", "name": "main", "locked": false}`, true},
+		{"event", `{"ts": "2026-01-02T03:04:05Z", "event": "moved", "port": 3000, "dir": "/shop", "name": "main", "locked": false}`, true},
+	} {
+		if err := v.validate(tc.def, []byte(tc.doc)); (err != nil) != tc.wantErr {
+			t.Errorf("validate(%s, %s) error = %v, wantErr %v", tc.def, tc.doc, err, tc.wantErr)
+		}
+	}
+}
+
+// TestSchema_Outputs validates fully populated outputs, so that every field the
+// code can print is covered by the schema.
+func TestSchema_Outputs(t *testing.T) {
+	v := newSchemaValidator(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	labels := map[string]string{"team": "payments"}
+
+	shown, err := formatShown(shownAllocation{Port: 3000, AllocationInfo: &allocations.AllocationInfo{
+		Directory: "/shop", AssignedAt: now, LastUsedAt: now, Locked: true, ProcessName: "node",
+		ContainerID: "abc123", Name: "web", Status: allocations.StatusExternal, LockedAt: now,
+		ExternalPID: 42, ExternalUser: "dev", ExternalProcessName: "python", NoFreeze: true,
+		Hostname: "shop.local", Alias: "shop", Labels: labels, Note: "demo", ComposeService: "web",
+		BlockStart: 3000, BlockEnd: 3009, Lease: 2 * time.Hour, LeaseExpiresAt: now,
+		OwnerPID: 4242, OwnerStartTime: 123456, HealthPath: "/healthz", Firewall: "ufw:192.168.1.0/24",
+		Tunnel: "alice@staging:4100",
+	}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := map[string]any{
+		"allocation": allocationResult{Port: 3000, Directory: "/shop", Name: "web", Error: "all ports in range 3000-3001 are busy or frozen",
+			Exhaustion: &rangeExhaustion{PortStart: 3000, PortEnd: 3001, Total: 2, Busy: 2, Suggestions: []string{"drop expired and stale allocations: port-selector gc"}}},
+		"check": checkResult{Directory: "/shop", Name: "web", Port: 3000, Allocated: true, Listening: true, PID: 42,
+			Process: "node", ProcessCwd: "/shop", OwnerVerified: true, HealthPath: "/healthz", HTTPStatus: 200, Healthy: true, Reason: "ok"},
+		"show": json.RawMessage(shown),
+		"vscode": vscodeProject{Version: vscodeContractVersion, Directory: "/shop", Alias: "shop", Allocations: []vscodeAllocation{
+			{Name: "web", Port: 3000, Locked: true, InputID: "port-selector.web", URL: "http://localhost:3000", Labels: labels}}},
+		"event":        allocationEvent{Time: now, Event: eventUpdate, Port: 3000, Dir: "/shop", Name: "web", Locked: true, Labels: labels, Changes: []string{"locked"}},
+		"group":        []groupEntry{{Name: "web", Port: 3000, Directory: "/shop", Locked: true}},
+		"devcontainer": devcontainerConfigFor(vscodeProject{Allocations: []vscodeAllocation{{Name: "web", Port: 3000}}}, "silent"),
+	}
+	for def, output := range outputs {
+		data, err := json.Marshal(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.validate(def, data); err != nil {
+			t.Errorf("%s output does not match the schema: %v\n%s", def, err, data)
+		}
+	}
+}
+
+// TestSchema_Binary validates what the binary prints in the --json modes.
+func TestSchema_Binary(t *testing.T) {
+	binary := buildBinary(t)
+	v := newSchemaValidator(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	store := allocations.NewStore()
+	store.SetAllocationWithName(workDir, 3940, "web")
+	store.SetLabels(3940, map[string]string{groupLabel: "ci"})
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) []byte {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		out, err := cmd.Output()
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return out
+	}
+
+	if out := run("--schema"); !bytes.Equal(out, outputSchema) {
+		t.Errorf("--schema printed %d bytes, want schema.json", len(out))
+	}
+	for _, tc := range []struct {
+		def  string
+		args []string
+	}{
+		{"allocation", []string{"--name", "web", "--json"}},
+		{"check", []string{"--check", "--name", "web", "--json"}},
+		{"show", []string{"show", "3940", "--json"}},
+		{"vscode", []string{"vscode", "--json"}},
+		{"group", []string{"group", "ci", "--format", "json"}},
+		{"devcontainer", []string{"devcontainer"}},
+		{"list", []string{"--list", "--format", "json"}},
+	} {
+		out := run(tc.args...)
+		if err := v.validate(tc.def, out); err != nil {
+			t.Errorf("%v output does not match the %s schema: %v\n%s", tc.args, tc.def, err, out)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// sessionLabel is the label set by --session, e.g. to the CI job ID.
+const sessionLabel = "session"
+
+// runSession implements `session end ID` and `session list`.
+func runSession(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: port-selector session end ID | session list")
+	}
+	switch args[0] {
+	case "end":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: port-selector session end ID")
+		}
+		return runSessionEnd(args[1])
+	case "list":
+		if len(args) != 1 {
+			return fmt.Errorf("unexpected argument: %s", args[1])
+		}
+		return runSessionList()
+	default:
+		return fmt.Errorf("unknown session command: %s (use end or list)", args[0])
+	}
+}
+
+// runSessionEnd frees every allocation tagged with the session, in any
+// directory and whether locked or not. Ending an unknown or already ended
+// session is not an error, so CI teardown steps can always run it.
+func runSessionEnd(id string) error {
+	if err := validateLabelValue(sessionLabel, id); err != nil {
+		return err
+	}
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	removed, err := forgetLabeled(configDir, sessionLabel, id, "session end "+id)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Printf("No allocations in session '%s'\n", id)
+		return nil
+	}
+	for _, a := range removed {
+		fmt.Printf("%s port %d (%s, '%s')\n", clearedVerb(), a.Port, pathutil.ShortenHomePath(a.Directory), a.Name)
+	}
+	fmt.Printf("Ended session '%s': freed %d allocation(s)\n", id, len(removed))
+	return nil
+}
+
+// sessionSummary is one row of `session list`.
+type sessionSummary struct {
+	id          string
+	allocations int
+	directories map[string]bool
+}
+
+// summarizeSessions groups tagged allocations by session ID, sorted by ID.
+func summarizeSessions(allocs []allocations.Allocation) []sessionSummary {
+	byID := make(map[string]*sessionSummary)
+	for _, a := range allocs {
+		id := a.Labels[sessionLabel]
+		if id == "" {
+			continue
+		}
+		s := byID[id]
+		if s == nil {
+			s = &sessionSummary{id: id, directories: make(map[string]bool)}
+			byID[id] = s
+		}
+		s.allocations++
+		s.directories[a.Directory] = true
+	}
+
+	summaries := make([]sessionSummary, 0, len(byID))
+	for _, s := range byID {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].id < summaries[j].id })
+	return summaries
+}
+
+// runSessionList prints the sessions that still hold allocations, which is
+// where leaked CI jobs show up.
+func runSessionList() error {
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	summaries := summarizeSessions(store.SortedByPort())
+	if len(summaries) == 0 {
+		fmt.Println("No sessions")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tALLOCATIONS\tDIRECTORIES")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", s.id, s.allocations, len(s.directories))
+	}
+	return w.Flush()
+}
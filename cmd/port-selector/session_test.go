@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestParseAllocOptions_Session(t *testing.T) {
+	opts, remaining, err := parseAllocOptions([]string{"--session", "job-981", "--group=e2e", "--name", "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.labels[sessionLabel] != "job-981" || opts.labels[groupLabel] != "e2e" {
+		t.Errorf("labels = %v, want session=job-981 and group=e2e", opts.labels)
+	}
+	if len(remaining) != 2 || remaining[0] != "--name" {
+		t.Errorf("remaining = %v, want [--name web]", remaining)
+	}
+	for _, bad := range [][]string{{"--session"}, {"--session="}, {"--session", "a,b"}} {
+		if _, _, err := parseAllocOptions(bad); err == nil {
+			t.Errorf("parseAllocOptions(%v) expected error", bad)
+		}
+	}
+}
+
+func TestSessionEnd_AcrossDirectories(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/ci/shop", 3000, "main")
+		s.SetLabels(3000, map[string]string{sessionLabel: "job-981"})
+		s.SetLockedByPort(3000, true)
+		s.SetAllocationWithName("/ci/billing", 3001, "db")
+		s.SetLabels(3001, map[string]string{sessionLabel: "job-981"})
+		s.SetAllocationWithName("/ci/billing", 3002, "main")
+		s.SetLabels(3002, map[string]string{sessionLabel: "job-982"})
+		s.SetAllocationWithName("/home/dev/shop", 3003, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaries := summarizeSessions(store.SortedByPort())
+	if len(summaries) != 2 || summaries[0].id != "job-981" || summaries[0].allocations != 2 || len(summaries[0].directories) != 2 {
+		t.Errorf("summarizeSessions() = %+v", summaries)
+	}
+
+	removed, err := forgetLabeled(configDir, sessionLabel, "job-981", "session end job-981")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %d allocations, want 2 (including the locked one)", len(removed))
+	}
+	store, err = allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for port, want := range map[int]bool{3000: false, 3001: false, 3002: true, 3003: true} {
+		if got := store.FindByPort(port) != nil; got != want {
+			t.Errorf("port %d allocated = %v, want %v", port, got, want)
+		}
+	}
+}
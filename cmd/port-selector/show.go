@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"gopkg.in/yaml.v3"
+)
+
+// shownAllocation is the `show` output: the port followed by every stored field.
+type shownAllocation struct {
+	Port                        int `yaml:"port"`
+	*allocations.AllocationInfo `yaml:",inline"`
+}
+
+// runShow prints all stored fields of one allocation as YAML (or JSON with --json).
+// The allocation is selected by PORT, or by --name NAME in the current directory.
+func runShow(args []string) error {
+	name, _, rest, err := parseNameFromArgs(args)
+	if err != nil {
+		return err
+	}
+	asJSON := false
+	portArg := 0
+	for _, arg := range rest {
+		switch {
+		case arg == "--json":
+			asJSON = true
+		case portArg == 0 && arg != "" && arg[0] != '-':
+			p, err := strconv.Atoi(arg)
+			if err != nil || p < 1 || p > 65535 {
+				return fmt.Errorf("invalid port number: %s (must be 1-65535)", arg)
+			}
+			portArg = p
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+
+	p := portArg
+	if p == 0 {
+		cwd, err := workingDir()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		alloc := store.FindByDirectoryAndName(cwd, name)
+		if alloc == nil {
+			return fmt.Errorf("no allocation for %s ('%s')", pathutil.ShortenHomePath(cwd), name)
+		}
+		p = alloc.Port
+	}
+	info := store.Allocations[p]
+	if info == nil {
+		return fmt.Errorf("no allocation found for port %d", p)
+	}
+
+	out, err := formatShown(shownAllocation{Port: p, AllocationInfo: info}, asJSON)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// formatShown renders an allocation as YAML, or as JSON with the same field names.
+func formatShown(s shownAllocation, asJSON bool) ([]byte, error) {
+	data, err := yaml.Marshal(s)
+	if err != nil || !asJSON {
+		return data, err
+	}
+
+	// Re-decode the YAML so JSON uses the stored field names
+	var fields map[string]any
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
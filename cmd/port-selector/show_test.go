@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestShow(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocationWithName(workDir, 3950, "web")
+	store.SetLockedByPort(3950, true)
+	store.SetNote(3950, "staging")
+	store.SetLabels(3950, map[string]string{"team": "payments"})
+	store.SetExternalAllocation(3951, 4242, "bob", "nginx", "/srv")
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	out, err := run("show", "--name", "web")
+	if err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	for _, want := range []string{"port: 3950", "name: web", "locked: true", "locked_at:", "note: staging", "team: payments"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in show output:\n%s", want, out)
+		}
+	}
+
+	out, err = run("--show", "3951", "--json")
+	if err != nil {
+		t.Fatalf("show --json failed: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(out), &fields); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if fields["port"] != float64(3951) || fields["external_pid"] != float64(4242) || fields["external_process_name"] != "nginx" {
+		t.Errorf("unexpected JSON fields: %v", fields)
+	}
+
+	if _, err := run("show", "3952"); err == nil {
+		t.Error("expected error for unallocated port")
+	}
+}
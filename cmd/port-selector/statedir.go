@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// dirWritable reports whether files can be created in a directory (replaced in tests).
+var dirWritable = pathutil.Writable
+
+// useStateDir keeps the store in the state directory (XDG_STATE_HOME) when the
+// config directory is read-only, e.g. on corporate images or nix-managed homes,
+// instead of failing every invocation on the lock file. The first switch copies
+// the existing store over and prints a notice. In read-only mode, or with quiet
+// (the shell prompt), the state directory is used only once it exists.
+func useStateDir(quiet bool) {
+	configDir, err := config.ConfigDir()
+	if err != nil || dirWritable(configDir) {
+		return
+	}
+	dir, err := config.StateDir()
+	if err != nil {
+		debug.Printf("main", "no state dir: %v", err)
+		return
+	}
+	if dir == "" {
+		return // --config DIR is used as is
+	}
+
+	_, statErr := os.Stat(dir)
+	first := os.IsNotExist(statErr)
+	if first && (quiet || allocations.IsReadOnly()) {
+		return
+	}
+	debug.Printf("main", "config dir %s is read-only, using %s", configDir, dir)
+	allocations.SetStateDir(dir)
+	if !first {
+		return
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		stderrf("warning: %v\n", err)
+		return
+	}
+	if _, err := allocations.SeedStateDir(configDir); err != nil {
+		stderrf("warning: %v\n", err)
+	}
+	stderrf("notice: %s is read-only; keeping allocations in %s\n",
+		pathutil.ShortenHomePath(configDir), pathutil.ShortenHomePath(dir))
+}
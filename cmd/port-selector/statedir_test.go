@@ -0,0 +1,61 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestUseStateDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDir := filepath.Join(tmpDir, "state", "port-selector")
+
+	store := allocations.NewStore()
+	store.SetAllocation("/home/user/a", 3000)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(writable func(string) bool) { dirWritable = writable }(dirWritable)
+	defer allocations.SetStateDir("")
+
+	// A writable config dir keeps the store
+	useStateDir(false)
+	if got := allocations.StateDir(configDir); got != configDir {
+		t.Fatalf("StateDir() with a writable config dir = %s, want %s", got, configDir)
+	}
+
+	// The prompt never creates the state dir
+	dirWritable = func(dir string) bool { return dir != configDir }
+	useStateDir(true)
+	if got := allocations.StateDir(configDir); got != configDir {
+		t.Fatalf("StateDir() in quiet mode = %s, want %s", got, configDir)
+	}
+
+	useStateDir(false)
+	if got := allocations.StateDir(configDir); got != stateDir {
+		t.Fatalf("StateDir() with a read-only config dir = %s, want %s", got, stateDir)
+	}
+	seeded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seeded.Allocations[3000] == nil {
+		t.Errorf("store was not copied to the state dir: %+v", seeded.Allocations)
+	}
+
+	// Once it exists, the prompt reads it too
+	allocations.SetStateDir("")
+	useStateDir(true)
+	if got := allocations.StateDir(configDir); got != stateDir {
+		t.Errorf("StateDir() in quiet mode after the switch = %s, want %s", got, stateDir)
+	}
+}
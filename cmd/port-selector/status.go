@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// rangeStatus is the one-screen overview printed by `status`.
+// Every port of the range is counted in exactly one of Locked, External,
+// Frozen, Excluded, Busy and Free, in that order of precedence.
+type rangeStatus struct {
+	PortStart, PortEnd int
+	Allocated          int // allocations inside the range
+	OutOfRange         int // allocations outside the range (--ephemeral, old ranges)
+	Locked             int
+	External           int
+	Frozen             int
+	Excluded           int // excludedPorts and excludedRanges
+	Busy               int // listening, but not locked, external, frozen or excluded
+	Free               int // available to a new allocation (see --free)
+	Oldest             *allocations.Allocation
+}
+
+// runStatus prints range utilization and store statistics.
+func runStatus(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load allocations: %w", err)
+	}
+
+	st := computeStatus(store, cfg, port.IsPortFree)
+	writeStatus(os.Stdout, st, time.Now())
+	if cfg.GetEphemeralOverlap() != config.OverlapIgnore {
+		if o := ephemeralOverlap(cfg); o != nil {
+			fmt.Printf("Ephemeral:   %s\n", o)
+		}
+	}
+	if h := healthSummary(store.SortedByPort(), port.IsPortFree, probeHealth); h != "" {
+		fmt.Printf("Health:      %s\n", h)
+	}
+
+	path := allocations.StorePath(configDir)
+	backend := cfg.Store
+	if backend == "" {
+		backend = allocations.BackendYAML
+	}
+	if info, err := os.Stat(path); err == nil {
+		fmt.Printf("Store:       %s (%s, %s, modified %s)\n", pathutil.ShortenHomePath(path), backend,
+			formatSize(info.Size()), info.ModTime().Local().Format("2006-01-02 15:04"))
+	} else {
+		fmt.Printf("Store:       %s (%s, not created yet)\n", pathutil.ShortenHomePath(path), backend)
+	}
+	return nil
+}
+
+// computeStatus classifies every port of the range and finds the oldest allocation.
+func computeStatus(store *allocations.Store, cfg *config.Config, isPortFree allocations.PortChecker) rangeStatus {
+	st := rangeStatus{PortStart: cfg.PortStart, PortEnd: cfg.PortEnd}
+	frozen := store.GetFrozenPortsWithPolicy(cfg.FreezePeriodFor)
+	excluded := cfg.ExcludedPortSet()
+
+	for p := cfg.PortStart; p <= cfg.PortEnd; p++ {
+		info := store.Allocations[p]
+		if info != nil {
+			st.Allocated++
+		}
+		switch {
+		case info != nil && info.Locked:
+			st.Locked++
+		case info != nil && info.Status == allocations.StatusExternal:
+			st.External++
+		case frozen[p]:
+			st.Frozen++
+		case excluded[p]:
+			st.Excluded++
+		case !isPortFree(p):
+			st.Busy++
+		default:
+			st.Free++
+		}
+	}
+
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Port < cfg.PortStart || alloc.Port > cfg.PortEnd {
+			st.OutOfRange++
+		}
+		if alloc.AssignedAt.IsZero() {
+			continue
+		}
+		if st.Oldest == nil || alloc.AssignedAt.Before(st.Oldest.AssignedAt) {
+			oldest := alloc
+			st.Oldest = &oldest
+		}
+	}
+	return st
+}
+
+// writeStatus renders the range part of `status`.
+func writeStatus(w io.Writer, st rangeStatus, now time.Time) {
+	size := st.PortEnd - st.PortStart + 1
+	fmt.Fprintf(w, "Range:       %d-%d (%d ports)\n", st.PortStart, st.PortEnd, size)
+	fmt.Fprintf(w, "Allocated:   %d in range", st.Allocated)
+	if st.OutOfRange > 0 {
+		fmt.Fprintf(w, " (+%d outside)", st.OutOfRange)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  locked:    %d\n", st.Locked)
+	fmt.Fprintf(w, "  external:  %d\n", st.External)
+	fmt.Fprintf(w, "  frozen:    %d\n", st.Frozen)
+	if st.Excluded > 0 {
+		fmt.Fprintf(w, "  excluded:  %d\n", st.Excluded)
+	}
+	fmt.Fprintf(w, "  busy:      %d\n", st.Busy)
+	fmt.Fprintf(w, "  free:      %d (%d%%)\n", st.Free, st.Free*100/size)
+	if st.Oldest != nil {
+		days := int(now.Sub(st.Oldest.AssignedAt).Hours() / 24)
+		fmt.Fprintf(w, "Oldest:      %d %s (%s), assigned %s (%dd ago)\n", st.Oldest.Port,
+			pathutil.ShortenHomePath(st.Oldest.Directory), st.Oldest.Name,
+			st.Oldest.AssignedAt.Local().Format("2006-01-02"), days)
+	}
+}
+
+// formatSize renders a file size in bytes, KiB or MiB.
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestComputeStatus(t *testing.T) {
+	cfg := &config.Config{PortStart: 3000, PortEnd: 3009, FreezePeriod: "1h"}
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/frozen", 3001, "main")
+	store.SetAllocationWithName("/locked", 3002, "main")
+	store.SetLockedByPort(3002, true)
+	store.SetExternalAllocation(3003, 1234, "root", "nginx", "/srv")
+	store.SetAllocationWithName("/ephemeral", 41873, "debugger")
+	store.Allocations[3001].AssignedAt = time.Now().Add(-48 * time.Hour)
+	isFree := func(p int) bool { return p != 3005 }
+
+	st := computeStatus(store, cfg, isFree)
+	if st.Allocated != 3 || st.OutOfRange != 1 {
+		t.Errorf("Allocated = %d, OutOfRange = %d; want 3, 1", st.Allocated, st.OutOfRange)
+	}
+	if st.Locked != 1 || st.External != 1 || st.Frozen != 1 || st.Busy != 1 || st.Free != 6 {
+		t.Errorf("buckets = %+v", st)
+	}
+	if st.Free != len(freePorts(store, cfg, 0, isFree)) {
+		t.Errorf("Free = %d, want the number of --free ports", st.Free)
+	}
+	if st.Oldest == nil || st.Oldest.Port != 3001 {
+		t.Errorf("Oldest = %v, want port 3001", st.Oldest)
+	}
+
+	var buf bytes.Buffer
+	writeStatus(&buf, st, time.Now())
+	for _, want := range []string{"Range:       3000-3009 (10 ports)", "(+1 outside)", "free:      6 (60%)", "(2d ago)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("status output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{512: "512 B", 12700: "12.4 KiB", 3 << 20: "3.0 MiB"} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// claimStickyPort moves the allocation for (cwd, name) to its sticky port when
+// the port is not listening, not locked or external, and not used by another
+// name of cwd. An unlocked allocation of another directory on the port is dropped:
+// that directory gets a new port on its next request. Returns the claimed port,
+// or 0 if (cwd, name) has no sticky port, already holds it or cannot take it now.
+func claimStickyPort(store *allocations.Store, cwd, name string, isPortFree allocations.PortChecker) int {
+	sticky := store.StickyPort(cwd, name)
+	if sticky == 0 {
+		return 0
+	}
+	if holder := store.FindByPort(sticky); holder != nil {
+		switch {
+		case holder.Directory == cwd && holder.Name == name:
+			return 0
+		case holder.Locked, holder.Status == allocations.StatusExternal, holder.Directory == cwd:
+			debug.Printf("main", "sticky port %d for name %s is held by %s (%s)", sticky, name, holder.Directory, holder.Name)
+			return 0
+		}
+	}
+	if !isPortFree(sticky) {
+		debug.Printf("main", "sticky port %d for name %s is busy", sticky, name)
+		return 0
+	}
+
+	if holder := store.FindByPort(sticky); holder != nil {
+		debug.Printf("main", "taking sticky port %d back from %s (%s)", sticky, holder.Directory, holder.Name)
+		store.RemoveByPort(sticky)
+	}
+	store.SetAllocationWithName(cwd, sticky, name)
+	return sticky
+}
+
+// runSetSticky makes a port sticky for the current directory and name (--sticky [PORT])
+// or removes the sticky port (--unsticky). Without PORT, the allocated port becomes
+// sticky, allocating one first if needed.
+func runSetSticky(name string, portArg int, sticky bool) error {
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if !sticky {
+		var cleared int
+		err := allocations.WithStore(configDir, func(store *allocations.Store) error {
+			if cleared = store.ClearSticky(cwd, name); cleared == 0 {
+				return fmt.Errorf("'%s' has no sticky port in %s", name, pathutil.ShortenHomePath(cwd))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Port %d is no longer sticky for '%s' in %s\n", cleared, name, pathutil.ShortenHomePath(cwd))
+		return nil
+	}
+
+	stickyPort, current, err := setSticky(configDir, cfg, cwd, name, portArg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Port %d is sticky for '%s' in %s\n", stickyPort, name, pathutil.ShortenHomePath(cwd))
+	if current != stickyPort {
+		stderrf("warning: port %d cannot be taken now; '%s' stays on port %d until it can\n", stickyPort, name, current)
+	}
+	return nil
+}
+
+// setSticky implements runSetSticky for --sticky. It returns the sticky port and
+// the port (cwd, name) is allocated to afterwards.
+func setSticky(configDir string, cfg *config.Config, cwd, name string, portArg int) (stickyPort, current int, err error) {
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		stickyPort = portArg
+		if stickyPort == 0 {
+			if stickyPort, err = allocatePort(store, cfg, cwd, name); err != nil {
+				return err
+			}
+		}
+		if owner := store.StickyOwnerOf(stickyPort); owner != nil && (owner.Directory != cwd || owner.Name != name) {
+			return fmt.Errorf("port %d is already sticky for '%s' in %s (run --unsticky there first)",
+				stickyPort, owner.Name, pathutil.ShortenHomePath(owner.Directory))
+		}
+		store.SetSticky(stickyPort, cwd, name)
+
+		// Move to the sticky port right away if it can be taken
+		current, err = allocatePort(store, cfg, cwd, name)
+		return err
+	})
+	return stickyPort, current, err
+}
+
+// stickyPortAvailable reports whether claimStickyPort would move (cwd, name) now.
+// Used by the lock-free fast path, which must not return the old port then.
+func stickyPortAvailable(store *allocations.Store, cwd, name string, current int) bool {
+	sticky := store.StickyPort(cwd, name)
+	return sticky != 0 && sticky != current && port.IsPortFree(sticky)
+}
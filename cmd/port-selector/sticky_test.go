@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+)
+
+func TestClaimStickyPort(t *testing.T) {
+	store := allocations.NewStore()
+	store.SetAllocationWithName("/project", 3005, "web")
+	store.SetAllocationWithName("/other", 3000, "main")
+	store.SetSticky(3000, "/project", "web")
+
+	busy := func(p int) bool { return p != 3000 }
+	if got := claimStickyPort(store, "/project", "web", busy); got != 0 {
+		t.Errorf("claimStickyPort() with a busy port = %d, want 0", got)
+	}
+
+	// Locked holders keep the port
+	store.SetLockedByPort(3000, true)
+	free := func(int) bool { return true }
+	if got := claimStickyPort(store, "/project", "web", free); got != 0 {
+		t.Errorf("claimStickyPort() with a locked holder = %d, want 0", got)
+	}
+	store.SetLockedByPort(3000, false)
+
+	// An unlocked holder in another directory gives the port back
+	if got := claimStickyPort(store, "/project", "web", free); got != 3000 {
+		t.Fatalf("claimStickyPort() = %d, want 3000", got)
+	}
+	if a := store.FindByDirectoryAndName("/project", "web"); a == nil || a.Port != 3000 {
+		t.Errorf("/project web = %+v, want port 3000", a)
+	}
+	if store.FindByDirectoryAndName("/other", "main") != nil || store.FindByPort(3005) != nil {
+		t.Error("displaced and old allocations should be gone")
+	}
+	if got := claimStickyPort(store, "/project", "web", free); got != 0 {
+		t.Errorf("claimStickyPort() when already held = %d, want 0", got)
+	}
+}
+
+func TestSetSticky(t *testing.T) {
+	configDir := t.TempDir()
+	cfg := &config.Config{PortStart: 47200, PortEnd: 47220}
+
+	sticky, current, err := setSticky(configDir, cfg, "/project", "web", 47210)
+	if err != nil || sticky != 47210 || current != 47210 {
+		t.Fatalf("setSticky(47210) = %d, %d, %v", sticky, current, err)
+	}
+
+	// Another directory takes the port while the owner is away
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.RemoveByDirectoryAndName("/project", "web")
+		s.SetAllocationWithName("/other", 47210, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner gets it back on its next request
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		p, err := allocatePort(s, cfg, "/project", "web")
+		if err == nil && p != 47210 {
+			t.Errorf("/project got port %d, want sticky 47210", p)
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := setSticky(configDir, cfg, "/third", "main", 47210); err == nil {
+		t.Error("expected error for a port sticky for another directory")
+	}
+}
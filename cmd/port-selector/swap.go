@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/port"
+)
+
+// runSwap exchanges the owners (directory and name) of two allocated ports.
+func runSwap(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: port-selector swap PORT1 PORT2")
+	}
+	var ports [2]int
+	for i, arg := range args {
+		p, err := strconv.Atoi(arg)
+		if err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port number: %s (must be 1-65535)", arg)
+		}
+		ports[i] = p
+	}
+	if ports[0] == ports[1] {
+		return fmt.Errorf("cannot swap port %d with itself", ports[0])
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	// Offline every port looks free, which would pass for "not listening"
+	if err := requireChecks("swap"); err != nil {
+		return err
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	a, b, err := swapAllocations(configDir, ports[0], ports[1], port.IsPortFree)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Port %d: %s ('%s')\n", a.Port, pathutil.ShortenHomePath(a.Directory), a.Name)
+	fmt.Printf("Port %d: %s ('%s')\n", b.Port, pathutil.ShortenHomePath(b.Directory), b.Name)
+	return nil
+}
+
+// swapAllocations implements runSwap and returns both allocations after the swap.
+// Locked, external and listening ports are refused: their owner is tied to the
+// port, and a running service would end up registered to the other directory.
+func swapAllocations(configDir string, portA, portB int, isPortFree allocations.PortChecker) (*allocations.Allocation, *allocations.Allocation, error) {
+	var a, b *allocations.Allocation
+	err := allocations.WithJournal(configDir, "swap", func(store *allocations.Store) error {
+		for _, p := range []int{portA, portB} {
+			alloc := store.FindByPort(p)
+			switch {
+			case alloc == nil:
+				return fmt.Errorf("no allocation found for port %d", p)
+			case alloc.Status == allocations.StatusExternal:
+				return fmt.Errorf("port %d is an external allocation and cannot be swapped", p)
+			case alloc.Locked:
+				return fmt.Errorf("port %d is locked (unlock it with 'port-selector --unlock %d' first)", p, p)
+			case !isPortFree(p):
+				return fmt.Errorf("port %d is in use; stop the service before swapping", p)
+			}
+		}
+		store.SwapPorts(portA, portB)
+		a, b = store.FindByPort(portA), store.FindByPort(portB)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestSwapAllocations(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/api", 3000, "main")
+		s.SetAllocationWithName("/web", 3010, "frontend")
+		s.SetNote(3010, "storybook")
+		s.SetAllocationWithName("/locked", 3020, "main")
+		s.SetLockedByPort(3020, true)
+		s.SetAllocationWithName("/busy", 3030, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	isPortFree := func(p int) bool { return p != 3030 }
+
+	a, b, err := swapAllocations(configDir, 3000, 3010, isPortFree)
+	if err != nil {
+		t.Fatalf("swapAllocations() error = %v", err)
+	}
+	if a.Port != 3000 || a.Directory != "/web" || a.Name != "frontend" || a.Note != "storybook" {
+		t.Errorf("port 3000 = %+v, want /web frontend with its note", a)
+	}
+	if b.Port != 3010 || b.Directory != "/api" || b.Name != "main" {
+		t.Errorf("port 3010 = %+v, want /api main", b)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := store.FindByDirectoryAndName("/web", "frontend"); got == nil || got.Port != 3000 {
+		t.Errorf("/web frontend = %+v, want port 3000", got)
+	}
+
+	for _, tc := range []struct {
+		a, b int
+		want string
+	}{
+		{3000, 3020, "locked"},
+		{3030, 3000, "in use"},
+		{3000, 3999, "no allocation"},
+	} {
+		if _, _, err := swapAllocations(configDir, tc.a, tc.b, isPortFree); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("swapAllocations(%d, %d) error = %v, want %q", tc.a, tc.b, err, tc.want)
+		}
+	}
+}
+
+func TestSwapAllocations_KeepsStickyAndBlocks(t *testing.T) {
+	configDir := t.TempDir()
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		s.SetAllocationWithName("/api", 3000, "main")
+		s.SetSticky(3000, "/api", "main")
+		s.SetBlock(3000, 3000, 3004)
+		s.SetAllocationWithName("/web", 3010, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	free := func(int) bool { return true }
+
+	if _, _, err := swapAllocations(configDir, 3000, 3010, free); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requesting the port again keeps the swap instead of claiming the old sticky port
+	if err := allocations.WithStore(configDir, func(s *allocations.Store) error {
+		if got := claimStickyPort(s, "/api", "main", free); got != 0 {
+			t.Errorf("claimStickyPort() after swap = %d, want 0 (already on its sticky port)", got)
+		}
+		if got := claimStickyPort(s, "/web", "main", free); got != 0 {
+			t.Errorf("claimStickyPort() for the other directory = %d, want 0", got)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := store.StickyPort("/api", "main"); got != 3010 {
+		t.Errorf("sticky port of /api = %d, want 3010", got)
+	}
+	if a := store.FindByDirectoryAndName("/api", "main"); a == nil || a.Port != 3010 {
+		t.Errorf("/api main = %+v, want port 3010", a)
+	}
+	if a := store.FindByDirectoryAndName("/web", "main"); a == nil || a.Port != 3000 {
+		t.Errorf("/web main = %+v, want port 3000", a)
+	}
+	if start, end := store.BlockFor("/api"); start != 3000 || end != 3004 {
+		t.Errorf("block of /api = %d-%d, want 3000-3004", start, end)
+	}
+	if _, end := store.BlockFor("/web"); end != 0 {
+		t.Errorf("/web got a block after the swap")
+	}
+}
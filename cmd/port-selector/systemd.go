@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// systemdPlaceholderExec is used as ExecStart when --exec is not given.
+const systemdPlaceholderExec = "/path/to/your/server"
+
+// systemdUnit holds the parameters of a generated service/socket pair.
+type systemdUnit struct {
+	Unit      string // unit name without suffix
+	Name      string // allocation name
+	Directory string
+	Port      int
+	Exec      string
+}
+
+// systemdUnitName derives a unit name from the directory and allocation name
+// (e.g., ~/code/shop + web -> shop-web).
+func systemdUnitName(dir, name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(filepath.Base(dir) + "-" + name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// service renders the .service unit.
+func (u systemdUnit) service() string {
+	execStart := u.Exec
+	if execStart == "" {
+		execStart = systemdPlaceholderExec
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by port-selector for %s ('%s', port %d)\n", pathutil.ShortenHomePath(u.Directory), u.Name, u.Port)
+	if u.Exec == "" {
+		b.WriteString("# Replace ExecStart with the command that starts the service.\n")
+	}
+	fmt.Fprintf(&b, `[Unit]
+Description=%s (%s) on port %d
+Requires=%s.socket
+After=%s.socket
+
+[Service]
+Type=simple
+WorkingDirectory=%s
+Environment=PORT=%d
+ExecStart=%s
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`, u.Name, pathutil.ShortenHomePath(u.Directory), u.Port, u.Unit, u.Unit, u.Directory, u.Port, execStart)
+	return b.String()
+}
+
+// socket renders the .socket unit.
+func (u systemdUnit) socket() string {
+	return fmt.Sprintf(`# Generated by port-selector for %s ('%s', port %d)
+[Unit]
+Description=Socket for %s (%s)
+
+[Socket]
+ListenStream=127.0.0.1:%d
+
+[Install]
+WantedBy=sockets.target
+`, pathutil.ShortenHomePath(u.Directory), u.Name, u.Port, u.Name, pathutil.ShortenHomePath(u.Directory), u.Port)
+}
+
+// runSystemd prints (or writes) a systemd user service and socket for the allocated port.
+func runSystemd(name string, args []string) error {
+	var unit systemdUnit
+	var outputDir string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--exec" || arg == "--unit" || arg == "--output":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", arg)
+			}
+			value := args[i+1]
+			i++
+			switch arg {
+			case "--exec":
+				unit.Exec = value
+			case "--unit":
+				unit.Unit = value
+			case "--output":
+				outputDir = value
+			}
+		case strings.HasPrefix(arg, "--exec="):
+			unit.Exec = strings.TrimPrefix(arg, "--exec=")
+		case strings.HasPrefix(arg, "--unit="):
+			unit.Unit = strings.TrimPrefix(arg, "--unit=")
+		case strings.HasPrefix(arg, "--output="):
+			outputDir = strings.TrimPrefix(arg, "--output=")
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	p, err := obtainPort(cfg, configDir, cwd, name, allocOptions{})
+	if err != nil {
+		return err
+	}
+
+	unit.Name = name
+	unit.Directory = cwd
+	unit.Port = p
+	if unit.Unit == "" {
+		unit.Unit = systemdUnitName(cwd, name)
+	}
+	debug.Printf("main", "generating systemd units %s for port %d", unit.Unit, p)
+
+	files := []struct{ name, content string }{
+		{unit.Unit + ".service", unit.service()},
+		{unit.Unit + ".socket", unit.socket()},
+	}
+
+	if outputDir == "" {
+		for i, f := range files {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("# %s\n%s", f.name, f.content)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for _, f := range files {
+		path := filepath.Join(outputDir, f.name)
+		if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		fmt.Printf("Wrote %s\n", pathutil.ShortenHomePath(path))
+	}
+	fmt.Printf("Run: systemctl --user daemon-reload && systemctl --user enable --now %s.socket\n", unit.Unit)
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnitName(t *testing.T) {
+	tests := []struct {
+		dir  string
+		name string
+		want string
+	}{
+		{"/home/user/code/shop", "web", "shop-web"},
+		{"/home/user/code/My Project", "main", "my-project-main"},
+		{"/srv/app.v2", "api_server", "app.v2-api_server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := systemdUnitName(tt.dir, tt.name); got != tt.want {
+				t.Errorf("systemdUnitName(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemdUnit_Render(t *testing.T) {
+	u := systemdUnit{Unit: "shop-web", Name: "web", Directory: "/srv/shop", Port: 3005, Exec: "/usr/bin/npm run dev"}
+
+	service := u.service()
+	for _, want := range []string{
+		"Requires=shop-web.socket",
+		"WorkingDirectory=/srv/shop",
+		"Environment=PORT=3005",
+		"ExecStart=/usr/bin/npm run dev",
+	} {
+		if !strings.Contains(service, want) {
+			t.Errorf("service unit missing %q:\n%s", want, service)
+		}
+	}
+
+	if socket := u.socket(); !strings.Contains(socket, "ListenStream=127.0.0.1:3005") {
+		t.Errorf("socket unit missing ListenStream:\n%s", socket)
+	}
+
+	u.Exec = ""
+	if service := u.service(); !strings.Contains(service, "ExecStart="+systemdPlaceholderExec) {
+		t.Errorf("expected placeholder ExecStart:\n%s", service)
+	}
+}
+
+func TestSystemd_Output(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	workDir := filepath.Join(tmpDir, "shop")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(tmpDir, "units")
+
+	cmd := exec.Command(binary, "systemd", "--name", "web", "--exec", "/usr/bin/true", "--output", outDir)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+
+	for _, name := range []string{"shop-web.service", "shop-web.socket"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// sshRunner runs ssh with the given arguments and returns its standard output.
+type sshRunner func(args ...string) ([]byte, error)
+
+// runSSH is the sshRunner used outside tests.
+func runSSH(args ...string) ([]byte, error) {
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// runTunnel forwards the allocation (cwd, name) to a port allocated by port-selector
+// on target (user@host) and runs ssh -R until interrupted. With --print, the ssh
+// command is printed instead. Both ends are recorded as external allocations:
+// the local one is held by ssh until it exits, the remote one (tunnel --accept)
+// until the tunnel frees its port.
+func runTunnel(name string, remainingArgs []string) error {
+	var target, accept string
+	printOnly := false
+	for i := 0; i < len(remainingArgs); i++ {
+		arg := remainingArgs[i]
+		switch {
+		case arg == "--print":
+			printOnly = true
+		case arg == "--accept":
+			if i+1 >= len(remainingArgs) {
+				return fmt.Errorf("--accept requires HOST:PORT")
+			}
+			i++
+			accept = remainingArgs[i]
+		case strings.HasPrefix(arg, "--accept="):
+			accept = strings.TrimPrefix(arg, "--accept=")
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown option: %s", arg)
+		case target == "":
+			target = arg
+		default:
+			return fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if accept != "" {
+		if target != "" || printOnly {
+			return fmt.Errorf("--accept cannot be combined with user@host or --print")
+		}
+		return runTunnelAccept(name, accept)
+	}
+	if target == "" {
+		return fmt.Errorf("usage: port-selector tunnel user@host [--name NAME] [--print]")
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	localPort, err := obtainPort(cfg, configDir, cwd, name, allocOptions{})
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	hostname, _, _ = strings.Cut(hostname, ".")
+	remotePort, err := allocateRemotePort(runSSH, target, tunnelName(hostname, name), hostname, localPort)
+	if err != nil {
+		return err
+	}
+
+	peer := target + ":" + strconv.Itoa(remotePort)
+	args := tunnelArgs(target, remotePort, localPort)
+	if printOnly {
+		// The printed ssh is not ours to wait for: the local end stays external
+		// until --refresh or gc finds the port free
+		if err := recordTunnel(configDir, localPort, peer, 0); err != nil {
+			return err
+		}
+		fmt.Println("ssh " + strings.Join(args, " "))
+		return nil
+	}
+
+	stderrf("Forwarding %s:%d to localhost:%d ('%s'), Ctrl+C to stop\n", target, remotePort, localPort, name)
+	// ssh gets Ctrl+C too; catch it here so that stopping the tunnel is not an error
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+
+	cmd := exec.Command("ssh", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ssh -R %d:localhost:%d failed: %w", remotePort, localPort, err)
+	}
+	if err := recordTunnel(configDir, localPort, peer, cmd.Process.Pid); err != nil {
+		stderrf("warning: %v\n", err)
+	}
+	err = cmd.Wait()
+	if endErr := allocations.WithStore(configDir, func(store *allocations.Store) error {
+		store.EndTunnel(localPort, peer)
+		return nil
+	}); endErr != nil {
+		stderrf("warning: %v\n", endErr)
+	}
+	if err != nil && len(interrupted) == 0 {
+		return fmt.Errorf("ssh -R %d:localhost:%d failed: %w", remotePort, localPort, err)
+	}
+	return nil
+}
+
+// recordTunnel records localPort as the local end of the tunnel to peer, held
+// by the ssh client pid (0 when ssh is run by the user).
+func recordTunnel(configDir string, localPort int, peer string, pid int) error {
+	return allocations.WithStore(configDir, func(store *allocations.Store) error {
+		store.SetTunnel(localPort, peer, pid, currentUserName())
+		return nil
+	})
+}
+
+// runTunnelAccept is the remote half of runTunnel: it records a port for the
+// tunnel name from peer (HOST:PORT) as an external allocation held by sshd, and
+// prints it. The port of an earlier tunnel with the same name is reused.
+func runTunnelAccept(name, peer string) error {
+	host, p, _ := strings.Cut(peer, ":")
+	if n, err := strconv.Atoi(p); host == "" || err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid --accept value %q (expected HOST:PORT)", peer)
+	}
+
+	cfg, err := loadConfigAndInitLogger()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	var remotePort int
+	err = allocations.WithStore(configDir, func(store *allocations.Store) error {
+		if end := store.FindTunnelEnd(name); end != nil {
+			remotePort = end.Port
+		} else {
+			p, err := allocatePort(store, cfg, cwd, name)
+			if err != nil {
+				return err
+			}
+			remotePort = p
+		}
+		store.SetTunnelEnd(remotePort, name, peer, currentUserName())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(remotePort)
+	return nil
+}
+
+// tunnelName returns the allocation name used on the remote host for a tunnel
+// of the local name from hostname.
+func tunnelName(hostname, name string) string {
+	if hostname == "" {
+		return "tunnel-" + name
+	}
+	return "tunnel-" + hostname + "-" + name
+}
+
+// allocateRemotePort runs port-selector tunnel --accept on target over ssh to
+// record the remote end remoteName of the tunnel from localPort on hostname.
+func allocateRemotePort(run sshRunner, target, remoteName, hostname string, localPort int) (int, error) {
+	if hostname == "" {
+		hostname = "localhost"
+	}
+	peer := fmt.Sprintf("%s:%d", hostname, localPort)
+	remoteCmd := "port-selector tunnel --accept " + shellQuote(peer) + " --name " + shellQuote(remoteName)
+	debug.Printf("main", "allocating remote port: ssh %s %s", target, remoteCmd)
+
+	out, err := run(target, remoteCmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate a port on %s (is port-selector installed there?): %w", target, err)
+	}
+	p, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("unexpected output from port-selector on %s: %q", target, strings.TrimSpace(string(out)))
+	}
+	return p, nil
+}
+
+// tunnelArgs returns the ssh arguments that forward remotePort on target to localPort.
+func tunnelArgs(target string, remotePort, localPort int) []string {
+	return []string{"-N", "-o", "ExitOnForwardFailure=yes", "-R", fmt.Sprintf("%d:localhost:%d", remotePort, localPort), target}
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
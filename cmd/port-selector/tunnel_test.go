@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestAllocateRemotePort(t *testing.T) {
+	var gotArgs []string
+	run := func(out string, err error) sshRunner {
+		return func(args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte(out), err
+		}
+	}
+
+	p, err := allocateRemotePort(run("4100\n", nil), "alice@staging", tunnelName("laptop", "web"), "laptop", 3010)
+	if err != nil || p != 4100 {
+		t.Fatalf("allocateRemotePort() = %d, %v, want 4100", p, err)
+	}
+	want := []string{"alice@staging", "port-selector tunnel --accept 'laptop:3010' --name 'tunnel-laptop-web'"}
+	if !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("ssh args = %q, want %q", gotArgs, want)
+	}
+
+	if _, err := allocateRemotePort(run("", errors.New("exit status 127")), "alice@staging", "tunnel-web", "", 3010); err == nil || !strings.Contains(err.Error(), "installed") {
+		t.Errorf("expected ssh failure error, got %v", err)
+	}
+	if _, err := allocateRemotePort(run("error: no free port\n", nil), "alice@staging", "tunnel-web", "", 3010); err == nil || !strings.Contains(err.Error(), "unexpected output") {
+		t.Errorf("expected unexpected output error, got %v", err)
+	}
+}
+
+func TestTunnelArgs(t *testing.T) {
+	got := strings.Join(tunnelArgs("alice@staging", 4100, 3010), " ")
+	if got != "-N -o ExitOnForwardFailure=yes -R 4100:localhost:3010 alice@staging" {
+		t.Errorf("tunnelArgs() = %q", got)
+	}
+	if q := shellQuote("it's"); q != `'it'\''s'` {
+		t.Errorf("shellQuote() = %s", q)
+	}
+}
+
+func TestTunnel_AcceptRecordsExternalEnd(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	accept := func() int {
+		t.Helper()
+		cmd := exec.Command(binary, "tunnel", "--accept", "laptop:3010", "--name", "tunnel-laptop-web")
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("tunnel --accept failed: %v\n%s", err, output)
+		}
+		p, err := strconv.Atoi(strings.TrimSpace(string(output)))
+		if err != nil {
+			t.Fatalf("tunnel --accept output = %q, want a port", output)
+		}
+		return p
+	}
+
+	p := accept()
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := store.FindByPort(p)
+	if a == nil || a.Status != allocations.StatusExternal || a.ExternalProcessName != "sshd" ||
+		a.Tunnel != "laptop:3010" || a.Name != "tunnel-laptop-web" {
+		t.Fatalf("remote end = %+v, want an external allocation of the tunnel", a)
+	}
+	if a.Directory == tmpDir {
+		t.Errorf("remote end is bound to the working directory %s", a.Directory)
+	}
+
+	if again := accept(); again != p {
+		t.Errorf("second tunnel --accept = %d, want the same port %d", again, p)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// runUndo reverts the most recent journaled operation (--forget, --forget-glob,
+// --forget-prefix, --forget-all, --lock PORT, gc), or lists the journal with --list.
+func runUndo(args []string) error {
+	list := false
+	force := false
+	for _, arg := range args {
+		switch arg {
+		case "--list":
+			list = true
+		case "--force", "-f":
+			force = true
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	if list {
+		entries, err := allocations.Journal(configDir)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("Nothing to undo.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tOPERATION\tPORTS")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Operation, formatPorts(e.Ports()))
+		}
+		return w.Flush()
+	}
+
+	e, err := allocations.Undo(configDir, force)
+	if err != nil {
+		if errors.Is(err, allocations.ErrNothingToUndo) {
+			fmt.Println("Nothing to undo.")
+			return nil
+		}
+		return err
+	}
+
+	for _, p := range e.Ports() {
+		if before := e.Before[p]; before != nil {
+			fmt.Printf("Restored port %d (%s, '%s')\n", p, pathutil.ShortenHomePath(before.Directory), before.Name)
+		} else {
+			fmt.Printf("Removed port %d\n", p)
+		}
+	}
+	fmt.Printf("Undid %s from %s\n", e.Operation, e.Time.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// formatPorts joins ports with commas, eliding the middle of long lists.
+func formatPorts(ports []int) string {
+	const maxShown = 5
+	s := ""
+	for i, p := range ports {
+		if i == maxShown {
+			return fmt.Sprintf("%s, ... (%d total)", s, len(ports))
+		}
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprint(p)
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestFormatPorts(t *testing.T) {
+	if got := formatPorts([]int{3000, 3001}); got != "3000, 3001" {
+		t.Errorf("formatPorts() = %q", got)
+	}
+	if got := formatPorts([]int{1, 2, 3, 4, 5, 6, 7}); got != "1, 2, 3, 4, 5, ... (7 total)" {
+		t.Errorf("formatPorts() = %q", got)
+	}
+}
+
+func TestUndo_ForgetAll(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(filepath.Join(tmpDir, "a"), 3850)
+	store.SetAllocation(filepath.Join(tmpDir, "b"), 3851)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	env := append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	run("--forget-all")
+
+	if output := run("undo", "--list"); !strings.Contains(output, "--forget-all") || !strings.Contains(output, "3850, 3851") {
+		t.Errorf("expected --forget-all in journal, got: %s", output)
+	}
+
+	output := run("undo")
+	if !strings.Contains(output, "Restored port 3850") || !strings.Contains(output, "Undid --forget-all") {
+		t.Errorf("unexpected undo output: %s", output)
+	}
+
+	loaded, err := allocations.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Count() != 2 {
+		t.Errorf("expected 2 allocations after undo, got %d", loaded.Count())
+	}
+
+	if output := run("undo"); !strings.Contains(output, "Nothing to undo") {
+		t.Errorf("expected nothing to undo, got: %s", output)
+	}
+}
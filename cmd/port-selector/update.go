@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/update"
+)
+
+// updateCheckCommand is the hidden command run in a detached child process to
+// refresh the release cache without delaying the parent.
+const updateCheckCommand = "__update-check"
+
+// checkForUpdate prints a notice about a newer release known from the cache and,
+// at most once per update.Interval, refreshes the cache in the background.
+// Called after the port has been printed; it never waits for the network.
+func checkForUpdate(configDir string) {
+	if version == "dev" || allocations.IsDryRun() {
+		return
+	}
+	if notice := update.Notice(configDir, version); notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+
+	now := time.Now()
+	if !update.Due(configDir, now) {
+		return
+	}
+	if err := update.MarkChecked(configDir, now); err != nil {
+		debug.Printf("update", "failed to write cache: %v", err)
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		debug.Printf("update", "cannot find executable: %v", err)
+		return
+	}
+	cmd := exec.Command(exe, updateCheckCommand, configDir)
+	if err := cmd.Start(); err != nil {
+		debug.Printf("update", "failed to start update check: %v", err)
+		return
+	}
+	cmd.Process.Release()
+}
+
+// runUpdateCheck fetches the latest release into the cache of configDir.
+func runUpdateCheck(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: port-selector %s CONFIG_DIR", updateCheckCommand)
+	}
+	return update.Refresh(args[0])
+}
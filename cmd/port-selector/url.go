@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+	"github.com/dapi/port-selector/internal/qr"
+)
+
+// lanURL returns http://IP:PORT with the given path.
+func lanURL(ip net.IP, p int, path string) string {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "http://" + net.JoinHostPort(ip.String(), strconv.Itoa(p)) + path
+}
+
+// runURL prints the URL of the allocation of (cwd, name): on localhost, or with
+// --lan on the machine's LAN address. --qr also renders it as a QR code for
+// opening on a phone, which implies --lan.
+func runURL(name string, args []string) error {
+	var path string
+	lan, showQR := false, false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--lan":
+			lan = true
+		case arg == "--qr":
+			lan, showQR = true, true
+		case arg == "--path":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--path requires a value")
+			}
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--path="):
+			path = strings.TrimPrefix(arg, "--path=")
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	alloc := store.FindByDirectoryAndName(cwd, name)
+	if alloc == nil {
+		return fmt.Errorf("no allocation found for %s with name '%s'", pathutil.ShortenHomePath(cwd), name)
+	}
+
+	url := allocationURL(alloc.Port, path)
+	if lan {
+		network, err := lanNetwork()
+		if err != nil {
+			return err
+		}
+		url = lanURL(network.IP, alloc.Port, path)
+	}
+	fmt.Println(url)
+
+	if showQR {
+		code, err := qr.Encode(url)
+		if err != nil {
+			return err
+		}
+		fmt.Print(code.Terminal(2))
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestLanURL(t *testing.T) {
+	ip := net.IPv4(192, 168, 1, 20)
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{"", "http://192.168.1.20:3000"},
+		{"admin", "http://192.168.1.20:3000/admin"},
+		{"/admin", "http://192.168.1.20:3000/admin"},
+	} {
+		if got := lanURL(ip, 3000, tc.path); got != tc.want {
+			t.Errorf("lanURL(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/config"
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// vscodeContractVersion is the version of the `vscode --json` output.
+// It is bumped only on incompatible changes; new fields may be added at any time.
+const vscodeContractVersion = 1
+
+// vscodeInputPrefix prefixes the ids of the inputs managed in .vscode/tasks.json.
+// Inputs with other ids are left untouched.
+const vscodeInputPrefix = "port-selector."
+
+// vscodeProject is the `vscode --json` output consumed by the editor extension.
+type vscodeProject struct {
+	Version     int                `json:"version"`
+	Directory   string             `json:"directory"`
+	Alias       string             `json:"alias,omitempty"`
+	Allocations []vscodeAllocation `json:"allocations"`
+}
+
+// vscodeAllocation is one named port of the project.
+type vscodeAllocation struct {
+	Name    string            `json:"name"`
+	Port    int               `json:"port"`
+	Locked  bool              `json:"locked"`
+	InputID string            `json:"input_id"` // referenced as ${input:<input_id>} in tasks and launch configurations
+	URL     string            `json:"url"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// runVSCode adds the ports of the current directory as inputs to .vscode/tasks.json.
+// With --json it prints the project's ports instead; with --print it prints the
+// updated tasks.json without writing it.
+func runVSCode(args []string) error {
+	asJSON, printOnly := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "--print":
+			printOnly = true
+		default:
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if _, err := loadConfigAndInitLogger(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	store, err := allocations.Load(configDir)
+	if err != nil {
+		return err
+	}
+	project := vscodeProjectFor(store, cwd)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(project)
+	}
+
+	if len(project.Allocations) == 0 {
+		return fmt.Errorf("no allocations for %s (run port-selector first)", pathutil.ShortenHomePath(cwd))
+	}
+
+	path := filepath.Join(cwd, ".vscode", "tasks.json")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", pathutil.ShortenHomePath(path), err)
+	}
+	updated, err := mergeTaskInputs(data, project.Allocations)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pathutil.ShortenHomePath(path), err)
+	}
+
+	if printOnly || allocations.IsDryRun() {
+		if allocations.IsDryRun() {
+			stderrf("dry-run: would write %s\n", pathutil.ShortenHomePath(path))
+		}
+		_, err := os.Stdout.Write(updated)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create .vscode directory: %w", err)
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pathutil.ShortenHomePath(path), err)
+	}
+	fmt.Printf("Wrote %d input(s) to %s\n", len(project.Allocations), pathutil.ShortenHomePath(path))
+	for _, a := range project.Allocations {
+		fmt.Printf("  ${input:%s} = %d\n", a.InputID, a.Port)
+	}
+	return nil
+}
+
+// vscodeProjectFor collects the allocations of dir, sorted by port.
+// External allocations are skipped: they belong to other processes.
+func vscodeProjectFor(store *allocations.Store, dir string) vscodeProject {
+	project := vscodeProject{
+		Version:     vscodeContractVersion,
+		Directory:   dir,
+		Allocations: []vscodeAllocation{},
+	}
+	for _, alloc := range store.SortedByPort() {
+		if alloc.Directory != dir || alloc.Status == allocations.StatusExternal {
+			continue
+		}
+		if alloc.Alias != "" {
+			project.Alias = alloc.Alias
+		}
+		project.Allocations = append(project.Allocations, vscodeAllocation{
+			Name:    alloc.Name,
+			Port:    alloc.Port,
+			Locked:  alloc.Locked,
+			InputID: vscodeInputPrefix + alloc.Name,
+			URL:     allocationURL(alloc.Port, ""),
+			Labels:  alloc.Labels,
+		})
+	}
+	return project
+}
+
+// mergeTaskInputs returns tasks.json content with the port-selector inputs replaced
+// by allocs. Other inputs, tasks and keys are kept. An empty data starts a new file.
+// Files with comments or trailing commas (JSONC) are rejected rather than rewritten.
+func mergeTaskInputs(data []byte, allocs []vscodeAllocation) ([]byte, error) {
+	doc := map[string]any{"version": "2.0.0", "tasks": []any{}}
+	if len(bytes.TrimSpace(data)) > 0 {
+		doc = nil
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("not plain JSON (%v); remove comments or add the inputs by hand (see 'port-selector vscode --json')", err)
+		}
+		if doc == nil {
+			return nil, fmt.Errorf("expected a JSON object")
+		}
+	}
+
+	var inputs []any
+	if existing, ok := doc["inputs"]; ok {
+		list, ok := existing.([]any)
+		if !ok {
+			return nil, fmt.Errorf("\"inputs\" is not a list")
+		}
+		for _, in := range list {
+			if obj, ok := in.(map[string]any); ok {
+				if id, _ := obj["id"].(string); strings.HasPrefix(id, vscodeInputPrefix) {
+					continue
+				}
+			}
+			inputs = append(inputs, in)
+		}
+	}
+	for _, a := range allocs {
+		inputs = append(inputs, map[string]any{
+			"id":          a.InputID,
+			"type":        "promptString",
+			"description": fmt.Sprintf("Port of '%s' (port-selector)", a.Name),
+			"default":     strconv.Itoa(a.Port),
+		})
+	}
+	doc["inputs"] = inputs
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
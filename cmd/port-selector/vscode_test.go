@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestMergeTaskInputs(t *testing.T) {
+	allocs := []vscodeAllocation{{Name: "web", Port: 3010, InputID: "port-selector.web"}}
+
+	existing := `{
+  "version": "2.0.0",
+  "tasks": [{"label": "dev", "type": "shell", "command": "npm run dev"}],
+  "inputs": [
+    {"id": "env", "type": "pickString", "options": ["dev", "prod"]},
+    {"id": "port-selector.old", "type": "promptString", "default": "3000"}
+  ]
+}`
+	out, err := mergeTaskInputs([]byte(existing), allocs)
+	if err != nil {
+		t.Fatalf("mergeTaskInputs() error = %v", err)
+	}
+	var doc struct {
+		Tasks  []map[string]any `json:"tasks"`
+		Inputs []map[string]any `json:"inputs"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, out)
+	}
+	if len(doc.Tasks) != 1 || doc.Tasks[0]["label"] != "dev" {
+		t.Errorf("tasks not preserved: %v", doc.Tasks)
+	}
+	if len(doc.Inputs) != 2 || doc.Inputs[0]["id"] != "env" || doc.Inputs[1]["id"] != "port-selector.web" || doc.Inputs[1]["default"] != "3010" {
+		t.Errorf("unexpected inputs: %v", doc.Inputs)
+	}
+
+	out, err = mergeTaskInputs(nil, allocs)
+	if err != nil {
+		t.Fatalf("mergeTaskInputs(nil) error = %v", err)
+	}
+	if !strings.Contains(string(out), `"version": "2.0.0"`) || !strings.Contains(string(out), `"id": "port-selector.web"`) {
+		t.Errorf("unexpected new tasks.json:\n%s", out)
+	}
+
+	if _, err := mergeTaskInputs([]byte("{\n  // comment\n}"), allocs); err == nil {
+		t.Error("expected error for tasks.json with comments")
+	}
+}
+
+func TestVSCode(t *testing.T) {
+	binary := buildBinary(t)
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocationWithName(workDir, 3930, "web")
+	store.SetAllocationWithName(workDir, 3931, "api")
+	store.SetAllocation(filepath.Join(tmpDir, "other"), 3932)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) []byte {
+		t.Helper()
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v failed: %v\n%s", args, err, out)
+		}
+		return out
+	}
+
+	var project vscodeProject
+	if err := json.Unmarshal(run("vscode", "--json"), &project); err != nil {
+		t.Fatal(err)
+	}
+	if project.Version != vscodeContractVersion || project.Directory != workDir || len(project.Allocations) != 2 {
+		t.Fatalf("unexpected project: %+v", project)
+	}
+	if a := project.Allocations[0]; a.Name != "web" || a.Port != 3930 || a.InputID != "port-selector.web" || a.URL != "http://localhost:3930" {
+		t.Errorf("unexpected allocation: %+v", a)
+	}
+
+	run("vscode")
+	data, err := os.ReadFile(filepath.Join(workDir, ".vscode", "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"default": "3931"`) || strings.Contains(string(data), "3932") {
+		t.Errorf("unexpected tasks.json:\n%s", data)
+	}
+}
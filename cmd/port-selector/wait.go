@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// defaultWaitTimeout is how long --wait blocks when --timeout is not given.
+const defaultWaitTimeout = 30 * time.Second
+
+// waitPollInterval is the delay between port checks in --wait.
+var waitPollInterval = 200 * time.Millisecond
+
+// waitForPort polls until the port is listening (or free, if listening is false).
+// Returns an error if the state is not reached within timeout.
+func waitForPort(p int, listening bool, timeout time.Duration, isPortFree allocations.PortChecker) error {
+	state := "listening"
+	if !listening {
+		state = "free"
+	}
+	debug.Printf("main", "waiting up to %s for port %d to be %s", timeout, p, state)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if isPortFree(p) != listening {
+			debug.Printf("main", "port %d is %s", p, state)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for port %d to be %s", timeout, p, state)
+		}
+		time.Sleep(waitPollInterval)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapi/port-selector/internal/allocations"
+)
+
+func TestWaitForPort(t *testing.T) {
+	orig := waitPollInterval
+	waitPollInterval = time.Millisecond
+	defer func() { waitPollInterval = orig }()
+
+	t.Run("becomes listening", func(t *testing.T) {
+		calls := 0
+		isPortFree := func(int) bool {
+			calls++
+			return calls < 3
+		}
+		if err := waitForPort(3000, true, time.Second, isPortFree); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("becomes free", func(t *testing.T) {
+		if err := waitForPort(3000, false, time.Second, func(int) bool { return true }); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		err := waitForPort(3000, true, 10*time.Millisecond, func(int) bool { return true })
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected timeout error, got %v", err)
+		}
+	})
+}
+
+func TestParseAllocOptions_Wait(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantTimeout time.Duration
+		wantFree    bool
+		wantErr     bool
+	}{
+		{"default timeout", []string{"--wait"}, defaultWaitTimeout, false, false},
+		{"custom timeout", []string{"--wait", "--timeout", "5s"}, 5 * time.Second, false, false},
+		{"timeout with equals", []string{"--wait", "--timeout=1m", "--free"}, time.Minute, true, false},
+		{"invalid timeout", []string{"--wait", "--timeout", "soon"}, 0, false, true},
+		{"missing timeout value", []string{"--wait", "--timeout"}, 0, false, true},
+		{"free without wait", []string{"--free"}, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, remaining, err := parseAllocOptions(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAllocOptions(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !opts.wait || opts.waitTimeout != tt.wantTimeout || opts.waitFree != tt.wantFree || len(remaining) != 0 {
+				t.Errorf("parseAllocOptions(%v) = %+v, %v", tt.args, opts, remaining)
+			}
+		})
+	}
+}
+
+func TestWait_Listening(t *testing.T) {
+	binary := buildBinary(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	listenPort := ln.Addr().(*net.TCPAddr).Port
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "port-selector")
+	workDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := allocations.NewStore()
+	store.SetAllocation(workDir, listenPort)
+	if err := allocations.Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "--wait", "--timeout", "5s")
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+filepath.Join(tmpDir, ".config"))
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != strconv.Itoa(listenPort) {
+		t.Errorf("expected %d, got %s", listenPort, output)
+	}
+}
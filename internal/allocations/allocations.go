@@ -4,22 +4,37 @@
 package allocations
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
 	"github.com/dapi/port-selector/internal/logger"
-	"gopkg.in/yaml.v3"
+	"github.com/dapi/port-selector/internal/pathutil"
 )
 
 const allocationsFileName = "allocations.yaml"
 
+// ErrCorrupted is returned when the allocations file cannot be parsed.
+var ErrCorrupted = errors.New("allocations file corrupted")
+
+// lockFileName is the file used for flock. Locking a separate file keeps the lock
+// valid while allocations.yaml is replaced via rename.
+const lockFileName = "allocations.yaml.lock"
+
 // UnknownDirectoryFormat is the format string for unknown directory placeholders.
 const UnknownDirectoryFormat = "(unknown:%d)"
 
+// KubeDirectoryFormat is the format string for the directory placeholder of
+// kubectl port-forwards: (k8s:CONTEXT/NAMESPACE).
+const KubeDirectoryFormat = "(k8s:%s/%s)"
+
 // AllocationStatus represents the type of allocation.
 type AllocationStatus string
 
@@ -31,18 +46,33 @@ const (
 
 // AllocationInfo represents a single port allocation entry.
 type AllocationInfo struct {
-	Directory           string           `yaml:"directory"`
-	AssignedAt          time.Time        `yaml:"assigned_at"`
-	LastUsedAt          time.Time        `yaml:"last_used_at,omitempty"`
-	Locked              bool             `yaml:"locked,omitempty"`
-	ProcessName         string           `yaml:"process_name,omitempty"`
-	ContainerID         string           `yaml:"container_id,omitempty"`
-	Name                string           `yaml:"name,omitempty"`
-	Status              AllocationStatus `yaml:"status,omitempty"`                // StatusNormal or StatusExternal
-	LockedAt            time.Time        `yaml:"locked_at,omitempty"`             // Time when port was locked
-	ExternalPID         int              `yaml:"external_pid,omitempty"`          // PID of external process (0 = unknown)
-	ExternalUser        string           `yaml:"external_user,omitempty"`         // User of external process
-	ExternalProcessName string           `yaml:"external_process_name,omitempty"` // Name of external process
+	Directory           string            `yaml:"directory"`
+	AssignedAt          time.Time         `yaml:"assigned_at"`
+	LastUsedAt          time.Time         `yaml:"last_used_at,omitempty"`
+	Locked              bool              `yaml:"locked,omitempty"`
+	ProcessName         string            `yaml:"process_name,omitempty"`
+	ContainerID         string            `yaml:"container_id,omitempty"`
+	Name                string            `yaml:"name,omitempty"`
+	Status              AllocationStatus  `yaml:"status,omitempty"`                // StatusNormal or StatusExternal
+	LockedAt            time.Time         `yaml:"locked_at,omitempty"`             // Time when port was locked
+	ExternalPID         int               `yaml:"external_pid,omitempty"`          // PID of external process (0 = unknown)
+	ExternalUser        string            `yaml:"external_user,omitempty"`         // User of external process
+	ExternalProcessName string            `yaml:"external_process_name,omitempty"` // Name of external process
+	NoFreeze            bool              `yaml:"no_freeze,omitempty"`             // Port is not frozen after use (--no-freeze)
+	Hostname            string            `yaml:"hostname,omitempty"`              // Hostname registered for the project (e.g., shop.local)
+	Alias               string            `yaml:"alias,omitempty"`                 // Human alias of the directory (e.g., myshop for @myshop)
+	Labels              map[string]string `yaml:"labels,omitempty"`                // Arbitrary key=value metadata (--label team=payments)
+	Note                string            `yaml:"note,omitempty"`                  // Free-text note (port-selector note PORT TEXT)
+	ComposeService      string            `yaml:"compose_service,omitempty"`       // Compose service of the container publishing the port
+	BlockStart          int               `yaml:"block_start,omitempty"`           // First port of the project's contiguous block (compose projects)
+	BlockEnd            int               `yaml:"block_end,omitempty"`             // Last port of the project's contiguous block
+	Lease               time.Duration     `yaml:"lease,omitempty"`                 // Lease length renewed on each reissue (--lease)
+	LeaseExpiresAt      time.Time         `yaml:"lease_expires_at,omitempty"`      // Allocation expires at this time unless renewed
+	OwnerPID            int               `yaml:"owner_pid,omitempty"`             // Process that uses the port (--pid); gc frees the port when it exits
+	OwnerStartTime      uint64            `yaml:"owner_start_time,omitempty"`      // Start time of OwnerPID (clock ticks after boot), guards against PID reuse
+	HealthPath          string            `yaml:"health_path,omitempty"`           // HTTP path probed by --check and status (--health /healthz)
+	Firewall            string            `yaml:"firewall,omitempty"`              // Firewall rule opened for the port as TOOL:SOURCE (firewall --allow-lan)
+	Tunnel              string            `yaml:"tunnel,omitempty"`                // Other end of a reverse ssh tunnel as HOST:PORT (tunnel command)
 }
 
 // Store is the root structure for the allocations file.
@@ -50,12 +80,45 @@ type AllocationInfo struct {
 type Store struct {
 	LastIssuedPort int                     `yaml:"last_issued_port,omitempty"`
 	Allocations    map[int]*AllocationInfo `yaml:"allocations"`
+	Sticky         map[int]*StickyOwner    `yaml:"sticky,omitempty"` // Ports preferred for a directory and name (--sticky)
+	// DirectoriesNormalized is set once migrateDirectories has run on the store
+	DirectoriesNormalized bool `yaml:"directories_normalized,omitempty"`
+
+	// loaded holds serialized entries as last read by the remote backend.
+	// nil means the store was not read from it.
+	loaded map[int]string
+	// etag is the version of the remote store seen by Read (remote backend only).
+	etag string
+	// loadedSticky and loadedLastIssued are Sticky and LastIssuedPort as last read
+	// by the remote backend, to merge concurrent changes of them.
+	loadedSticky     map[int]StickyOwner
+	loadedLastIssued int
 }
 
-// file holds the opened file handle for locking.
+// file holds the opened lock file handle.
 type file struct {
-	path string
-	f    *os.File
+	lockPath string   // allocations.yaml.lock
+	f        *os.File // lock file handle
+}
+
+// lockWaitObserver receives how long each openAndLock waited for the lock (bench).
+var lockWaitObserver atomic.Pointer[func(time.Duration)]
+
+// SetLockWaitObserver makes fn receive the lock wait of every locked operation.
+// fn must be safe for concurrent use. nil removes the observer.
+func SetLockWaitObserver(fn func(time.Duration)) {
+	if fn == nil {
+		lockWaitObserver.Store(nil)
+		return
+	}
+	lockWaitObserver.Store(&fn)
+}
+
+// observeLockWait reports a lock wait to the observer, if any.
+func observeLockWait(wait time.Duration) {
+	if fn := lockWaitObserver.Load(); fn != nil {
+		(*fn)(wait)
+	}
 }
 
 // Allocation represents a single port allocation (for external use).
@@ -68,11 +131,27 @@ type Allocation struct {
 	ProcessName         string
 	ContainerID         string
 	Name                string
-	Status              AllocationStatus // StatusNormal or StatusExternal
-	LockedAt            time.Time        // Time when port was locked
-	ExternalPID         int              // PID of external process (0 = unknown)
-	ExternalUser        string           // User of external process
-	ExternalProcessName string           // Name of external process
+	Status              AllocationStatus  // StatusNormal or StatusExternal
+	LockedAt            time.Time         // Time when port was locked
+	ExternalPID         int               // PID of external process (0 = unknown)
+	ExternalUser        string            // User of external process
+	ExternalProcessName string            // Name of external process
+	NoFreeze            bool              // Port is not frozen after use (--no-freeze)
+	Hostname            string            // Hostname registered for the project (e.g., shop.local)
+	Alias               string            // Human alias of the directory (e.g., myshop for @myshop)
+	Labels              map[string]string // Arbitrary key=value metadata (--label team=payments)
+	Note                string            // Free-text note (port-selector note PORT TEXT)
+	ComposeService      string            // Compose service of the container publishing the port
+	BlockStart          int               // First port of the project's contiguous block (compose projects)
+	BlockEnd            int               // Last port of the project's contiguous block
+	Lease               time.Duration     // Lease length renewed on each reissue (--lease)
+	LeaseExpiresAt      time.Time         // Allocation expires at this time unless renewed
+	OwnerPID            int               // Process that uses the port (--pid); gc frees the port when it exits
+	OwnerStartTime      uint64            // Start time of OwnerPID (clock ticks after boot), guards against PID reuse
+	HealthPath          string            // HTTP path probed by --check and status
+	Firewall            string            // Firewall rule opened for the port as TOOL:SOURCE
+	Tunnel              string            // Other end of a reverse ssh tunnel as HOST:PORT
+	Host                string            // Machine whose store holds the allocation (set only by LoadAllHosts)
 }
 
 // toAllocation converts AllocationInfo to Allocation with the given port number.
@@ -91,6 +170,21 @@ func (info *AllocationInfo) toAllocation(port int) *Allocation {
 		ExternalPID:         info.ExternalPID,
 		ExternalUser:        info.ExternalUser,
 		ExternalProcessName: info.ExternalProcessName,
+		NoFreeze:            info.NoFreeze,
+		Hostname:            info.Hostname,
+		Alias:               info.Alias,
+		Labels:              info.Labels,
+		Note:                info.Note,
+		ComposeService:      info.ComposeService,
+		BlockStart:          info.BlockStart,
+		BlockEnd:            info.BlockEnd,
+		Lease:               info.Lease,
+		LeaseExpiresAt:      info.LeaseExpiresAt,
+		OwnerPID:            info.OwnerPID,
+		OwnerStartTime:      info.OwnerStartTime,
+		HealthPath:          info.HealthPath,
+		Firewall:            info.Firewall,
+		Tunnel:              info.Tunnel,
 	}
 }
 
@@ -101,181 +195,178 @@ func NewStore() *Store {
 	}
 }
 
-// read reads the store from the locked file.
-func (fl *file) read() (*Store, error) {
-	// Seek to beginning
-	if _, err := fl.f.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("failed to seek: %w", err)
-	}
+// WithStore executes a function with exclusive access to the allocations store.
+// The store is automatically loaded before and saved after the function executes
+// (in dry-run mode the changes are printed instead of saved; in read-only mode
+// the store is read without locking and any change fails with ErrReadOnly).
+// Returns the result of the function.
+func WithStore(configDir string, fn func(*Store) error) error {
+	return withStore(configDir, fn, nil)
+}
 
-	stat, err := fl.f.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+// withStore implements WithStore. afterWrite, if set, runs under the lock once
+// the store has been written, so files kept next to the store never record a
+// change that failed to persist.
+func withStore(configDir string, fn func(*Store) error, afterWrite func() error) error {
+	// Firewall rules are closed once the lock is released: sudo may ask for a password
+	var orphaned []Allocation
+	defer func() { closeFirewallRules(orphaned) }()
+
+	if !IsReadOnly() {
+		fl, err := openAndLock(configDir)
+		if err != nil {
+			return err
+		}
+		defer fl.unlock()
 	}
 
-	// Empty file - return new store
-	if stat.Size() == 0 {
-		debug.Printf("allocations", "file is empty, returning new store")
-		return NewStore(), nil
+	b := currentBackend()
+	path := storePath(b, configDir)
+	store, recovered, err := readVerified(b, path)
+	if err != nil {
+		if errors.Is(err, ErrCorrupted) {
+			i18n.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			i18n.Fprintf(os.Stderr, "       File: %s\n", path)
+			i18n.Fprintf(os.Stderr, "       Use 'port-selector repair' to salvage it, 'port-selector restore' to recover from a backup, or fix the file manually.\n")
+		}
+		return err
 	}
 
-	data := make([]byte, stat.Size())
-	n, err := fl.f.Read(data)
+	rules := store.firewallRules()
+	migrated, err := migrateLegacyFiles(configDir, store)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return err
 	}
-	data = data[:n]
 
-	var store Store
-	if err := yaml.Unmarshal(data, &store); err != nil {
-		debug.Printf("allocations", "YAML parse error: %v", err)
-		fmt.Fprintf(os.Stderr, "ERROR: allocations file corrupted: %v\n", err)
-		fmt.Fprintf(os.Stderr, "       File: %s\n", fl.path)
-		fmt.Fprintf(os.Stderr, "       Use --forget-all to reset, or fix the file manually.\n")
-		return nil, fmt.Errorf("allocations file corrupted: %w", err)
+	// The snapshot is taken before the directory migration, so its changes are
+	// backed up, shown by --dry-run and journaled (as their own entry) like any other
+	backups := currentBackupCount()
+	var before map[int]*AllocationInfo
+	if IsDryRun() || backups > 0 || directoriesPending(store) {
+		before = store.copyAllocations()
 	}
-
-	if store.Allocations == nil {
-		store.Allocations = make(map[int]*AllocationInfo)
+	lastIssued := store.LastIssuedPort
+	var migration *JournalEntry
+	if len(migrateDirectories(store)) > 0 {
+		migration = newJournalEntry("directory migration", before, lastIssued, store)
 	}
-
-	// Normalize directory paths and names
-	for port, info := range store.Allocations {
-		if info != nil {
-			info.Directory = filepath.Clean(info.Directory)
-			// Normalize empty name to "main" for legacy allocations
-			if info.Name == "" {
-				info.Name = "main"
-			}
-			store.Allocations[port] = info
-		}
+	if IsReadOnly() {
+		// Read-only runs migrate in memory only; only fn must not change the store
+		before = store.copyAllocations()
 	}
 
-	debug.Printf("allocations", "loaded %d allocations, last_issued_port=%d",
-		len(store.Allocations), store.LastIssuedPort)
-	return &store, nil
-}
-
-// write writes the store to the locked file.
-func (fl *file) write(store *Store) error {
-	data, err := yaml.Marshal(store)
-	if err != nil {
-		return fmt.Errorf("failed to marshal store: %w", err)
+	if err := fn(store); err != nil {
+		return err
 	}
 
-	// Truncate and seek to beginning
-	if err := fl.f.Truncate(0); err != nil {
-		return fmt.Errorf("failed to truncate: %w", err)
-	}
-	if _, err := fl.f.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek: %w", err)
+	if IsReadOnly() {
+		return readOnlyViolation(before, lastIssued, store)
 	}
 
-	if _, err := fl.f.Write(data); err != nil {
-		return fmt.Errorf("failed to write: %w", err)
+	if IsDryRun() {
+		writeDiff(dryRunOutput, before, store.Allocations)
+		orphaned = store.orphanedRules(rules)
+		return nil
 	}
 
-	if err := fl.f.Sync(); err != nil {
-		return fmt.Errorf("failed to sync: %w", err)
+	if recovered {
+		// Keep the damaged file for inspection; it must not displace a good backup
+		quarantine := quarantinePath(path)
+		if err := os.Rename(path, quarantine); err != nil {
+			return fmt.Errorf("failed to quarantine corrupted file: %w", err)
+		}
+		logger.Log(logger.AllocRecover, logger.Field("count", len(store.Allocations)), logger.Field("quarantine", quarantine))
+	} else if backups > 0 && (diffAllocations(before, store.Allocations) != nil || store.LastIssuedPort != lastIssued) {
+		if err := rotateBackups(path, backups); err != nil {
+			return err
+		}
 	}
 
-	debug.Printf("allocations", "saved %d allocations", len(store.Allocations))
-	return nil
-}
-
-// WithStore executes a function with exclusive access to the allocations store.
-// The store is automatically loaded before and saved after the function executes.
-// Returns the result of the function.
-func WithStore(configDir string, fn func(*Store) error) error {
-	fl, err := openAndLock(configDir)
-	if err != nil {
+	if err := b.Write(path, store); err != nil {
 		return err
 	}
-	defer fl.unlock()
 
-	store, err := fl.read()
-	if err != nil {
-		return err
+	if migrated {
+		removeLegacyFiles(configDir)
 	}
-
-	if err := fn(store); err != nil {
+	orphaned = store.orphanedRules(rules)
+	if err := appendJournal(configDir, migration); err != nil {
 		return err
 	}
-
-	return fl.write(store)
+	if afterWrite != nil {
+		return afterWrite()
+	}
+	return nil
 }
 
 // Load reads allocations from the config directory (without locking).
 // Returns empty store if file doesn't exist, error for other failures.
 // Use WithStore for operations that need locking.
 func Load(configDir string) (*Store, error) {
-	path := filepath.Join(configDir, allocationsFileName)
+	b := currentBackend()
+	path := storePath(b, configDir)
 	debug.Printf("allocations", "loading from %s", path)
 
-	data, err := os.ReadFile(path)
+	store, _, err := readVerified(b, path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			debug.Printf("allocations", "file does not exist, returning empty store")
-			return NewStore(), nil
+		if errors.Is(err, ErrCorrupted) {
+			return nil, fmt.Errorf("%w (use 'port-selector repair' to salvage it or 'port-selector restore' to recover from a backup)", err)
 		}
-		debug.Printf("allocations", "failed to read file: %v", err)
-		return nil, fmt.Errorf("cannot read allocations file: %w", err)
+		return nil, err
+	}
+	return store, nil
+}
+
+// Save writes store to the config directory (without locking).
+// Use WithStore for operations that need locking.
+func Save(configDir string, store *Store) error {
+	if err := checkWritable("save the store"); err != nil {
+		return err
+	}
+	b := currentBackend()
+	path := storePath(b, configDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
 	}
+	debug.Printf("allocations", "saving %d allocations to %s", len(store.Allocations), path)
 
-	var store Store
-	if err := yaml.Unmarshal(data, &store); err != nil {
-		debug.Printf("allocations", "YAML parse error: %v", err)
-		return nil, fmt.Errorf("allocations file corrupted (use --forget-all to reset): %w", err)
+	if err := b.Write(path, store); err != nil {
+		return err
 	}
 
-	if store.Allocations == nil {
-		store.Allocations = make(map[int]*AllocationInfo)
+	debug.Printf("allocations", "saved successfully")
+	return nil
+}
+
+// normalize fills in defaults and cleans up entries after reading a store.
+func (s *Store) normalize() {
+	if s.Allocations == nil {
+		s.Allocations = make(map[int]*AllocationInfo)
 	}
 
 	// Normalize directory paths and names
-	for port, info := range store.Allocations {
+	for port, info := range s.Allocations {
 		if info != nil {
 			info.Directory = filepath.Clean(info.Directory)
 			// Normalize empty name to "main" for legacy allocations
 			if info.Name == "" {
 				info.Name = "main"
 			}
-			store.Allocations[port] = info
+			s.Allocations[port] = info
 		}
 	}
-
-	debug.Printf("allocations", "loaded %d allocations", len(store.Allocations))
-	return &store, nil
-}
-
-// Save writes store to the config directory (without locking).
-// Use WithStore for operations that need locking.
-func Save(configDir string, store *Store) error {
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	path := filepath.Join(configDir, allocationsFileName)
-	tmpPath := path + ".tmp"
-
-	debug.Printf("allocations", "saving %d allocations to %s", len(store.Allocations), path)
-
-	data, err := yaml.Marshal(store)
-	if err != nil {
-		return fmt.Errorf("failed to marshal store: %w", err)
-	}
-
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write temp file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, path); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("failed to rename temp file: %w", err)
+	for _, owner := range s.Sticky {
+		if owner != nil {
+			owner.Directory = filepath.Clean(owner.Directory)
+			owner.Name = normalizeName(owner.Name)
+		}
 	}
+}
 
-	debug.Printf("allocations", "saved successfully")
-	return nil
+// writeFileAtomic writes data to path with pathutil.WriteFileAtomic, so a crash
+// mid-write never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte) error {
+	return pathutil.WriteFileAtomic(path, data, 0644)
 }
 
 // FindByDirectory returns the allocation for a given directory, or nil if not found.
@@ -417,11 +508,13 @@ func (s *Store) SetAllocationWithPortCheckAndName(dir string, newPort int, proce
 		}
 	}
 
-	// Update or create allocation for the port
+	// Update or create allocation for the port; it inherits the directory's alias
+	alias := s.aliasFor(dir, newPort)
 	existing := s.Allocations[newPort]
 	if existing != nil {
 		// Update existing
 		existing.Directory = dir
+		existing.Alias = alias
 		existing.Name = name
 		existing.AssignedAt = now
 		existing.LastUsedAt = now
@@ -441,6 +534,7 @@ func (s *Store) SetAllocationWithPortCheckAndName(dir string, newPort int, proce
 			AssignedAt:  now,
 			LastUsedAt:  now,
 			ProcessName: processName,
+			Alias:       alias,
 		}
 		// Log new allocation
 		if processName != "" {
@@ -500,6 +594,18 @@ func (s *Store) AddAllocationForScan(dir string, port int, processName, containe
 	}
 }
 
+// AddKubeForwardForScan records a kubectl port-forward found by --scan. The directory is
+// the (k8s:CONTEXT/NAMESPACE) placeholder and the name is the forwarded target (e.g., svc/web).
+func (s *Store) AddKubeForwardForScan(port int, context, namespace, target string) {
+	s.AddAllocationForScan(fmt.Sprintf(KubeDirectoryFormat, context, namespace), port, "kubectl", "")
+	s.Allocations[port].Name = target
+}
+
+// IsKubeForward reports whether the allocation was recorded for a kubectl port-forward.
+func (a *Allocation) IsKubeForward() bool {
+	return strings.HasPrefix(a.Directory, "(k8s:")
+}
+
 // SetUnknownPortAllocation adds an allocation for a busy port with unknown ownership.
 func (s *Store) SetUnknownPortAllocation(port int, processName string) {
 	now := time.Now().UTC()
@@ -571,6 +677,7 @@ func (s *Store) RemoveByPort(port int) bool {
 func (s *Store) RemoveAll() int {
 	count := len(s.Allocations)
 	s.Allocations = make(map[int]*AllocationInfo)
+	s.Sticky = nil
 	s.LastIssuedPort = 0
 	if count > 0 {
 		logger.Log(logger.AllocDeleteAll, logger.Field("count", count))
@@ -578,16 +685,17 @@ func (s *Store) RemoveAll() int {
 	return count
 }
 
-// RemoveExpired removes allocations older than the given TTL.
-// Locked allocations are never removed by TTL - they must be explicitly unlocked or forgotten.
+// RemoveExpired removes allocations older than the given TTL and allocations
+// whose lease has run out (independently of the TTL).
+// Locked allocations are never removed - they must be explicitly unlocked or forgotten.
 // Returns the count of removed items.
 func (s *Store) RemoveExpired(ttl time.Duration) int {
+	count := s.removeExpiredLeases(time.Now())
 	if ttl <= 0 {
-		return 0
+		return count
 	}
 
 	cutoff := time.Now().Add(-ttl)
-	count := 0
 
 	for port, info := range s.Allocations {
 		if info == nil {
@@ -598,6 +706,10 @@ func (s *Store) RemoveExpired(ttl time.Duration) int {
 			debug.Printf("allocations", "skipping TTL expiration for locked port %d", port)
 			continue
 		}
+		// A tunnel end lives as long as its tunnel holds the port
+		if info.isTunnelEnd() {
+			continue
+		}
 		// Use LastUsedAt if available, otherwise AssignedAt
 		checkTime := info.LastUsedAt
 		if checkTime.IsZero() {
@@ -648,7 +760,7 @@ func (s *Store) SetLocked(dir string, locked bool) bool {
 				info.LockedAt = time.Now().UTC()
 			}
 			s.Allocations[port] = info
-			logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("locked", locked))
+			logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("dir", info.Directory), logger.Field("locked", locked))
 			return true
 		}
 	}
@@ -663,7 +775,7 @@ func (s *Store) SetLockedByPort(port int, locked bool) bool {
 		if locked {
 			info.LockedAt = time.Now().UTC()
 		}
-		logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("locked", locked))
+		logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("dir", info.Directory), logger.Field("locked", locked))
 		return true
 	}
 	return false
@@ -698,18 +810,30 @@ func (s *Store) GetLockedPortsForExclusion(currentDir string) map[int]bool {
 	return locked
 }
 
+// FreezePolicy returns the freeze period for an allocation with the given directory and name.
+type FreezePolicy func(dir, name string) time.Duration
+
 // GetFrozenPorts returns ports that were recently used (within freeze period).
 // This replaces the history package functionality.
 func (s *Store) GetFrozenPorts(freezePeriod time.Duration) map[int]bool {
-	frozen := make(map[int]bool)
-	if freezePeriod <= 0 {
-		return frozen
-	}
+	return s.GetFrozenPortsWithPolicy(func(string, string) time.Duration {
+		return freezePeriod
+	})
+}
 
-	cutoff := time.Now().Add(-freezePeriod)
+// GetFrozenPortsWithPolicy returns ports that were used within their freeze period,
+// where the period is determined per allocation by policy.
+// Allocations marked NoFreeze are never frozen.
+func (s *Store) GetFrozenPortsWithPolicy(policy FreezePolicy) map[int]bool {
+	frozen := make(map[int]bool)
+	now := time.Now()
 
 	for port, info := range s.Allocations {
-		if info == nil {
+		if info == nil || info.NoFreeze {
+			continue
+		}
+		freezePeriod := policy(info.Directory, info.Name)
+		if freezePeriod <= 0 {
 			continue
 		}
 		// Use LastUsedAt if available, otherwise AssignedAt
@@ -717,7 +841,7 @@ func (s *Store) GetFrozenPorts(freezePeriod time.Duration) map[int]bool {
 		if checkTime.IsZero() {
 			checkTime = info.AssignedAt
 		}
-		if checkTime.After(cutoff) {
+		if checkTime.After(now.Add(-freezePeriod)) {
 			frozen[port] = true
 		}
 	}
@@ -725,6 +849,273 @@ func (s *Store) GetFrozenPorts(freezePeriod time.Duration) map[int]bool {
 	return frozen
 }
 
+// SetNoFreeze marks the allocation for the given port as exempt from (or subject to) the freeze period.
+// Returns true if allocation was found and updated.
+func (s *Store) SetNoFreeze(port int, noFreeze bool) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.NoFreeze == noFreeze {
+		return true
+	}
+	info.NoFreeze = noFreeze
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("no_freeze", noFreeze))
+	return true
+}
+
+// SetHostname records the hostname for the allocation on the given port (empty clears it).
+// Returns true if allocation was found and updated.
+func (s *Store) SetHostname(port int, hostname string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.Hostname == hostname {
+		return true
+	}
+	info.Hostname = hostname
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("hostname", hostname))
+	return true
+}
+
+// SetFirewall records the firewall rule opened for the port (empty clears it).
+// Returns true if allocation was found and updated.
+func (s *Store) SetFirewall(port int, rule string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.Firewall == rule {
+		return true
+	}
+	info.Firewall = rule
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("firewall", rule))
+	return true
+}
+
+// SetContainerID records the container that publishes the port (empty clears it).
+// Returns true if allocation was found and updated.
+func (s *Store) SetContainerID(port int, containerID string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.ContainerID == containerID {
+		return true
+	}
+	info.ContainerID = containerID
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("container", containerID))
+	return true
+}
+
+// SetComposeService records the compose service of the container that publishes the port.
+// Returns true if allocation was found and updated.
+func (s *Store) SetComposeService(port int, service string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.ComposeService == service {
+		return true
+	}
+	info.ComposeService = service
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("service", service))
+	return true
+}
+
+// SetName renames the allocation on the given port, keeping its port, lock and timestamps.
+// Returns true if allocation was found and updated.
+func (s *Store) SetName(port int, name string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	name = normalizeName(name)
+	if info.Name == name {
+		return true
+	}
+	oldName := info.Name
+	info.Name = name
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", name),
+		logger.Field("old_name", oldName))
+	return true
+}
+
+// SetDirectory moves the allocation on the given port to dir, keeping its name, lock and timestamps.
+// Returns true if allocation was found and updated.
+func (s *Store) SetDirectory(port int, dir string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	dir = filepath.Clean(dir)
+	if info.Directory == dir {
+		return true
+	}
+	oldDir := info.Directory
+	info.Directory = dir
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", dir),
+		logger.Field("name", info.Name),
+		logger.Field("old_dir", oldDir))
+	return true
+}
+
+// SwapPorts exchanges the allocations on two ports, so each directory and name
+// continues with its note, labels, timestamps and project block on the other
+// port. Sticky ports follow their owners, so the next request doesn't take the
+// old port back. Firewall rules and tunnels belong to the ports and are
+// dropped; WithStore closes the rules. Returns false if either port has no
+// allocation.
+func (s *Store) SwapPorts(a, b int) bool {
+	infoA, infoB := s.Allocations[a], s.Allocations[b]
+	if infoA == nil || infoB == nil {
+		return false
+	}
+	infoA.Firewall, infoB.Firewall = "", ""
+	infoA.Tunnel, infoB.Tunnel = "", ""
+	s.Allocations[a], s.Allocations[b] = infoB, infoA
+	if ownerA, ownerB := s.Sticky[a], s.Sticky[b]; ownerA != nil || ownerB != nil {
+		// Build a new map: snapshots taken for dry-run share the old one
+		sticky := make(map[int]*StickyOwner, len(s.Sticky)+1)
+		for p, owner := range s.Sticky {
+			if p != a && p != b {
+				sticky[p] = owner
+			}
+		}
+		if ownerA != nil {
+			sticky[b] = ownerA
+		}
+		if ownerB != nil {
+			sticky[a] = ownerB
+		}
+		s.Sticky = sticky
+	}
+	for _, port := range []int{a, b} {
+		info := s.Allocations[port]
+		logger.Log(logger.AllocUpdate,
+			logger.Field("port", port),
+			logger.Field("dir", info.Directory),
+			logger.Field("name", info.Name),
+			logger.Field("swapped", true))
+	}
+	return true
+}
+
+// SetHealthPath records the HTTP health-check path of the allocation on the given port
+// (empty clears it). Returns true if allocation was found and updated.
+func (s *Store) SetHealthPath(port int, path string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.HealthPath == path {
+		return true
+	}
+	info.HealthPath = path
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("health_path", path))
+	return true
+}
+
+// SetNote records a free-text note for the allocation on the given port (empty clears it).
+// Returns true if allocation was found and updated.
+func (s *Store) SetNote(port int, note string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.Note == note {
+		return true
+	}
+	info.Note = note
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("note", note))
+	return true
+}
+
+// FindByHostname returns the allocation with the given hostname, or nil if not found.
+func (s *Store) FindByHostname(hostname string) *Allocation {
+	for port, info := range s.Allocations {
+		if info != nil && info.Hostname != "" && strings.EqualFold(info.Hostname, hostname) {
+			return info.toAllocation(port)
+		}
+	}
+	return nil
+}
+
+// SetAlias sets (or clears, with an empty alias) the alias of all allocations of dir.
+// Returns the number of updated allocations.
+func (s *Store) SetAlias(dir, alias string) int {
+	dir = filepath.Clean(dir)
+	count := 0
+	for port, info := range s.Allocations {
+		if info == nil || info.Directory != dir || info.Alias == alias {
+			continue
+		}
+		info.Alias = alias
+		count++
+		logger.Log(logger.AllocUpdate,
+			logger.Field("port", port),
+			logger.Field("dir", dir),
+			logger.Field("name", info.Name),
+			logger.Field("alias", alias))
+	}
+	return count
+}
+
+// FindDirectoryByAlias returns the directory with the given alias (case-insensitive),
+// or an empty string if no allocation has it.
+func (s *Store) FindDirectoryByAlias(alias string) string {
+	for _, info := range s.Allocations {
+		if info != nil && info.Alias != "" && strings.EqualFold(info.Alias, alias) {
+			return info.Directory
+		}
+	}
+	return ""
+}
+
+// aliasFor returns the alias of dir taken from its other allocations (excluding exceptPort).
+func (s *Store) aliasFor(dir string, exceptPort int) string {
+	for port, info := range s.Allocations {
+		if port != exceptPort && info != nil && info.Directory == dir && info.Alias != "" {
+			return info.Alias
+		}
+	}
+	return ""
+}
+
 // Count returns the number of allocations.
 func (s *Store) Count() int {
 	return len(s.Allocations)
@@ -832,7 +1223,7 @@ func (s *Store) SetLockedByDirectoryAndName(dir string, name string, locked bool
 			if locked {
 				info.LockedAt = time.Now().UTC()
 			}
-			logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("locked", locked), logger.Field("name", name))
+			logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("dir", info.Directory), logger.Field("locked", locked), logger.Field("name", name))
 			return true
 		}
 	}
@@ -854,7 +1245,7 @@ func (s *Store) SetLockedByPortAndName(port int, name string, locked bool) bool
 	if locked {
 		info.LockedAt = time.Now().UTC()
 	}
-	logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("locked", locked), logger.Field("name", name))
+	logger.Log(logger.AllocLock, logger.Field("port", port), logger.Field("dir", info.Directory), logger.Field("locked", locked), logger.Field("name", name))
 	return true
 }
 
@@ -875,6 +1266,7 @@ func (s *Store) UnlockOtherLockedPorts(dir string, name string, exceptPort int)
 			info.Locked = false
 			logger.Log(logger.AllocLock,
 				logger.Field("port", port),
+				logger.Field("dir", info.Directory),
 				logger.Field("locked", false),
 				logger.Field("name", name),
 				logger.Field("reason", "new_lock_for_same_name"))
@@ -939,6 +1331,69 @@ func (s *Store) SetExternalAllocation(port int, pid int, user, processName, cwd
 		logger.Field("action", "create"))
 }
 
+// SetTunnelEnd records port as the remote end of a reverse ssh tunnel from peer
+// (HOST:PORT): an external allocation named name, held by sshd for user and not
+// bound to a directory. Like other external allocations it is removed once the
+// port is free again (--refresh, gc); allocationTTL and the other gc rules
+// leave it alone.
+func (s *Store) SetTunnelEnd(port int, name, peer, user string) {
+	s.SetExternalAllocation(port, 0, user, "sshd", "")
+	info := s.Allocations[port]
+	info.Directory = fmt.Sprintf(UnknownDirectoryFormat, port)
+	info.Name = normalizeName(name)
+	info.Locked, info.LockedAt = false, time.Time{}
+	info.Lease, info.LeaseExpiresAt = 0, time.Time{}
+	info.OwnerPID, info.OwnerStartTime = 0, 0
+	info.Tunnel = peer
+}
+
+// SetTunnel records port as the local end of a reverse ssh tunnel to peer
+// (USER@HOST:PORT): the allocation stays with its directory and name but is held
+// by the ssh client pid for user until EndTunnel.
+func (s *Store) SetTunnel(port int, peer string, pid int, user string) bool {
+	if s.Allocations[port] == nil {
+		return false
+	}
+	s.SetExternalAllocation(port, pid, user, "ssh", "")
+	s.Allocations[port].Tunnel = peer
+	return true
+}
+
+// EndTunnel turns the local end of the tunnel to peer back into a normal
+// allocation. Returns false if port is no longer that tunnel end.
+func (s *Store) EndTunnel(port int, peer string) bool {
+	info := s.Allocations[port]
+	if info == nil || !info.isTunnelEnd() || info.Tunnel != peer {
+		return false
+	}
+	info.Status = StatusNormal
+	info.ExternalPID, info.ExternalUser, info.ExternalProcessName = 0, "", ""
+	info.Tunnel = ""
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("tunnel", ""))
+	return true
+}
+
+// FindTunnelEnd returns the remote end of the tunnel named name, or nil.
+func (s *Store) FindTunnelEnd(name string) *Allocation {
+	name = normalizeName(name)
+	for port, info := range s.Allocations {
+		if info != nil && info.isTunnelEnd() && info.Name == name && info.Directory == fmt.Sprintf(UnknownDirectoryFormat, port) {
+			return info.toAllocation(port)
+		}
+	}
+	return nil
+}
+
+// isTunnelEnd reports whether the allocation is either end of a reverse ssh
+// tunnel (SetTunnel, SetTunnelEnd).
+func (info *AllocationInfo) isTunnelEnd() bool {
+	return info.Status == StatusExternal && info.Tunnel != ""
+}
+
 // RefreshExternalAllocations removes stale external allocations (ports that are now free).
 // Updates LastUsedAt for allocations that are still active.
 // Returns the count of removed allocations and an error if isPortFree is nil.
@@ -1135,6 +1135,79 @@ func TestGetFrozenPorts_ZeroFreezePeriod(t *testing.T) {
 	}
 }
 
+func TestGetFrozenPortsWithPolicy(t *testing.T) {
+	now := time.Now()
+	store := NewStore()
+	store.Allocations[3000] = &AllocationInfo{
+		Directory:  "/home/user/project-a",
+		Name:       "main",
+		LastUsedAt: now.Add(-5 * time.Minute),
+	}
+	store.Allocations[3001] = &AllocationInfo{
+		Directory:  "/home/user/project-a",
+		Name:       "tmp",
+		LastUsedAt: now.Add(-5 * time.Minute),
+	}
+	store.Allocations[3002] = &AllocationInfo{
+		Directory:  "/home/user/project-b",
+		Name:       "main",
+		LastUsedAt: now.Add(-5 * time.Minute),
+		NoFreeze:   true,
+	}
+
+	frozen := store.GetFrozenPortsWithPolicy(func(dir, name string) time.Duration {
+		if name == "tmp" {
+			return 0
+		}
+		return time.Hour
+	})
+
+	if !frozen[3000] {
+		t.Error("port 3000 should be frozen")
+	}
+	if frozen[3001] {
+		t.Error("port 3001 should NOT be frozen (policy returns 0 for tmp)")
+	}
+	if frozen[3002] {
+		t.Error("port 3002 should NOT be frozen (NoFreeze)")
+	}
+}
+
+func TestSetNoFreeze(t *testing.T) {
+	store := NewStore()
+	store.SetAllocation("/project", 3000)
+
+	if !store.SetNoFreeze(3000, true) {
+		t.Fatal("expected SetNoFreeze to succeed")
+	}
+	if alloc := store.FindByPort(3000); !alloc.NoFreeze {
+		t.Error("expected NoFreeze to be set")
+	}
+	if store.SetNoFreeze(3999, true) {
+		t.Error("expected SetNoFreeze to fail for missing allocation")
+	}
+}
+
+func TestSetHostname(t *testing.T) {
+	store := NewStore()
+	store.SetAllocation("/project", 3000)
+
+	if !store.SetHostname(3000, "project.local") {
+		t.Fatal("expected SetHostname to succeed")
+	}
+	if alloc := store.FindByHostname("PROJECT.local"); alloc == nil || alloc.Port != 3000 {
+		t.Errorf("expected case-insensitive lookup to find port 3000, got %+v", alloc)
+	}
+
+	store.SetHostname(3000, "")
+	if alloc := store.FindByHostname("project.local"); alloc != nil {
+		t.Errorf("expected hostname to be cleared, got %+v", alloc)
+	}
+	if store.SetHostname(3999, "x.local") {
+		t.Error("expected SetHostname to fail for missing allocation")
+	}
+}
+
 func TestCount(t *testing.T) {
 	store := NewStore()
 	if store.Count() != 0 {
@@ -1291,6 +1364,62 @@ func TestWithStore_CorruptedFile(t *testing.T) {
 	}
 }
 
+func TestWithStore_AtomicWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, allocationsFileName)
+
+	err := WithStore(tmpDir, func(store *Store) error {
+		store.SetAllocation("/project-a", 3000)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WithStore(tmpDir, func(store *Store) error {
+		store.SetAllocation("/project-b", 3001)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file must be replaced via rename, not rewritten in place
+	if os.SameFile(before, after) {
+		t.Error("expected allocations file to be replaced, not rewritten in place")
+	}
+	if after.Mode().Perm() != 0644 {
+		t.Errorf("expected permissions 0644, got %v", after.Mode().Perm())
+	}
+
+	// No temp files must be left behind
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("unexpected temp file left behind: %s", e.Name())
+		}
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Count() != 2 {
+		t.Errorf("expected 2 allocations, got %d", loaded.Count())
+	}
+}
+
 // Tests for issue #52: Multiple ports allocated to same directory
 
 func TestFindByDirectory_MultiplePortsSelectsMostRecentLastUsedAt(t *testing.T) {
@@ -2761,3 +2890,46 @@ func TestSortedByPort_IncludesExternalFields(t *testing.T) {
 		t.Errorf("expected empty Status for port 3001, got %q", sorted[1].Status)
 	}
 }
+
+func TestSetAlias(t *testing.T) {
+	store := NewStore()
+	store.SetAllocationWithName("/code/shop", 3000, "main")
+	store.SetAllocationWithName("/code/shop", 3001, "api")
+	store.SetAllocationWithName("/code/other", 3002, "main")
+
+	if n := store.SetAlias("/code/shop", "myshop"); n != 2 {
+		t.Errorf("SetAlias() updated %d allocations, want 2", n)
+	}
+	if dir := store.FindDirectoryByAlias("MyShop"); dir != "/code/shop" {
+		t.Errorf("FindDirectoryByAlias() = %q, want /code/shop", dir)
+	}
+
+	// New allocations of the directory inherit the alias
+	store.SetAllocationWithName("/code/shop", 3003, "worker")
+	if a := store.FindByPort(3003); a == nil || a.Alias != "myshop" {
+		t.Errorf("expected new allocation to inherit alias, got %+v", a)
+	}
+	if a := store.FindByPort(3002); a.Alias != "" {
+		t.Errorf("other directory must not get the alias, got %q", a.Alias)
+	}
+
+	store.SetAlias("/code/shop", "")
+	if dir := store.FindDirectoryByAlias("myshop"); dir != "" {
+		t.Errorf("expected alias to be cleared, found %q", dir)
+	}
+}
+
+func TestSetComposeService(t *testing.T) {
+	store := NewStore()
+	store.AddAllocationForScan("/home/user/shop", 3000, "docker-proxy", "0123456789ab")
+
+	if !store.SetComposeService(3000, "web") {
+		t.Fatal("SetComposeService() = false for existing port")
+	}
+	if got := store.FindByPort(3000).ComposeService; got != "web" {
+		t.Errorf("ComposeService = %q, want web", got)
+	}
+	if store.SetComposeService(3001, "db") {
+		t.Error("SetComposeService() = true for missing port")
+	}
+}
@@ -0,0 +1,209 @@
+package allocations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// Backend names accepted by SetBackend and the `store` config option.
+const (
+	BackendYAML   = "yaml"
+	BackendRemote = "remote"
+)
+
+// Backend reads and writes the allocations store in a specific storage format.
+// Locking is handled by WithStore independently of the backend.
+type Backend interface {
+	// Name returns the backend name used in config.
+	Name() string
+	// Path returns the path of the backing file inside configDir.
+	Path(configDir string) string
+	// Read loads the store. Returns an empty store if the backing file doesn't exist.
+	Read(path string) (*Store, error)
+	// Write persists the store so that readers never observe a partial write.
+	Write(path string, store *Store) error
+}
+
+var (
+	backend   Backend = yamlBackend{}
+	storeFile string  // overrides the backend's file inside configDir (--store)
+	remoteURL string  // URL of the remote store (remoteURL config option)
+	backendMu sync.Mutex
+)
+
+// SetBackend selects the storage backend by name. Empty name selects YAML.
+func SetBackend(name string) error {
+	b, err := NewBackend(name)
+	if err != nil {
+		return err
+	}
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backend = b
+	debug.Printf("allocations", "using %s backend", b.Name())
+	return nil
+}
+
+// NewBackend returns the backend with the given name. Empty name selects YAML.
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendYAML:
+		return yamlBackend{}, nil
+	case BackendRemote:
+		backendMu.Lock()
+		url := remoteURL
+		backendMu.Unlock()
+		if url == "" {
+			return nil, fmt.Errorf("store %s requires remoteURL in config", BackendRemote)
+		}
+		return remoteBackend{url: url}, nil
+	default:
+		return nil, fmt.Errorf("unknown store backend %q (use %s or %s)", name, BackendYAML, BackendRemote)
+	}
+}
+
+// SetRemoteURL sets the URL used by the remote backend. Call it before SetBackend.
+func SetRemoteURL(url string) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	remoteURL = url
+}
+
+// SetStoreFile makes the store read and write the allocations at path instead of
+// the backend's default file in the config directory. Empty path restores the default.
+// The lock file and the undo journal are kept next to path.
+func SetStoreFile(path string) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	storeFile = path
+	if path != "" {
+		debug.Printf("allocations", "using store file %s", path)
+	}
+}
+
+// storePath returns the allocations file used by backend b.
+func storePath(b Backend, configDir string) string {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	if storeFile != "" {
+		return storeFile
+	}
+	return b.Path(hostDir(stateRoot(configDir), storeHost))
+}
+
+// sidecarPath returns the path of a helper file (lock, journal) stored next to the
+// allocations: in configDir (or the state dir), or next to the --store file as <file>.<suffix>.
+func sidecarPath(configDir, name, suffix string) string {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	if storeFile != "" {
+		return storeFile + "." + suffix
+	}
+	return filepath.Join(hostDir(stateRoot(configDir), storeHost), name)
+}
+
+// currentBackend returns the selected backend.
+func currentBackend() Backend {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return backend
+}
+
+// Convert copies all allocations from one backend to another inside configDir.
+// The target file is overwritten. Returns the number of converted allocations.
+func Convert(configDir string, from, to Backend) (int, error) {
+	if err := checkWritable("convert the store"); err != nil {
+		return 0, err
+	}
+	fl, err := openAndLock(configDir)
+	if err != nil {
+		return 0, err
+	}
+	defer fl.unlock()
+
+	dir := StoreDir(configDir)
+	store, err := from.Read(from.Path(dir))
+	if err != nil {
+		return 0, err
+	}
+	// The snapshot belongs to the source; write the target completely
+	store.loaded = nil
+	if err := to.Write(to.Path(dir), store); err != nil {
+		return 0, err
+	}
+	debug.Printf("allocations", "converted %d allocations from %s to %s", len(store.Allocations), from.Name(), to.Name())
+	return len(store.Allocations), nil
+}
+
+// yamlBackend stores allocations in allocations.yaml (default). Each write adds
+// a checksum header that Read verifies, see verifyChecksum.
+type yamlBackend struct{}
+
+func (yamlBackend) Name() string { return BackendYAML }
+
+func (yamlBackend) Path(configDir string) string {
+	return filepath.Join(configDir, allocationsFileName)
+}
+
+func (yamlBackend) Read(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			debug.Printf("allocations", "file does not exist, returning empty store")
+			return NewStore(), nil
+		}
+		debug.Printf("allocations", "failed to read file: %v", err)
+		return nil, fmt.Errorf("cannot read allocations file: %w", err)
+	}
+
+	// Empty file - return new store
+	if len(data) == 0 {
+		debug.Printf("allocations", "file is empty, returning new store")
+		return NewStore(), nil
+	}
+
+	body, result, reason := verifyChecksum(data)
+	if result == checksumTruncated {
+		debug.Printf("allocations", "checksum: %s", reason)
+		return nil, fmt.Errorf("%w: %s", ErrChecksum, reason)
+	}
+
+	var store Store
+	if result == checksumEdited {
+		// A hand edit leaves a well-formed store; anything else (a bit flip, a
+		// partial overwrite) is damage and falls back to a backup
+		if err := decodeEdited(body, &store); err != nil {
+			debug.Printf("allocations", "checksum: %s: %v", reason, err)
+			return nil, fmt.Errorf("%w: %s: %v", ErrChecksum, reason, err)
+		}
+		i18n.Fprintf(os.Stderr, "warning: %s: %s; accepting it as a manual edit\n", path, i18n.Message(reason))
+	} else if err := yaml.Unmarshal(body, &store); err != nil {
+		debug.Printf("allocations", "YAML parse error: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	store.normalize()
+
+	debug.Printf("allocations", "loaded %d allocations, last_issued_port=%d",
+		len(store.Allocations), store.LastIssuedPort)
+	return &store, nil
+}
+
+func (yamlBackend) Write(path string, store *Store) error {
+	data, err := yaml.Marshal(store)
+	if err != nil {
+		return fmt.Errorf("failed to marshal store: %w", err)
+	}
+
+	if err := writeFileAtomic(path, addChecksum(data)); err != nil {
+		return err
+	}
+
+	debug.Printf("allocations", "saved %d allocations", len(store.Allocations))
+	return nil
+}
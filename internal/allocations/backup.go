@@ -0,0 +1,248 @@
+package allocations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// backupSuffix is appended to the store file name to form the backup path.
+// Rotated backups add a number: allocations.yaml.bak.1 is the most recent.
+const backupSuffix = ".bak"
+
+// backupCount is the number of rotated backups kept by WithStore (0 disables).
+var backupCount int
+
+// SetBackupCount makes WithStore keep the last n versions of the store file as
+// <store>.bak.1 (most recent) to <store>.bak.n, taken before each change. 0 disables.
+func SetBackupCount(n int) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backupCount = n
+}
+
+// currentBackupCount returns the number of rotated backups to keep.
+func currentBackupCount() int {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return backupCount
+}
+
+// BackupInfo describes a backup file of the store.
+type BackupInfo struct {
+	Path    string
+	Index   int // 1 for the most recent rotated backup, 0 for the <store>.bak copy
+	ModTime time.Time
+}
+
+// Backup copies the current store file to <store>.bak, overwriting the previous
+// backup, and returns the backup path. Call it inside WithStore before a
+// destructive change. Returns an empty path if there is nothing to back up
+// or in dry-run mode.
+func Backup(configDir string) (string, error) {
+	if IsDryRun() || IsReadOnly() {
+		return "", nil
+	}
+	path := storePath(currentBackend(), configDir)
+	backupPath := path + backupSuffix
+	if err := copyStoreFile(path, backupPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	debug.Printf("allocations", "backed up store to %s", backupPath)
+	return backupPath, nil
+}
+
+// copyStoreFile atomically copies the store file at src to dst.
+func copyStoreFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, data)
+}
+
+// rotateBackups shifts <path>.bak.i to <path>.bak.i+1, drops backups beyond n
+// and copies path to <path>.bak.1. A missing store file is not an error.
+func rotateBackups(path string, n int) error {
+	existing, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	// Shift from the oldest, so nothing is overwritten
+	for i := len(existing) - 1; i >= 0; i-- {
+		b := existing[i]
+		if b.Index == 0 {
+			continue
+		}
+		if b.Index >= n {
+			if err := os.Remove(b.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(b.Path, rotatedBackupPath(path, b.Index+1)); err != nil {
+			return err
+		}
+	}
+
+	if err := copyStoreFile(path, rotatedBackupPath(path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	debug.Printf("allocations", "rotated backups of %s (keeping %d)", path, n)
+	return nil
+}
+
+// rotatedBackupPath returns <path>.bak.<i>.
+func rotatedBackupPath(path string, i int) string {
+	return path + backupSuffix + "." + strconv.Itoa(i)
+}
+
+// listBackups returns the backups of the store file at path: <path>.bak first,
+// then <path>.bak.1, <path>.bak.2, ... in order.
+func listBackups(path string) ([]BackupInfo, error) {
+	matches, err := filepath.Glob(path + backupSuffix + "*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []BackupInfo
+	for _, m := range matches {
+		index := 0
+		if rest := strings.TrimPrefix(m, path+backupSuffix); rest != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(rest, "."))
+			if err != nil || n < 1 || !strings.HasPrefix(rest, ".") {
+				continue // e.g. a temp file of writeFileAtomic
+			}
+			index = n
+		}
+		fi, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupInfo{Path: m, Index: index, ModTime: fi.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Index < backups[j].Index })
+	return backups, nil
+}
+
+// Backups returns the existing backups of the store in configDir.
+func Backups(configDir string) ([]BackupInfo, error) {
+	return listBackups(storePath(currentBackend(), configDir))
+}
+
+// ReadBackup reads a backup file with the current backend.
+func ReadBackup(path string) (*Store, error) {
+	return currentBackend().Read(path)
+}
+
+// BackupPath resolves a backup reference: a number selects <store>.bak.N,
+// anything else is a file path.
+func BackupPath(configDir, ref string) string {
+	if n, err := strconv.Atoi(ref); err == nil {
+		return rotatedBackupPath(storePath(currentBackend(), configDir), n)
+	}
+	return ref
+}
+
+// Restore replaces the store in configDir with the backup at from and returns
+// the restored store. It works even if the current store is corrupted. With
+// backups enabled, the replaced store becomes the newest backup.
+func Restore(configDir, from string) (*Store, error) {
+	if err := checkWritable("restore the store"); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(from); err != nil {
+		return nil, fmt.Errorf("cannot read backup: %w", err)
+	}
+
+	b := currentBackend()
+	restored, err := b.Read(from)
+	if err != nil {
+		return nil, fmt.Errorf("cannot restore from %s: %w", from, err)
+	}
+	// The snapshot belongs to the backup file; rewrite the store completely
+	restored.loaded = nil
+
+	var orphaned []Allocation
+	defer func() { closeFirewallRules(orphaned) }()
+
+	fl, err := openAndLock(configDir)
+	if err != nil {
+		return nil, err
+	}
+	defer fl.unlock()
+
+	path := storePath(b, configDir)
+	current, err := b.Read(path)
+	if err != nil && !errors.Is(err, ErrCorrupted) {
+		return nil, err
+	}
+	var before map[int]*AllocationInfo
+	var rules map[int]AllocationInfo
+	if current != nil {
+		before = current.Allocations
+		rules = current.firewallRules()
+	}
+	restored.keepFirewallRules(rules)
+
+	if IsDryRun() {
+		writeDiff(dryRunOutput, before, restored.Allocations)
+		orphaned = restored.orphanedRules(rules)
+		return restored, nil
+	}
+
+	if n := currentBackupCount(); n > 0 {
+		if err := rotateBackups(path, n); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.Write(path, restored); err != nil {
+		return nil, err
+	}
+	orphaned = restored.orphanedRules(rules)
+
+	logger.Log(logger.AllocRestore, logger.Field("from", from), logger.Field("count", len(restored.Allocations)))
+	return restored, nil
+}
+
+// readVerified reads the store at path. If the file fails checksum verification
+// (see ErrChecksum), it falls back to the most recent backup that reads cleanly,
+// prints a warning and reports recovered. Without a usable backup the
+// verification error is returned.
+func readVerified(b Backend, path string) (store *Store, recovered bool, err error) {
+	store, err = b.Read(path)
+	if err == nil || !errors.Is(err, ErrChecksum) {
+		return store, false, err
+	}
+
+	backups, listErr := listBackups(path)
+	if listErr != nil {
+		return nil, false, err
+	}
+	sort.SliceStable(backups, func(i, j int) bool { return backups[i].ModTime.After(backups[j].ModTime) })
+	for _, backup := range backups {
+		fromBackup, readErr := b.Read(backup.Path)
+		if readErr != nil {
+			debug.Printf("allocations", "skipping backup %s: %v", backup.Path, readErr)
+			continue
+		}
+		// The snapshot belongs to the backup file; rewrite the store completely
+		fromBackup.loaded = nil
+		i18n.Fprintf(os.Stderr, "WARNING: %v\n", err)
+		i18n.Fprintf(os.Stderr, "         File: %s\n", path)
+		i18n.Fprintf(os.Stderr, "         Using backup %s\n", backup.Path)
+		return fromBackup, true, nil
+	}
+	return nil, false, err
+}
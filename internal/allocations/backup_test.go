@@ -0,0 +1,134 @@
+package allocations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	configDir := t.TempDir()
+
+	path, err := Backup(configDir)
+	if err != nil || path != "" {
+		t.Fatalf("Backup() without a store = %q, %v; want empty path", path, err)
+	}
+
+	store := NewStore()
+	store.SetAllocation("/home/user/a", 3000)
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err = Backup(configDir)
+	if err != nil {
+		t.Fatalf("Backup() error: %v", err)
+	}
+	if want := filepath.Join(configDir, allocationsFileName+backupSuffix); path != want {
+		t.Errorf("Backup() path = %q, want %q", path, want)
+	}
+
+	original, _ := os.ReadFile(filepath.Join(configDir, allocationsFileName))
+	backup, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("backup content differs from store:\n%s\nvs\n%s", backup, original)
+	}
+}
+
+func TestWithStore_RotatesBackups(t *testing.T) {
+	configDir := t.TempDir()
+	SetBackupCount(2)
+	t.Cleanup(func() { SetBackupCount(0) })
+
+	for port := 3000; port < 3004; port++ {
+		err := WithStore(configDir, func(store *Store) error {
+			store.SetAllocationWithName("/home/user/a", port, fmt.Sprint(port))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No change: no new backup
+	if err := WithStore(configDir, func(*Store) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := Backups(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[0].Index != 1 || backups[1].Index != 2 {
+		t.Fatalf("expected backups 1 and 2, got %+v", backups)
+	}
+
+	// .bak.1 holds the state before the last change (3 allocations)
+	for i, want := range []int{3, 2} {
+		store, err := ReadBackup(backups[i].Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if store.Count() != want {
+			t.Errorf("backup %d has %d allocations, want %d", backups[i].Index, store.Count(), want)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	configDir := t.TempDir()
+	SetBackupCount(3)
+	t.Cleanup(func() { SetBackupCount(0) })
+
+	store := NewStore()
+	store.SetAllocation("/home/user/a", 3000)
+	store.SetAllocation("/home/user/b", 3001)
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithStore(configDir, func(store *Store) error {
+		store.RemoveAll()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A corrupted store must not prevent restoring
+	storeFile := filepath.Join(configDir, allocationsFileName)
+	if err := os.WriteFile(storeFile, []byte("allocations: [broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Restore(configDir, BackupPath(configDir, "1"))
+	if err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if restored.Count() != 2 {
+		t.Errorf("restored %d allocations, want 2", restored.Count())
+	}
+
+	loaded, err := Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FindByPort(3000) == nil || loaded.FindByPort(3001) == nil {
+		t.Errorf("expected ports 3000 and 3001 after restore, got %d allocations", loaded.Count())
+	}
+
+	// The corrupted store was kept as the newest backup
+	data, err := os.ReadFile(BackupPath(configDir, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "allocations: [broken" {
+		t.Errorf("expected corrupted store in backup 1, got %q", data)
+	}
+
+	if _, err := Restore(configDir, BackupPath(configDir, "9")); err == nil {
+		t.Error("expected error for missing backup")
+	}
+}
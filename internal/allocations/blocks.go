@@ -0,0 +1,56 @@
+package allocations
+
+import (
+	"path/filepath"
+
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// BlockFor returns the port block recorded for dir's allocations, or (0, 0) if none.
+func (s *Store) BlockFor(dir string) (start, end int) {
+	dir = filepath.Clean(dir)
+	for _, info := range s.Allocations {
+		if info != nil && info.Directory == dir && info.BlockEnd > 0 {
+			return info.BlockStart, info.BlockEnd
+		}
+	}
+	return 0, 0
+}
+
+// SetBlock records the contiguous block of the project the allocation on port belongs to.
+// Returns true if allocation was found and updated.
+func (s *Store) SetBlock(port, start, end int) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if info.BlockStart == start && info.BlockEnd == end {
+		return true
+	}
+	info.BlockStart, info.BlockEnd = start, end
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("block_start", start),
+		logger.Field("block_end", end))
+	return true
+}
+
+// ReservedBlockPorts returns the unallocated ports inside blocks of directories other than dir.
+// Other allocations avoid them while the rest of the range has free ports.
+func (s *Store) ReservedBlockPorts(dir string) map[int]bool {
+	dir = filepath.Clean(dir)
+	reserved := make(map[int]bool)
+	for _, info := range s.Allocations {
+		if info == nil || info.BlockEnd == 0 || info.Directory == dir {
+			continue
+		}
+		for p := info.BlockStart; p <= info.BlockEnd; p++ {
+			if s.Allocations[p] == nil {
+				reserved[p] = true
+			}
+		}
+	}
+	return reserved
+}
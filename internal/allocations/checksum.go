@@ -0,0 +1,100 @@
+package allocations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checksumPrefix starts the header line written above the YAML store. The
+// header is a YAML comment, so older versions and editors read the file as usual:
+//
+//	# port-selector checksum: length=1234 sha256=9f86d0...
+const checksumPrefix = "# port-selector checksum: "
+
+// ErrChecksum is returned when the store file doesn't match its checksum header
+// and can't be used as is. It wraps ErrCorrupted.
+var ErrChecksum = fmt.Errorf("%w: checksum verification failed", ErrCorrupted)
+
+// checksumResult is the outcome of verifying a store file against its header.
+type checksumResult int
+
+const (
+	checksumMissing   checksumResult = iota // no header: written by an older version or by hand
+	checksumValid                           // the body matches the header
+	checksumTruncated                       // the body is a cut-off prefix, e.g. after a crash
+	checksumEdited                          // the body was changed after it was written
+)
+
+// addChecksum prepends a header with the length and SHA-256 of data.
+func addChecksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	header := fmt.Sprintf("%slength=%d sha256=%s\n", checksumPrefix, len(data), hex.EncodeToString(sum[:]))
+	return append([]byte(header), data...)
+}
+
+// verifyChecksum splits the header off data and checks the body against it.
+// A body shorter than recorded that doesn't end with a newline is reported as
+// truncated: writers always end the file with one, editors almost always keep
+// it. Any other mismatch is treated as a manual edit.
+func verifyChecksum(data []byte) ([]byte, checksumResult, string) {
+	if !bytes.HasPrefix(data, []byte(checksumPrefix)) {
+		return data, checksumMissing, ""
+	}
+	header, body, found := bytes.Cut(data, []byte("\n"))
+	if !found {
+		return nil, checksumTruncated, "file ends inside the checksum header"
+	}
+
+	var length int
+	var sum string
+	for _, field := range bytes.Fields(header[len(checksumPrefix):]) {
+		key, value, _ := bytes.Cut(field, []byte("="))
+		switch string(key) {
+		case "length":
+			length, _ = strconv.Atoi(string(value))
+		case "sha256":
+			sum = string(value)
+		}
+	}
+
+	actual := sha256.Sum256(body)
+	switch {
+	case len(body) == length && hex.EncodeToString(actual[:]) == sum:
+		return body, checksumValid, ""
+	case len(body) < length && !bytes.HasSuffix(body, []byte("\n")):
+		return body, checksumTruncated, fmt.Sprintf("file has %d of %d bytes, probably truncated by a crash", len(body), length)
+	default:
+		return body, checksumEdited, "file was changed after it was written"
+	}
+}
+
+// decodeEdited decodes a store body that doesn't match its checksum. It is
+// stricter than a normal read: unknown keys and allocations without a valid
+// port or an absolute directory are rejected, as a damaged file rarely keeps
+// all of them intact. An external allocation may keep the placeholder of an
+// unknown directory instead.
+func decodeEdited(body []byte, store *Store) error {
+	dec := yaml.NewDecoder(bytes.NewReader(body))
+	dec.KnownFields(true)
+	if err := dec.Decode(store); err != nil {
+		return err
+	}
+	for port, info := range store.Allocations {
+		switch {
+		case port < 1 || port > 65535:
+			return fmt.Errorf("invalid port %d", port)
+		case info == nil:
+			return fmt.Errorf("port %d has no valid directory", port)
+		case info.Status == StatusExternal && info.Directory == fmt.Sprintf(UnknownDirectoryFormat, port):
+		case !filepath.IsAbs(info.Directory):
+			return fmt.Errorf("port %d has no valid directory", port)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package allocations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	body := []byte("allocations:\n  3000:\n    directory: /home/user/a\nlast_issued_port: 3000\n")
+	data := addChecksum(body)
+
+	tests := []struct {
+		name string
+		data []byte
+		want checksumResult
+	}{
+		{"valid", data, checksumValid},
+		{"no header", body, checksumMissing},
+		{"truncated", data[:len(data)-10], checksumTruncated},
+		{"truncated header", data[:40], checksumTruncated},
+		{"edited", []byte(strings.Replace(string(data), "/home/user/a", "/home/user/b", 1)), checksumEdited},
+		{"lines removed", []byte(strings.Replace(string(data), "last_issued_port: 3000\n", "", 1)), checksumEdited},
+		{"lines added", append(append([]byte{}, data...), "# note\n"...), checksumEdited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, _ := verifyChecksum(tt.data)
+			if got != tt.want {
+				t.Errorf("verifyChecksum() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLBackend_Checksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), allocationsFileName)
+	store := NewStore()
+	store.SetAllocation("/home/user/a", 3000)
+	store.SetExternalAllocation(3005, 0, "", "python", "")
+	if err := (yamlBackend{}).Write(path, store); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), checksumPrefix) {
+		t.Fatalf("store has no checksum header:\n%s", data)
+	}
+
+	// Hand edits are accepted
+	edited := strings.Replace(string(data), "/home/user/a", "/home/user/b", 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := (yamlBackend{}).Read(path)
+	if err != nil {
+		t.Fatalf("Read() of an edited store: %v", err)
+	}
+	if info := loaded.Allocations[3000]; info == nil || info.Directory != "/home/user/b" {
+		t.Errorf("edited allocation = %+v", info)
+	}
+	if info := loaded.Allocations[3005]; info == nil || info.Status != StatusExternal {
+		t.Errorf("external allocation of an unknown directory after an edit = %+v", info)
+	}
+
+	// Damage that doesn't look like an edit fails verification
+	for _, damaged := range []string{
+		strings.Replace(string(data), "directory:", "directorx:", 1),
+		strings.Replace(string(data), "/home/user/a", "home/user/a", 1),
+		strings.Replace(string(data), "3000:", "0:", 1),
+	} {
+		if err := os.WriteFile(path, []byte(damaged), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (yamlBackend{}).Read(path); !errors.Is(err, ErrChecksum) {
+			t.Errorf("Read() of a damaged store = %v, want ErrChecksum:\n%s", err, damaged)
+		}
+	}
+
+	// A cut-off file fails verification
+	if err := os.WriteFile(path, data[:len(data)-5], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (yamlBackend{}).Read(path); !errors.Is(err, ErrChecksum) || !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Read() of a truncated store = %v, want ErrChecksum", err)
+	}
+}
+
+func TestWithStore_FallsBackToBackup(t *testing.T) {
+	configDir := t.TempDir()
+	SetBackupCount(2)
+	t.Cleanup(func() { SetBackupCount(0) })
+
+	for port := 3000; port < 3003; port++ {
+		err := WithStore(configDir, func(store *Store) error {
+			store.SetAllocationWithName("/home/user/a", port, fmt.Sprint(port))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(configDir, allocationsFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load uses the most recent backup: the state before the last change
+	store, err := Load(configDir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if store.Count() != 2 {
+		t.Errorf("Load() returned %d allocations, want 2", store.Count())
+	}
+
+	err = WithStore(configDir, func(store *Store) error {
+		store.SetAllocationWithName("/home/user/b", 4000, "main")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithStore() error: %v", err)
+	}
+
+	store, err = (yamlBackend{}).Read(path)
+	if err != nil {
+		t.Fatalf("store is still damaged: %v", err)
+	}
+	if store.Count() != 3 || store.Allocations[4000] == nil {
+		t.Errorf("store after recovery = %+v", store.Allocations)
+	}
+	quarantined, _ := filepath.Glob(path + ".corrupt-*")
+	if len(quarantined) != 1 {
+		t.Errorf("expected the damaged file to be kept, got %v", quarantined)
+	}
+	if backups, _ := Backups(configDir); len(backups) != 2 {
+		t.Errorf("backups after recovery = %+v, want 2", backups)
+	}
+}
+
+func TestWithStore_ChecksumWithoutBackup(t *testing.T) {
+	configDir := t.TempDir()
+	if err := WithStore(configDir, func(store *Store) error {
+		store.SetAllocation("/home/user/a", 3000)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(configDir, allocationsFileName)
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)-3], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(configDir); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Load() = %v, want ErrChecksum", err)
+	}
+}
@@ -0,0 +1,42 @@
+package allocations
+
+// Change describes how one port differs between two versions of the store.
+type Change struct {
+	Port   int
+	Before *AllocationInfo // nil if the port was added
+	After  *AllocationInfo // nil if the port was removed
+	Fields []string        // "field: old -> new" for updated entries
+}
+
+// Diff returns the changes between two versions of the store, ordered by port.
+// Updates without visible field changes (sub-second timestamps) are skipped.
+func Diff(before, after *Store) []Change {
+	var b, a map[int]*AllocationInfo
+	if before != nil {
+		b = before.Allocations
+	}
+	if after != nil {
+		a = after.Allocations
+	}
+	e := diffAllocations(b, a)
+	if e == nil {
+		return nil
+	}
+
+	var changes []Change
+	for _, port := range e.Ports() {
+		c := Change{Port: port, Before: e.Before[port], After: e.After[port]}
+		if c.Before != nil && c.After != nil {
+			if c.Fields = changedFields(c.Before, c.After); len(c.Fields) == 0 {
+				continue
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// StorePath returns the allocations file used for configDir by the current backend.
+func StorePath(configDir string) string {
+	return storePath(currentBackend(), configDir)
+}
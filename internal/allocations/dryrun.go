@@ -0,0 +1,94 @@
+package allocations
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapi/port-selector/internal/pathutil"
+)
+
+// dryRun makes WithStore report changes instead of writing them.
+var dryRun atomic.Bool
+
+// dryRunOutput receives the change report in dry-run mode.
+var dryRunOutput io.Writer = os.Stderr
+
+// SetDryRun enables or disables dry-run mode. In dry-run mode WithStore prints
+// the changes made by fn and leaves the store, the undo journal and legacy files untouched.
+func SetDryRun(v bool) {
+	dryRun.Store(v)
+}
+
+// IsDryRun returns true if dry-run mode is enabled.
+func IsDryRun() bool {
+	return dryRun.Load()
+}
+
+// writeDiff prints the difference between two allocation maps in diff style:
+// "+" for added, "-" for removed and "~" for changed entries (with the changed fields).
+func writeDiff(w io.Writer, before, after map[int]*AllocationInfo) {
+	e := diffAllocations(before, after)
+	if e == nil {
+		fmt.Fprintln(w, "dry-run: no changes")
+		return
+	}
+
+	fmt.Fprintf(w, "dry-run: would change %d allocation(s):\n", len(e.Before))
+	for _, port := range e.Ports() {
+		old, cur := e.Before[port], e.After[port]
+		switch {
+		case old == nil:
+			fmt.Fprintf(w, "+ %d %s\n", port, describeInfo(cur))
+		case cur == nil:
+			fmt.Fprintf(w, "- %d %s\n", port, describeInfo(old))
+		default:
+			// Sub-second timestamp updates produce no visible field changes
+			if changes := changedFields(old, cur); len(changes) > 0 {
+				fmt.Fprintf(w, "~ %d %s: %s\n", port, describeInfo(cur), strings.Join(changes, ", "))
+			} else {
+				fmt.Fprintf(w, "~ %d %s\n", port, describeInfo(cur))
+			}
+		}
+	}
+}
+
+// describeInfo returns "<dir> ('<name>')" for an allocation entry.
+func describeInfo(info *AllocationInfo) string {
+	return fmt.Sprintf("%s ('%s')", pathutil.ShortenHomePath(info.Directory), info.Name)
+}
+
+// changedFields lists fields that differ between old and cur as "field: old -> new",
+// using the YAML field names.
+func changedFields(old, cur *AllocationInfo) []string {
+	var changes []string
+	ov, cv := reflect.ValueOf(*old), reflect.ValueOf(*cur)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		a, b := formatField(ov.Field(i)), formatField(cv.Field(i))
+		if a == b {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if ov.Field(i).IsZero() {
+			a = "-"
+		}
+		if cv.Field(i).IsZero() {
+			b = "-"
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, a, b))
+	}
+	return changes
+}
+
+// formatField formats a field value for the change report.
+func formatField(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Local().Format(time.RFC3339)
+	}
+	return fmt.Sprint(v.Interface())
+}
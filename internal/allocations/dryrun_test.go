@@ -0,0 +1,75 @@
+package allocations
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteDiff(t *testing.T) {
+	before := map[int]*AllocationInfo{
+		3000: {Directory: "/a", Name: "main"},
+		3001: {Directory: "/b", Name: "web"},
+	}
+	after := map[int]*AllocationInfo{
+		3000: {Directory: "/a", Name: "main", Locked: true},
+		3002: {Directory: "/c", Name: "main"},
+	}
+
+	var buf bytes.Buffer
+	writeDiff(&buf, before, after)
+	want := `dry-run: would change 3 allocation(s):
+~ 3000 /a ('main'): locked: - -> true
+- 3001 /b ('web')
++ 3002 /c ('main')
+`
+	if buf.String() != want {
+		t.Errorf("writeDiff() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	writeDiff(&buf, before, before)
+	if !strings.Contains(buf.String(), "no changes") {
+		t.Errorf("expected no changes, got %q", buf.String())
+	}
+}
+
+func TestWithStore_DryRun(t *testing.T) {
+	configDir := t.TempDir()
+	store := NewStore()
+	store.SetAllocation("/project", 3000)
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	dryRunOutput = &buf
+	SetDryRun(true)
+	t.Cleanup(func() {
+		SetDryRun(false)
+		dryRunOutput = os.Stderr
+	})
+
+	err := WithJournal(configDir, "--forget-all", func(s *Store) error {
+		s.RemoveAll()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "- 3000 /project ('main')") {
+		t.Errorf("expected removal in dry-run report, got %q", buf.String())
+	}
+
+	loaded, err := Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FindByPort(3000) == nil {
+		t.Error("dry run must not modify the store")
+	}
+	if entries, _ := Journal(configDir); len(entries) != 0 {
+		t.Error("dry run must not write the undo journal")
+	}
+}
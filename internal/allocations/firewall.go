@@ -0,0 +1,74 @@
+package allocations
+
+import "sort"
+
+// firewallCloser deletes the firewall rules of removed allocations, guarded by backendMu.
+var firewallCloser func([]Allocation)
+
+// SetFirewallCloser sets the function that deletes the firewall rules of
+// allocations that lost their port: removed, or with the port passed to another
+// directory or name. WithStore calls it after the store is written (in dry-run
+// mode, after the diff is printed), whichever command removed them.
+func SetFirewallCloser(closer func([]Allocation)) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	firewallCloser = closer
+}
+
+// currentFirewallCloser returns the function set by SetFirewallCloser.
+func currentFirewallCloser() func([]Allocation) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return firewallCloser
+}
+
+// firewallRules returns copies of the allocations that have a firewall rule.
+func (s *Store) firewallRules() map[int]AllocationInfo {
+	var rules map[int]AllocationInfo
+	for port, info := range s.Allocations {
+		if info != nil && info.Firewall != "" {
+			if rules == nil {
+				rules = make(map[int]AllocationInfo)
+			}
+			rules[port] = *info
+		}
+	}
+	return rules
+}
+
+// orphanedRules returns the allocations from rules whose port no longer
+// belongs to them in s, ordered by port.
+func (s *Store) orphanedRules(rules map[int]AllocationInfo) []Allocation {
+	var orphaned []Allocation
+	for port, info := range rules {
+		cur := s.Allocations[port]
+		if cur != nil && cur.Directory == info.Directory && cur.Name == info.Name {
+			continue
+		}
+		orphaned = append(orphaned, *info.toAllocation(port))
+	}
+	sort.Slice(orphaned, func(i, j int) bool { return orphaned[i].Port < orphaned[j].Port })
+	return orphaned
+}
+
+// keepFirewallRules sets the recorded rules of s, read from elsewhere (a backup,
+// the undo journal), to the rules open now: a port keeps its rule only while it
+// belongs to the same directory and name.
+func (s *Store) keepFirewallRules(rules map[int]AllocationInfo) {
+	for port, info := range s.Allocations {
+		if info == nil {
+			continue
+		}
+		info.Firewall = ""
+		if r, ok := rules[port]; ok && r.Directory == info.Directory && r.Name == info.Name {
+			info.Firewall = r.Firewall
+		}
+	}
+}
+
+// closeFirewallRules passes allocs to the firewall closer, if any.
+func closeFirewallRules(allocs []Allocation) {
+	if closer := currentFirewallCloser(); closer != nil && len(allocs) > 0 {
+		closer(allocs)
+	}
+}
@@ -0,0 +1,70 @@
+package allocations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithStore_ClosesOrphanedFirewallRules(t *testing.T) {
+	var closed []int
+	SetFirewallCloser(func(removed []Allocation) {
+		for _, a := range removed {
+			closed = append(closed, a.Port)
+		}
+	})
+	t.Cleanup(func() { SetFirewallCloser(nil) })
+
+	configDir := t.TempDir()
+	if err := WithStore(configDir, func(s *Store) error {
+		for port, dir := range map[int]string{3000: "/a", 3001: "/b", 3002: "/c"} {
+			s.SetAllocation(dir, port)
+			s.SetFirewall(port, "ufw:192.168.1.0/24")
+		}
+		s.SetLockedByPort(3002, true)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if closed != nil {
+		t.Fatalf("opening rules closed %v", closed)
+	}
+
+	// Changing an allocation keeps its rule, removing it closes the rule
+	if err := WithJournal(configDir, "--forget", func(s *Store) error {
+		s.SetLockedByPort(3002, false)
+		s.RemoveByPort(3000)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(closed, []int{3000}) {
+		t.Errorf("closed after --forget = %v, want [3000]", closed)
+	}
+
+	// Undo restores the allocation without the closed rule
+	if _, err := Undo(configDir, false); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := store.Allocations[3000]; info == nil || info.Firewall != "" {
+		t.Errorf("undone allocation = %+v, want it without a rule", info)
+	}
+	if info := store.Allocations[3002]; info == nil || info.Firewall == "" {
+		t.Errorf("allocation changed by the undone operation lost its rule: %+v", info)
+	}
+
+	// Ports passed to another directory close their rules
+	closed = nil
+	if err := WithStore(configDir, func(s *Store) error {
+		s.SwapPorts(3001, 3002)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(closed, []int{3001, 3002}) {
+		t.Errorf("closed after swap = %v, want [3001 3002]", closed)
+	}
+}
@@ -0,0 +1,145 @@
+package allocations
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// Reasons reported for allocations collected by garbage collection.
+const (
+	GCReasonExpired          = "expired"
+	GCReasonLeaseExpired     = "lease_expired"
+	GCReasonOwnerExited      = "owner_exited"
+	GCReasonStaleExternal    = "stale_external"
+	GCReasonMissingDirectory = "missing_directory"
+)
+
+// GCOptions controls which allocations are considered garbage.
+type GCOptions struct {
+	TTL        time.Duration         // Expire unlocked allocations unused for longer than this (0 = disabled)
+	IsPortFree PortChecker           // Remove external allocations whose port is free (nil = skip)
+	DirExists  func(dir string) bool // Remove allocations whose directory is gone (nil = skip)
+	// Remove allocations whose owner process (--pid) has exited (nil = skip)
+	OwnerAlive func(pid int, startTime uint64) bool
+	// Treat a listening port (per IsPortFree) as in use: it is not expired by TTL,
+	// and TouchListening refreshes its LastUsedAt
+	TouchListening bool
+}
+
+// GCCandidate is an allocation that garbage collection removes, with the reason.
+type GCCandidate struct {
+	Allocation
+	Reason string
+}
+
+// DirExists reports whether dir exists and is a directory.
+func DirExists(dir string) bool {
+	fi, err := os.Stat(dir)
+	return err == nil && fi.IsDir()
+}
+
+// FindGarbage returns allocations that CollectGarbage would remove, sorted by port.
+// Locked allocations are never collected. The store is not modified.
+func (s *Store) FindGarbage(opts GCOptions) []GCCandidate {
+	now := time.Now()
+	var cutoff time.Time
+	if opts.TTL > 0 {
+		cutoff = now.Add(-opts.TTL)
+	}
+
+	var candidates []GCCandidate
+	for port, info := range s.Allocations {
+		if info == nil || info.Locked {
+			continue
+		}
+
+		reason := ""
+		switch {
+		case info.Status == StatusExternal:
+			if opts.IsPortFree != nil && opts.IsPortFree(port) {
+				reason = GCReasonStaleExternal
+			}
+			if reason == "" && info.isTunnelEnd() {
+				// A tunnel end lives as long as its tunnel holds the port
+				continue
+			}
+		case opts.DirExists != nil && filepath.IsAbs(info.Directory) && !opts.DirExists(info.Directory):
+			reason = GCReasonMissingDirectory
+		}
+
+		if reason == "" && info.OwnerPID > 0 && opts.OwnerAlive != nil && !opts.OwnerAlive(info.OwnerPID, info.OwnerStartTime) {
+			reason = GCReasonOwnerExited
+		}
+		if reason == "" && leaseExpired(info, now) {
+			reason = GCReasonLeaseExpired
+		}
+		if reason == "" && !cutoff.IsZero() && !opts.listening(info, port) {
+			checkTime := info.LastUsedAt
+			if checkTime.IsZero() {
+				checkTime = info.AssignedAt
+			}
+			if checkTime.Before(cutoff) {
+				reason = GCReasonExpired
+			}
+		}
+
+		if reason != "" {
+			candidates = append(candidates, GCCandidate{Allocation: *info.toAllocation(port), Reason: reason})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Port < candidates[j].Port
+	})
+	return candidates
+}
+
+// listening reports whether TouchListening applies to the allocation on port:
+// it is not external and something is listening on it.
+func (opts GCOptions) listening(info *AllocationInfo, port int) bool {
+	return opts.TouchListening && opts.IsPortFree != nil && info.Status != StatusExternal && !opts.IsPortFree(port)
+}
+
+// TouchListening sets LastUsedAt to now for the allocations whose port is listening,
+// so that services which never request their port again are not expired by TTL
+// while they serve. Does nothing unless opts.TouchListening is set. Returns the
+// number of refreshed allocations.
+func (s *Store) TouchListening(opts GCOptions) int {
+	now := time.Now().UTC()
+	count := 0
+	for port, info := range s.Allocations {
+		if info == nil || !opts.listening(info, port) {
+			continue
+		}
+		info.LastUsedAt = now
+		logger.Log(logger.AllocUpdate,
+			logger.Field("port", port),
+			logger.Field("dir", info.Directory),
+			logger.Field("last_used_at", now.Format(time.RFC3339)),
+			logger.Field("reason", "listening"))
+		count++
+	}
+	return count
+}
+
+// CollectGarbage removes expired, stale external, and orphaned allocations in one pass.
+// Returns the removed allocations sorted by port.
+func (s *Store) CollectGarbage(opts GCOptions) []GCCandidate {
+	candidates := s.FindGarbage(opts)
+	for _, c := range candidates {
+		event := logger.AllocDelete
+		if c.Reason == GCReasonExpired || c.Reason == GCReasonLeaseExpired {
+			event = logger.AllocExpire
+		}
+		logger.Log(event,
+			logger.Field("port", c.Port),
+			logger.Field("dir", c.Directory),
+			logger.Field("reason", c.Reason))
+		delete(s.Allocations, c.Port)
+	}
+	return candidates
+}
@@ -0,0 +1,186 @@
+package allocations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+	existingDir := t.TempDir()
+
+	store := NewStore()
+	store.Allocations[3000] = &AllocationInfo{Directory: existingDir, Name: "main", LastUsedAt: now}
+	store.Allocations[3001] = &AllocationInfo{Directory: existingDir, Name: "old", LastUsedAt: old}
+	store.Allocations[3002] = &AllocationInfo{Directory: existingDir, Name: "locked", LastUsedAt: old, Locked: true}
+	store.Allocations[3003] = &AllocationInfo{Directory: "/nonexistent/project", Name: "main", LastUsedAt: now}
+	store.Allocations[3004] = &AllocationInfo{Directory: "/nonexistent/project", Name: "main", LastUsedAt: now, Status: StatusExternal}
+	store.Allocations[3005] = &AllocationInfo{Directory: "/nonexistent/busy", Name: "main", LastUsedAt: now, Status: StatusExternal}
+	store.Allocations[3006] = &AllocationInfo{Directory: "(unknown:3006)", Name: "main", LastUsedAt: now}
+
+	opts := GCOptions{
+		TTL:        24 * time.Hour,
+		IsPortFree: func(port int) bool { return port != 3005 },
+		DirExists:  DirExists,
+	}
+
+	want := map[int]string{
+		3001: GCReasonExpired,
+		3003: GCReasonMissingDirectory,
+		3004: GCReasonStaleExternal,
+	}
+
+	found := store.FindGarbage(opts)
+	if len(found) != len(want) {
+		t.Fatalf("FindGarbage() returned %d candidates, want %d: %+v", len(found), len(want), found)
+	}
+	if len(store.Allocations) != 7 {
+		t.Error("FindGarbage() must not modify the store")
+	}
+
+	removed := store.CollectGarbage(opts)
+	for i, c := range removed {
+		if want[c.Port] != c.Reason {
+			t.Errorf("port %d: reason %q, want %q", c.Port, c.Reason, want[c.Port])
+		}
+		if i > 0 && removed[i-1].Port > c.Port {
+			t.Error("expected candidates sorted by port")
+		}
+		if store.Allocations[c.Port] != nil {
+			t.Errorf("port %d should be removed", c.Port)
+		}
+	}
+	for _, port := range []int{3000, 3002, 3005, 3006} {
+		if store.Allocations[port] == nil {
+			t.Errorf("port %d should be kept", port)
+		}
+	}
+}
+
+func TestTouchListening(t *testing.T) {
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	store := NewStore()
+	store.Allocations[3000] = &AllocationInfo{Directory: "/a", Name: "main", LastUsedAt: old}
+	store.Allocations[3001] = &AllocationInfo{Directory: "/b", Name: "main", LastUsedAt: old}
+	store.Allocations[3002] = &AllocationInfo{Directory: "/c", Name: "main", LastUsedAt: old, Status: StatusExternal}
+
+	opts := GCOptions{
+		TTL:            24 * time.Hour,
+		IsPortFree:     func(port int) bool { return port == 3001 },
+		TouchListening: true,
+	}
+	// The external allocation expires as before: it is not refreshed by traffic
+	found := store.FindGarbage(opts)
+	if len(found) != 2 || found[0].Port != 3001 || found[1].Port != 3002 {
+		t.Fatalf("FindGarbage() = %+v, want ports 3001 and 3002", found)
+	}
+
+	if n := store.TouchListening(opts); n != 1 {
+		t.Errorf("TouchListening() = %d, want 1", n)
+	}
+	if !store.Allocations[3000].LastUsedAt.After(old) {
+		t.Error("listening allocation was not refreshed")
+	}
+	if !store.Allocations[3002].LastUsedAt.Equal(old) {
+		t.Error("external allocation must not be refreshed")
+	}
+
+	opts.TouchListening = false
+	if n := store.TouchListening(opts); n != 0 {
+		t.Errorf("TouchListening() without the option = %d, want 0", n)
+	}
+}
+
+func TestCollectGarbage_Disabled(t *testing.T) {
+	store := NewStore()
+	store.Allocations[3000] = &AllocationInfo{Directory: "/nonexistent", LastUsedAt: time.Now().Add(-time.Hour * 1000)}
+
+	if removed := store.CollectGarbage(GCOptions{}); len(removed) != 0 {
+		t.Errorf("expected nothing removed with empty options, got %+v", removed)
+	}
+}
+
+func TestRemoveExpired_Lease(t *testing.T) {
+	s := NewStore()
+	s.SetAllocationWithName("/leased", 3000, "main")
+	s.SetAllocationWithName("/plain", 3001, "main")
+	s.SetAllocationWithName("/locked", 3002, "main")
+	s.SetLease(3000, time.Hour)
+	s.SetLease(3002, time.Hour)
+	s.SetLocked("/locked", true)
+
+	// Leases run out independently of the TTL (0 = disabled)
+	s.Allocations[3000].LeaseExpiresAt = time.Now().Add(-time.Minute)
+	s.Allocations[3002].LeaseExpiresAt = time.Now().Add(-time.Minute)
+	if got := s.FindGarbage(GCOptions{}); len(got) != 1 || got[0].Port != 3000 || got[0].Reason != GCReasonLeaseExpired {
+		t.Errorf("FindGarbage() = %+v, want port 3000 with lease_expired", got)
+	}
+	if removed := s.RemoveExpired(0); removed != 1 {
+		t.Errorf("RemoveExpired(0) removed %d, want 1", removed)
+	}
+	if s.FindByPort(3000) != nil || s.FindByPort(3001) == nil || s.FindByPort(3002) == nil {
+		t.Errorf("unexpected store after lease expiry: %v", s.SortedByPort())
+	}
+
+	s.SetLease(3001, time.Hour)
+	s.Allocations[3001].LeaseExpiresAt = time.Now().Add(time.Minute)
+	if !s.RenewLease(3001) || time.Until(s.Allocations[3001].LeaseExpiresAt) < 59*time.Minute {
+		t.Error("RenewLease() should extend the lease by its length")
+	}
+}
+
+func TestFindGarbage_OwnerExited(t *testing.T) {
+	s := NewStore()
+	s.SetAllocationWithName("/running", 3000, "main")
+	s.SetAllocationWithName("/exited", 3001, "main")
+	s.SetAllocationWithName("/unbound", 3002, "main")
+	s.SetOwner(3000, 100, 5)
+	s.SetOwner(3001, 200, 7)
+
+	alive := func(pid int, startTime uint64) bool { return pid == 100 && startTime == 5 }
+	got := s.CollectGarbage(GCOptions{OwnerAlive: alive})
+	if len(got) != 1 || got[0].Port != 3001 || got[0].Reason != GCReasonOwnerExited {
+		t.Fatalf("CollectGarbage() = %+v, want port 3001 with owner_exited", got)
+	}
+	if s.FindByPort(3000) == nil || s.FindByPort(3002) == nil {
+		t.Errorf("unexpected store after gc: %v", s.SortedByPort())
+	}
+}
+
+func TestTunnelEnds(t *testing.T) {
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	dir := t.TempDir()
+
+	store := NewStore()
+	store.SetAllocationWithName(dir, 3100, "web")
+	store.SetTunnel(3100, "alice@staging:4100", 4242, "bob")
+	store.SetTunnelEnd(4100, "tunnel-laptop-web", "laptop:3100", "alice")
+	store.SetTunnelEnd(4101, "tunnel-laptop-api", "laptop:3101", "alice")
+	for _, port := range []int{3100, 4100, 4101} {
+		store.Allocations[port].LastUsedAt = old
+	}
+
+	local := store.FindByPort(3100)
+	if local.Status != StatusExternal || local.ExternalProcessName != "ssh" || local.Directory != dir || local.Tunnel != "alice@staging:4100" {
+		t.Errorf("local end = %+v", local)
+	}
+	if end := store.FindTunnelEnd("tunnel-laptop-web"); end == nil || end.Port != 4100 || end.Directory != "(unknown:4100)" {
+		t.Errorf("FindTunnelEnd() = %+v, want port 4100", end)
+	}
+
+	if removed := store.RemoveExpired(24 * time.Hour); removed != 0 {
+		t.Errorf("RemoveExpired() removed %d tunnel ends", removed)
+	}
+	found := store.FindGarbage(GCOptions{TTL: 24 * time.Hour, IsPortFree: func(port int) bool { return port == 4101 }})
+	if len(found) != 1 || found[0].Port != 4101 || found[0].Reason != GCReasonStaleExternal {
+		t.Errorf("FindGarbage() = %+v, want only the closed tunnel 4101", found)
+	}
+
+	if !store.EndTunnel(3100, "alice@staging:4100") {
+		t.Fatal("EndTunnel() = false")
+	}
+	if local := store.FindByPort(3100); local.Status != StatusNormal || local.ExternalPID != 0 || local.Tunnel != "" || local.Name != "web" {
+		t.Errorf("after EndTunnel() = %+v", local)
+	}
+}
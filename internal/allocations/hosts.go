@@ -0,0 +1,109 @@
+package allocations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// hostsDirName holds one store directory per machine when allocations are
+// namespaced by hostname (perHost config option).
+const hostsDirName = "hosts"
+
+var storeHost string // hostname namespacing the store, guarded by backendMu
+
+// SetHost namespaces the store by hostname: the allocations, lock file, undo
+// journal and backups live in <configDir>/hosts/<host>, so machines sharing a
+// synced config directory don't mix their allocations. Empty host restores the
+// shared store.
+func SetHost(host string) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	storeHost = SanitizeHost(host)
+	if storeHost != "" {
+		debug.Printf("allocations", "using store of host %s", storeHost)
+	}
+}
+
+// CurrentHost returns the hostname set by SetHost, or "" for the shared store.
+func CurrentHost() string {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return storeHost
+}
+
+// SanitizeHost turns a hostname into a directory name: the short name in
+// lower case, without path separators.
+func SanitizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		host = host[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, host)
+}
+
+// StoreDir returns the directory holding the store for configDir: the host's
+// directory when the store is namespaced by SetHost, otherwise StateDir(configDir).
+func StoreDir(configDir string) string {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return hostDir(stateRoot(configDir), storeHost)
+}
+
+// hostDir returns the directory of host's store inside configDir.
+func hostDir(configDir, host string) string {
+	if host == "" {
+		return configDir
+	}
+	return filepath.Join(configDir, hostsDirName, host)
+}
+
+// Hosts returns the hostnames that have a store in configDir, sorted.
+func Hosts(configDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(StateDir(configDir), hostsDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read hosts directory: %w", err)
+	}
+	var hosts []string
+	for _, e := range entries {
+		if e.IsDir() {
+			hosts = append(hosts, e.Name())
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+// LoadAllHosts reads the stores of all hosts in configDir (without locking) and
+// returns their allocations with Host set, sorted by host and port.
+func LoadAllHosts(configDir string) ([]Allocation, error) {
+	hosts, err := Hosts(configDir)
+	if err != nil {
+		return nil, err
+	}
+	b := currentBackend()
+	var result []Allocation
+	for _, host := range hosts {
+		store, err := b.Read(b.Path(hostDir(StateDir(configDir), host)))
+		if err != nil {
+			return nil, fmt.Errorf("host %s: %w", host, err)
+		}
+		for _, alloc := range store.SortedByPort() {
+			alloc.Host = host
+			result = append(result, alloc)
+		}
+	}
+	return result, nil
+}
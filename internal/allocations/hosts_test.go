@@ -0,0 +1,50 @@
+package allocations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeHost(t *testing.T) {
+	tests := map[string]string{
+		"Laptop.local": "laptop",
+		"workstation":  "workstation",
+		"a/b":          "a_b",
+		" ":            "",
+	}
+	for in, want := range tests {
+		if got := SanitizeHost(in); got != want {
+			t.Errorf("SanitizeHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetHost_SeparatesStores(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(func() { SetHost("") })
+
+	for _, host := range []string{"laptop", "workstation"} {
+		SetHost(host)
+		if err := WithStore(dir, func(s *Store) error {
+			s.SetAllocationWithName("/project", 3000, "main")
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, hostsDirName, host, allocationsFileName)); err != nil {
+			t.Errorf("expected store of %s: %v", host, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, allocationsFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no shared store, got %v", err)
+	}
+
+	all, err := LoadAllHosts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Host != "laptop" || all[1].Host != "workstation" || all[0].Port != 3000 {
+		t.Errorf("LoadAllHosts() = %+v", all)
+	}
+}
@@ -0,0 +1,285 @@
+package allocations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// journalFileName is the file holding recent destructive operations for `undo`.
+const journalFileName = "undo-journal.yaml"
+
+// journalLimit is the number of operations kept in the journal.
+const journalLimit = 10
+
+// ErrNothingToUndo is returned by Undo when the journal is empty.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrUndoConflict is returned by Undo when an affected port changed after the operation.
+var ErrUndoConflict = errors.New("allocations changed since the operation")
+
+// JournalEntry records the state of the ports affected by one operation.
+// A nil value in Before means the port was not allocated before the operation,
+// a nil value in After means the operation removed it.
+type JournalEntry struct {
+	Time           time.Time               `yaml:"time"`
+	Operation      string                  `yaml:"operation"`
+	LastIssuedPort int                     `yaml:"last_issued_port,omitempty"` // before the operation
+	Before         map[int]*AllocationInfo `yaml:"before"`
+	After          map[int]*AllocationInfo `yaml:"after"`
+}
+
+// Ports returns the affected ports in ascending order.
+func (e *JournalEntry) Ports() []int {
+	ports := make([]int, 0, len(e.Before))
+	for p := range e.Before {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// journalFile is the on-disk layout of the journal, oldest entry first.
+type journalFile struct {
+	Entries []JournalEntry `yaml:"entries"`
+}
+
+// copyAllocations returns a deep copy of the allocations map.
+func (s *Store) copyAllocations() map[int]*AllocationInfo {
+	result := make(map[int]*AllocationInfo, len(s.Allocations))
+	for port, info := range s.Allocations {
+		if info != nil {
+			c := *info
+			result[port] = &c
+		}
+	}
+	return result
+}
+
+// sameInfo reports whether two entries are equal as stored on disk
+// (time values are compared by their serialized form).
+func sameInfo(a, b *AllocationInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	da, errA := yaml.Marshal(a)
+	db, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && string(da) == string(db)
+}
+
+// onlyUsageChanged reports whether a and b differ only in LastUsedAt.
+func onlyUsageChanged(a, b *AllocationInfo) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	c := *a
+	c.LastUsedAt = b.LastUsedAt
+	return sameInfo(&c, b)
+}
+
+// diffAllocations returns a journal entry for the ports that differ between before and after,
+// or nil if nothing changed.
+func diffAllocations(before, after map[int]*AllocationInfo) *JournalEntry {
+	e := &JournalEntry{Before: make(map[int]*AllocationInfo), After: make(map[int]*AllocationInfo)}
+	check := func(port int) {
+		if _, seen := e.Before[port]; seen {
+			return
+		}
+		if !sameInfo(before[port], after[port]) {
+			e.Before[port] = before[port]
+			e.After[port] = after[port]
+		}
+	}
+	for port := range before {
+		check(port)
+	}
+	for port := range after {
+		check(port)
+	}
+	if len(e.Before) == 0 {
+		return nil
+	}
+	return e
+}
+
+// WithJournal works like WithStore but records the ports changed by fn in the undo journal,
+// so the operation can be reverted with Undo. The journal is written only after the store.
+func WithJournal(configDir, operation string, fn func(*Store) error) error {
+	var e *JournalEntry
+	return withStore(configDir, func(store *Store) error {
+		before := store.copyAllocations()
+		lastIssued := store.LastIssuedPort
+		if err := fn(store); err != nil {
+			return err
+		}
+		e = newJournalEntry(operation, before, lastIssued, store)
+		return nil
+	}, func() error {
+		return appendJournal(configDir, e)
+	})
+}
+
+// newJournalEntry returns the entry for an operation that changed the store from
+// before to its current state, or nil if the allocations didn't change. Ports
+// whose only change is LastUsedAt (e.g. gc refreshing listening ports) are left
+// out: such entries would push real operations out of the journal.
+func newJournalEntry(operation string, before map[int]*AllocationInfo, lastIssued int, store *Store) *JournalEntry {
+	e := diffAllocations(before, store.Allocations)
+	if e == nil {
+		return nil
+	}
+	for port := range e.Before {
+		if onlyUsageChanged(e.Before[port], e.After[port]) {
+			delete(e.Before, port)
+			delete(e.After, port)
+		}
+	}
+	if len(e.Before) == 0 {
+		return nil
+	}
+	e.Time = time.Now().UTC()
+	e.Operation = operation
+	e.LastIssuedPort = lastIssued
+	// Copy after-state so later changes to the store don't leak into the entry
+	for port, info := range e.After {
+		if info != nil {
+			c := *info
+			e.After[port] = &c
+		}
+	}
+	return e
+}
+
+// appendJournal adds e to the journal, dropping the oldest entries over journalLimit.
+// A nil entry is ignored.
+func appendJournal(configDir string, e *JournalEntry) error {
+	if e == nil {
+		return nil
+	}
+	entries, err := readJournal(configDir)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, *e)
+	if len(entries) > journalLimit {
+		entries = entries[len(entries)-journalLimit:]
+	}
+	debug.Printf("allocations", "journal: %s changed %d port(s)", e.Operation, len(e.Before))
+	return writeJournal(configDir, entries)
+}
+
+// Journal returns the recorded operations, most recent first.
+func Journal(configDir string) ([]JournalEntry, error) {
+	entries, err := readJournal(configDir)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// Undo restores the ports affected by the most recent journaled operation and
+// removes it from the journal. Unless force is set, it refuses with ErrUndoConflict
+// when any of those ports changed after the operation.
+func Undo(configDir string, force bool) (*JournalEntry, error) {
+	var undone *JournalEntry
+	var entries []JournalEntry
+	err := withStore(configDir, func(store *Store) error {
+		var err error
+		entries, err = readJournal(configDir)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return ErrNothingToUndo
+		}
+		e := entries[len(entries)-1]
+
+		if !force {
+			for _, port := range e.Ports() {
+				cur := store.Allocations[port]
+				// A port only used since (e.g. refreshed by gc) is not a conflict
+				if !sameInfo(cur, e.After[port]) && !onlyUsageChanged(e.After[port], cur) {
+					return fmt.Errorf("%w: port %d (use --force to overwrite)", ErrUndoConflict, port)
+				}
+			}
+		}
+
+		for _, port := range e.Ports() {
+			if info := e.Before[port]; info != nil {
+				c := *info
+				// A rule closed when the allocation was removed is not reopened
+				c.Firewall = ""
+				if cur := store.Allocations[port]; cur != nil && cur.Directory == c.Directory && cur.Name == c.Name {
+					c.Firewall = cur.Firewall
+				}
+				store.Allocations[port] = &c
+			} else {
+				delete(store.Allocations, port)
+			}
+		}
+		if e.LastIssuedPort != 0 && store.LastIssuedPort == 0 {
+			store.LastIssuedPort = e.LastIssuedPort
+		}
+		logger.Log(logger.AllocUndo,
+			logger.Field("operation", e.Operation),
+			logger.Field("count", len(e.Before)))
+
+		undone = &e
+		return nil
+	}, func() error {
+		return writeJournal(configDir, entries[:len(entries)-1])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return undone, nil
+}
+
+// journalPath returns the path of the undo journal for the store in configDir.
+func journalPath(configDir string) string {
+	return sidecarPath(configDir, journalFileName, "journal")
+}
+
+// readJournal reads the journal entries, oldest first. A missing file yields no entries.
+func readJournal(configDir string) ([]JournalEntry, error) {
+	data, err := os.ReadFile(journalPath(configDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read undo journal: %w", err)
+	}
+	var jf journalFile
+	if err := yaml.Unmarshal(data, &jf); err != nil {
+		return nil, fmt.Errorf("failed to parse undo journal: %w", err)
+	}
+	return jf.Entries, nil
+}
+
+// writeJournal replaces the journal with entries. An empty list removes the file.
+func writeJournal(configDir string, entries []JournalEntry) error {
+	if IsDryRun() || IsReadOnly() {
+		return nil
+	}
+	path := journalPath(configDir)
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove undo journal: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(journalFile{Entries: entries})
+	if err != nil {
+		return fmt.Errorf("failed to marshal undo journal: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
@@ -0,0 +1,229 @@
+package allocations
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithJournalAndUndo(t *testing.T) {
+	configDir := t.TempDir()
+
+	store := NewStore()
+	store.SetAllocationWithName("/project", 3000, "web")
+	store.SetAllocationWithName("/other", 3001, "main")
+	store.SetLockedByPort(3001, true)
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Undo(configDir, false); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("Undo() on empty journal: error = %v, want ErrNothingToUndo", err)
+	}
+
+	err := WithJournal(configDir, "--forget-all", func(s *Store) error {
+		s.RemoveAll()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Journal(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Operation != "--forget-all" {
+		t.Fatalf("Journal() = %+v, want one --forget-all entry", entries)
+	}
+	if ports := entries[0].Ports(); len(ports) != 2 || ports[0] != 3000 || ports[1] != 3001 {
+		t.Errorf("Ports() = %v, want [3000 3001]", ports)
+	}
+
+	e, err := Undo(configDir, false)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if e.Operation != "--forget-all" {
+		t.Errorf("undone operation = %q", e.Operation)
+	}
+
+	loaded, err := Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.FindByPort(3000); a == nil || a.Directory != "/project" || a.Name != "web" {
+		t.Errorf("port 3000 not restored: %+v", a)
+	}
+	if a := loaded.FindByPort(3001); a == nil || !a.Locked {
+		t.Errorf("port 3001 not restored as locked: %+v", a)
+	}
+	if entries, _ := Journal(configDir); len(entries) != 0 {
+		t.Errorf("expected empty journal after undo, got %d entries", len(entries))
+	}
+}
+
+func TestUndo_Conflict(t *testing.T) {
+	configDir := t.TempDir()
+
+	store := NewStore()
+	store.SetAllocationWithName("/project", 3000, "main")
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WithJournal(configDir, "--forget", func(s *Store) error {
+		s.RemoveByPort(3000)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Port reallocated to another directory after the operation
+	err = WithStore(configDir, func(s *Store) error {
+		s.SetAllocationWithName("/elsewhere", 3000, "main")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Undo(configDir, false); !errors.Is(err, ErrUndoConflict) {
+		t.Fatalf("Undo() error = %v, want ErrUndoConflict", err)
+	}
+	if _, err := Undo(configDir, true); err != nil {
+		t.Fatalf("Undo(force) error = %v", err)
+	}
+
+	loaded, err := Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.FindByPort(3000); a == nil || a.Directory != "/project" {
+		t.Errorf("expected port 3000 restored to /project, got %+v", a)
+	}
+}
+
+func TestWithJournal_NoChangesNotRecorded(t *testing.T) {
+	configDir := t.TempDir()
+
+	err := WithJournal(configDir, "--forget", func(s *Store) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := Journal(configDir); len(entries) != 0 {
+		t.Errorf("expected no journal entries, got %+v", entries)
+	}
+}
+
+func TestWithJournal_Limit(t *testing.T) {
+	configDir := t.TempDir()
+
+	for i := 0; i < journalLimit+3; i++ {
+		port := 3000 + i
+		err := WithJournal(configDir, "--lock", func(s *Store) error {
+			s.SetAllocation("/project", port)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Journal(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != journalLimit {
+		t.Errorf("journal has %d entries, want %d", len(entries), journalLimit)
+	}
+}
+
+// failingWriteBackend reads like the YAML backend but fails every write.
+type failingWriteBackend struct{ yamlBackend }
+
+func (failingWriteBackend) Write(string, *Store) error { return errors.New("disk full") }
+
+func TestWithJournal_StoreWriteFails(t *testing.T) {
+	configDir := t.TempDir()
+
+	store := NewStore()
+	store.SetAllocationWithName("/project", 3000, "main")
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithJournal(configDir, "--forget", func(s *Store) error {
+		s.RemoveByPort(3000)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	backendMu.Lock()
+	backend = failingWriteBackend{}
+	backendMu.Unlock()
+	t.Cleanup(func() {
+		backendMu.Lock()
+		backend = yamlBackend{}
+		backendMu.Unlock()
+	})
+
+	err := WithJournal(configDir, "--forget-all", func(s *Store) error {
+		s.SetAllocationWithName("/other", 3001, "main")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("WithJournal() succeeded with a failing store write")
+	}
+	if _, err := Undo(configDir, false); err == nil {
+		t.Fatal("Undo() succeeded with a failing store write")
+	}
+
+	entries, err := Journal(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Operation != "--forget" {
+		t.Errorf("Journal() = %+v, want only the --forget entry", entries)
+	}
+}
+
+func TestWithJournal_UsageOnlyNotRecorded(t *testing.T) {
+	configDir := t.TempDir()
+	store := NewStore()
+	store.SetAllocationWithName("/project", 3000, "main")
+	store.SetAllocationWithName("/other", 3001, "main")
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	touch := func(s *Store) {
+		s.Allocations[3000].LastUsedAt = time.Now().UTC().Add(time.Minute)
+	}
+	if err := WithJournal(configDir, "gc", func(s *Store) error {
+		touch(s)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := Journal(configDir); len(entries) != 0 {
+		t.Fatalf("timestamp-only gc was journaled: %+v", entries)
+	}
+
+	// With a real change, only the changed port is recorded
+	if err := WithJournal(configDir, "gc", func(s *Store) error {
+		touch(s)
+		s.RemoveByPort(3001)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Journal(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Ports()) != 1 || entries[0].Ports()[0] != 3001 {
+		t.Errorf("Journal() = %+v, want one entry for port 3001", entries)
+	}
+}
@@ -0,0 +1,87 @@
+package allocations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// labelKeyPattern matches valid label keys (e.g., team, app.kubernetes.io/part-of).
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_./-]*$`)
+
+// ParseLabel splits "key=value" into its parts. The value may be empty;
+// a missing "=" is reported as hasValue=false (used by key-only filters).
+func ParseLabel(s string) (key, value string, hasValue bool, err error) {
+	key, value, hasValue = strings.Cut(s, "=")
+	if !labelKeyPattern.MatchString(key) {
+		return "", "", false, fmt.Errorf("invalid label %q (use key=value; keys may contain letters, digits, '.', '_', '/' and '-')", s)
+	}
+	if strings.ContainsAny(value, ",\n") {
+		return "", "", false, fmt.Errorf("invalid label %q (values may not contain ',' or newlines)", s)
+	}
+	return key, value, hasValue, nil
+}
+
+// FormatLabels returns labels as "k1=v1,k2=v2" sorted by key.
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + labels[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+// SetLabels merges labels into the allocation for the given port.
+// An empty value removes the key. Returns true if allocation was found.
+func (s *Store) SetLabels(port int, labels map[string]string) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+
+	// Build a new map: snapshots taken for dry-run and the journal share the old one
+	merged := make(map[string]string, len(info.Labels)+len(labels))
+	for k, v := range info.Labels {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		if v == "" {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		merged = nil
+	}
+	if FormatLabels(merged) == FormatLabels(info.Labels) {
+		return true
+	}
+
+	info.Labels = merged
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("name", info.Name),
+		logger.Field("labels", FormatLabels(merged)))
+	return true
+}
+
+// HasLabels reports whether the allocation carries all of labels
+// (an empty value in labels would be removed, so it must be absent).
+func (a *Allocation) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if a.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package allocations
+
+import "testing"
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		in       string
+		key      string
+		value    string
+		hasValue bool
+		wantErr  bool
+	}{
+		{"team=payments", "team", "payments", true, false},
+		{"app.io/part-of=shop", "app.io/part-of", "shop", true, false},
+		{"team=", "team", "", true, false},
+		{"team", "team", "", false, false},
+		{"=x", "", "", false, true},
+		{"bad key=x", "", "", false, true},
+		{"team=a,b", "", "", false, true},
+	}
+	for _, tt := range tests {
+		key, value, hasValue, err := ParseLabel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLabel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if key != tt.key || value != tt.value || hasValue != tt.hasValue {
+			t.Errorf("ParseLabel(%q) = %q, %q, %v", tt.in, key, value, hasValue)
+		}
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	store := NewStore()
+	store.SetAllocation("/proj", 3000)
+
+	if !store.SetLabels(3000, map[string]string{"team": "payments", "env": "dev"}) {
+		t.Fatal("SetLabels() returned false for existing port")
+	}
+	before := store.copyAllocations()
+	store.SetLabels(3000, map[string]string{"env": "", "tier": "db"})
+
+	if got := FormatLabels(store.Allocations[3000].Labels); got != "team=payments,tier=db" {
+		t.Errorf("labels = %q, want team=payments,tier=db", got)
+	}
+	if got := FormatLabels(before[3000].Labels); got != "env=dev,team=payments" {
+		t.Errorf("snapshot labels changed to %q", got)
+	}
+	if e := diffAllocations(before, store.Allocations); e == nil {
+		t.Error("expected label change to show up in the diff")
+	}
+
+	alloc := store.FindByPort(3000)
+	if !alloc.HasLabels(map[string]string{"team": "payments"}) || alloc.HasLabels(map[string]string{"env": "dev"}) {
+		t.Errorf("HasLabels() mismatch for %v", alloc.Labels)
+	}
+
+	store.SetLabels(3000, map[string]string{"team": "", "tier": ""})
+	if store.Allocations[3000].Labels != nil {
+		t.Errorf("expected labels to be cleared, got %v", store.Allocations[3000].Labels)
+	}
+	if store.SetLabels(3001, map[string]string{"team": "x"}) {
+		t.Error("SetLabels() returned true for unknown port")
+	}
+}
@@ -0,0 +1,69 @@
+package allocations
+
+import (
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// SetLease gives the allocation on port a lease of length d starting now.
+// The allocation expires when the lease runs out unless it is renewed.
+// d <= 0 removes the lease. Returns false if there is no allocation on port.
+func (s *Store) SetLease(port int, d time.Duration) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	if d <= 0 {
+		info.Lease = 0
+		info.LeaseExpiresAt = time.Time{}
+		logger.Log(logger.AllocUpdate, logger.Field("port", port), logger.Field("dir", info.Directory), logger.Field("lease", "none"))
+		return true
+	}
+	info.Lease = d
+	info.LeaseExpiresAt = time.Now().UTC().Add(d)
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", info.Directory),
+		logger.Field("lease", d.String()),
+		logger.Field("lease_expires_at", info.LeaseExpiresAt.Format(time.RFC3339)))
+	return true
+}
+
+// RenewLease extends the lease of the allocation on port by its lease length from now.
+// Returns false if there is no allocation on port or it has no lease.
+func (s *Store) RenewLease(port int) bool {
+	info := s.Allocations[port]
+	if info == nil || info.Lease <= 0 {
+		return false
+	}
+	return s.SetLease(port, info.Lease)
+}
+
+// leaseExpired reports whether info has a lease that ran out before now.
+func leaseExpired(info *AllocationInfo, now time.Time) bool {
+	return info.Lease > 0 && !info.LeaseExpiresAt.IsZero() && info.LeaseExpiresAt.Before(now)
+}
+
+// removeExpiredLeases removes unlocked allocations whose lease ran out before now.
+func (s *Store) removeExpiredLeases(now time.Time) int {
+	count := 0
+	for port, info := range s.Allocations {
+		if info == nil || !leaseExpired(info, now) {
+			continue
+		}
+		if info.Locked {
+			debug.Printf("allocations", "skipping lease expiration for locked port %d", port)
+			continue
+		}
+		logger.Log(logger.AllocExpire,
+			logger.Field("port", port),
+			logger.Field("dir", info.Directory),
+			logger.Field("reason", "lease"),
+			logger.Field("lease_expires_at", info.LeaseExpiresAt.Format(time.RFC3339)))
+		delete(s.Allocations, port)
+		count++
+	}
+	return count
+}
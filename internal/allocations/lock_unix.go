@@ -7,41 +7,46 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
 )
 
-// openAndLock opens the allocations file and acquires an exclusive lock.
+// openAndLock opens the lock file and acquires an exclusive lock.
 func openAndLock(configDir string) (*file, error) {
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	lockPath := sidecarPath(configDir, lockFileName, "lock")
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	path := filepath.Join(configDir, allocationsFileName)
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open allocations file: %w", err)
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
 	}
 
 	// Acquire exclusive lock (blocking)
+	start := time.Now()
 	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
 		f.Close()
 		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
+	wait := time.Since(start)
+	observeLockWait(wait)
 
-	debug.Printf("allocations", "acquired lock on %s", path)
-	return &file{path: path, f: f}, nil
+	debug.Printf("allocations", "acquired lock on %s after %s", lockPath, wait)
+	return &file{lockPath: lockPath, f: f}, nil
 }
 
 // unlock releases the lock and closes the file.
 func (fl *file) unlock() {
 	if fl.f != nil {
 		if err := syscall.Flock(int(fl.f.Fd()), syscall.LOCK_UN); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to release lock on %s: %v\n", fl.path, err)
+			i18n.Fprintf(os.Stderr, "warning: failed to release lock on %s: %v\n", fl.lockPath, err)
 		}
 		if err := fl.f.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %v\n", fl.path, err)
+			i18n.Fprintf(os.Stderr, "warning: failed to close %s: %v\n", fl.lockPath, err)
 		}
-		debug.Printf("allocations", "released lock on %s", fl.path)
+		debug.Printf("allocations", "released lock on %s", fl.lockPath)
 	}
 }
@@ -0,0 +1,101 @@
+package allocations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
+	"github.com/dapi/port-selector/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// Legacy file names from versions before 0.6.0, when issued ports and the
+// last-used port were stored separately from allocations.
+const (
+	legacyHistoryFileName  = "issued-ports.yaml"
+	legacyLastUsedFileName = "last-used"
+)
+
+// legacyIssuedPort is a single entry of the legacy issued-ports.yaml file.
+type legacyIssuedPort struct {
+	Port     int       `yaml:"port"`
+	IssuedAt time.Time `yaml:"issuedAt"`
+}
+
+// legacyHistory is the root structure of the legacy issued-ports.yaml file.
+type legacyHistory struct {
+	Ports []legacyIssuedPort `yaml:"ports"`
+}
+
+// migrateLegacyFiles merges legacy issued-ports.yaml and last-used files into the store.
+// Issue times refresh LastUsedAt of matching allocations so that freeze periods survive
+// the upgrade; entries without an allocation are dropped.
+// Returns true if any legacy file was found. The files are removed by removeLegacyFiles
+// only after the merged store has been written.
+func migrateLegacyFiles(configDir string, store *Store) (bool, error) {
+	historyPath := filepath.Join(configDir, legacyHistoryFileName)
+	lastUsedPath := filepath.Join(configDir, legacyLastUsedFileName)
+
+	historyData, historyErr := os.ReadFile(historyPath)
+	if historyErr != nil && !os.IsNotExist(historyErr) {
+		return false, fmt.Errorf("failed to read legacy history file: %w", historyErr)
+	}
+	lastUsedData, lastUsedErr := os.ReadFile(lastUsedPath)
+	if lastUsedErr != nil && !os.IsNotExist(lastUsedErr) {
+		return false, fmt.Errorf("failed to read legacy last-used file: %w", lastUsedErr)
+	}
+	if historyErr != nil && lastUsedErr != nil {
+		return false, nil
+	}
+
+	merged := 0
+	if historyErr == nil {
+		var history legacyHistory
+		if err := yaml.Unmarshal(historyData, &history); err != nil {
+			// Unparseable legacy data carries nothing worth keeping
+			debug.Printf("allocations", "ignoring corrupted legacy history: %v", err)
+		}
+		for _, entry := range history.Ports {
+			info := store.Allocations[entry.Port]
+			if info == nil {
+				continue
+			}
+			if entry.IssuedAt.After(info.LastUsedAt) {
+				info.LastUsedAt = entry.IssuedAt.UTC()
+				merged++
+			}
+		}
+	}
+
+	lastUsed := 0
+	if lastUsedErr == nil {
+		if p, err := strconv.Atoi(strings.TrimSpace(string(lastUsedData))); err == nil && p > 0 && p <= 65535 {
+			lastUsed = p
+			if store.LastIssuedPort == 0 {
+				store.LastIssuedPort = p
+			}
+		}
+	}
+
+	debug.Printf("allocations", "migrated legacy files: %d issued ports merged, last_used=%d", merged, lastUsed)
+	logger.Log(logger.AllocMigrate,
+		logger.Field("source", "legacy_history"),
+		logger.Field("merged", merged),
+		logger.Field("last_used", lastUsed))
+	return true, nil
+}
+
+// removeLegacyFiles deletes legacy files after a successful migration.
+func removeLegacyFiles(configDir string) {
+	for _, name := range []string{legacyHistoryFileName, legacyLastUsedFileName} {
+		path := filepath.Join(configDir, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			i18n.Fprintf(os.Stderr, "warning: failed to remove legacy file %s: %v\n", path, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package allocations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithStore_MigratesLegacyFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Existing allocation with an older LastUsedAt than the legacy history
+	store := NewStore()
+	old := time.Now().Add(-48 * time.Hour).UTC()
+	store.Allocations[3000] = &AllocationInfo{Directory: "/project", Name: "main", AssignedAt: old, LastUsedAt: old}
+	if err := Save(tmpDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	issuedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	history := "ports:\n" +
+		"  - port: 3000\n    issuedAt: " + issuedAt.Format(time.RFC3339) + "\n" +
+		"  - port: 3999\n    issuedAt: " + issuedAt.Format(time.RFC3339) + "\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, legacyHistoryFileName), []byte(history), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, legacyLastUsedFileName), []byte("3005\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WithStore(tmpDir, func(s *Store) error {
+		if !s.Allocations[3000].LastUsedAt.Equal(issuedAt) {
+			t.Errorf("expected LastUsedAt %v, got %v", issuedAt, s.Allocations[3000].LastUsedAt)
+		}
+		if s.Allocations[3999] != nil {
+			t.Error("expected history entry without allocation to be dropped")
+		}
+		if s.LastIssuedPort != 3005 {
+			t.Errorf("expected LastIssuedPort 3005, got %d", s.LastIssuedPort)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{legacyHistoryFileName, legacyLastUsedFileName} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected legacy file %s to be removed", name)
+		}
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LastIssuedPort != 3005 {
+		t.Errorf("expected migrated LastIssuedPort to be persisted, got %d", loaded.LastIssuedPort)
+	}
+}
+
+func TestWithStore_KeepsLegacyFilesOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacyPath := filepath.Join(tmpDir, legacyLastUsedFileName)
+	if err := os.WriteFile(legacyPath, []byte("3005"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WithStore(tmpDir, func(s *Store) error {
+		return os.ErrInvalid
+	})
+	if err == nil {
+		t.Fatal("expected error from callback")
+	}
+
+	if _, err := os.Stat(legacyPath); err != nil {
+		t.Errorf("expected legacy file to be kept when the store was not written: %v", err)
+	}
+}
+
+func TestMigrateLegacyFiles_NoLegacyFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	migrated, err := migrateLegacyFiles(tmpDir, NewStore())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if migrated {
+		t.Error("expected no migration without legacy files")
+	}
+}
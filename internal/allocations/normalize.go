@@ -0,0 +1,186 @@
+package allocations
+
+import (
+	"os"
+	"sort"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// directoryNormalizer maps a directory to the form it is stored in, guarded by backendMu.
+var directoryNormalizer func(string) string
+
+// SetDirectoryNormalizer sets the function that maps a directory to the form
+// new allocations are stored in (e.g. with symlinks resolved). Stores written
+// before it was set are migrated to it once, see migrateDirectories. nil
+// disables the migration.
+func SetDirectoryNormalizer(normalize func(string) string) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	directoryNormalizer = normalize
+}
+
+// currentDirectoryNormalizer returns the function set by SetDirectoryNormalizer.
+func currentDirectoryNormalizer() func(string) string {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return directoryNormalizer
+}
+
+// directoriesPending reports whether migrateDirectories will change the store.
+func directoriesPending(store *Store) bool {
+	return !store.DirectoriesNormalized && currentDirectoryNormalizer() != nil
+}
+
+// migrateDirectories normalizes the directories of a store that predates
+// directory normalization, so allocations stored under a symlinked or
+// differently spelled path are found again after the upgrade instead of being
+// allocated anew; duplicates are merged as by NormalizeDirectories. It runs
+// once per store: DirectoriesNormalized marks it done. Returns the changes.
+func migrateDirectories(store *Store) []DirectoryChange {
+	if !directoriesPending(store) {
+		return nil
+	}
+	changes := store.NormalizeDirectories(currentDirectoryNormalizer())
+	store.DirectoriesNormalized = true
+	for _, c := range changes {
+		if c.LockedDuplicateOf > 0 {
+			i18n.Fprintf(os.Stderr, "warning: ports %d and %d are both locked for %s ('%s'); both are kept, forget one with 'port-selector --forget %d'\n",
+				c.LockedDuplicateOf, c.Port, c.To, c.Name, c.Port)
+		}
+	}
+	if len(changes) > 0 {
+		debug.Printf("allocations", "normalized %d directories", len(changes))
+		logger.Log(logger.AllocMigrate,
+			logger.Field("source", "directories"),
+			logger.Field("changed", len(changes)))
+	}
+	return changes
+}
+
+// DirectoryChange is an allocation whose directory NormalizeDirectories rewrote
+// or removed as a duplicate.
+type DirectoryChange struct {
+	Allocation        // the allocation as it was before the change
+	To         string // the normalized directory
+	// DuplicateOf is the port kept for the same directory and name when this
+	// allocation was removed, or 0 when its directory was rewritten
+	DuplicateOf int
+	// LockedDuplicateOf is the locked port kept for the same directory and name
+	// when this allocation is locked too: locked allocations are never removed
+	// automatically, so both are kept
+	LockedDuplicateOf int
+}
+
+// NormalizeDirectories rewrites the directory of every allocation and sticky
+// port to normalize(dir), and merges the allocations that then share a
+// directory and name: the locked one is kept, else the most recently used,
+// else the lowest port; the others are removed. Locked duplicates of a locked
+// allocation are kept too (see LockedDuplicateOf). External allocations are
+// rewritten but never merged. Returns the changes sorted by port.
+func (s *Store) NormalizeDirectories(normalize func(string) string) []DirectoryChange {
+	type key struct{ dir, name string }
+	groups := make(map[key][]int)
+	var changes []DirectoryChange
+	changed := make(map[int]int) // port -> index in changes
+	for _, a := range s.SortedByPort() {
+		to := normalize(a.Directory)
+		if a.Status != StatusExternal {
+			k := key{to, normalizeName(a.Name)}
+			groups[k] = append(groups[k], a.Port)
+		}
+		if to != a.Directory {
+			changed[a.Port] = len(changes)
+			changes = append(changes, DirectoryChange{Allocation: a, To: to})
+		}
+	}
+
+	duplicates := make(map[int]int)       // removed port -> kept port
+	lockedDuplicates := make(map[int]int) // locked port kept beside another locked port
+	for _, ports := range groups {
+		if len(ports) < 2 {
+			continue
+		}
+		kept := ports[0]
+		for _, p := range ports[1:] {
+			if s.preferOnMerge(p, kept) {
+				kept = p
+			}
+		}
+		for _, p := range ports {
+			switch {
+			case p == kept:
+			case s.Allocations[p].Locked:
+				lockedDuplicates[p] = kept
+			default:
+				duplicates[p] = kept
+			}
+		}
+	}
+	for p, kept := range lockedDuplicates {
+		if i, ok := changed[p]; ok {
+			changes[i].LockedDuplicateOf = kept
+		} else {
+			a := *s.Allocations[p].toAllocation(p)
+			changes = append(changes, DirectoryChange{Allocation: a, To: a.Directory, LockedDuplicateOf: kept})
+		}
+	}
+	for p, kept := range duplicates {
+		info := s.Allocations[p]
+		a := *info.toAllocation(p)
+		if i, ok := changed[p]; ok {
+			changes[i].DuplicateOf = kept
+		} else {
+			changes = append(changes, DirectoryChange{Allocation: a, To: a.Directory, DuplicateOf: kept})
+		}
+		delete(s.Allocations, p)
+		logger.Log(logger.AllocDelete,
+			logger.Field("port", p),
+			logger.Field("dir", a.Directory),
+			logger.Field("name", a.Name),
+			logger.Field("reason", "duplicate"),
+			logger.Field("kept_port", kept))
+	}
+	for _, c := range changes {
+		if c.DuplicateOf == 0 {
+			s.SetDirectory(c.Port, c.To)
+		}
+	}
+
+	if len(s.Sticky) > 0 {
+		// Build a new map: snapshots taken for dry-run share the old one
+		sticky := make(map[int]*StickyOwner, len(s.Sticky))
+		for p, owner := range s.Sticky {
+			if owner != nil {
+				sticky[p] = &StickyOwner{Directory: normalize(owner.Directory), Name: owner.Name}
+			}
+		}
+		s.Sticky = sticky
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Port < changes[j].Port })
+	return changes
+}
+
+// preferOnMerge reports whether the allocation on port a should be kept over
+// the one on b when they are duplicates: locked first, then the most recently
+// used, then the lower port.
+func (s *Store) preferOnMerge(a, b int) bool {
+	infoA, infoB := s.Allocations[a], s.Allocations[b]
+	if infoA.Locked != infoB.Locked {
+		return infoA.Locked
+	}
+	usedA, usedB := infoA.LastUsedAt, infoB.LastUsedAt
+	if usedA.IsZero() {
+		usedA = infoA.AssignedAt
+	}
+	if usedB.IsZero() {
+		usedB = infoB.AssignedAt
+	}
+	if !usedA.Equal(usedB) {
+		return usedA.After(usedB)
+	}
+	return a < b
+}
@@ -0,0 +1,176 @@
+package allocations
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeDirectories(t *testing.T) {
+	store := NewStore()
+	store.SetAllocationWithName("/real/shop", 3000, "web")
+	store.SetLockedByPort(3000, true)
+	store.SetAllocationWithName("/link/shop", 3001, "web")
+	store.SetAllocationWithName("/link/shop", 3002, "main")
+	// Duplicates cannot be made through the API, only by older versions
+	now := time.Now().UTC()
+	store.Allocations[3003] = &AllocationInfo{Directory: "/link/blog", Name: "main", AssignedAt: now, LastUsedAt: now.Add(time.Hour)}
+	store.Allocations[3004] = &AllocationInfo{Directory: "/link/blog", Name: "main", AssignedAt: now}
+	store.Allocations[3005] = &AllocationInfo{Directory: "/real/blog", Name: "main", AssignedAt: now}
+	store.SetSticky(3002, "/link/shop", "main")
+
+	changes := store.NormalizeDirectories(func(dir string) string {
+		return strings.Replace(dir, "/link/", "/real/", 1)
+	})
+
+	want := map[int]int{3001: 3000, 3002: 0, 3003: 0, 3004: 3003, 3005: 3003}
+	if len(changes) != len(want) {
+		t.Fatalf("NormalizeDirectories() = %+v, want changes for %v", changes, want)
+	}
+	for _, c := range changes {
+		if dup, ok := want[c.Port]; !ok || c.DuplicateOf != dup {
+			t.Errorf("change for port %d: duplicate of %d, want %d", c.Port, c.DuplicateOf, dup)
+		}
+	}
+
+	for port, dir := range map[int]string{3000: "/real/shop", 3002: "/real/shop", 3003: "/real/blog"} {
+		if info := store.Allocations[port]; info == nil || info.Directory != dir {
+			t.Errorf("port %d = %+v, want it in %s", port, info, dir)
+		}
+	}
+	for _, port := range []int{3001, 3004, 3005} {
+		if store.Allocations[port] != nil {
+			t.Errorf("duplicate port %d was not removed", port)
+		}
+	}
+	if got := store.StickyPort("/real/shop", "main"); got != 3002 {
+		t.Errorf("StickyPort() = %d, want the sticky port moved to the real directory", got)
+	}
+
+	if changes := store.NormalizeDirectories(func(dir string) string { return dir }); len(changes) != 0 {
+		t.Errorf("second NormalizeDirectories() = %+v, want no changes", changes)
+	}
+}
+
+func TestWithStore_MigratesDirectories(t *testing.T) {
+	configDir := t.TempDir()
+	store := NewStore()
+	store.SetAllocationWithName("/link/shop", 3000, "main")
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	SetDirectoryNormalizer(func(dir string) string { return strings.Replace(dir, "/link/", "/real/", 1) })
+	t.Cleanup(func() { SetDirectoryNormalizer(nil) })
+
+	var found *Allocation
+	if err := WithStore(configDir, func(s *Store) error {
+		found = s.FindByDirectoryAndName("/real/shop", "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.Port != 3000 {
+		t.Fatalf("allocation under the normalized directory = %+v, want port 3000", found)
+	}
+
+	// The migration runs once: later stores keep what was written
+	SetDirectoryNormalizer(func(dir string) string { return "/other" })
+	store, err := Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.DirectoriesNormalized || store.Allocations[3000].Directory != "/real/shop" {
+		t.Errorf("store after migration = %+v, normalized=%v", store.Allocations[3000], store.DirectoriesNormalized)
+	}
+	if err := WithStore(configDir, func(*Store) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if store, _ := Load(configDir); store.Allocations[3000].Directory != "/real/shop" {
+		t.Errorf("directory rewritten again: %s", store.Allocations[3000].Directory)
+	}
+}
+
+func TestNormalizeDirectories_KeepsLockedDuplicates(t *testing.T) {
+	now := time.Now().UTC()
+	store := NewStore()
+	store.Allocations[3000] = &AllocationInfo{Directory: "/link/api", Name: "main", AssignedAt: now, Locked: true}
+	store.Allocations[3001] = &AllocationInfo{Directory: "/real/api", Name: "main", AssignedAt: now, Locked: true}
+	store.Allocations[3002] = &AllocationInfo{Directory: "/link/api", Name: "main", AssignedAt: now}
+
+	changes := store.NormalizeDirectories(func(dir string) string {
+		return strings.Replace(dir, "/link/", "/real/", 1)
+	})
+
+	for _, port := range []int{3000, 3001} {
+		if info := store.Allocations[port]; info == nil || !info.Locked || info.Directory != "/real/api" {
+			t.Errorf("locked port %d = %+v, want it kept in /real/api", port, info)
+		}
+	}
+	if store.Allocations[3002] != nil {
+		t.Error("unlocked duplicate 3002 was not removed")
+	}
+	want := map[int][2]int{3000: {0, 0}, 3001: {0, 3000}, 3002: {3000, 0}}
+	if len(changes) != len(want) {
+		t.Fatalf("NormalizeDirectories() = %+v, want changes for %v", changes, want)
+	}
+	for _, c := range changes {
+		if got := [2]int{c.DuplicateOf, c.LockedDuplicateOf}; got != want[c.Port] {
+			t.Errorf("change for port %d: duplicate of %v, want %v", c.Port, got, want[c.Port])
+		}
+	}
+}
+
+func TestWithStore_MigrationIsBackedUpAndJournaled(t *testing.T) {
+	configDir := t.TempDir()
+	now := time.Now().UTC()
+	store := NewStore()
+	store.Allocations[3000] = &AllocationInfo{Directory: "/real/shop", Name: "main", AssignedAt: now, LastUsedAt: now}
+	store.Allocations[3001] = &AllocationInfo{Directory: "/link/shop", Name: "main", AssignedAt: now}
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+
+	SetDirectoryNormalizer(func(dir string) string { return strings.Replace(dir, "/link/", "/real/", 1) })
+	SetBackupCount(1)
+	t.Cleanup(func() {
+		SetDirectoryNormalizer(nil)
+		SetBackupCount(0)
+	})
+
+	// --dry-run shows the duplicate the migration would remove
+	var buf bytes.Buffer
+	dryRunOutput = &buf
+	SetDryRun(true)
+	err := WithStore(configDir, func(*Store) error { return nil })
+	SetDryRun(false)
+	dryRunOutput = os.Stderr
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "- 3001") {
+		t.Errorf("dry-run report = %q, want the removed duplicate", buf.String())
+	}
+
+	if err := WithJournal(configDir, "--lock", func(s *Store) error {
+		s.SetLockedByPort(3000, true)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := Backups(configDir); len(backups) != 1 {
+		t.Errorf("backups = %+v, want the store before the migration", backups)
+	}
+	entries, err := Journal(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Operation != "--lock" || entries[1].Operation != "directory migration" {
+		t.Fatalf("Journal() = %+v, want --lock after the migration", entries)
+	}
+	if ports := entries[1].Ports(); len(ports) != 1 || ports[0] != 3001 {
+		t.Errorf("migration entry ports = %v, want [3001]", ports)
+	}
+}
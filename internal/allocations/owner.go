@@ -0,0 +1,17 @@
+package allocations
+
+import "github.com/dapi/port-selector/internal/logger"
+
+// SetOwner binds the allocation on port to the process pid started at startTime,
+// so gc can free it as soon as that process exits. pid 0 removes the binding.
+// Returns false if there is no allocation on port.
+func (s *Store) SetOwner(port, pid int, startTime uint64) bool {
+	info := s.Allocations[port]
+	if info == nil {
+		return false
+	}
+	info.OwnerPID = pid
+	info.OwnerStartTime = startTime
+	logger.Log(logger.AllocUpdate, logger.Field("port", port), logger.Field("dir", info.Directory), logger.Field("owner_pid", pid))
+	return true
+}
@@ -0,0 +1,64 @@
+package allocations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned when an operation would change the store in read-only mode.
+var ErrReadOnly = errors.New("store is read-only")
+
+// readOnly makes WithStore and the other writers refuse to change the store.
+var readOnly atomic.Bool
+
+// SetReadOnly enables or disables read-only mode. In read-only mode lookups of
+// existing allocations work, but any change to the store fails with ErrReadOnly
+// and nothing is written (not even the lock file or the undo journal).
+func SetReadOnly(v bool) {
+	readOnly.Store(v)
+}
+
+// IsReadOnly returns true if read-only mode is enabled.
+func IsReadOnly() bool {
+	return readOnly.Load()
+}
+
+// checkWritable returns ErrReadOnly for operation in read-only mode.
+func checkWritable(operation string) error {
+	if IsReadOnly() {
+		return fmt.Errorf("%w: cannot %s", ErrReadOnly, operation)
+	}
+	return nil
+}
+
+// readOnlyViolation returns ErrReadOnly if fn changed the store in a way that
+// needs a write. Refreshed last_used_at timestamps of looked-up allocations
+// are dropped silently, so lookups keep working.
+func readOnlyViolation(before map[int]*AllocationInfo, lastIssued int, store *Store) error {
+	e := diffAllocations(before, store.Allocations)
+	changed := 0
+	if e != nil {
+		for _, port := range e.Ports() {
+			old, cur := e.Before[port], e.After[port]
+			if old == nil || cur == nil {
+				changed++
+				continue
+			}
+			for _, f := range changedFields(old, cur) {
+				if !strings.HasPrefix(f, "last_used_at:") {
+					changed++
+					break
+				}
+			}
+		}
+	}
+	if changed == 0 && store.LastIssuedPort == lastIssued {
+		return nil
+	}
+	if changed == 0 {
+		changed = 1
+	}
+	return fmt.Errorf("%w: operation would change %d allocation(s) (remove --read-only or readOnly: true to allow it)", ErrReadOnly, changed)
+}
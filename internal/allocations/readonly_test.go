@@ -0,0 +1,61 @@
+package allocations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithStore_ReadOnly(t *testing.T) {
+	configDir := t.TempDir()
+	if err := WithStore(configDir, func(s *Store) error {
+		s.SetAllocationWithName("/project", 3000, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(filepath.Join(configDir, allocationsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetReadOnly(true)
+	t.Cleanup(func() { SetReadOnly(false) })
+
+	// A lookup refreshes last_used_at; that is dropped, not an error
+	var found int
+	if err := WithStore(configDir, func(s *Store) error {
+		a := s.FindByDirectoryAndName("/project", "main")
+		if a == nil {
+			t.Fatal("expected existing allocation")
+		}
+		found = a.Port
+		s.UpdateLastUsedByPort(a.Port)
+		return nil
+	}); err != nil {
+		t.Fatalf("lookup in read-only mode: %v", err)
+	}
+	if found != 3000 {
+		t.Errorf("lookup returned port %d, want 3000", found)
+	}
+
+	err = WithStore(configDir, func(s *Store) error {
+		s.SetAllocationWithName("/other", 3001, "main")
+		return nil
+	})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly for a new allocation, got %v", err)
+	}
+	if _, err := Restore(configDir, filepath.Join(configDir, allocationsFileName)); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly from Restore, got %v", err)
+	}
+
+	after, err := os.ReadFile(filepath.Join(configDir, allocationsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("store file changed in read-only mode")
+	}
+}
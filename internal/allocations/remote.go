@@ -0,0 +1,421 @@
+package allocations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// remoteCacheFileName is the local copy of the remote store inside the config directory.
+const remoteCacheFileName = "allocations.remote.yaml"
+
+// RemoteTokenEnvVar holds an optional bearer token sent to the remote store.
+const RemoteTokenEnvVar = "PORT_SELECTOR_REMOTE_TOKEN"
+
+// remoteTimeout bounds a single request to the remote store.
+const remoteTimeout = 5 * time.Second
+
+// remoteRetries is how many times Write merges and retries after a concurrent change.
+const remoteRetries = 3
+
+// remoteCacheOnly makes Read use the cache without a request, guarded by backendMu.
+var remoteCacheOnly bool
+
+// SetRemoteCacheOnly makes the remote backend read its local cache, when there
+// is one, without asking the server, so that callers that must not wait on the
+// network (the shell prompt) stay fast. Writes still go to the server.
+func SetRemoteCacheOnly(on bool) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	remoteCacheOnly = on
+}
+
+// ErrRemoteConflict is returned when a concurrent change to the remote store
+// touches the same allocation as the local change.
+var ErrRemoteConflict = errors.New("remote store conflict")
+
+// errPreconditionFailed means the remote store changed since it was read.
+var errPreconditionFailed = errors.New("remote store changed since it was read")
+
+// remoteBackend keeps the store as a YAML document on an HTTP(S) server shared by
+// a team. Reads refresh a local cache with If-None-Match; writes are conditional
+// (If-Match on the ETag seen by Read), so concurrent writers never overwrite each
+// other. On a concurrent change, Write re-reads the remote store, re-applies the
+// local per-port changes and retries; changes to the same port are a conflict.
+//
+// The server must return an ETag on GET and PUT and honor If-Match and
+// If-None-Match: * on PUT.
+type remoteBackend struct {
+	url    string
+	client *http.Client
+}
+
+func (remoteBackend) Name() string { return BackendRemote }
+
+func (remoteBackend) Path(configDir string) string {
+	return filepath.Join(configDir, remoteCacheFileName)
+}
+
+// isRemoteCache reports whether path is the cache of the remote store.
+// Other paths (backups, --store FILE) are plain YAML files.
+func isRemoteCache(path string) bool {
+	return filepath.Base(path) == remoteCacheFileName
+}
+
+func (b remoteBackend) Read(path string) (*Store, error) {
+	if !isRemoteCache(path) {
+		return yamlBackend{}.Read(path)
+	}
+
+	etag := readETag(path)
+	if _, err := os.Stat(path); err != nil {
+		etag = "" // no cache to fall back on after 304 Not Modified
+	}
+	backendMu.Lock()
+	cacheOnly := remoteCacheOnly
+	backendMu.Unlock()
+	if cacheOnly && etag != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			debug.Printf("allocations", "reading remote store from cache only")
+			return decodeRemoteWithETag(data, etag)
+		}
+	}
+
+	data, newETag, err := b.get(etag)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		debug.Printf("allocations", "remote store does not exist yet, returning empty store")
+		store := NewStore()
+		store.loaded = make(map[int]string)
+		return store, nil
+	case err != nil:
+		cached, cacheErr := os.ReadFile(path)
+		if cacheErr != nil {
+			return nil, fmt.Errorf("cannot read remote store: %w", err)
+		}
+		i18n.Fprintf(os.Stderr, "warning: remote store unreachable (%v), using cached copy\n", err)
+		data, newETag = cached, etag
+	case data == nil:
+		debug.Printf("allocations", "remote store not modified, using cache")
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("cannot read remote store cache: %w", err)
+		}
+	case IsReadOnly() || IsDryRun():
+		debug.Printf("allocations", "not updating the remote store cache in read-only or dry-run mode")
+	default:
+		if err := writeRemoteCache(path, data, newETag); err != nil {
+			return nil, err
+		}
+	}
+
+	store, err := decodeRemoteWithETag(data, newETag)
+	if err != nil {
+		return nil, err
+	}
+	debug.Printf("allocations", "loaded %d allocations from %s (etag %s)", len(store.Allocations), b.url, newETag)
+	return store, nil
+}
+
+// decodeRemoteWithETag is decodeRemote for the document with the given ETag.
+func decodeRemoteWithETag(data []byte, etag string) (*Store, error) {
+	store, err := decodeRemote(data)
+	if err != nil {
+		return nil, err
+	}
+	store.etag = etag
+	return store, nil
+}
+
+func (b remoteBackend) Write(path string, store *Store) error {
+	if !isRemoteCache(path) {
+		return yamlBackend{}.Write(path, store)
+	}
+
+	for attempt := 0; attempt < remoteRetries; attempt++ {
+		data, err := yaml.Marshal(store)
+		if err != nil {
+			return fmt.Errorf("failed to marshal store: %w", err)
+		}
+		rows, err := serializeRows(store)
+		if err != nil {
+			return err
+		}
+
+		// Without a snapshot from Read (conversion, restore), overwrite unconditionally
+		etag, err := b.put(data, store.etag, store.loaded == nil)
+		if err == nil {
+			store.loaded = rows
+			store.etag = etag
+			store.snapshotMeta()
+			debug.Printf("allocations", "saved %d allocations to %s", len(store.Allocations), b.url)
+			return writeRemoteCache(path, data, etag)
+		}
+		if !errors.Is(err, errPreconditionFailed) {
+			return err
+		}
+
+		debug.Printf("allocations", "remote store changed concurrently, merging (attempt %d)", attempt+1)
+		remote, err := b.Read(path)
+		if err != nil {
+			return err
+		}
+		if err := mergeRemote(store, rows, remote); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: store kept changing while saving, try again", ErrRemoteConflict)
+}
+
+// mergeRemote re-applies the local changes of store (rows, compared to the
+// snapshot taken by Read) on top of the freshly read remote store. Sticky ports
+// are merged per port like allocations; when both sides moved LastIssuedPort,
+// the higher one wins.
+func mergeRemote(store *Store, rows map[int]string, remote *Store) error {
+	changed := make(map[int]bool)
+	for port, row := range rows {
+		if store.loaded[port] != row {
+			changed[port] = true
+		}
+	}
+	for port := range store.loaded {
+		if _, ok := rows[port]; !ok {
+			changed[port] = true
+		}
+	}
+
+	for port := range changed {
+		base, mine, theirs := store.loaded[port], rows[port], remote.loaded[port]
+		if theirs != base && theirs != mine {
+			return fmt.Errorf("%w: port %d was changed by someone else", ErrRemoteConflict, port)
+		}
+		if info := store.Allocations[port]; info != nil {
+			remote.Allocations[port] = info
+		} else {
+			delete(remote.Allocations, port)
+		}
+	}
+
+	// Two writers may have given the same directory and name different ports
+	seen := make(map[string]int)
+	for port, info := range remote.Allocations {
+		key := info.Directory + "\x00" + info.Name
+		if other, ok := seen[key]; ok && (changed[port] || changed[other]) {
+			return fmt.Errorf("%w: %s (%s) was allocated ports %d and %d", ErrRemoteConflict, info.Directory, info.Name, other, port)
+		}
+		seen[key] = port
+	}
+
+	if err := mergeSticky(store, remote); err != nil {
+		return err
+	}
+	mineIssued, theirsIssued := store.LastIssuedPort != store.loadedLastIssued, remote.LastIssuedPort != store.loadedLastIssued
+	if !mineIssued || (theirsIssued && remote.LastIssuedPort > store.LastIssuedPort) {
+		store.LastIssuedPort = remote.LastIssuedPort
+	}
+	store.DirectoriesNormalized = store.DirectoriesNormalized || remote.DirectoriesNormalized
+
+	store.Allocations = remote.Allocations
+	store.loaded = remote.loaded
+	store.etag = remote.etag
+	store.loadedSticky = remote.loadedSticky
+	store.loadedLastIssued = remote.loadedLastIssued
+	return nil
+}
+
+// mergeSticky re-applies the local changes of sticky ports on top of the remote
+// ones; a port changed on both sides differently is a conflict.
+func mergeSticky(store, remote *Store) error {
+	owner := func(m map[int]*StickyOwner, port int) StickyOwner {
+		if o := m[port]; o != nil {
+			return *o
+		}
+		return StickyOwner{}
+	}
+	changed := make(map[int]bool)
+	for port := range store.Sticky {
+		if owner(store.Sticky, port) != store.loadedSticky[port] {
+			changed[port] = true
+		}
+	}
+	for port := range store.loadedSticky {
+		if store.Sticky[port] == nil {
+			changed[port] = true
+		}
+	}
+
+	merged := make(map[int]*StickyOwner, len(remote.Sticky))
+	for port, o := range remote.Sticky {
+		merged[port] = o
+	}
+	for port := range changed {
+		base, mine, theirs := store.loadedSticky[port], owner(store.Sticky, port), owner(remote.Sticky, port)
+		if theirs != base && theirs != mine {
+			return fmt.Errorf("%w: sticky port %d was changed by someone else", ErrRemoteConflict, port)
+		}
+		if o := store.Sticky[port]; o != nil {
+			merged[port] = o
+		} else {
+			delete(merged, port)
+		}
+	}
+	store.Sticky = merged
+	return nil
+}
+
+// snapshotMeta records Sticky and LastIssuedPort as read from or written to
+// the remote store, for mergeRemote.
+func (s *Store) snapshotMeta() {
+	s.loadedSticky = make(map[int]StickyOwner, len(s.Sticky))
+	for port, o := range s.Sticky {
+		if o != nil {
+			s.loadedSticky[port] = *o
+		}
+	}
+	s.loadedLastIssued = s.LastIssuedPort
+}
+
+// get fetches the remote store. Returns nil data if it matches etag (304 Not
+// Modified) and os.ErrNotExist if the store does not exist yet.
+func (b remoteBackend) get(etag string) ([]byte, string, error) {
+	req, err := b.request(http.MethodGet, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := b.httpClient().Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read remote store: %w", err)
+		}
+		return data, resp.Header.Get("ETag"), nil
+	case http.StatusNotModified:
+		return nil, etag, nil
+	case http.StatusNotFound:
+		return nil, "", os.ErrNotExist
+	default:
+		return nil, "", fmt.Errorf("GET %s: %s", b.url, resp.Status)
+	}
+}
+
+// put uploads the store if the remote still has etag (or does not exist yet if
+// etag is empty). force skips the precondition.
+func (b remoteBackend) put(data []byte, etag string, force bool) (string, error) {
+	req, err := b.request(http.MethodPut, data)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	switch {
+	case force:
+	case etag != "":
+		req.Header.Set("If-Match", etag)
+	default:
+		req.Header.Set("If-None-Match", "*")
+	}
+	resp, err := b.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed:
+		return "", errPreconditionFailed
+	default:
+		return "", fmt.Errorf("PUT %s: %s", b.url, resp.Status)
+	}
+}
+
+// request builds a request to the remote store with the optional bearer token.
+func (b remoteBackend) request(method string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, b.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote store URL: %w", err)
+	}
+	if token := os.Getenv(RemoteTokenEnvVar); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+func (b remoteBackend) httpClient() *http.Client {
+	if b.client != nil {
+		return b.client
+	}
+	return &http.Client{Timeout: remoteTimeout}
+}
+
+// decodeRemote parses a remote store document and records its per-port snapshot.
+func decodeRemote(data []byte) (*Store, error) {
+	store := NewStore()
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, store); err != nil {
+			return nil, fmt.Errorf("%w: remote store: %v", ErrCorrupted, err)
+		}
+	}
+	store.normalize()
+	rows, err := serializeRows(store)
+	if err != nil {
+		return nil, err
+	}
+	store.loaded = rows
+	store.snapshotMeta()
+	return store, nil
+}
+
+// serializeRows returns the YAML of each allocation keyed by port.
+func serializeRows(store *Store) (map[int]string, error) {
+	rows := make(map[int]string, len(store.Allocations))
+	for port, info := range store.Allocations {
+		if info == nil {
+			continue
+		}
+		data, err := yaml.Marshal(info)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal allocation for port %d: %w", port, err)
+		}
+		rows[port] = string(data)
+	}
+	return rows, nil
+}
+
+// readETag returns the ETag of the cached remote store, or "".
+func readETag(path string) string {
+	data, err := os.ReadFile(path + ".etag")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// writeRemoteCache stores the remote document and its ETag next to each other.
+func writeRemoteCache(path string, data []byte, etag string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(path+".etag", []byte(etag+"\n"))
+}
@@ -0,0 +1,334 @@
+package allocations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// remoteServer is an in-memory document store honoring ETag preconditions.
+type remoteServer struct {
+	mu      sync.Mutex
+	data    []byte
+	version int
+}
+
+func (s *remoteServer) etag() string { return fmt.Sprintf(`"v%d"`, s.version) }
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		if s.data == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == s.etag() {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", s.etag())
+		w.Write(s.data)
+	case http.MethodPut:
+		if m := r.Header.Get("If-Match"); m != "" && (s.data == nil || m != s.etag()) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && s.data != nil {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.data = data
+		s.version++
+		w.Header().Set("ETag", s.etag())
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// useRemoteBackend switches to the remote backend backed by srv for the duration of a test.
+func useRemoteBackend(t *testing.T, srv *remoteServer) {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	SetRemoteURL(ts.URL + "/allocations.yaml")
+	if err := SetBackend(BackendRemote); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetRemoteURL("")
+		if err := SetBackend(BackendYAML); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRemoteBackend_RoundTrip(t *testing.T) {
+	srv := &remoteServer{}
+	useRemoteBackend(t, srv)
+	dirA, dirB := t.TempDir(), t.TempDir() // two machines sharing the remote store
+
+	if err := WithStore(dirA, func(s *Store) error {
+		s.SetAllocationWithName("/project-a", 3000, "web")
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Load(dirB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.FindByPort(3000); a == nil || a.Directory != "/project-a" {
+		t.Errorf("expected port 3000 for /project-a on the other machine, got %v", a)
+	}
+	if _, err := os.Stat(filepath.Join(dirB, remoteCacheFileName)); err != nil {
+		t.Errorf("expected local cache: %v", err)
+	}
+
+	// Unchanged remote is served from the cache (304)
+	if _, err := Load(dirB); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRemoteBackend_MergesConcurrentChanges(t *testing.T) {
+	srv := &remoteServer{}
+	useRemoteBackend(t, srv)
+	dirA, dirB := t.TempDir(), t.TempDir()
+
+	if err := WithStore(dirA, func(s *Store) error {
+		s.SetAllocationWithName("/shared", 3000, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// B reads, then A writes before B saves
+	err := WithStore(dirB, func(s *Store) error {
+		if err := WithStore(dirA, func(s *Store) error {
+			s.SetAllocationWithName("/project-a", 3001, "main")
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		s.SetAllocationWithName("/project-b", 3002, "main")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected non-overlapping changes to merge, got %v", err)
+	}
+
+	loaded, err := Load(dirA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []int{3000, 3001, 3002} {
+		if loaded.FindByPort(p) == nil {
+			t.Errorf("expected port %d after merge, got %v", p, loaded.SortedByPort())
+		}
+	}
+}
+
+func TestRemoteBackend_Conflict(t *testing.T) {
+	srv := &remoteServer{}
+	useRemoteBackend(t, srv)
+	dirA, dirB := t.TempDir(), t.TempDir()
+
+	if _, err := Load(dirA); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both machines give the same port to different directories
+	err := WithStore(dirB, func(s *Store) error {
+		if err := WithStore(dirA, func(s *Store) error {
+			s.SetAllocationWithName("/project-a", 3000, "main")
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		s.SetAllocationWithName("/project-b", 3000, "main")
+		return nil
+	})
+	if !errors.Is(err, ErrRemoteConflict) {
+		t.Fatalf("expected ErrRemoteConflict, got %v", err)
+	}
+
+	loaded, err := Load(dirA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.FindByPort(3000); a == nil || a.Directory != "/project-a" {
+		t.Errorf("expected the first writer to keep port 3000, got %v", a)
+	}
+}
+
+func TestRemoteBackend_UnreachableUsesCache(t *testing.T) {
+	srv := &remoteServer{}
+	useRemoteBackend(t, srv)
+	dir := t.TempDir()
+
+	if err := WithStore(dir, func(s *Store) error {
+		s.SetAllocationWithName("/project-a", 3000, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	SetRemoteURL("http://127.0.0.1:1/allocations.yaml")
+	if err := SetBackend(BackendRemote); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("expected cached store, got %v", err)
+	}
+	if loaded.FindByPort(3000) == nil {
+		t.Error("expected port 3000 from the cache")
+	}
+}
+
+func TestRemoteBackend_MergesStickyAndLastIssued(t *testing.T) {
+	srv := &remoteServer{}
+	useRemoteBackend(t, srv)
+	dirA, dirB := t.TempDir(), t.TempDir()
+
+	if err := WithStore(dirA, func(s *Store) error {
+		s.SetAllocationWithName("/project-a", 3001, "main")
+		s.SetAllocationWithName("/project-b", 3002, "main")
+		s.LastIssuedPort = 3002
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WithStore(dirB, func(s *Store) error {
+		if err := WithStore(dirA, func(s *Store) error {
+			s.SetSticky(3001, "/project-a", "main")
+			s.LastIssuedPort = 3005
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		s.SetSticky(3002, "/project-b", "main")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected non-overlapping sticky changes to merge, got %v", err)
+	}
+
+	loaded, err := Load(dirA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.StickyPort("/project-a", "main") != 3001 || loaded.StickyPort("/project-b", "main") != 3002 {
+		t.Errorf("sticky ports after merge = %+v, want both", loaded.Sticky)
+	}
+	if loaded.LastIssuedPort != 3005 {
+		t.Errorf("last issued port after merge = %d, want the other writer's 3005", loaded.LastIssuedPort)
+	}
+
+	// The same sticky port given to different owners is a conflict
+	err = WithStore(dirB, func(s *Store) error {
+		if err := WithStore(dirA, func(s *Store) error {
+			s.SetSticky(3000, "/project-a", "web")
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		s.SetSticky(3000, "/project-b", "web")
+		return nil
+	})
+	if !errors.Is(err, ErrRemoteConflict) {
+		t.Errorf("expected ErrRemoteConflict for sticky port 3000, got %v", err)
+	}
+}
+
+func TestRemoteBackend_CacheModes(t *testing.T) {
+	srv := &remoteServer{}
+	useRemoteBackend(t, srv)
+	dirA, dirB := t.TempDir(), t.TempDir()
+
+	if err := WithStore(dirA, func(s *Store) error {
+		s.SetAllocationWithName("/project-a", 3000, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read-only mode doesn't write the cache
+	SetReadOnly(true)
+	loaded, err := Load(dirB)
+	SetReadOnly(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FindByPort(3000) == nil {
+		t.Errorf("expected port 3000 from the server, got %v", loaded.SortedByPort())
+	}
+	if _, err := os.Stat(filepath.Join(dirB, remoteCacheFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no cache in read-only mode, got %v", err)
+	}
+
+	// Cache-only reads don't see later changes on the server
+	if _, err := Load(dirB); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithStore(dirA, func(s *Store) error {
+		s.SetAllocationWithName("/project-b", 3001, "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	SetRemoteCacheOnly(true)
+	t.Cleanup(func() { SetRemoteCacheOnly(false) })
+	loaded, err = Load(dirB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FindByPort(3001) != nil {
+		t.Error("cache-only read asked the server")
+	}
+}
+
+func TestConvert_YAMLToRemote(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := WithStore(tmpDir, func(s *Store) error {
+		s.SetAllocationWithName("/project-a", 3000, "web")
+		s.SetAllocationWithName("/project-b", 3001, "api")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &remoteServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	count, err := Convert(tmpDir, yamlBackend{}, remoteBackend{url: ts.URL + "/allocations.yaml"})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Convert() = %d, want 2", count)
+	}
+
+	useRemoteBackend(t, srv)
+	loaded, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.FindByPort(3001); a == nil || a.Directory != "/project-b" || a.Name != "api" {
+		t.Errorf("converted port 3001 = %v, want /project-b api", a)
+	}
+}
@@ -0,0 +1,139 @@
+package allocations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotCorrupted is returned by Repair when the store parses fine.
+var ErrNotCorrupted = errors.New("allocations file is not corrupted")
+
+var (
+	// entryLine matches the start of an allocation entry ("  3000:").
+	entryLine = regexp.MustCompile(`^  (\d+):\s*$`)
+	// lastIssuedLine matches the last issued port line.
+	lastIssuedLine = regexp.MustCompile(`^last_issued_port:\s*(\d+)\s*$`)
+)
+
+// RepairResult describes what Repair salvaged from a corrupted store.
+type RepairResult struct {
+	Store          *Store   // the repaired store
+	Dropped        []string // descriptions of entries that could not be parsed
+	QuarantinePath string   // where the broken original was moved
+}
+
+// Repair salvages the parseable entries of a corrupted YAML store, moves the
+// broken file to <store>.corrupt-<time> and writes the repaired store in its place.
+// Returns ErrNotCorrupted if the store parses. In dry-run mode nothing is written.
+func Repair(configDir string) (*RepairResult, error) {
+	if err := checkWritable("repair the store"); err != nil {
+		return nil, err
+	}
+	b := currentBackend()
+	if b.Name() != BackendYAML {
+		return nil, fmt.Errorf("repair supports the %s store only", BackendYAML)
+	}
+
+	fl, err := openAndLock(configDir)
+	if err != nil {
+		return nil, err
+	}
+	defer fl.unlock()
+
+	path := storePath(b, configDir)
+	if _, err := b.Read(path); !errors.Is(err, ErrCorrupted) {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrNotCorrupted
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read allocations file: %w", err)
+	}
+	store, dropped := salvage(data)
+	result := &RepairResult{Store: store, Dropped: dropped}
+	debug.Printf("allocations", "repair: salvaged %d allocations, dropped %d", len(store.Allocations), len(dropped))
+
+	if IsDryRun() {
+		return result, nil
+	}
+
+	result.QuarantinePath = quarantinePath(path)
+	if err := os.Rename(path, result.QuarantinePath); err != nil {
+		return nil, fmt.Errorf("failed to quarantine corrupted file: %w", err)
+	}
+	if err := b.Write(path, store); err != nil {
+		return nil, err
+	}
+
+	logger.Log(logger.AllocRepair, logger.Field("recovered", len(store.Allocations)),
+		logger.Field("dropped", len(dropped)), logger.Field("quarantine", result.QuarantinePath))
+	return result, nil
+}
+
+// quarantinePath returns the path a damaged store file at path is moved to.
+func quarantinePath(path string) string {
+	return path + ".corrupt-" + time.Now().UTC().Format("20060102T150405Z")
+}
+
+// salvage parses a broken store line by line: each "  PORT:" block under
+// allocations is decoded on its own, so one damaged entry doesn't lose the rest.
+// Returns the recovered store and descriptions of the dropped blocks.
+func salvage(data []byte) (*Store, []string) {
+	store := NewStore()
+	var dropped []string
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		if m := lastIssuedLine.FindStringSubmatch(lines[i]); m != nil {
+			store.LastIssuedPort, _ = strconv.Atoi(m[1])
+			continue
+		}
+		m := entryLine.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		start := i + 1
+		var body strings.Builder
+		for i+1 < len(lines) && (strings.HasPrefix(lines[i+1], "    ") || strings.TrimSpace(lines[i+1]) == "") {
+			i++
+			body.WriteString(strings.TrimPrefix(lines[i], "    "))
+			body.WriteString("\n")
+		}
+
+		port, err := strconv.Atoi(m[1])
+		if err != nil || port < 1 || port > 65535 {
+			dropped = append(dropped, fmt.Sprintf("line %d: invalid port %s", start, m[1]))
+			continue
+		}
+		var info AllocationInfo
+		if err := yaml.Unmarshal([]byte(body.String()), &info); err != nil {
+			dropped = append(dropped, fmt.Sprintf("line %d: port %d: %v", start, port, err))
+			continue
+		}
+		if info.Directory == "" {
+			dropped = append(dropped, fmt.Sprintf("line %d: port %d: missing directory", start, port))
+			continue
+		}
+		if _, exists := store.Allocations[port]; exists {
+			dropped = append(dropped, fmt.Sprintf("line %d: port %d: duplicate entry", start, port))
+			continue
+		}
+		store.Allocations[port] = &info
+	}
+
+	store.normalize()
+	return store, dropped
+}
@@ -0,0 +1,84 @@
+package allocations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const corruptedStore = `last_issued_port: 3012
+allocations:
+  3000:
+    directory: /home/user/project-a
+    name: main
+    assigned_at: 2026-01-06T20:00:00Z
+    locked: true
+  3010:
+    directory: /home/user/myproject
+    name: [web
+    assigned_at: 2026-01-06T20:00:00Z
+  3011:
+    directory: /home/user/myproject
+    name: api
+    assigned_at: 2026-01-06T20:01:00Z
+  3012:
+    name: db
+`
+
+func TestSalvage(t *testing.T) {
+	store, dropped := salvage([]byte(corruptedStore))
+
+	if store.LastIssuedPort != 3012 {
+		t.Errorf("LastIssuedPort = %d, want 3012", store.LastIssuedPort)
+	}
+	if store.Count() != 2 {
+		t.Fatalf("salvaged %d allocations, want 2", store.Count())
+	}
+	if a := store.FindByPort(3000); a == nil || !a.Locked || a.Directory != "/home/user/project-a" {
+		t.Errorf("port 3000 not salvaged correctly: %+v", a)
+	}
+	if a := store.FindByPort(3011); a == nil || a.Name != "api" {
+		t.Errorf("port 3011 not salvaged correctly: %+v", a)
+	}
+
+	if len(dropped) != 2 || !strings.Contains(dropped[0], "port 3010") || !strings.Contains(dropped[1], "missing directory") {
+		t.Errorf("unexpected dropped entries: %v", dropped)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	configDir := t.TempDir()
+	path := filepath.Join(configDir, allocationsFileName)
+
+	store := NewStore()
+	store.SetAllocation("/home/user/a", 3000)
+	if err := Save(configDir, store); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Repair(configDir); !errors.Is(err, ErrNotCorrupted) {
+		t.Fatalf("Repair() on a valid store: %v, want ErrNotCorrupted", err)
+	}
+
+	if err := os.WriteFile(path, []byte(corruptedStore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Repair(configDir)
+	if err != nil {
+		t.Fatalf("Repair() error: %v", err)
+	}
+
+	quarantined, err := os.ReadFile(result.QuarantinePath)
+	if err != nil || string(quarantined) != corruptedStore {
+		t.Errorf("corrupted original not quarantined at %s: %v", result.QuarantinePath, err)
+	}
+
+	loaded, err := Load(configDir)
+	if err != nil {
+		t.Fatalf("Load() after repair: %v", err)
+	}
+	if loaded.Count() != 2 || loaded.LastIssuedPort != 3012 {
+		t.Errorf("repaired store has %d allocations (last issued %d), want 2 (3012)", loaded.Count(), loaded.LastIssuedPort)
+	}
+}
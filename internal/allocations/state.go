@@ -0,0 +1,69 @@
+package allocations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+var stateDir string // replaces configDir for the store files, guarded by backendMu
+
+// SetStateDir keeps the store, lock file, undo journal and backups in dir
+// instead of the config directory, e.g. when the config directory is
+// read-only. Empty dir restores the default.
+func SetStateDir(dir string) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	stateDir = dir
+	if dir != "" {
+		debug.Printf("allocations", "using state dir %s", dir)
+	}
+}
+
+// StateDir returns the directory holding the store and other state for
+// configDir: the directory set by SetStateDir, or configDir.
+func StateDir(configDir string) string {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return stateRoot(configDir)
+}
+
+// stateRoot is StateDir for callers holding backendMu.
+func stateRoot(configDir string) string {
+	if stateDir != "" {
+		return stateDir
+	}
+	return configDir
+}
+
+// SeedStateDir copies the store file of configDir to the state directory set
+// by SetStateDir unless it already has one, so the allocations made before
+// the switch are kept. Returns whether a store was copied.
+func SeedStateDir(configDir string) (bool, error) {
+	b := currentBackend()
+	backendMu.Lock()
+	if stateDir == "" || stateDir == configDir {
+		backendMu.Unlock()
+		return false, nil
+	}
+	src := b.Path(hostDir(configDir, storeHost))
+	dst := b.Path(hostDir(stateDir, storeHost))
+	backendMu.Unlock()
+
+	if _, err := os.Stat(dst); err == nil {
+		return false, nil
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return false, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := copyStoreFile(src, dst); err != nil {
+		return false, fmt.Errorf("failed to copy store to state directory: %w", err)
+	}
+	debug.Printf("allocations", "copied store %s to %s", src, dst)
+	return true, nil
+}
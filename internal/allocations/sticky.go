@@ -0,0 +1,84 @@
+package allocations
+
+import (
+	"path/filepath"
+
+	"github.com/dapi/port-selector/internal/logger"
+)
+
+// StickyOwner is the directory and name a sticky port is preferred for.
+type StickyOwner struct {
+	Directory string `yaml:"directory"`
+	Name      string `yaml:"name,omitempty"`
+}
+
+// StickyPort returns the sticky port of (dir, name), or 0 if it has none.
+func (s *Store) StickyPort(dir, name string) int {
+	dir = filepath.Clean(dir)
+	name = normalizeName(name)
+	for port, owner := range s.Sticky {
+		if owner != nil && owner.Directory == dir && owner.Name == name {
+			return port
+		}
+	}
+	return 0
+}
+
+// StickyOwnerOf returns the owner of a sticky port, or nil if the port is not sticky.
+func (s *Store) StickyOwnerOf(port int) *StickyOwner {
+	return s.Sticky[port]
+}
+
+// IsSticky reports whether the allocation on port is held by the owner of the sticky port.
+func (s *Store) IsSticky(port int) bool {
+	owner, info := s.Sticky[port], s.Allocations[port]
+	return owner != nil && info != nil && info.Directory == owner.Directory && info.Name == owner.Name
+}
+
+// SetSticky makes port the sticky port of (dir, name), replacing its previous
+// sticky port and any other owner of port.
+func (s *Store) SetSticky(port int, dir, name string) {
+	dir = filepath.Clean(dir)
+	name = normalizeName(name)
+
+	// Build a new map: snapshots taken for dry-run share the old one
+	sticky := make(map[int]*StickyOwner, len(s.Sticky)+1)
+	for p, owner := range s.Sticky {
+		if owner != nil && !(owner.Directory == dir && owner.Name == name) {
+			sticky[p] = owner
+		}
+	}
+	sticky[port] = &StickyOwner{Directory: dir, Name: name}
+	s.Sticky = sticky
+
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", dir),
+		logger.Field("name", name),
+		logger.Field("sticky", true))
+}
+
+// ClearSticky removes the sticky port of (dir, name) and returns it, or 0 if there was none.
+func (s *Store) ClearSticky(dir, name string) int {
+	port := s.StickyPort(dir, name)
+	if port == 0 {
+		return 0
+	}
+	sticky := make(map[int]*StickyOwner, len(s.Sticky))
+	for p, owner := range s.Sticky {
+		if p != port {
+			sticky[p] = owner
+		}
+	}
+	if len(sticky) == 0 {
+		sticky = nil
+	}
+	s.Sticky = sticky
+
+	logger.Log(logger.AllocUpdate,
+		logger.Field("port", port),
+		logger.Field("dir", filepath.Clean(dir)),
+		logger.Field("name", normalizeName(name)),
+		logger.Field("sticky", false))
+	return port
+}
@@ -0,0 +1,63 @@
+package allocations
+
+import "testing"
+
+func TestSticky_SetAndClear(t *testing.T) {
+	s := NewStore()
+	s.SetAllocationWithName("/project", 3000, "web")
+	s.SetSticky(3000, "/project", "web")
+	if got := s.StickyPort("/project/", "web"); got != 3000 {
+		t.Errorf("StickyPort() = %d, want 3000", got)
+	}
+	if !s.IsSticky(3000) {
+		t.Error("IsSticky(3000) = false, want true")
+	}
+
+	// A new sticky port replaces the old one
+	s.SetSticky(3005, "/project", "web")
+	if s.StickyOwnerOf(3000) != nil || s.StickyPort("/project", "web") != 3005 {
+		t.Errorf("sticky = %v, want only 3005", s.Sticky)
+	}
+	if s.IsSticky(3005) {
+		t.Error("IsSticky(3005) = true for a port allocated to nobody")
+	}
+
+	if got := s.ClearSticky("/project", "web"); got != 3005 || s.Sticky != nil {
+		t.Errorf("ClearSticky() = %d, sticky = %v", got, s.Sticky)
+	}
+	if got := s.ClearSticky("/project", "web"); got != 0 {
+		t.Errorf("second ClearSticky() = %d, want 0", got)
+	}
+}
+
+func TestSticky_Persisted(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := WithStore(tmpDir, func(s *Store) error {
+		s.SetAllocationWithName("/project", 3000, "main")
+		s.SetSticky(3000, "/project", "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.StickyPort("/project", "main"); got != 3000 {
+		t.Errorf("StickyPort() after reload = %d, want 3000", got)
+	}
+
+	if err := WithStore(tmpDir, func(s *Store) error {
+		s.ClearSticky("/project", "main")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = Load(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Sticky) != 0 {
+		t.Errorf("sticky after clear = %v, want none", loaded.Sticky)
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dapi/port-selector/internal/debug"
+	"github.com/dapi/port-selector/internal/i18n"
+	"github.com/dapi/port-selector/internal/pathutil"
 	"gopkg.in/yaml.v3"
 )
 
@@ -25,21 +29,109 @@ const (
 	DefaultPortEnd       = 4000
 	DefaultFreezePeriod  = "24h"
 	DefaultAllocationTTL = "" // empty means disabled
+	DefaultExpiryWarning = "3d"
 	DefaultLog           = "~/.config/port-selector/port-selector.log"
+	DefaultStore         = "yaml"
+	DefaultComposeBlock  = 10
+	MaxBackups           = 100
+)
+
+// Policies for an existing allocation whose port is taken by a foreign process (onConflict).
+const (
+	ConflictReuse      = "reuse"      // warn and return the port anyway (default)
+	ConflictFail       = "fail"       // return an error
+	ConflictReallocate = "reallocate" // drop the allocation and allocate a new port
+)
+
+// Ways to find the project root of a subdirectory (rootDetection).
+const (
+	RootDetectionNone   = "none"   // every directory is its own project (default)
+	RootDetectionGit    = "git"    // the nearest directory with .git
+	RootDetectionConfig = "config" // the nearest directory with .port-selector.yaml
+)
+
+// How directories that differ only in letter case are treated (pathCase).
+const (
+	PathCaseAuto        = "auto"        // insensitive on macOS and Windows, sensitive elsewhere (default)
+	PathCaseSensitive   = "sensitive"   // /Users/me/Project and /users/me/project are different directories
+	PathCaseInsensitive = "insensitive" // they are the same directory, stored as spelled on disk
+)
+
+// Reactions to a port range that overlaps the kernel's ephemeral range (ephemeralOverlap).
+const (
+	OverlapWarn   = "warn"   // print a warning when a new port is searched (default)
+	OverlapFail   = "fail"   // refuse to allocate new ports
+	OverlapIgnore = "ignore" // don't check
 )
 
 // Config represents the application configuration.
 type Config struct {
-	PortStart     int    `yaml:"portStart"`
-	PortEnd       int    `yaml:"portEnd"`
-	FreezePeriod  string `yaml:"freezePeriod,omitempty"`
-	AllocationTTL string `yaml:"allocationTTL,omitempty"`
-	Log           string `yaml:"log,omitempty"`
+	PortStart        int    `yaml:"portStart"`
+	PortEnd          int    `yaml:"portEnd"`
+	FreezePeriod     string `yaml:"freezePeriod,omitempty"`
+	AllocationTTL    string `yaml:"allocationTTL,omitempty"`
+	ExpiryWarning    string `yaml:"expiryWarning,omitempty"`
+	Log              string `yaml:"log,omitempty"`
+	LogFormat        string `yaml:"logFormat,omitempty"`
+	LogTarget        string `yaml:"logTarget,omitempty"`
+	Store            string `yaml:"store,omitempty"`
+	RemoteURL        string `yaml:"remoteURL,omitempty"`
+	Notify           bool   `yaml:"notify,omitempty"`
+	ReadOnly         bool   `yaml:"readOnly,omitempty"`
+	PerHost          bool   `yaml:"perHost,omitempty"`
+	Backups          int    `yaml:"backups,omitempty"`
+	UpdateCheck      bool   `yaml:"updateCheck,omitempty"`
+	ContainerRuntime string `yaml:"containerRuntime,omitempty"`
+	SocketSource     string `yaml:"socketSource,omitempty"`
+	PortCheck        string `yaml:"portCheck,omitempty"`
+	CheckTimeoutMs   int    `yaml:"checkTimeoutMs,omitempty"`
+	Checks           string `yaml:"checks,omitempty"`
+	Language         string `yaml:"language,omitempty"`
+	RootDetection    string `yaml:"rootDetection,omitempty"`
+	Symlinks         string `yaml:"symlinks,omitempty"`
+	PathCase         string `yaml:"pathCase,omitempty"`
+	OnConflict       string `yaml:"onConflict,omitempty"`
+	VerifyOwner      bool   `yaml:"verifyOwner,omitempty"`
+	EphemeralOverlap string `yaml:"ephemeralOverlap,omitempty"`
+	ComposeBlockSize int    `yaml:"composeBlockSize,omitempty"`
+
+	// FreezeRules override freezePeriod for matching allocations (first match wins)
+	FreezeRules []FreezeRule `yaml:"freezeRules,omitempty"`
+
+	// ExcludedPorts and ExcludedRanges ("5000-5010") are never allocated, even when free
+	ExcludedPorts  []int    `yaml:"excludedPorts,omitempty"`
+	ExcludedRanges []string `yaml:"excludedRanges,omitempty"`
+
+	// AdvertiseNames are the allocation names the advertise command announces via mDNS
+	AdvertiseNames []string `yaml:"advertiseNames,omitempty"`
 
 	// Legacy field for backward compatibility (deprecated)
 	FreezePeriodMinutesLegacy int `yaml:"freezePeriodMinutes,omitempty"`
 }
 
+// FreezeRule overrides the freeze period for allocations matching a name and/or directory.
+type FreezeRule struct {
+	Name         string `yaml:"name,omitempty"`      // allocation name (empty matches any)
+	Directory    string `yaml:"directory,omitempty"` // directory glob, supports ~ (empty matches any)
+	FreezePeriod string `yaml:"freezePeriod"`        // e.g., 0, 1h, 7d
+}
+
+// matches reports whether the rule applies to the given directory and name.
+func (r FreezeRule) matches(dir, name string) bool {
+	if r.Name != "" && r.Name != name {
+		return false
+	}
+	if r.Directory != "" {
+		pattern := pathutil.ExpandHome(r.Directory)
+		if pattern != dir {
+			if ok, err := filepath.Match(pattern, dir); err != nil || !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // DefaultConfig returns a new Config with default values.
 func DefaultConfig() *Config {
 	return &Config{
@@ -48,6 +140,7 @@ func DefaultConfig() *Config {
 		FreezePeriod:  DefaultFreezePeriod,
 		AllocationTTL: DefaultAllocationTTL,
 		Log:           DefaultLog,
+		Store:         DefaultStore,
 	}
 }
 
@@ -78,9 +171,129 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("invalid allocationTTL: %w", err)
 		}
 	}
+	if c.ExpiryWarning != "" && c.ExpiryWarning != "0" {
+		if _, err := ParseDuration(c.ExpiryWarning); err != nil {
+			return fmt.Errorf("invalid expiryWarning: %w", err)
+		}
+	}
+	for i, rule := range c.FreezeRules {
+		if rule.Name == "" && rule.Directory == "" {
+			return fmt.Errorf("freezeRules[%d]: name or directory is required", i)
+		}
+		if _, err := ParseDuration(rule.FreezePeriod); err != nil {
+			return fmt.Errorf("freezeRules[%d]: invalid freezePeriod: %w", i, err)
+		}
+		if rule.Directory != "" {
+			if _, err := filepath.Match(rule.Directory, ""); err != nil {
+				return fmt.Errorf("freezeRules[%d]: invalid directory pattern: %w", i, err)
+			}
+		}
+	}
+	for i, p := range c.ExcludedPorts {
+		if p < 1 || p > 65535 {
+			return fmt.Errorf("excludedPorts[%d]: port %d must be between 1 and 65535", i, p)
+		}
+	}
+	for i, r := range c.ExcludedRanges {
+		if _, _, err := parsePortRange(r); err != nil {
+			return fmt.Errorf("excludedRanges[%d]: %w", i, err)
+		}
+	}
+	for i, name := range c.AdvertiseNames {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("advertiseNames[%d]: name must not be empty", i)
+		}
+	}
+	if c.LogFormat != "" && c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("invalid logFormat %q (must be text or json)", c.LogFormat)
+	}
+	switch c.LogTarget {
+	case "", "file", "syslog", "journald":
+	default:
+		return fmt.Errorf("invalid logTarget %q (must be file, syslog or journald)", c.LogTarget)
+	}
+	if c.Store != "" && c.Store != "yaml" && c.Store != "remote" {
+		return fmt.Errorf("invalid store %q (must be yaml or remote)", c.Store)
+	}
+	if c.RemoteURL != "" && !strings.HasPrefix(c.RemoteURL, "http://") && !strings.HasPrefix(c.RemoteURL, "https://") {
+		return fmt.Errorf("invalid remoteURL %q (must be an http:// or https:// URL)", c.RemoteURL)
+	}
+	if c.Store == "remote" && c.RemoteURL == "" {
+		return fmt.Errorf("store remote requires remoteURL")
+	}
+	if c.Store == "remote" && c.PerHost {
+		return fmt.Errorf("perHost cannot be used with store remote, which is shared on purpose")
+	}
+	switch c.ContainerRuntime {
+	case "", "auto", "docker", "podman", "nerdctl":
+	default:
+		return fmt.Errorf("invalid containerRuntime %q (must be auto, docker, podman or nerdctl)", c.ContainerRuntime)
+	}
+	switch c.SocketSource {
+	case "", "auto", "netlink", "proc":
+	default:
+		return fmt.Errorf("invalid socketSource %q (must be auto, netlink or proc)", c.SocketSource)
+	}
+	if c.ComposeBlockSize < 0 || c.ComposeBlockSize > c.PortEnd-c.PortStart+1 {
+		return fmt.Errorf("composeBlockSize (%d) must be between 0 and the range size (%d)", c.ComposeBlockSize, c.PortEnd-c.PortStart+1)
+	}
+	switch c.PortCheck {
+	case "", "bind", "strict":
+	default:
+		return fmt.Errorf("invalid portCheck %q (must be bind or strict)", c.PortCheck)
+	}
+	if c.CheckTimeoutMs < 0 {
+		return fmt.Errorf("checkTimeoutMs (%d) must not be negative", c.CheckTimeoutMs)
+	}
+	switch c.Checks {
+	case "", "on", "off":
+	default:
+		return fmt.Errorf("invalid checks %q (must be on or off)", c.Checks)
+	}
+	switch c.Language {
+	case "", "auto", "en", "ru":
+	default:
+		return fmt.Errorf("invalid language %q (must be auto, en or ru)", c.Language)
+	}
+	switch c.RootDetection {
+	case "", RootDetectionNone, RootDetectionGit, RootDetectionConfig:
+	default:
+		return fmt.Errorf("invalid rootDetection %q (must be git, config or none)", c.RootDetection)
+	}
+	switch c.Symlinks {
+	case "", "resolve", "keep":
+	default:
+		return fmt.Errorf("invalid symlinks %q (must be resolve or keep)", c.Symlinks)
+	}
+	switch c.PathCase {
+	case "", PathCaseAuto, PathCaseSensitive, PathCaseInsensitive:
+	default:
+		return fmt.Errorf("invalid pathCase %q (must be auto, sensitive or insensitive)", c.PathCase)
+	}
+	if err := ValidateConflictPolicy(c.OnConflict); err != nil {
+		return err
+	}
+	switch c.EphemeralOverlap {
+	case "", OverlapWarn, OverlapFail, OverlapIgnore:
+	default:
+		return fmt.Errorf("invalid ephemeralOverlap %q (must be warn, fail or ignore)", c.EphemeralOverlap)
+	}
+	if c.Backups < 0 || c.Backups > MaxBackups {
+		return fmt.Errorf("backups (%d) must be between 0 and %d", c.Backups, MaxBackups)
+	}
 	return nil
 }
 
+// ValidateConflictPolicy checks an onConflict value ("" means the default).
+func ValidateConflictPolicy(policy string) error {
+	switch policy {
+	case "", ConflictReuse, ConflictFail, ConflictReallocate:
+		return nil
+	default:
+		return fmt.Errorf("invalid onConflict %q (must be reuse, fail or reallocate)", policy)
+	}
+}
+
 // ParseDuration parses a duration string like "30d", "720h", "24h30m".
 // Supports: d (days), h (hours), m (minutes), s (seconds).
 func ParseDuration(s string) (time.Duration, error) {
@@ -116,13 +329,114 @@ func (c *Config) GetFreezePeriod() time.Duration {
 	}
 	d, err := ParseDuration(c.FreezePeriod)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "warning: invalid freezePeriod %q, using default: %v\n", c.FreezePeriod, err)
+		i18n.Fprintf(os.Stderr, "warning: invalid freezePeriod %q, using default: %v\n", c.FreezePeriod, err)
 		d, _ = ParseDuration(DefaultFreezePeriod)
 		return d
 	}
 	return d
 }
 
+// GetComposeBlockSize returns the number of adjacent ports reserved for a compose project
+// (DefaultComposeBlock when not set; 1 disables blocks).
+func (c *Config) GetComposeBlockSize() int {
+	if c.ComposeBlockSize == 0 {
+		return DefaultComposeBlock
+	}
+	return c.ComposeBlockSize
+}
+
+// GetLogTarget returns where allocation events are logged ("file" when not set).
+func (c *Config) GetLogTarget() string {
+	if c.LogTarget == "" {
+		return "file"
+	}
+	return c.LogTarget
+}
+
+// CaseInsensitivePaths reports whether directories that differ only in case are
+// the same directory. With pathCase auto (or unset), this depends on the platform:
+// the default filesystems of macOS and Windows are case-insensitive.
+func (c *Config) CaseInsensitivePaths() bool {
+	switch c.PathCase {
+	case PathCaseSensitive:
+		return false
+	case PathCaseInsensitive:
+		return true
+	}
+	return DefaultCaseInsensitivePaths()
+}
+
+// DefaultCaseInsensitivePaths reports whether paths are case-insensitive on
+// this platform (pathCase: auto).
+func DefaultCaseInsensitivePaths() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// GetOnConflict returns the conflict policy (ConflictReuse when not set).
+func (c *Config) GetOnConflict() string {
+	if c.OnConflict == "" {
+		return ConflictReuse
+	}
+	return c.OnConflict
+}
+
+// GetEphemeralOverlap returns the reaction to an overlap with the kernel's
+// ephemeral range (OverlapWarn when not set).
+func (c *Config) GetEphemeralOverlap() string {
+	if c.EphemeralOverlap == "" {
+		return OverlapWarn
+	}
+	return c.EphemeralOverlap
+}
+
+// FreezePeriodFor returns the freeze period for an allocation with the given directory and name.
+// The first matching freeze rule wins; otherwise the global freeze period is used.
+func (c *Config) FreezePeriodFor(dir, name string) time.Duration {
+	for _, rule := range c.FreezeRules {
+		if rule.matches(dir, name) {
+			d, err := ParseDuration(rule.FreezePeriod)
+			if err != nil {
+				continue
+			}
+			return d
+		}
+	}
+	return c.GetFreezePeriod()
+}
+
+// parsePortRange parses "START-END" with 1 <= START <= END <= 65535.
+func parsePortRange(s string) (start, end int, err error) {
+	a, b, ok := strings.Cut(s, "-")
+	if ok {
+		start, err = strconv.Atoi(strings.TrimSpace(a))
+		if err == nil {
+			end, err = strconv.Atoi(strings.TrimSpace(b))
+		}
+	}
+	if !ok || err != nil || start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range %q (use START-END, e.g. 5000-5010)", s)
+	}
+	return start, end, nil
+}
+
+// ExcludedPortSet returns the ports of excludedPorts and excludedRanges.
+func (c *Config) ExcludedPortSet() map[int]bool {
+	excluded := make(map[int]bool, len(c.ExcludedPorts))
+	for _, p := range c.ExcludedPorts {
+		excluded[p] = true
+	}
+	for _, r := range c.ExcludedRanges {
+		start, end, err := parsePortRange(r)
+		if err != nil {
+			continue
+		}
+		for p := start; p <= end; p++ {
+			excluded[p] = true
+		}
+	}
+	return excluded
+}
+
 // GetAllocationTTL returns the parsed allocation TTL duration.
 // Returns 0 if TTL is disabled, empty, or has an invalid format.
 // Logs a warning to stderr if the format is invalid.
@@ -132,14 +446,62 @@ func (c *Config) GetAllocationTTL() time.Duration {
 	}
 	d, err := ParseDuration(c.AllocationTTL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "warning: invalid allocationTTL %q, TTL disabled: %v\n", c.AllocationTTL, err)
+		i18n.Fprintf(os.Stderr, "warning: invalid allocationTTL %q, TTL disabled: %v\n", c.AllocationTTL, err)
 		return 0
 	}
 	return d
 }
 
-// ConfigDir returns the path to the configuration directory.
-func ConfigDir() (string, error) {
+// GetExpiryWarning returns how long before expiry (TTL or lease) the allocations of
+// the directory are warned about. Defaults to DefaultExpiryWarning; 0 disables warnings.
+func (c *Config) GetExpiryWarning() time.Duration {
+	s := c.ExpiryWarning
+	if s == "" {
+		s = DefaultExpiryWarning
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "warning: invalid expiryWarning %q, expiry warnings disabled: %v\n", c.ExpiryWarning, err)
+		return 0
+	}
+	return d
+}
+
+// dirOverride replaces the default configuration directory when set (--config).
+var dirOverride string
+
+// profile selects a configuration directory under profiles/ (--profile).
+var profile string
+
+// profilesDirName is the directory inside the default config dir holding profiles.
+const profilesDirName = "profiles"
+
+// profilePattern matches valid profile names.
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// SetDir makes ConfigDir return dir instead of the user config directory.
+// Empty dir restores the default.
+func SetDir(dir string) {
+	dirOverride = dir
+}
+
+// SetProfile makes ConfigDir return the directory of the named profile,
+// each profile having its own config and allocations. Empty name selects the default pool.
+func SetProfile(name string) error {
+	if name != "" && !profilePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the selected profile name, or empty for the default pool.
+func Profile() string {
+	return profile
+}
+
+// baseDir returns the default configuration directory (~/.config/port-selector).
+func baseDir() (string, error) {
 	userConfigDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user config dir: %w", err)
@@ -147,6 +509,67 @@ func ConfigDir() (string, error) {
 	return filepath.Join(userConfigDir, appName), nil
 }
 
+// ConfigDir returns the path to the configuration directory:
+// the --config override, the selected profile's directory, or the default.
+func ConfigDir() (string, error) {
+	if dirOverride != "" {
+		return dirOverride, nil
+	}
+	dir, err := baseDir()
+	if err != nil {
+		return "", err
+	}
+	if profile != "" {
+		return filepath.Join(dir, profilesDirName, profile), nil
+	}
+	return dir, nil
+}
+
+// StateDir returns the directory for allocations when the configuration
+// directory is read-only: $XDG_STATE_HOME/port-selector (default
+// ~/.local/state/port-selector), with the selected profile's subdirectory.
+// Returns an empty path with --config, whose directory is used as is.
+func StateDir() (string, error) {
+	if dirOverride != "" {
+		return "", nil
+	}
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home dir: %w", err)
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	dir := filepath.Join(base, appName)
+	if profile != "" {
+		return filepath.Join(dir, profilesDirName, profile), nil
+	}
+	return dir, nil
+}
+
+// Profiles returns the names of existing profiles, sorted.
+func Profiles() ([]string, error) {
+	dir, err := baseDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, profilesDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && profilePattern.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
 // ConfigPath returns the full path to the configuration file.
 func ConfigPath() (string, error) {
 	dir, err := ConfigDir()
@@ -171,10 +594,14 @@ func Load() (*Config, error) {
 		debug.Printf("config", "config file not found, creating default")
 		// Create default config
 		cfg := DefaultConfig()
+		if dirOverride != "" || profile != "" {
+			// Keep the log inside an alternate config directory too
+			cfg.Log = filepath.Join(filepath.Dir(configPath), appName+".log")
+		}
 		if err := Save(cfg); err != nil {
 			debug.Printf("config", "failed to save default config: %v", err)
 			// Warn user about inability to save config
-			fmt.Fprintf(os.Stderr, "warning: could not save default config: %v\n", err)
+			i18n.Fprintf(os.Stderr, "warning: could not save default config: %v\n", err)
 			// If we can't save, just return defaults without error
 			return cfg, nil
 		}
@@ -255,12 +682,239 @@ func marshalConfigWithComments(cfg *Config) ([]byte, error) {
 		buf = append(buf, "# allocationTTL: 30d\n\n"...)
 	}
 
+	// expiryWarning
+	buf = append(buf, "# Warn when another allocation of the directory expires (TTL or lease) within this time (0 to disable)\n"...)
+	if cfg.ExpiryWarning != "" && cfg.ExpiryWarning != DefaultExpiryWarning {
+		buf = append(buf, fmt.Sprintf("expiryWarning: %s\n\n", cfg.ExpiryWarning)...)
+	} else {
+		buf = append(buf, fmt.Sprintf("# expiryWarning: %s\n\n", DefaultExpiryWarning)...)
+	}
+
 	// log
 	buf = append(buf, "# Path to log file for tracking allocation changes (supports ~ for home directory)\n"...)
 	if cfg.Log != "" {
-		buf = append(buf, fmt.Sprintf("log: %s\n", cfg.Log)...)
+		buf = append(buf, fmt.Sprintf("log: %s\n\n", cfg.Log)...)
+	} else {
+		buf = append(buf, fmt.Sprintf("log: %s\n\n", DefaultLog)...)
+	}
+
+	// logFormat
+	buf = append(buf, "# Log line format: text (default) or json (one JSON object per line)\n"...)
+	if cfg.LogFormat != "" && cfg.LogFormat != "text" {
+		buf = append(buf, fmt.Sprintf("logFormat: %s\n\n", cfg.LogFormat)...)
+	} else {
+		buf = append(buf, "# logFormat: text\n\n"...)
+	}
+
+	// logTarget
+	buf = append(buf, "# Where allocation events go: file (default, the log path above), syslog or journald\n"...)
+	if cfg.LogTarget != "" && cfg.LogTarget != "file" {
+		buf = append(buf, fmt.Sprintf("logTarget: %s\n\n", cfg.LogTarget)...)
+	} else {
+		buf = append(buf, "# logTarget: journald\n\n"...)
+	}
+
+	// store
+	buf = append(buf, "# Storage backend for allocations: yaml (default) or remote\n"...)
+	buf = append(buf, "# (shared YAML document at remoteURL, written with If-Match)\n"...)
+	if cfg.Store != "" && cfg.Store != DefaultStore {
+		buf = append(buf, fmt.Sprintf("store: %s\n", cfg.Store)...)
+	} else {
+		buf = append(buf, fmt.Sprintf("# store: %s\n", DefaultStore)...)
+	}
+	if cfg.RemoteURL != "" {
+		buf = append(buf, fmt.Sprintf("remoteURL: %s\n", cfg.RemoteURL)...)
+	} else {
+		buf = append(buf, "# remoteURL: https://ports.example.com/team/allocations.yaml\n"...)
+	}
+
+	// notify
+	buf = append(buf, "\n# Desktop notification when an allocated port is taken by another process\n"...)
+	if cfg.Notify {
+		buf = append(buf, "notify: true\n"...)
+	} else {
+		buf = append(buf, "# notify: true\n"...)
+	}
+
+	// perHost
+	buf = append(buf, "\n# Keep a separate store per hostname, for config directories synced across machines\n"...)
+	if cfg.PerHost {
+		buf = append(buf, "perHost: true\n"...)
+	} else {
+		buf = append(buf, "# perHost: true\n"...)
+	}
+
+	// readOnly
+	buf = append(buf, "\n# Never change the allocations store; lookups of existing allocations still work\n"...)
+	if cfg.ReadOnly {
+		buf = append(buf, "readOnly: true\n"...)
+	} else {
+		buf = append(buf, "# readOnly: true\n"...)
+	}
+
+	// backups
+	buf = append(buf, "\n# Keep this many copies of the allocations store, taken before each change (0 to disable)\n"...)
+	if cfg.Backups > 0 {
+		buf = append(buf, fmt.Sprintf("backups: %d\n", cfg.Backups)...)
+	} else {
+		buf = append(buf, "# backups: 5\n"...)
+	}
+
+	// updateCheck
+	buf = append(buf, "\n# Check GitHub once a day for a new release and print a notice to stderr\n"...)
+	if cfg.UpdateCheck {
+		buf = append(buf, "updateCheck: true\n"...)
 	} else {
-		buf = append(buf, fmt.Sprintf("log: %s\n", DefaultLog)...)
+		buf = append(buf, "# updateCheck: true\n"...)
+	}
+
+	// containerRuntime
+	buf = append(buf, "\n# Container runtime used to attribute published ports: auto (default), docker, podman or nerdctl\n"...)
+	if cfg.ContainerRuntime != "" && cfg.ContainerRuntime != "auto" {
+		buf = append(buf, fmt.Sprintf("containerRuntime: %s\n", cfg.ContainerRuntime)...)
+	} else {
+		buf = append(buf, "# containerRuntime: podman\n"...)
+	}
+
+	// socketSource
+	buf = append(buf, "\n# How listening sockets are read: auto (sock_diag netlink, falling back to /proc), netlink or proc\n"...)
+	if cfg.SocketSource != "" && cfg.SocketSource != "auto" {
+		buf = append(buf, fmt.Sprintf("socketSource: %s\n", cfg.SocketSource)...)
+	} else {
+		buf = append(buf, "# socketSource: proc\n"...)
+	}
+
+	// composeBlockSize
+	buf = append(buf, "\n# Adjacent ports reserved for the named ports of a compose project (default 10, 1 disables)\n"...)
+	if cfg.ComposeBlockSize != 0 {
+		buf = append(buf, fmt.Sprintf("composeBlockSize: %d\n", cfg.ComposeBlockSize)...)
+	} else {
+		buf = append(buf, "# composeBlockSize: 5\n"...)
+	}
+
+	// portCheck
+	buf = append(buf, "\n# How a port is tested for availability: bind (default) or strict\n# (strict also treats TIME_WAIT and interface-specific listeners as busy)\n"...)
+	if cfg.PortCheck != "" && cfg.PortCheck != "bind" {
+		buf = append(buf, fmt.Sprintf("portCheck: %s\n", cfg.PortCheck)...)
+	} else {
+		buf = append(buf, "# portCheck: strict\n"...)
+	}
+
+	// checkTimeoutMs
+	buf = append(buf, "\n# A port check that takes longer than this reports the port busy (default 1000)\n"...)
+	if cfg.CheckTimeoutMs > 0 {
+		buf = append(buf, fmt.Sprintf("checkTimeoutMs: %d\n", cfg.CheckTimeoutMs)...)
+	} else {
+		buf = append(buf, "# checkTimeoutMs: 250\n"...)
+	}
+
+	// checks
+	buf = append(buf, "\n# Liveness checks (bind, /proc, container runtime): on (default) or off\n# (off relies on the allocations store alone, for sandboxes without network syscalls)\n"...)
+	if cfg.Checks == "off" {
+		buf = append(buf, "checks: off\n"...)
+	} else {
+		buf = append(buf, "# checks: off\n"...)
+	}
+
+	// language
+	buf = append(buf, "\n# Language of errors and warnings on stderr: auto (default, from LC_ALL/LC_MESSAGES/LANG), en or ru\n"...)
+	if cfg.Language != "" && cfg.Language != "auto" {
+		buf = append(buf, fmt.Sprintf("language: %s\n", cfg.Language)...)
+	} else {
+		buf = append(buf, "# language: ru\n"...)
+	}
+
+	// rootDetection
+	buf = append(buf, "\n# Resolve allocations from subdirectories to the project root: none (default),\n# git (nearest directory with .git) or config (nearest directory with .port-selector.yaml)\n"...)
+	if cfg.RootDetection != "" && cfg.RootDetection != RootDetectionNone {
+		buf = append(buf, fmt.Sprintf("rootDetection: %s\n", cfg.RootDetection)...)
+	} else {
+		buf = append(buf, "# rootDetection: git\n"...)
+	}
+
+	// symlinks
+	buf = append(buf, "\n# Directories reached through a symlink: resolve (default, share the real path's\n# allocations) or keep (the symlinked path is a directory of its own)\n"...)
+	if cfg.Symlinks == "keep" {
+		buf = append(buf, "symlinks: keep\n"...)
+	} else {
+		buf = append(buf, "# symlinks: keep\n"...)
+	}
+
+	// pathCase
+	buf = append(buf, "\n# Directories that differ only in case: auto (default; the same on macOS and Windows),\n# sensitive or insensitive (stored as spelled on disk)\n"...)
+	if cfg.PathCase != "" && cfg.PathCase != PathCaseAuto {
+		buf = append(buf, fmt.Sprintf("pathCase: %s\n", cfg.PathCase)...)
+	} else {
+		buf = append(buf, "# pathCase: insensitive\n"...)
+	}
+
+	// onConflict
+	buf = append(buf, "\n# What to do when the port of an existing unlocked allocation is taken by a process\n# outside its directory: reuse (warn, default), fail or reallocate\n"...)
+	if cfg.OnConflict != "" && cfg.OnConflict != ConflictReuse {
+		buf = append(buf, fmt.Sprintf("onConflict: %s\n", cfg.OnConflict)...)
+	} else {
+		buf = append(buf, "# onConflict: fail\n"...)
+	}
+
+	// verifyOwner
+	buf = append(buf, "\n# Also check that a busy locked port is held by a process in its directory\n"...)
+	if cfg.VerifyOwner {
+		buf = append(buf, "verifyOwner: true\n"...)
+	} else {
+		buf = append(buf, "# verifyOwner: true\n"...)
+	}
+
+	// ephemeralOverlap
+	buf = append(buf, "\n# When the range overlaps the kernel's ephemeral range (ip_local_port_range):\n# warn (default), fail (refuse new allocations) or ignore\n"...)
+	if cfg.EphemeralOverlap != "" && cfg.EphemeralOverlap != OverlapWarn {
+		buf = append(buf, fmt.Sprintf("ephemeralOverlap: %s\n", cfg.EphemeralOverlap)...)
+	} else {
+		buf = append(buf, "# ephemeralOverlap: fail\n"...)
+	}
+
+	// excludedPorts, excludedRanges
+	buf = append(buf, "\n# Ports that are never allocated, even when free (e.g., well-known services inside the range)\n"...)
+	if len(cfg.ExcludedPorts) > 0 {
+		ports := make([]string, len(cfg.ExcludedPorts))
+		for i, p := range cfg.ExcludedPorts {
+			ports[i] = strconv.Itoa(p)
+		}
+		buf = append(buf, fmt.Sprintf("excludedPorts: [%s]\n", strings.Join(ports, ", "))...)
+	} else {
+		buf = append(buf, "# excludedPorts: [3306, 3389, 5432]\n"...)
+	}
+	if len(cfg.ExcludedRanges) > 0 {
+		ranges := make([]string, len(cfg.ExcludedRanges))
+		for i, r := range cfg.ExcludedRanges {
+			ranges[i] = strconv.Quote(r)
+		}
+		buf = append(buf, fmt.Sprintf("excludedRanges: [%s]\n", strings.Join(ranges, ", "))...)
+	} else {
+		buf = append(buf, "# excludedRanges: [\"6000-6063\"]\n"...)
+	}
+
+	// advertiseNames
+	buf = append(buf, "\n# Allocation names announced on the LAN via mDNS by 'port-selector advertise' (opt-in)\n"...)
+	if len(cfg.AdvertiseNames) > 0 {
+		names := make([]string, len(cfg.AdvertiseNames))
+		for i, n := range cfg.AdvertiseNames {
+			names[i] = strconv.Quote(n)
+		}
+		buf = append(buf, fmt.Sprintf("advertiseNames: [%s]\n", strings.Join(names, ", "))...)
+	} else {
+		buf = append(buf, "# advertiseNames: [web, api]\n"...)
+	}
+
+	// freezeRules
+	if len(cfg.FreezeRules) > 0 {
+		rules, err := yaml.Marshal(struct {
+			FreezeRules []FreezeRule `yaml:"freezeRules"`
+		}{cfg.FreezeRules})
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\n# Per-name/directory freeze period overrides (first match wins)\n"...)
+		buf = append(buf, rules...)
 	}
 
 	return buf, nil
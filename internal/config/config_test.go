@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -292,6 +293,214 @@ func TestConfig_Validate_AllocationTTL(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate_Store(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   string
+		wantErr bool
+	}{
+		{"empty is valid", "", false},
+		{"yaml is valid", "yaml", false},
+		{"sqlite was removed", "sqlite", true},
+		{"unknown backend", "postgres", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{PortStart: 3000, PortEnd: 4000, Store: tt.store}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_ContainerRuntime(t *testing.T) {
+	for runtime, wantErr := range map[string]bool{"": false, "auto": false, "docker": false, "podman": false, "nerdctl": false, "lxc": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, ContainerRuntime: runtime}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with containerRuntime %q error = %v, wantErr %v", runtime, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_SocketSource(t *testing.T) {
+	for source, wantErr := range map[string]bool{"": false, "auto": false, "netlink": false, "proc": false, "ss": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, SocketSource: source}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with socketSource %q error = %v, wantErr %v", source, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_PortCheck(t *testing.T) {
+	for check, wantErr := range map[string]bool{"": false, "bind": false, "strict": false, "dial": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, PortCheck: check}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with portCheck %q error = %v, wantErr %v", check, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_CheckTimeoutMs(t *testing.T) {
+	for ms, wantErr := range map[int]bool{0: false, 250: false, -1: true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, CheckTimeoutMs: ms}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with checkTimeoutMs %d error = %v, wantErr %v", ms, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_Checks(t *testing.T) {
+	for checks, wantErr := range map[string]bool{"": false, "on": false, "off": false, "none": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, Checks: checks}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with checks %q error = %v, wantErr %v", checks, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_Language(t *testing.T) {
+	for language, wantErr := range map[string]bool{"": false, "auto": false, "en": false, "ru": false, "de": true, "ru_RU": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, Language: language}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with language %q error = %v, wantErr %v", language, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_RootDetection(t *testing.T) {
+	for mode, wantErr := range map[string]bool{"": false, "none": false, "git": false, "config": false, "hg": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, RootDetection: mode}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with rootDetection %q error = %v, wantErr %v", mode, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_Symlinks(t *testing.T) {
+	for value, wantErr := range map[string]bool{"": false, "resolve": false, "keep": false, "follow": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, Symlinks: value}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with symlinks %q error = %v, wantErr %v", value, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_PathCase(t *testing.T) {
+	for value, wantErr := range map[string]bool{"": false, "auto": false, "sensitive": false, "insensitive": false, "ignore": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, PathCase: value}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with pathCase %q error = %v, wantErr %v", value, err, wantErr)
+		}
+	}
+	for value, want := range map[string]bool{"": DefaultCaseInsensitivePaths(), "auto": DefaultCaseInsensitivePaths(), "sensitive": false, "insensitive": true} {
+		if got := (&Config{PathCase: value}).CaseInsensitivePaths(); got != want {
+			t.Errorf("CaseInsensitivePaths() with pathCase %q = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestConfig_Validate_OnConflict(t *testing.T) {
+	for policy, wantErr := range map[string]bool{"": false, "reuse": false, "fail": false, "reallocate": false, "steal": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, OnConflict: policy}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with onConflict %q error = %v, wantErr %v", policy, err, wantErr)
+		}
+	}
+	if got := (&Config{}).GetOnConflict(); got != ConflictReuse {
+		t.Errorf("GetOnConflict() default = %q, want %q", got, ConflictReuse)
+	}
+}
+
+func TestConfig_ComposeBlockSize(t *testing.T) {
+	cfg := &Config{PortStart: 3000, PortEnd: 3009}
+	if got := cfg.GetComposeBlockSize(); got != DefaultComposeBlock {
+		t.Errorf("GetComposeBlockSize() = %d, want default %d", got, DefaultComposeBlock)
+	}
+	for size, wantErr := range map[int]bool{0: false, 1: false, 10: false, 11: true, -1: true} {
+		cfg.ComposeBlockSize = size
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with composeBlockSize %d error = %v, wantErr %v", size, err, wantErr)
+		}
+	}
+}
+
+func TestConfig_ExcludedPorts(t *testing.T) {
+	cfg := &Config{PortStart: 3000, PortEnd: 4000, ExcludedPorts: []int{3306}, ExcludedRanges: []string{"3500-3502"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	got := cfg.ExcludedPortSet()
+	for _, p := range []int{3306, 3500, 3501, 3502} {
+		if !got[p] {
+			t.Errorf("port %d not excluded", p)
+		}
+	}
+	if len(got) != 4 {
+		t.Errorf("ExcludedPortSet() = %v, want 4 ports", got)
+	}
+
+	for _, bad := range []string{"3500", "3502-3500", "a-b", "0-10", "60000-70000"} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, ExcludedRanges: []string{bad}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() with excludedRanges %q: expected error", bad)
+		}
+	}
+	if err := (&Config{PortStart: 3000, PortEnd: 4000, ExcludedPorts: []int{70000}}).Validate(); err == nil {
+		t.Error("Validate() with excludedPorts 70000: expected error")
+	}
+}
+
+func TestSaveAndLoad_ExcludedPorts(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	want := &Config{PortStart: 3000, PortEnd: 4000, ExcludedPorts: []int{3306, 3389}, ExcludedRanges: []string{"3500-3510"}}
+	if err := Save(want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.ExcludedPorts, want.ExcludedPorts) || !reflect.DeepEqual(cfg.ExcludedRanges, want.ExcludedRanges) {
+		t.Errorf("loaded excludedPorts %v, excludedRanges %v", cfg.ExcludedPorts, cfg.ExcludedRanges)
+	}
+}
+
+func TestSaveAndLoad_AdvertiseNames(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	want := &Config{PortStart: 3000, PortEnd: 4000, AdvertiseNames: []string{"web", "admin: ui"}}
+	if err := Save(want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.AdvertiseNames, want.AdvertiseNames) {
+		t.Errorf("loaded advertiseNames %v, want %v", cfg.AdvertiseNames, want.AdvertiseNames)
+	}
+	if err := (&Config{PortStart: 3000, PortEnd: 4000, AdvertiseNames: []string{" "}}).Validate(); err == nil {
+		t.Error("expected error for an empty advertise name")
+	}
+}
+
+func TestConfig_EphemeralOverlap(t *testing.T) {
+	cfg := &Config{PortStart: 3000, PortEnd: 4000}
+	if got := cfg.GetEphemeralOverlap(); got != OverlapWarn {
+		t.Errorf("GetEphemeralOverlap() = %q, want default %q", got, OverlapWarn)
+	}
+	for mode, wantErr := range map[string]bool{"": false, "warn": false, "fail": false, "ignore": false, "avoid": true} {
+		cfg.EphemeralOverlap = mode
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with ephemeralOverlap %q error = %v, wantErr %v", mode, err, wantErr)
+		}
+	}
+}
+
 func TestConfig_GetFreezePeriod(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -343,3 +552,187 @@ func TestConfig_GetFreezePeriod_LegacyCompatibility(t *testing.T) {
 		t.Errorf("GetFreezePeriod() with new field = %v, want %v", got, expected)
 	}
 }
+
+func TestConfig_FreezePeriodFor(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	cfg := &Config{
+		PortStart:    3000,
+		PortEnd:      4000,
+		FreezePeriod: "24h",
+		FreezeRules: []FreezeRule{
+			{Name: "tmp", FreezePeriod: "0"},
+			{Directory: "~/worktrees/*", FreezePeriod: "1h"},
+			{Directory: "/srv/app", Name: "web", FreezePeriod: "7d"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		dir   string
+		alloc string
+		want  time.Duration
+	}{
+		{"name rule", "/any/dir", "tmp", 0},
+		{"directory glob", filepath.Join(home, "worktrees", "feature"), "main", time.Hour},
+		{"glob does not cross slash", filepath.Join(home, "worktrees", "a", "b"), "main", 24 * time.Hour},
+		{"name and directory", "/srv/app", "web", 7 * 24 * time.Hour},
+		{"name and directory mismatch", "/srv/app", "api", 24 * time.Hour},
+		{"no match", "/other", "main", 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.FreezePeriodFor(tt.dir, tt.alloc); got != tt.want {
+				t.Errorf("FreezePeriodFor(%q, %q) = %v, want %v", tt.dir, tt.alloc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_FreezeRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    FreezeRule
+		wantErr bool
+	}{
+		{"valid name rule", FreezeRule{Name: "tmp", FreezePeriod: "0"}, false},
+		{"valid directory rule", FreezeRule{Directory: "/tmp/*", FreezePeriod: "1h"}, false},
+		{"missing selector", FreezeRule{FreezePeriod: "1h"}, true},
+		{"invalid period", FreezeRule{Name: "tmp", FreezePeriod: "soon"}, true},
+		{"invalid pattern", FreezeRule{Directory: "/tmp/[", FreezePeriod: "1h"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{PortStart: 3000, PortEnd: 4000, FreezeRules: []FreezeRule{tt.rule}}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoad_Notify(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := Save(&Config{PortStart: 3000, PortEnd: 4000, Notify: true}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Notify {
+		t.Error("expected Notify to be persisted")
+	}
+}
+
+func TestConfig_Validate_LogFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{"", false},
+		{"text", false},
+		{"json", false},
+		{"xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cfg := &Config{PortStart: 3000, PortEnd: 4000, LogFormat: tt.format}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigDir_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Cleanup(func() { SetProfile("") })
+
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(tmpDir, appName, profilesDirName, "work"); dir != want {
+		t.Errorf("ConfigDir() = %q, want %q", dir, want)
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, appName, profilesDirName, "oss"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	names, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "oss" {
+		t.Errorf("Profiles() = %v, want [oss]", names)
+	}
+
+	for _, bad := range []string{"../x", "a/b", ".hidden"} {
+		if err := SetProfile(bad); err == nil {
+			t.Errorf("SetProfile(%q) expected error", bad)
+		}
+	}
+}
+
+func TestStateDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmpDir)
+	t.Cleanup(func() { SetProfile(""); SetDir("") })
+
+	if dir, err := StateDir(); err != nil || dir != filepath.Join(tmpDir, appName) {
+		t.Errorf("StateDir() = %q, %v; want %q", dir, err, filepath.Join(tmpDir, appName))
+	}
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if dir, _ := StateDir(); dir != filepath.Join(tmpDir, appName, profilesDirName, "work") {
+		t.Errorf("StateDir() with a profile = %q", dir)
+	}
+
+	// An explicit --config directory is used as is
+	SetDir(filepath.Join(tmpDir, "custom"))
+	if dir, err := StateDir(); err != nil || dir != "" {
+		t.Errorf("StateDir() with --config = %q, %v; want empty", dir, err)
+	}
+}
+
+func TestConfig_Validate_PerHostRemote(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PerHost = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cfg.Store = "remote"
+	cfg.RemoteURL = "https://ports.example.com/allocations.yaml"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for perHost with store remote")
+	}
+}
+
+func TestConfig_Validate_LogTarget(t *testing.T) {
+	for target, wantErr := range map[string]bool{"": false, "file": false, "syslog": false, "journald": false, "kafka": true} {
+		cfg := &Config{PortStart: 3000, PortEnd: 4000, LogTarget: target}
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() with logTarget %q error = %v, wantErr %v", target, err, wantErr)
+		}
+	}
+	if got := (&Config{}).GetLogTarget(); got != "file" {
+		t.Errorf("GetLogTarget() default = %q, want file", got)
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys returns the config keys that can be read and set with `config get/set`,
+// in file order. Lists (freezeRules) and legacy fields are excluded.
+func Keys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Slice || strings.HasSuffix(f.Name, "Legacy") {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+// lookupKey resolves a key case-insensitively and returns its canonical name and field index.
+func lookupKey(key string) (string, int, error) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if strings.EqualFold(name, key) {
+			if t.Field(i).Type.Kind() == reflect.Slice {
+				return "", 0, fmt.Errorf("%s is a list; edit it with 'port-selector config edit'", name)
+			}
+			if strings.HasSuffix(t.Field(i).Name, "Legacy") {
+				break
+			}
+			return name, i, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unknown config key %q (available: %s)", key, strings.Join(Keys(), ", "))
+}
+
+// Get returns the value of a config key as it would be written in the file.
+// Unset optional keys return an empty string.
+func (c *Config) Get(key string) (string, error) {
+	_, i, err := lookupKey(key)
+	if err != nil {
+		return "", err
+	}
+	v := reflect.ValueOf(*c).Field(i)
+	if v.IsZero() && v.Kind() != reflect.Int {
+		return "", nil
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// set parses value into the field for key.
+func (c *Config) set(key, value string) error {
+	name, i, err := lookupKey(key)
+	if err != nil {
+		return err
+	}
+	f := reflect.ValueOf(c).Elem().Field(i)
+	switch f.Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", name, value)
+		}
+		f.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", name, value)
+		}
+		f.SetBool(b)
+	default:
+		f.SetString(value)
+	}
+	return nil
+}
+
+// SetValue returns the config file content with key set to value. Only the line
+// holding the key is rewritten, so comments and formatting are preserved; a
+// commented-out key ("# key: ...") is uncommented in place, otherwise the key is appended.
+// The resulting config is validated. Returns the canonical key name.
+func SetValue(data []byte, key, value string) ([]byte, string, error) {
+	name, _, err := lookupKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, "", fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := cfg.set(name, value); err != nil {
+		return nil, "", err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, "", fmt.Errorf("invalid config: %w", err)
+	}
+
+	line := name + ": " + yamlScalar(value)
+	quoted := regexp.QuoteMeta(name)
+	for _, re := range []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^` + quoted + `:.*$`),
+		regexp.MustCompile(`(?m)^#\s*` + quoted + `:.*$`),
+	} {
+		if loc := re.FindIndex(data); loc != nil {
+			var out bytes.Buffer
+			out.Write(data[:loc[0]])
+			out.WriteString(line)
+			out.Write(data[loc[1]:])
+			return out.Bytes(), name, nil
+		}
+	}
+
+	out := append([]byte{}, data...)
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	return append(out, line+"\n"...), name, nil
+}
+
+// yamlScalar quotes value if it would not round-trip as a plain YAML string.
+func yamlScalar(value string) string {
+	if value == "" || strings.ContainsAny(value, ":#'\"{}[],&*!|>%@`") || strings.TrimSpace(value) != value {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// ValidateFile checks that the config file parses, has no unknown keys and passes Validate.
+func ValidateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return cfg.Validate()
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetValue_PreservesComments(t *testing.T) {
+	data, err := marshalConfigWithComments(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, value string
+		wantLine   string
+	}{
+		{"freezePeriod", "12h", "freezePeriod: 12h"},
+		{"portend", "5000", "portEnd: 5000"},           // case-insensitive key
+		{"allocationTTL", "30d", "allocationTTL: 30d"}, // commented-out key is uncommented
+		{"notify", "true", "notify: true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			out, _, err := SetValue(data, tt.key, tt.value)
+			if err != nil {
+				t.Fatalf("SetValue() error = %v", err)
+			}
+			s := string(out)
+			if !strings.Contains(s, "\n"+tt.wantLine+"\n") {
+				t.Errorf("expected line %q in:\n%s", tt.wantLine, s)
+			}
+			if strings.Count(s, "\n") != strings.Count(string(data), "\n") {
+				t.Errorf("expected the line count to be unchanged:\n%s", s)
+			}
+			if !strings.Contains(s, "# Time to avoid reusing recently allocated ports") {
+				t.Error("expected comments to be preserved")
+			}
+		})
+	}
+}
+
+func TestSetValue_Appends(t *testing.T) {
+	out, name, err := SetValue([]byte("portStart: 3000\nportEnd: 4000"), "logFormat", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "logFormat" || string(out) != "portStart: 3000\nportEnd: 4000\nlogFormat: json\n" {
+		t.Errorf("SetValue() = %q, %q", name, out)
+	}
+}
+
+func TestSetValue_Invalid(t *testing.T) {
+	data := []byte("portStart: 3000\nportEnd: 4000\n")
+	for _, tt := range [][2]string{
+		{"portEnd", "abc"},
+		{"portEnd", "2000"}, // less than portStart
+		{"freezePeriod", "forever"},
+		{"notify", "maybe"},
+		{"nosuchkey", "1"},
+		{"freezeRules", "x"},
+	} {
+		if _, _, err := SetValue(data, tt[0], tt[1]); err == nil {
+			t.Errorf("SetValue(%q, %q) expected error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestConfig_Get(t *testing.T) {
+	cfg := DefaultConfig()
+	for key, want := range map[string]string{
+		"portStart":     "3000",
+		"freezePeriod":  DefaultFreezePeriod,
+		"allocationTTL": "",
+		"notify":        "",
+	} {
+		got, err := cfg.Get(key)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", key, err)
+		}
+		if got != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+	if _, err := cfg.Get("freezePeriodMinutes"); err == nil {
+		t.Error("expected legacy key to be rejected")
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if err := os.WriteFile(path, []byte("portStart: 3000\nportEnd: 4000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateFile(path); err != nil {
+		t.Errorf("ValidateFile() error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("portStart: 3000\nportEnd: 4000\nfreezePeriode: 1h\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateFile(path); err == nil || !strings.Contains(err.Error(), "freezePeriode") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the per-project config file read from the working directory.
+const ProjectFileName = ".port-selector.yaml"
+
+// NameFileName is the file whose content is the default allocation name of
+// the directory it is in.
+const NameFileName = ".port-selector-name"
+
+// ProjectConfig is the per-project configuration (.port-selector.yaml).
+//
+//	name: api
+//	preferred:
+//	  web: 3000
+//	  api: 3100
+//	monorepo:
+//	  packages/*: $(basename)
+type ProjectConfig struct {
+	// Name is the allocation name used without --name (empty for "main")
+	Name string `yaml:"name,omitempty"`
+	// Preferred maps allocation names to the port tried first for them
+	Preferred map[string]int `yaml:"preferred,omitempty"`
+	// Monorepo maps subdirectory patterns (filepath.Match syntax, relative to
+	// the file) to allocation names; $(basename) is the matched directory's name
+	Monorepo map[string]string `yaml:"monorepo,omitempty"`
+}
+
+// monorepoBasename in a monorepo name is replaced by the matched directory's name.
+const monorepoBasename = "$(basename)"
+
+// LoadProject reads .port-selector.yaml from dir. A missing file yields an empty config.
+func LoadProject(dir string) (*ProjectConfig, error) {
+	path := filepath.Join(dir, ProjectFileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ProjectConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var pc ProjectConfig
+	if err := yaml.Unmarshal(data, &pc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for name, port := range pc.Preferred {
+		if name == "" {
+			return nil, fmt.Errorf("%s: preferred port %d has an empty name", path, port)
+		}
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%s: preferred port for %q (%d) must be between 1 and 65535", path, name, port)
+		}
+	}
+	if strings.ContainsAny(pc.Name, "\n\r") {
+		return nil, fmt.Errorf("%s: name must be a single line", path)
+	}
+	for pattern, name := range pc.Monorepo {
+		if _, err := filepath.Match(pattern, ""); err != nil || pattern == "" || filepath.IsAbs(pattern) {
+			return nil, fmt.Errorf("%s: invalid monorepo pattern %q (use a relative path like packages/*)", path, pattern)
+		}
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "\n\r") {
+			return nil, fmt.Errorf("%s: monorepo pattern %q needs a name (e.g., %s)", path, pattern, monorepoBasename)
+		}
+	}
+	debug.Printf("config", "loaded project config %s: %d preferred ports", path, len(pc.Preferred))
+	return &pc, nil
+}
+
+// ProjectRoot returns the directory whose allocations dir uses under the
+// rootDetection mode: the nearest of dir and its ancestors that holds .git
+// (git) or .port-selector.yaml (config). Without such a directory, or with
+// mode none, it returns dir.
+func ProjectRoot(dir, mode string) string {
+	marker := ""
+	switch mode {
+	case RootDetectionGit:
+		marker = ".git"
+	case RootDetectionConfig:
+		marker = ProjectFileName
+	default:
+		return dir
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, marker)); err == nil {
+			if d != dir {
+				debug.Printf("config", "project root of %s is %s (%s)", dir, d, marker)
+			}
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// MonorepoMatch looks in the ancestors of dir for a .port-selector.yaml whose
+// monorepo patterns match dir or one of its parents below the file. It returns
+// the directory of that file (the project root) and the allocation name, or
+// empty strings without a match. Ancestor files that cannot be read are skipped.
+func MonorepoMatch(dir string) (root, name string) {
+	for anc := filepath.Dir(dir); ; anc = filepath.Dir(anc) {
+		pc, err := LoadProject(anc)
+		if err != nil {
+			debug.Printf("config", "skipping %s for monorepo rules: %v", anc, err)
+		} else if len(pc.Monorepo) > 0 {
+			if name := pc.monorepoName(anc, dir); name != "" {
+				debug.Printf("config", "monorepo: %s is %q at %s", dir, name, anc)
+				return anc, name
+			}
+		}
+		if filepath.Dir(anc) == anc {
+			return "", ""
+		}
+	}
+}
+
+// monorepoName returns the name of the deepest of dir and its parents below
+// root that matches a monorepo pattern. When several patterns match a
+// directory, the first in sorted order wins.
+func (pc *ProjectConfig) monorepoName(root, dir string) string {
+	patterns := make([]string, 0, len(pc.Monorepo))
+	for pattern := range pc.Monorepo {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for d := dir; d != root && d != filepath.Dir(d); d = filepath.Dir(d) {
+		rel, err := filepath.Rel(root, d)
+		if err != nil {
+			return ""
+		}
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(filepath.FromSlash(pattern), rel); ok {
+				return strings.ReplaceAll(pc.Monorepo[pattern], monorepoBasename, filepath.Base(d))
+			}
+		}
+	}
+	return ""
+}
+
+// DefaultName returns the allocation name used in dir without --name: the
+// first line of .port-selector-name, else the name key of .port-selector.yaml,
+// else "" (the caller's default).
+func DefaultName(dir string) (string, error) {
+	path := filepath.Join(dir, NameFileName)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if name, _, _ := strings.Cut(string(data), "\n"); strings.TrimSpace(name) != "" {
+		debug.Printf("config", "default name %q from %s", strings.TrimSpace(name), path)
+		return strings.TrimSpace(name), nil
+	}
+
+	pc, err := LoadProject(dir)
+	if err != nil {
+		return "", err
+	}
+	return pc.Name, nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+
+	pc, err := LoadProject(dir)
+	if err != nil || len(pc.Preferred) != 0 {
+		t.Fatalf("LoadProject() without file = %+v, %v", pc, err)
+	}
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, ProjectFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("preferred:\n  web: 3000\n  api: 3100\n")
+	pc, err = LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	if pc.Preferred["web"] != 3000 || pc.Preferred["api"] != 3100 {
+		t.Errorf("Preferred = %v", pc.Preferred)
+	}
+
+	write("preferred:\n  web: 70000\n")
+	if _, err := LoadProject(dir); err == nil {
+		t.Error("expected error for out-of-range preferred port")
+	}
+
+	write("preferred: [3000]\n")
+	if _, err := LoadProject(dir); err == nil {
+		t.Error("expected error for malformed project config")
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	dir := t.TempDir()
+	if name, err := DefaultName(dir); err != nil || name != "" {
+		t.Fatalf("DefaultName() without files = %q, %v", name, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ProjectFileName), []byte("name: web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := DefaultName(dir); err != nil || name != "web" {
+		t.Errorf("DefaultName() from %s = %q, %v, want web", ProjectFileName, name, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, NameFileName), []byte("  api \nignored\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := DefaultName(dir); err != nil || name != "api" {
+		t.Errorf("DefaultName() from %s = %q, %v, want api", NameFileName, name, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, NameFileName), []byte("\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := DefaultName(dir); name != "web" {
+		t.Errorf("DefaultName() with an empty %s = %q, want web", NameFileName, name)
+	}
+}
+
+func TestProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "apps", "web")
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "apps", ProjectFileName), []byte("preferred:\n  web: 3000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		dir, mode, want string
+	}{
+		{sub, RootDetectionGit, root},
+		{sub, RootDetectionConfig, filepath.Join(root, "apps")},
+		{sub, RootDetectionNone, sub},
+		{sub, "", sub},
+		{root, RootDetectionGit, root},
+		{root, RootDetectionConfig, root},
+	} {
+		if got := ProjectRoot(tc.dir, tc.mode); got != tc.want {
+			t.Errorf("ProjectRoot(%s, %q) = %s, want %s", tc.dir, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestMonorepoMatch(t *testing.T) {
+	root := t.TempDir()
+	api := filepath.Join(root, "packages", "api")
+	if err := os.MkdirAll(filepath.Join(api, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "tools"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "monorepo:\n  packages/*: $(basename)\n  apps/web: frontend\n"
+	if err := os.WriteFile(filepath.Join(root, ProjectFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		dir, wantRoot, wantName string
+	}{
+		{api, root, "api"},
+		{filepath.Join(api, "src"), root, "api"},
+		{filepath.Join(root, "tools"), "", ""},
+		{root, "", ""},
+	} {
+		if gotRoot, gotName := MonorepoMatch(tc.dir); gotRoot != tc.wantRoot || gotName != tc.wantName {
+			t.Errorf("MonorepoMatch(%s) = %q, %q; want %q, %q", tc.dir, gotRoot, gotName, tc.wantRoot, tc.wantName)
+		}
+	}
+
+	for _, bad := range []string{"monorepo:\n  \"[\": api\n", "monorepo:\n  packages/*: \"\"\n", "monorepo:\n  /abs/*: api\n"} {
+		if err := os.WriteFile(filepath.Join(root, ProjectFileName), []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadProject(root); err == nil {
+			t.Errorf("LoadProject() with %q expected error", bad)
+		}
+	}
+}
@@ -2,15 +2,27 @@
 package debug
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"sync/atomic"
+	"time"
 )
 
+// EnvVar enables debug output like --verbose; see Configure for its value.
+const EnvVar = "PORT_SELECTOR_DEBUG"
+
 // enabled controls whether debug output is printed.
 // Uses atomic.Bool for thread-safety when accessed from multiple goroutines.
 var enabled atomic.Bool
 
+// jsonOutput switches debug lines to one JSON object per line.
+var jsonOutput atomic.Bool
+
+// components limits output to the listed modules; nil means all modules.
+var components atomic.Pointer[map[string]bool]
+
 // SetEnabled sets the debug mode state.
 func SetEnabled(v bool) {
 	enabled.Store(v)
@@ -21,11 +33,80 @@ func IsEnabled() bool {
 	return enabled.Load()
 }
 
-// Printf prints a debug message to stderr if debug mode is enabled.
+// SetJSON switches debug output to JSON lines:
+// {"ts":"...","component":"allocations","msg":"..."}
+func SetJSON(v bool) {
+	jsonOutput.Store(v)
+}
+
+// SetComponents limits debug output to the given modules (main, allocations,
+// port, docker, config, ...). An empty list enables all modules.
+func SetComponents(names []string) {
+	if len(names) == 0 {
+		components.Store(nil)
+		return
+	}
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	components.Store(&set)
+}
+
+// Configure enables debug output from a --verbose=VALUE or PORT_SELECTOR_DEBUG
+// value: "", "1", "true" or "all" enable every module, "0" or "false" nothing;
+// otherwise VALUE is a comma-separated list of modules, where "json" selects
+// JSON lines instead of a module.
+func Configure(value string) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "1", "true", "all":
+		SetEnabled(true)
+		return
+	case "0", "false":
+		return
+	}
+
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+		case "json":
+			SetJSON(true)
+		default:
+			names = append(names, name)
+		}
+	}
+	SetComponents(names)
+	SetEnabled(true)
+}
+
+// componentEnabled reports whether output of module is selected.
+func componentEnabled(module string) bool {
+	set := components.Load()
+	return set == nil || (*set)[module]
+}
+
+// jsonLine is a debug line in JSON format.
+type jsonLine struct {
+	TS        string `json:"ts"`
+	Component string `json:"component"`
+	Msg       string `json:"msg"`
+}
+
+// Printf prints a debug message to stderr if debug mode is enabled for module.
 // Format: [DEBUG] module: message
 func Printf(module, format string, args ...interface{}) {
-	if enabled.Load() {
-		msg := fmt.Sprintf(format, args...)
-		fmt.Fprintf(os.Stderr, "[DEBUG] %s: %s\n", module, msg)
+	if !enabled.Load() || !componentEnabled(module) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if jsonOutput.Load() {
+		data, err := json.Marshal(jsonLine{TS: time.Now().UTC().Format(time.RFC3339Nano), Component: module, Msg: msg})
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%s\n", data)
+			return
+		}
 	}
+	fmt.Fprintf(os.Stderr, "[DEBUG] %s: %s\n", module, msg)
 }
@@ -2,6 +2,7 @@ package debug
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"sync"
 	"testing"
@@ -163,3 +164,67 @@ func TestPrintfFormatting(t *testing.T) {
 		})
 	}
 }
+
+// captureStderr returns what fn writes to stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	fn()
+	w.Close()
+	os.Stderr = oldStderr
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
+
+func resetDebug() {
+	SetEnabled(false)
+	SetJSON(false)
+	SetComponents(nil)
+}
+
+func TestConfigure_Components(t *testing.T) {
+	defer resetDebug()
+	Configure("allocations, Port")
+
+	output := captureStderr(t, func() {
+		Printf("allocations", "a")
+		Printf("main", "m")
+		Printf("port", "p")
+	})
+	expected := "[DEBUG] allocations: a\n[DEBUG] port: p\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestConfigure_JSON(t *testing.T) {
+	defer resetDebug()
+	Configure("json")
+
+	output := captureStderr(t, func() { Printf("main", "hello %d", 1) })
+	var line jsonLine
+	if err := json.Unmarshal([]byte(output), &line); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", output, err)
+	}
+	if line.Component != "main" || line.Msg != "hello 1" || line.TS == "" {
+		t.Errorf("unexpected JSON line: %+v", line)
+	}
+}
+
+func TestConfigure_Values(t *testing.T) {
+	defer resetDebug()
+	for value, want := range map[string]bool{"1": true, "all": true, "true": true, "0": false, "false": false} {
+		resetDebug()
+		Configure(value)
+		if IsEnabled() != want {
+			t.Errorf("Configure(%q): IsEnabled() = %v, want %v", value, IsEnabled(), want)
+		}
+		if !componentEnabled("docker") {
+			t.Errorf("Configure(%q) should not filter modules", value)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// composeWorkingDirLabel is set by docker compose (and podman-compose) on project containers.
+const composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+
+// composeServiceLabel holds the compose service name (e.g., web, db).
+const composeServiceLabel = "com.docker.compose.service"
+
+// apiTimeout bounds a single Engine API request.
+const apiTimeout = 2 * time.Second
+
+// listCacheTTL is how long a container list is reused. --scan and --list look up
+// many ports in one run; a single list call answers all of them.
+const listCacheTTL = 2 * time.Second
+
+// apiContainer is the part of a /containers/json entry used for port attribution.
+type apiContainer struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+	Ports []struct {
+		PublicPort int `json:"PublicPort"`
+	} `json:"Ports"`
+	Labels map[string]string `json:"Labels"`
+	Mounts []struct {
+		Type   string `json:"Type"`
+		Source string `json:"Source"`
+	} `json:"Mounts"`
+}
+
+// apiRuntime talks to a Docker-compatible Engine API over a unix socket
+// (dockerd, or podman's API service).
+type apiRuntime struct {
+	name   string
+	socket func() string // socket path, or "" if the API is not reachable locally
+
+	mu       sync.Mutex
+	listedAt time.Time
+	list     []apiContainer
+	listErr  error
+}
+
+func (r *apiRuntime) Name() string { return r.name }
+
+// Available reports whether the API socket answers a container list.
+func (r *apiRuntime) Available() bool {
+	_, err := r.containers()
+	if err != nil {
+		debug.Printf("docker", "%s API not available: %v", r.name, err)
+	}
+	return err == nil
+}
+
+// containers returns the running containers, listing them at most once per listCacheTTL.
+func (r *apiRuntime) containers() ([]apiContainer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.listedAt.IsZero() && time.Since(r.listedAt) < listCacheTTL {
+		return r.list, r.listErr
+	}
+	r.list, r.listErr = r.fetch()
+	r.listedAt = time.Now()
+	return r.list, r.listErr
+}
+
+// fetch performs GET /containers/json on the socket.
+func (r *apiRuntime) fetch() ([]apiContainer, error) {
+	path := r.socket()
+	if path == "" {
+		return nil, fmt.Errorf("no socket")
+	}
+	debug.Printf("docker", "listing containers via %s", path)
+
+	// The host part is ignored: every request goes to the socket
+	resp, err := r.client().Get("http://" + r.name + "/containers/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET /containers/json: %s", resp.Status)
+	}
+
+	var list []apiContainer
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode container list: %w", err)
+	}
+	debug.Printf("docker", "%s API: %d running containers", r.name, len(list))
+	return list, nil
+}
+
+// client returns an HTTP client that sends every request to the API socket.
+func (r *apiRuntime) client() *http.Client {
+	path := r.socket()
+	return &http.Client{
+		Timeout: apiTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+// find returns the first running container for which match returns true.
+func (r *apiRuntime) find(match func(c *apiContainer) bool) *apiContainer {
+	list, err := r.containers()
+	if err != nil {
+		return nil
+	}
+	for i := range list {
+		if match(&list[i]) {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// FindContainerByPort finds a container that publishes the given port.
+func (r *apiRuntime) FindContainerByPort(port int) string {
+	c := r.find(func(c *apiContainer) bool {
+		for _, p := range c.Ports {
+			if p.PublicPort == port {
+				return true
+			}
+		}
+		return false
+	})
+	if c == nil {
+		debug.Printf("docker", "no %s container found on port %d", r.name, port)
+		return ""
+	}
+	debug.Printf("docker", "found container: %s", shortID(c.ID))
+	return shortID(c.ID)
+}
+
+// ProjectDirectory returns the compose working dir or the first bind mount of a container.
+func (r *apiRuntime) ProjectDirectory(containerID string) string {
+	if containerID == "" {
+		return ""
+	}
+	c := r.find(func(c *apiContainer) bool { return strings.HasPrefix(c.ID, containerID) })
+	if c == nil {
+		return ""
+	}
+	return projectDir(c)
+}
+
+// ComposeService returns the compose service name of a container (empty outside compose).
+func (r *apiRuntime) ComposeService(containerID string) string {
+	if containerID == "" {
+		return ""
+	}
+	c := r.find(func(c *apiContainer) bool { return strings.HasPrefix(c.ID, containerID) })
+	if c == nil {
+		return ""
+	}
+	return c.Labels[composeServiceLabel]
+}
+
+// projectDir returns the compose working dir or the first bind mount source of c.
+func projectDir(c *apiContainer) string {
+	if dir := c.Labels[composeWorkingDirLabel]; dir != "" {
+		debug.Printf("docker", "found compose working dir: %s", dir)
+		return dir
+	}
+	for _, m := range c.Mounts {
+		if m.Type == "bind" && m.Source != "" {
+			debug.Printf("docker", "found bind mount source: %s", m.Source)
+			return m.Source
+		}
+	}
+	debug.Printf("docker", "no project directory found for container %s", shortID(c.ID))
+	return ""
+}
+
+// shortID truncates a container ID to the 12 characters shown by `docker ps`.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// socketFromEnv returns the socket path from a unix:// host variable.
+// ok is false when the variable points elsewhere (e.g., tcp://), so the API is skipped.
+func socketFromEnv(name string) (path string, set bool, ok bool) {
+	host := os.Getenv(name)
+	if host == "" {
+		return "", false, false
+	}
+	path, ok = strings.CutPrefix(host, "unix://")
+	return path, true, ok
+}
+
+// firstSocket returns the first path that exists and is a socket.
+func firstSocket(paths ...string) string {
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return p
+		}
+	}
+	return ""
+}
+
+// dockerSocket locates the dockerd socket ($DOCKER_HOST, rootless, then system).
+func dockerSocket() string {
+	if path, set, ok := socketFromEnv("DOCKER_HOST"); set {
+		if !ok {
+			return ""
+		}
+		return firstSocket(path)
+	}
+	var paths []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "docker.sock"))
+	}
+	return firstSocket(append(paths, "/var/run/docker.sock")...)
+}
+
+// podmanSocket locates podman's Docker-compatible API socket ($CONTAINER_HOST, rootless, then system).
+func podmanSocket() string {
+	if path, set, ok := socketFromEnv("CONTAINER_HOST"); set {
+		if !ok {
+			return ""
+		}
+		return firstSocket(path)
+	}
+	var paths []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	return firstSocket(append(paths, "/run/podman/podman.sock")...)
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// serveContainers starts an Engine API stub on a unix socket and counts list calls.
+func serveContainers(t *testing.T, body string) (string, *atomic.Int32) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	var calls atomic.Int32
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		w.Write([]byte(body))
+	})}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return path, &calls
+}
+
+func TestAPIRuntime(t *testing.T) {
+	path, calls := serveContainers(t, `[
+		{"Id": "0123456789abcdef", "Ports": [{"PrivatePort": 80, "PublicPort": 3000}],
+		 "Labels": {"com.docker.compose.project.working_dir": "/home/user/shop", "com.docker.compose.service": "web"}},
+		{"Id": "fedcba9876543210", "Ports": [{"PrivatePort": 5432, "PublicPort": 3001}, {"PrivatePort": 9000}],
+		 "Mounts": [{"Type": "volume", "Source": "/var/lib/x"}, {"Type": "bind", "Source": "/home/user/db"}]}
+	]`)
+	r := &apiRuntime{name: RuntimeDocker, socket: func() string { return path }}
+
+	if !r.Available() {
+		t.Fatal("expected API runtime to be available")
+	}
+	tests := []struct {
+		port    int
+		id      string
+		dir     string
+		service string
+	}{
+		{3000, "0123456789ab", "/home/user/shop", "web"},
+		{3001, "fedcba987654", "/home/user/db", ""},
+		{9000, "", "", ""},
+	}
+	for _, tt := range tests {
+		id := r.FindContainerByPort(tt.port)
+		if id != tt.id {
+			t.Errorf("FindContainerByPort(%d) = %q, want %q", tt.port, id, tt.id)
+		}
+		if dir := r.ProjectDirectory(id); dir != tt.dir {
+			t.Errorf("ProjectDirectory(%q) = %q, want %q", id, dir, tt.dir)
+		}
+		if service := r.ComposeService(id); service != tt.service {
+			t.Errorf("ComposeService(%q) = %q, want %q", id, service, tt.service)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected one container list call for all lookups, got %d", n)
+	}
+}
+
+func TestAPIRuntime_NoSocket(t *testing.T) {
+	r := &apiRuntime{name: RuntimeDocker, socket: func() string { return "" }}
+	if r.Available() {
+		t.Error("expected API runtime without socket to be unavailable")
+	}
+	if id := r.FindContainerByPort(3000); id != "" {
+		t.Errorf("FindContainerByPort() = %q, want empty", id)
+	}
+}
+
+func TestDockerSocket_NonUnixHost(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	if got := dockerSocket(); got != "" {
+		t.Errorf("dockerSocket() = %q for tcp DOCKER_HOST, want empty", got)
+	}
+}
+
+func TestWatchContainers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/json":
+			w.Write([]byte(`[{"Id": "0123456789abcdef", "Names": ["/shop-web-1"], "Ports": [{"PublicPort": 3000}, {"PublicPort": 3000}],
+				"Labels": {"com.docker.compose.project.working_dir": "/home/user/shop"}},
+				{"Id": "aaaaaaaaaaaaaaaa", "Names": ["/worker"], "Ports": [{"PrivatePort": 80}]}]`))
+		case "/events":
+			if !strings.Contains(r.URL.Query().Get("filters"), `"container"`) {
+				t.Errorf("unexpected events filter: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"Type":"container","Action":"start","Actor":{"ID":"0123456789abcdef"}}` + "\n"))
+			w.Write([]byte(`{"Type":"container","Action":"exec_start","Actor":{"ID":"0123456789abcdef"}}` + "\n"))
+			w.Write([]byte(`{"Type":"container","Action":"die","Actor":{"ID":"0123456789abcdef"}}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	})}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	t.Setenv("DOCKER_HOST", "unix://"+path)
+
+	containers, err := Containers()
+	if err != nil {
+		t.Fatalf("Containers() error = %v", err)
+	}
+	if len(containers) != 1 {
+		t.Fatalf("expected only the container with published ports, got %+v", containers)
+	}
+	c := containers[0]
+	if c.ID != "0123456789ab" || c.Name != "shop-web-1" || c.ProjectDir != "/home/user/shop" || len(c.Ports) != 1 || c.Ports[0] != 3000 {
+		t.Errorf("unexpected container: %+v", c)
+	}
+
+	var events []ContainerEvent
+	err = WatchContainers(context.Background(), func(e ContainerEvent) { events = append(events, e) })
+	if err == nil {
+		t.Error("expected an error when the stream ends")
+	}
+	if len(events) != 2 || events[0].Action != EventStart || events[1].Action != EventDie || events[1].ContainerID != "0123456789ab" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
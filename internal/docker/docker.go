@@ -1,8 +1,11 @@
-// Package docker provides Docker-specific functionality for port discovery.
+// Package docker provides container runtime functionality for port discovery.
+// Docker and Podman are queried through their Engine API socket when available,
+// falling back to the docker, podman and nerdctl CLIs.
 package docker
 
 import (
 	"bytes"
+	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -10,10 +13,65 @@ import (
 	"github.com/dapi/port-selector/internal/debug"
 )
 
-// ContainerInfo contains information about a Docker container using a port.
+// Container runtime names accepted by SetRuntime.
+const (
+	RuntimeAuto    = "auto"
+	RuntimeDocker  = "docker"
+	RuntimePodman  = "podman"
+	RuntimeNerdctl = "nerdctl"
+)
+
+// ContainerInfo contains information about a container using a port.
 type ContainerInfo struct {
 	ContainerID string
 	ProjectDir  string // from compose label or bind mount
+	Service     string // compose service name (empty outside compose)
+	Runtime     string // runtime that owns the container (docker, podman, nerdctl)
+}
+
+// Runtime is a container engine that can map a published port to a container.
+type Runtime interface {
+	Name() string
+	Available() bool
+	FindContainerByPort(port int) string
+	ProjectDirectory(containerID string) string
+	ComposeService(containerID string) string
+}
+
+// runtimes lists the supported runtimes in auto-detection order. The API client
+// of a runtime comes before its CLI: one list call answers every port.
+var runtimes = []Runtime{
+	&apiRuntime{name: RuntimeDocker, socket: dockerSocket},
+	cliRuntime{name: RuntimeDocker, publishFilter: true},
+	&apiRuntime{name: RuntimePodman, socket: podmanSocket},
+	cliRuntime{name: RuntimePodman},
+	cliRuntime{name: RuntimeNerdctl},
+}
+
+// proxyRuntimes maps port-forwarding process names to the runtime that spawns them.
+// Rootless Docker and nerdctl both use rootlesskit, so it maps to no particular runtime.
+var proxyRuntimes = map[string]string{
+	"docker-proxy":    RuntimeDocker,
+	"rootlessport":    RuntimePodman,
+	"rootlessport-ch": RuntimePodman, // rootlessport-child, truncated to 15 chars by the kernel
+	"conmon":          RuntimePodman,
+	"rootlesskit":     "",
+}
+
+// selected is the runtime set with SetRuntime; empty means auto-detection.
+var selected string
+
+// SetRuntime selects the container runtime ("" or "auto" to detect).
+func SetRuntime(name string) error {
+	switch name {
+	case "", RuntimeAuto:
+		selected = ""
+	case RuntimeDocker, RuntimePodman, RuntimeNerdctl:
+		selected = name
+	default:
+		return fmt.Errorf("unknown container runtime %q (use auto, docker, podman or nerdctl)", name)
+	}
+	return nil
 }
 
 // IsDockerProxy checks if the given process name indicates a docker-proxy process.
@@ -21,68 +79,130 @@ func IsDockerProxy(processName string) bool {
 	return processName == "docker-proxy"
 }
 
-// IsDockerAvailable checks if the docker CLI is available.
-func IsDockerAvailable() bool {
-	_, err := exec.LookPath("docker")
-	available := err == nil
-	debug.Printf("docker", "CLI available: %v", available)
-	return available
+// IsContainerProxy checks if the given process forwards a published container port
+// (docker-proxy, podman's rootlessport/conmon, or rootlesskit).
+func IsContainerProxy(processName string) bool {
+	_, ok := proxyRuntimes[processName]
+	return ok
 }
 
-// FindContainerByPort finds a container that publishes the given port.
-// Returns empty string if no container is found.
-func FindContainerByPort(port int) string {
-	debug.Printf("docker", "looking for container on port %d", port)
+// candidates returns the runtimes to query for a port held by processName:
+// the configured runtime only, or all runtimes with the one hinted by processName first.
+func candidates(processName string) []Runtime {
+	var first, rest []Runtime
+	hint := proxyRuntimes[processName]
+	for _, r := range runtimes {
+		switch {
+		case selected != "" && r.Name() != selected:
+		case r.Name() == hint:
+			first = append(first, r)
+		default:
+			rest = append(rest, r)
+		}
+	}
+	return append(first, rest...)
+}
 
-	if !IsDockerAvailable() {
-		return ""
+// GetContainerInfo returns container information for a port held by processName
+// (may be empty if unknown). Returns nil if no runtime has a container publishing the port.
+func GetContainerInfo(port int, processName string) *ContainerInfo {
+	answered := make(map[string]bool) // runtimes already queried through one of their clients
+	for _, r := range candidates(processName) {
+		if answered[r.Name()] || !r.Available() {
+			continue
+		}
+		answered[r.Name()] = true
+		containerID := r.FindContainerByPort(port)
+		if containerID == "" {
+			continue
+		}
+		return &ContainerInfo{
+			ContainerID: containerID,
+			ProjectDir:  r.ProjectDirectory(containerID),
+			Service:     r.ComposeService(containerID),
+			Runtime:     r.Name(),
+		}
 	}
+	return nil
+}
+
+// cliRuntime queries a Docker-compatible CLI (docker, podman, nerdctl).
+type cliRuntime struct {
+	name          string
+	publishFilter bool // supports `ps --filter publish=PORT`; otherwise the Ports column is parsed
+}
 
-	filter := formatPublishFilter(port)
-	debug.Printf("docker", "running: docker ps --filter %s --format {{.ID}}", filter)
+func (r cliRuntime) Name() string { return r.name }
+
+// Available checks if the runtime CLI is installed.
+func (r cliRuntime) Available() bool {
+	_, err := exec.LookPath(r.name)
+	available := err == nil
+	debug.Printf("docker", "%s CLI available: %v", r.name, available)
+	return available
+}
 
-	cmd := exec.Command("docker", "ps", "--filter", filter, "--format", "{{.ID}}")
+// run executes the runtime CLI and returns its trimmed stdout.
+func (r cliRuntime) run(args ...string) (string, bool) {
+	debug.Printf("docker", "running: %s %s", r.name, strings.Join(args, " "))
+	cmd := exec.Command(r.name, args...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = nil
 
 	if err := cmd.Run(); err != nil {
-		debug.Printf("docker", "docker ps failed: %v", err)
-		return ""
+		debug.Printf("docker", "%s %s failed: %v", r.name, args[0], err)
+		return "", false
+	}
+	return strings.TrimSpace(out.String()), true
+}
+
+// FindContainerByPort finds a container that publishes the given port.
+// Returns empty string if no container is found.
+func (r cliRuntime) FindContainerByPort(port int) string {
+	debug.Printf("docker", "looking for %s container on port %d", r.name, port)
+
+	var containerID string
+	if r.publishFilter {
+		out, ok := r.run("ps", "--filter", formatPublishFilter(port), "--format", "{{.ID}}")
+		if !ok {
+			return ""
+		}
+		// If multiple containers, take the first one
+		containerID, _, _ = strings.Cut(out, "\n")
+	} else {
+		out, ok := r.run("ps", "--format", "{{.ID}}\t{{.Ports}}")
+		if !ok {
+			return ""
+		}
+		containerID = findPublishedPort(out, port)
 	}
 
-	containerID := strings.TrimSpace(out.String())
 	if containerID == "" {
 		debug.Printf("docker", "no container found on port %d", port)
 		return ""
 	}
-
-	// If multiple containers, take the first one
-	if idx := strings.Index(containerID, "\n"); idx != -1 {
-		containerID = containerID[:idx]
-	}
-
 	debug.Printf("docker", "found container: %s", containerID)
 	return containerID
 }
 
-// GetProjectDirectory returns the project directory for a container.
-// It first tries the docker-compose label, then falls back to bind mounts.
-func GetProjectDirectory(containerID string) string {
+// ProjectDirectory returns the project directory for a container.
+// It first tries the compose label, then falls back to bind mounts.
+func (r cliRuntime) ProjectDirectory(containerID string) string {
 	if containerID == "" {
 		return ""
 	}
 
 	debug.Printf("docker", "getting project directory for container %s", containerID)
 
-	// Try docker-compose label first
-	if dir := getComposeWorkingDir(containerID); dir != "" {
+	// Try compose label first
+	if dir := r.composeWorkingDir(containerID); dir != "" {
 		debug.Printf("docker", "found compose working dir: %s", dir)
 		return dir
 	}
 
 	// Fallback to bind mount
-	dir := getBindMountSource(containerID)
+	dir := r.bindMountSource(containerID)
 	if dir != "" {
 		debug.Printf("docker", "found bind mount source: %s", dir)
 	} else {
@@ -91,78 +211,93 @@ func GetProjectDirectory(containerID string) string {
 	return dir
 }
 
-// GetContainerInfo returns full container information for a port.
-// This is a convenience function that combines FindContainerByPort and GetProjectDirectory.
-func GetContainerInfo(port int) *ContainerInfo {
-	containerID := FindContainerByPort(port)
-	if containerID == "" {
-		return nil
-	}
-
-	projectDir := GetProjectDirectory(containerID)
-
-	return &ContainerInfo{
-		ContainerID: containerID,
-		ProjectDir:  projectDir,
-	}
-}
-
 // formatPublishFilter creates the filter string for docker ps.
 func formatPublishFilter(port int) string {
 	return "publish=" + strconv.Itoa(port)
 }
 
-// getComposeWorkingDir gets the working directory from docker-compose label.
-func getComposeWorkingDir(containerID string) string {
-	debug.Printf("docker", "checking compose label for container %s", containerID)
+// findPublishedPort returns the first container ID from "ID\tPORTS" lines
+// whose ports publish the given host port.
+func findPublishedPort(psOutput string, port int) string {
+	for _, line := range strings.Split(psOutput, "\n") {
+		id, ports, ok := strings.Cut(line, "\t")
+		if ok && publishesPort(ports, port) {
+			return strings.TrimSpace(id)
+		}
+	}
+	return ""
+}
 
-	cmd := exec.Command("docker", "inspect", containerID,
-		"--format", "{{index .Config.Labels \"com.docker.compose.project.working_dir\"}}")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = nil
+// publishesPort reports whether a Ports column value ("0.0.0.0:3000->80/tcp, :::3000->80/tcp",
+// "127.0.0.1:3001-3002->3001-3002/tcp") publishes the given host port.
+func publishesPort(ports string, port int) bool {
+	for _, mapping := range strings.Split(ports, ",") {
+		host, _, ok := strings.Cut(strings.TrimSpace(mapping), "->")
+		if !ok {
+			continue // exposed but not published
+		}
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[i+1:]
+		}
+		first, last, isRange := strings.Cut(host, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil {
+				continue
+			}
+		}
+		if port >= start && port <= end {
+			return true
+		}
+	}
+	return false
+}
 
-	if err := cmd.Run(); err != nil {
-		debug.Printf("docker", "docker inspect failed: %v", err)
+// ComposeService returns the compose service name of a container (empty outside compose).
+func (r cliRuntime) ComposeService(containerID string) string {
+	if containerID == "" {
 		return ""
 	}
+	return r.label(containerID, composeServiceLabel)
+}
+
+// composeWorkingDir gets the working directory from the compose label.
+func (r cliRuntime) composeWorkingDir(containerID string) string {
+	return r.label(containerID, composeWorkingDirLabel)
+}
 
-	dir := strings.TrimSpace(out.String())
-	// docker inspect returns "<no value>" if label doesn't exist
-	if dir == "" || dir == "<no value>" {
-		debug.Printf("docker", "no compose label found")
+// label returns the value of a container label, or "" if it is not set.
+func (r cliRuntime) label(containerID, key string) string {
+	debug.Printf("docker", "checking label %s for container %s", key, containerID)
+
+	value, ok := r.run("inspect", containerID,
+		"--format", "{{index .Config.Labels \""+key+"\"}}")
+	// inspect returns "<no value>" if label doesn't exist
+	if !ok || value == "" || value == "<no value>" {
+		debug.Printf("docker", "label %s not found", key)
 		return ""
 	}
 
-	return dir
+	return value
 }
 
-// getBindMountSource gets the first bind mount source directory.
-func getBindMountSource(containerID string) string {
+// bindMountSource gets the first bind mount source directory.
+func (r cliRuntime) bindMountSource(containerID string) string {
 	debug.Printf("docker", "checking bind mounts for container %s", containerID)
 
 	// Use Go template to iterate over mounts and find bind mounts
-	cmd := exec.Command("docker", "inspect", containerID,
+	result, ok := r.run("inspect", containerID,
 		"--format", "{{range .Mounts}}{{if eq .Type \"bind\"}}{{.Source}}\n{{end}}{{end}}")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = nil
-
-	if err := cmd.Run(); err != nil {
-		debug.Printf("docker", "docker inspect failed: %v", err)
-		return ""
-	}
-
-	result := strings.TrimSpace(out.String())
-	if result == "" {
+	if !ok || result == "" {
 		debug.Printf("docker", "no bind mounts found")
 		return ""
 	}
 
 	// Return the first bind mount
-	if idx := strings.Index(result, "\n"); idx != -1 {
-		return result[:idx]
-	}
-
-	return result
+	first, _, _ := strings.Cut(result, "\n")
+	return first
 }
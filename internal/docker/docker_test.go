@@ -65,29 +65,88 @@ func TestFormatPublishFilter(t *testing.T) {
 	}
 }
 
-func TestGetProjectDirectory_EmptyReturnsEmpty(t *testing.T) {
-	// GetProjectDirectory with empty containerID should return empty string
+func TestProjectDirectory_EmptyReturnsEmpty(t *testing.T) {
+	// ProjectDirectory with empty containerID should return empty string
 	// This tests the guard clause at the beginning of the function
-	result := GetProjectDirectory("")
-	if result != "" {
-		t.Errorf("GetProjectDirectory(\"\") = %q, want empty string", result)
+	for _, r := range runtimes {
+		if result := r.ProjectDirectory(""); result != "" {
+			t.Errorf("%s ProjectDirectory(\"\") = %q, want empty string", r.Name(), result)
+		}
 	}
 }
 
-func TestFindContainerByPort_NoDocker(t *testing.T) {
-	// This test verifies behavior when docker is not available or port is not published
+func TestFindContainerByPort_NoContainer(t *testing.T) {
+	// This test verifies behavior when the runtime is not available or port is not published
 	// In most test environments, this will return empty string
-	result := FindContainerByPort(99999) // unlikely to be in use
-	if result != "" {
-		// If docker is running and happens to have this port, skip the test
-		t.Skip("Docker container found on test port, skipping")
+	for _, r := range runtimes {
+		if result := r.FindContainerByPort(99999); result != "" { // unlikely to be in use
+			// If the runtime is running and happens to have this port, skip the test
+			t.Skipf("%s container found on test port, skipping", r.Name())
+		}
 	}
 }
 
 func TestGetContainerInfo_NoContainer(t *testing.T) {
 	// Test with a port that's unlikely to have a container
-	info := GetContainerInfo(99999)
+	info := GetContainerInfo(99999, "")
 	if info != nil {
 		t.Skip("Container found on test port, skipping")
 	}
 }
+
+func TestPublishesPort(t *testing.T) {
+	tests := []struct {
+		ports string
+		port  int
+		want  bool
+	}{
+		{"0.0.0.0:3000->80/tcp, :::3000->80/tcp", 3000, true},
+		{"0.0.0.0:3000->80/tcp", 80, false},
+		{"127.0.0.1:3001-3003->3001-3003/tcp", 3002, true},
+		{"127.0.0.1:3001-3003->3001-3003/tcp", 3004, false},
+		{"80/tcp", 80, false},
+		{"", 3000, false},
+	}
+	for _, tt := range tests {
+		if got := publishesPort(tt.ports, tt.port); got != tt.want {
+			t.Errorf("publishesPort(%q, %d) = %v, want %v", tt.ports, tt.port, got, tt.want)
+		}
+	}
+
+	out := "abc123\t0.0.0.0:8080->80/tcp\ndef456\t0.0.0.0:3000->3000/tcp"
+	if got := findPublishedPort(out, 3000); got != "def456" {
+		t.Errorf("findPublishedPort() = %q, want def456", got)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	t.Cleanup(func() { SetRuntime("") })
+
+	if got := candidates("rootlessport")[0].Name(); got != RuntimePodman {
+		t.Errorf("rootlessport should try podman first, got %s", got)
+	}
+	if got := candidates("")[0].Name(); got != RuntimeDocker {
+		t.Errorf("default order should start with docker, got %s", got)
+	}
+
+	if err := SetRuntime(RuntimeNerdctl); err != nil {
+		t.Fatal(err)
+	}
+	if c := candidates("docker-proxy"); len(c) != 1 || c[0].Name() != RuntimeNerdctl {
+		t.Errorf("configured runtime should be the only candidate, got %v", c)
+	}
+	if err := SetRuntime("lxc"); err == nil {
+		t.Error("expected error for unknown runtime")
+	}
+}
+
+func TestIsContainerProxy(t *testing.T) {
+	for _, name := range []string{"docker-proxy", "rootlessport", "conmon", "rootlesskit"} {
+		if !IsContainerProxy(name) {
+			t.Errorf("IsContainerProxy(%q) = false", name)
+		}
+	}
+	if IsContainerProxy("nginx") {
+		t.Error("IsContainerProxy(nginx) = true")
+	}
+}
@@ -0,0 +1,146 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// Container is a running container with its published host ports.
+type Container struct {
+	ID         string // short ID, as shown by `docker ps`
+	Name       string
+	ProjectDir string // compose working dir or first bind mount (may be empty)
+	Service    string // compose service name (may be empty)
+	Ports      []int  // published host ports, sorted
+	Runtime    string
+}
+
+// Container event actions reported by WatchContainers.
+const (
+	EventStart = "start"
+	EventDie   = "die"
+)
+
+// ContainerEvent is a container start or stop.
+type ContainerEvent struct {
+	Action      string // EventStart or EventDie
+	ContainerID string // short ID
+}
+
+// eventsFilter limits the event stream to container starts and stops.
+var eventsFilter = `{"type":["container"],"event":["start","die"]}`
+
+// apiEvent is the part of an /events message used by WatchContainers.
+type apiEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID string `json:"ID"`
+	} `json:"Actor"`
+}
+
+// watchRuntime returns the API runtime to watch: the configured one, or the first with a socket.
+func watchRuntime() (*apiRuntime, error) {
+	for _, r := range candidates("") {
+		if api, ok := r.(*apiRuntime); ok && api.socket() != "" {
+			return api, nil
+		}
+	}
+	return nil, fmt.Errorf("no Docker or Podman API socket found (set DOCKER_HOST=unix://...)")
+}
+
+// Containers returns the running containers that publish ports, read fresh from the API.
+func Containers() ([]Container, error) {
+	r, err := watchRuntime()
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Container
+	for i := range list {
+		if c := r.toContainer(&list[i]); len(c.Ports) > 0 {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// toContainer converts an API list entry into a Container.
+func (r *apiRuntime) toContainer(c *apiContainer) Container {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, p := range c.Ports {
+		if p.PublicPort > 0 && !seen[p.PublicPort] {
+			seen[p.PublicPort] = true
+			ports = append(ports, p.PublicPort)
+		}
+	}
+	sort.Ints(ports)
+
+	name := shortID(c.ID)
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+	return Container{
+		ID:         shortID(c.ID),
+		Name:       name,
+		ProjectDir: projectDir(c),
+		Service:    c.Labels[composeServiceLabel],
+		Ports:      ports,
+		Runtime:    r.name,
+	}
+}
+
+// WatchContainers calls fn for every container start and stop until ctx is done
+// or the event stream fails.
+func WatchContainers(ctx context.Context, fn func(ContainerEvent)) error {
+	r, err := watchRuntime()
+	if err != nil {
+		return err
+	}
+	debug.Printf("docker", "watching %s events", r.name)
+
+	// No client timeout: the stream stays open until ctx is cancelled
+	client := r.client()
+	client.Timeout = 0
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://"+r.name+"/events?filters="+url.QueryEscape(eventsFilter), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET /events: %s", resp.Status)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var e apiEvent
+		if err := dec.Decode(&e); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("event stream ended: %w", err)
+		}
+		if e.Type != "container" || (e.Action != EventStart && e.Action != EventDie) {
+			continue
+		}
+		debug.Printf("docker", "event: %s %s", e.Action, shortID(e.Actor.ID))
+		fn(ContainerEvent{Action: e.Action, ContainerID: shortID(e.Actor.ID)})
+	}
+}
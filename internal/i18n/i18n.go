@@ -0,0 +1,250 @@
+// Package i18n translates the messages printed to stderr (errors, warnings,
+// hints and progress notices). Ports and other machine-readable output on
+// stdout are never translated.
+//
+// Messages are looked up by their English format string, so a message without
+// a translation is printed in English. Error arguments are translated too: the
+// text of an error is matched against the formats in the catalog (see
+// Message), so an error created with fmt.Errorf is printed in the selected
+// language when its format has a translation. Text that does not come from
+// port-selector, such as operating system errors, stays in English.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	Russian = "ru"
+)
+
+// catalogs maps a language to its translations, keyed by the English format.
+var catalogs = map[string]map[string]string{
+	Russian: russian,
+}
+
+var lang = English
+
+// SetLanguage selects the language of messages. Unknown languages select English.
+func SetLanguage(l string) {
+	if _, ok := catalogs[l]; ok {
+		lang = l
+		return
+	}
+	lang = English
+}
+
+// Language returns the selected language.
+func Language() string {
+	return lang
+}
+
+// Detect returns the language of the locale in the environment, following the
+// POSIX precedence LC_ALL, LC_MESSAGES, LANG. "ru_RU.UTF-8" selects Russian;
+// anything else, including an unset locale, selects English.
+func Detect(getenv func(string) string) string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := getenv(key)
+		if value == "" {
+			continue
+		}
+		code, _, _ := strings.Cut(value, "_")
+		code, _, _ = strings.Cut(code, ".")
+		if _, ok := catalogs[strings.ToLower(code)]; ok {
+			return strings.ToLower(code)
+		}
+		return English
+	}
+	return English
+}
+
+// T returns the translation of format in the selected language, or format itself.
+func T(format string) string {
+	if translated, ok := catalogs[lang][format]; ok {
+		return translated
+	}
+	return format
+}
+
+// Sprintf formats the translation of format. Error arguments are replaced by
+// the translation of their text.
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), translateErrors(args)...)
+}
+
+// Fprintf writes the translation of format to w. Error arguments are replaced
+// by the translation of their text.
+func Fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, T(format), translateErrors(args)...)
+}
+
+func translateErrors(args []any) []any {
+	if lang == English {
+		return args
+	}
+	translated := make([]any, len(args))
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			arg = Message(err.Error())
+		}
+		translated[i] = arg
+	}
+	return translated
+}
+
+// Message translates an already formatted message, such as the text of an
+// error. The message is matched against the English formats of the catalog;
+// the values in place of %s, %v and %w are translated in turn, so a chain of
+// wrapped errors is translated link by link. When the whole message matches
+// no format, the longest prefix before ": " that does is translated and the
+// rest is translated separately; a prefix that matches nothing ("open /path")
+// is kept as is.
+func Message(msg string) string {
+	if lang == English {
+		return msg
+	}
+	return translateMessage(msg, catalogs[lang], compiledPatterns(lang))
+}
+
+func translateMessage(msg string, catalog map[string]string, patterns []pattern) string {
+	if translated, ok := matchMessage(msg, catalog, patterns); ok {
+		return translated
+	}
+	for end := strings.LastIndex(msg, ": "); end > 0; end = strings.LastIndex(msg[:end], ": ") {
+		if translated, ok := matchMessage(msg[:end], catalog, patterns); ok {
+			return translated + ": " + translateMessage(msg[end+2:], catalog, patterns)
+		}
+	}
+	if prefix, rest, ok := strings.Cut(msg, ": "); ok {
+		return prefix + ": " + translateMessage(rest, catalog, patterns)
+	}
+	return msg
+}
+
+// matchMessage translates msg if it is a catalog entry or was formatted from one.
+func matchMessage(msg string, catalog map[string]string, patterns []pattern) (string, bool) {
+	if translated, ok := catalog[msg]; ok {
+		return translated, true
+	}
+	for _, p := range patterns {
+		values := p.re.FindStringSubmatch(msg)
+		if values == nil || !p.plausible(values[1:]) {
+			continue
+		}
+		values = values[1:]
+		for i, verb := range p.verbs {
+			switch verb[len(verb)-1] {
+			case 's', 'v', 'w':
+				values[i] = translateMessage(values[i], catalog, patterns)
+			}
+		}
+		return p.fill(values), true
+	}
+	return "", false
+}
+
+// verbPattern matches a formatting verb of package fmt.
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// pattern matches messages formatted from one English format of a catalog.
+type pattern struct {
+	re         *regexp.Regexp
+	verbs      []string
+	translated string
+	literal    int
+}
+
+// plausible reports whether values can come from the format: only the last
+// value may be a wrapped message with its own ": ". This keeps a loose format
+// such as "%s failed: %w" from swallowing a chain of other messages.
+func (p pattern) plausible(values []string) bool {
+	for _, value := range values[:len(values)-1] {
+		if strings.Contains(value, ": ") {
+			return false
+		}
+	}
+	return true
+}
+
+// fill puts values into the translated format in place of its verbs.
+func (p pattern) fill(values []string) string {
+	i := 0
+	return verbPattern.ReplaceAllStringFunc(p.translated, func(verb string) string {
+		if verb == "%%" {
+			return "%"
+		}
+		value := values[i]
+		i++
+		return value
+	})
+}
+
+var (
+	patternsMu sync.Mutex
+	patterns   = map[string][]pattern{}
+)
+
+// compiledPatterns returns the patterns of the catalog of l, the most specific
+// (longest fixed text) first.
+func compiledPatterns(l string) []pattern {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	if compiled, ok := patterns[l]; ok {
+		return compiled
+	}
+	var compiled []pattern
+	for english, translated := range catalogs[l] {
+		if p, ok := compilePattern(english, translated); ok {
+			compiled = append(compiled, p)
+		}
+	}
+	sort.Slice(compiled, func(i, j int) bool {
+		if compiled[i].literal != compiled[j].literal {
+			return compiled[i].literal > compiled[j].literal
+		}
+		return compiled[i].re.String() < compiled[j].re.String()
+	})
+	patterns[l] = compiled
+	return compiled
+}
+
+// compilePattern turns an English format into a pattern. Formats without
+// verbs are matched exactly by the catalog lookup, and formats that are only
+// verbs and separators (such as "%w: %v") would match anything.
+func compilePattern(english, translated string) (pattern, bool) {
+	english = strings.TrimSuffix(english, "\n")
+	translated = strings.TrimSuffix(translated, "\n")
+	p := pattern{translated: translated}
+	var expr strings.Builder
+	expr.WriteString("(?s)^")
+	last := 0
+	for _, loc := range verbPattern.FindAllStringIndex(english, -1) {
+		literal := english[last:loc[0]]
+		expr.WriteString(regexp.QuoteMeta(literal))
+		p.literal += len(strings.Trim(literal, " :;,()'\""))
+		verb := english[loc[0]:loc[1]]
+		if verb == "%%" {
+			expr.WriteString("%")
+		} else {
+			expr.WriteString("(.*?)")
+			p.verbs = append(p.verbs, verb)
+		}
+		last = loc[1]
+	}
+	literal := english[last:]
+	expr.WriteString(regexp.QuoteMeta(literal))
+	expr.WriteString("$")
+	p.literal += len(strings.Trim(literal, " :;,()'\""))
+	if len(p.verbs) == 0 || p.literal == 0 {
+		return pattern{}, false
+	}
+	p.re = regexp.MustCompile(expr.String())
+	return p, true
+}
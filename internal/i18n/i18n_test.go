@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, English},
+		{map[string]string{"LANG": "ru_RU.UTF-8"}, Russian},
+		{map[string]string{"LANG": "ru"}, Russian},
+		{map[string]string{"LANG": "en_US.UTF-8"}, English},
+		{map[string]string{"LANG": "C"}, English},
+		{map[string]string{"LANG": "de_DE.UTF-8"}, English},
+		{map[string]string{"LANG": "ru_RU.UTF-8", "LC_MESSAGES": "C"}, English},
+		{map[string]string{"LANG": "en_US.UTF-8", "LC_ALL": "ru_RU.UTF-8", "LC_MESSAGES": "C"}, Russian},
+	}
+	for _, tt := range tests {
+		if got := Detect(func(key string) string { return tt.env[key] }); got != tt.want {
+			t.Errorf("Detect(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	t.Cleanup(func() { SetLanguage(English) })
+
+	SetLanguage(Russian)
+	if got := Sprintf("error: %v\n", "boom"); got != "ошибка: boom\n" {
+		t.Errorf("Sprintf() in Russian = %q", got)
+	}
+	if got := Sprintf("not translated %d", 1); got != "not translated 1" {
+		t.Errorf("Sprintf() without a translation = %q, want the English text", got)
+	}
+
+	SetLanguage("de")
+	if Language() != English {
+		t.Errorf("SetLanguage(de) selected %q, want English", Language())
+	}
+	if got := Sprintf("error: %v\n", "boom"); got != "error: boom\n" {
+		t.Errorf("Sprintf() in English = %q", got)
+	}
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for english, translated := range catalog {
+			if want, got := verbPattern.FindAllString(english, -1), verbPattern.FindAllString(translated, -1); !slices.Equal(got, want) {
+				t.Errorf("%s translation of %q has verbs %v, want %v", lang, english, got, want)
+			}
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	t.Cleanup(func() { SetLanguage(English) })
+
+	SetLanguage(Russian)
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"store is read-only", "хранилище доступно только для чтения"},
+		{"no free ports in range 3000-4000", "нет свободных портов в диапазоне 3000-4000"},
+		{"failed to load allocations: allocations file corrupted", "не удалось загрузить аллокации: файл аллокаций повреждён"},
+		{"open /tmp/x: permission denied", "open /tmp/x: permission denied"},
+		{"alpha: failed to acquire lock: resource busy", "alpha: не удалось получить блокировку: resource busy"},
+		{"allocations file corrupted: checksum verification failed: file was changed after it was written: yaml: line 3", "файл аллокаций повреждён: контрольная сумма не совпадает: файл изменён после записи: yaml: line 3"},
+		{"not in the catalog", "not in the catalog"},
+	}
+	for _, tt := range tests {
+		if got := Message(tt.msg); got != tt.want {
+			t.Errorf("Message(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+
+	err := fmt.Errorf("failed to load config: %w", errors.New("portStart must be positive"))
+	if got := Sprintf("error: %v\n", err); got != "ошибка: не удалось загрузить конфигурацию: portStart должен быть положительным\n" {
+		t.Errorf("Sprintf() with an error = %q", got)
+	}
+
+	SetLanguage(English)
+	if got := Message("store is read-only"); got != "store is read-only" {
+		t.Errorf("Message() in English = %q", got)
+	}
+}
@@ -0,0 +1,410 @@
+package i18n
+
+// russian is the Russian catalog. Translations must keep the verbs of the
+// English format in the same order (checked by TestCatalogVerbs).
+var russian = map[string]string{
+	"error: %v\n":                    "ошибка: %v\n",
+	"error: unknown option: %s\n":    "ошибка: неизвестная опция: %s\n",
+	"error: unknown arguments: %v\n": "ошибка: неизвестные аргументы: %v\n",
+
+	"warning: %v\n":                 "предупреждение: %v\n",
+	"warning: %s\n":                 "предупреждение: %s\n",
+	"warning: %v; retrying in %s\n": "предупреждение: %v; повтор через %s\n",
+	"warning: %s %s: %v\n":          "предупреждение: %s %s: %v\n",
+
+	"warning: failed to initialize logger: %v\n":                            "предупреждение: не удалось инициализировать лог: %v\n",
+	"warning: failed to load allocations: %v\n":                             "предупреждение: не удалось загрузить аллокации: %v\n",
+	"warning: failed to update timestamp for port %d\n":                     "предупреждение: не удалось обновить время использования порта %d\n",
+	"warning: skipped %d unparsable line(s) in %s\n":                        "предупреждение: пропущено нераспознанных строк: %d в %s\n",
+	"warning: cannot determine hostname (set %s), using the shared store\n": "предупреждение: не удалось определить имя хоста (задайте %s), используется общее хранилище\n",
+
+	"warning: port %d was allocated to %s\n":                                                                               "предупреждение: порт %d был выделен для %s\n",
+	"warning: port %d is taken by %s; allocating a new port\n":                                                             "предупреждение: порт %d занят процессом %s; выделяется новый порт\n",
+	"warning: port %d is busy; use --forget to get a new port\n":                                                           "предупреждение: порт %d занят; используйте --forget, чтобы получить новый порт\n",
+	"warning: port %d is busy (%s); use --forget to get a new port\n":                                                      "предупреждение: порт %d занят (%s); используйте --forget, чтобы получить новый порт\n",
+	"warning: port %d cannot be taken now; '%s' stays on port %d until it can\n":                                           "предупреждение: порт %d сейчас недоступен; '%s' остаётся на порту %d, пока он не освободится\n",
+	"warning: locked port %d is held by %s, not by a process in %s\n":                                                      "предупреждение: заблокированный порт %d занят процессом %s, а не процессом из %s\n",
+	"warning: failed to remove the firewall rule for port %d (%s): %v\n":                                                   "предупреждение: не удалось удалить правило файрвола для порта %d (%s): %v\n",
+	"warning: ports %d and %d are both locked for %s ('%s'); both are kept, forget one with 'port-selector --forget %d'\n": "предупреждение: порты %d и %d оба заблокированы для %s ('%s'); оба сохранены, освободите один командой 'port-selector --forget %d'\n",
+	"warning: %s is already routed to port %d (%s); skipping port %d (%s)\n":                                               "предупреждение: %s уже направлен на порт %d (%s); порт %d (%s) пропущен\n",
+	"warning: $%s=%d is allocated to %s ('%s'%s); not registering it for %s\n":                                             "предупреждение: $%s=%d выделен для %s ('%s'%s); не регистрируется для %s\n",
+	"warning: %s; outbound connections may take them transiently (set ephemeralOverlap: ignore to silence)\n":              "предупреждение: %s; исходящие соединения могут временно занимать их (ephemeralOverlap: ignore отключает предупреждение)\n",
+
+	"allocation for '%s' (port %d) expires in %s; run 'port-selector --name %s' to renew it or --lock to keep it": "аллокация '%s' (порт %d) истекает через %s; выполните 'port-selector --name %s', чтобы продлить её, или --lock, чтобы сохранить",
+
+	"notice: %s is read-only; keeping allocations in %s\n": "примечание: %s доступен только для чтения; аллокации хранятся в %s\n",
+
+	"dry-run: would write %s\n":    "dry-run: был бы записан %s\n",
+	"dry-run: would set %s = %s\n": "dry-run: было бы установлено %s = %s\n",
+	"dry-run: would run sudo %s\n": "dry-run: была бы выполнена команда sudo %s\n",
+
+	"\nTip: Run with sudo for full process info: sudo port-selector --list\n": "\nСовет: запустите через sudo, чтобы видеть все процессы: sudo port-selector --list\n",
+	"\nTip: Run with sudo for full process info: sudo port-selector --scan\n": "\nСовет: запустите через sudo, чтобы видеть все процессы: sudo port-selector --scan\n",
+
+	"No allocations to route.\n": "Нет аллокаций для маршрутизации.\n",
+	"Watching container events (%d running with published ports), Ctrl+C to stop\n": "Отслеживание событий контейнеров (запущено с опубликованными портами: %d), Ctrl+C для остановки\n",
+	"Forwarding %s:%d to localhost:%d ('%s'), Ctrl+C to stop\n":                     "Проброс %s:%d на localhost:%d ('%s'), Ctrl+C для остановки\n",
+	"Advertising %s on %s.local via mDNS, Ctrl+C to stop\n":                         "Анонс %s на %s.local через mDNS, Ctrl+C для остановки\n",
+
+	"ERROR: %v\n":       "ОШИБКА: %v\n",
+	"       File: %s\n": "       Файл: %s\n",
+	"       Use 'port-selector repair' to salvage it, 'port-selector restore' to recover from a backup, or fix the file manually.\n": "       Используйте 'port-selector repair', чтобы спасти его, 'port-selector restore', чтобы восстановить из резервной копии, или исправьте файл вручную.\n",
+	"WARNING: %v\n":                                    "ПРЕДУПРЕЖДЕНИЕ: %v\n",
+	"         File: %s\n":                              "         Файл: %s\n",
+	"         Using backup %s\n":                       "         Используется резервная копия %s\n",
+	"warning: %s: %s; accepting it as a manual edit\n": "предупреждение: %s: %s; изменение принято как ручная правка\n",
+	"warning: failed to release lock on %s: %v\n":      "предупреждение: не удалось снять блокировку %s: %v\n",
+	"warning: failed to close %s: %v\n":                "предупреждение: не удалось закрыть %s: %v\n",
+	"warning: file locking not available on Windows, concurrent access may cause data corruption\n": "предупреждение: блокировка файлов недоступна в Windows, одновременный доступ может повредить данные\n",
+	"warning: failed to remove legacy file %s: %v\n":                                                "предупреждение: не удалось удалить устаревший файл %s: %v\n",
+	"warning: remote store unreachable (%v), using cached copy\n":                                   "предупреждение: удалённое хранилище недоступно (%v), используется кэшированная копия\n",
+	"warning: invalid freezePeriod %q, using default: %v\n":                                         "предупреждение: неверный freezePeriod %q, используется значение по умолчанию: %v\n",
+	"warning: invalid allocationTTL %q, TTL disabled: %v\n":                                         "предупреждение: неверный allocationTTL %q, TTL отключён: %v\n",
+	"warning: invalid expiryWarning %q, expiry warnings disabled: %v\n":                             "предупреждение: неверный expiryWarning %q, предупреждения об истечении отключены: %v\n",
+	"warning: could not save default config: %v\n":                                                  "предупреждение: не удалось сохранить конфигурацию по умолчанию: %v\n",
+	"warning: failed to write to syslog: %v\n":                                                      "предупреждение: не удалось записать в syslog: %v\n",
+	"warning: failed to write to journald: %v\n":                                                    "предупреждение: не удалось записать в journald: %v\n",
+	"warning: failed to open log file: %v\n":                                                        "предупреждение: не удалось открыть файл лога: %v\n",
+	"warning: failed to write to log file: %v\n":                                                    "предупреждение: не удалось записать в файл лога: %v\n",
+	"warning: socketSource netlink is unavailable (%v), reading /proc instead\n":                    "предупреждение: socketSource netlink недоступен (%v), читается /proc\n",
+	"warning: cannot read %s: %v\n":                                                                 "предупреждение: не удалось прочитать %s: %v\n",
+	"warning: error reading %s: %v\n":                                                               "предупреждение: ошибка чтения %s: %v\n",
+
+	// Error texts, matched against formatted errors by Message.
+	"unknown option: %s":                                      "неизвестная опция: %s",
+	"unknown argument: %s":                                    "неизвестный аргумент: %s",
+	"unknown arguments: %v":                                   "неизвестные аргументы: %v",
+	"unexpected argument: %s":                                 "неожиданный аргумент: %s",
+	"%s requires a value":                                     "%s требует значение",
+	"%s requires a number":                                    "%s требует число",
+	"%s requires a pattern":                                   "%s требует шаблон",
+	"--%s requires a name":                                    "--%s требует имя",
+	"invalid %s value: %s":                                    "неверное значение %s: %s",
+	"invalid %s name: %q":                                     "неверное имя %s: %q",
+	"invalid %s path %s: %w":                                  "неверный путь %s %s: %w",
+	"--format requires a value":                               "--format требует значение",
+	"--format requires a value (%s)":                          "--format требует значение (%s)",
+	"invalid format %q (use %s)":                              "неверный формат %q (используйте %s)",
+	"invalid format %q (use summary or dotenv)":               "неверный формат %q (используйте summary или dotenv)",
+	"invalid format %q (use table, dotenv or json)":           "неверный формат %q (используйте table, dotenv или json)",
+	"invalid format %q (use caddy, nginx or traefik)":         "неверный формат %q (используйте caddy, nginx или traefik)",
+	"--profile cannot be combined with --config":              "--profile нельзя использовать вместе с --config",
+	"--name requires a value":                                 "--name требует значение",
+	"--name cannot be empty":                                  "--name не может быть пустым",
+	"--name cannot be combined with a port":                   "--name нельзя использовать вместе с портом",
+	"--label requires key=value":                              "--label требует key=value",
+	"--label requires key=value (use key= to remove a label)": "--label требует key=value (key= удаляет метку)",
+	"--lease requires a duration (e.g., 2h)":                  "--lease требует длительность (например, 2h)",
+	"invalid --lease value: %s":                               "неверное значение --lease: %s",
+	"--pid requires a process ID":                             "--pid требует ID процесса",
+	"invalid --pid value: %s":                                 "неверное значение --pid: %s",
+	"--on-conflict requires reuse, fail or reallocate":        "--on-conflict требует reuse, fail или reallocate",
+	"--health requires a path (e.g., /healthz)":               "--health требует путь (например, /healthz)",
+	"invalid --health value: %s (must start with /)":          "неверное значение --health: %s (должно начинаться с /)",
+	"--timeout requires a value (e.g., 30s)":                  "--timeout требует значение (например, 30s)",
+	"invalid --timeout value: %s":                             "неверное значение --timeout: %s",
+	"--free and --timeout require --wait":                     "--free и --timeout требуют --wait",
+	"--hold cannot be combined with --wait":                   "--hold нельзя использовать вместе с --wait",
+	"--json cannot be combined with --hold or --respect-env":  "--json нельзя использовать вместе с --hold или --respect-env",
+	"--count requires a number":                               "--count требует число",
+	"invalid --count value: %s":                               "неверное значение --count: %s",
+	"--interval requires a duration":                          "--interval требует длительность",
+	"invalid --interval %q (e.g., 500ms, 2s)":                 "неверный --interval %q (например, 500ms, 2s)",
+	"--columns requires a list (e.g., port,name,dir,status)":  "--columns требует список (например, port,name,dir,status)",
+	"--group-by requires a value (dir)":                       "--group-by требует значение (dir)",
+	"invalid --group-by %q (use dir)":                         "неверный --group-by %q (используйте dir)",
+	"invalid --stale age %q (use a duration like 7d or 12h)":  "неверный возраст --stale %q (используйте длительность, например 7d или 12h)",
+	"--group-by only applies to the table format":             "--group-by применим только к табличному формату",
+	"unknown column %q (available: %s)":                       "неизвестная колонка %q (доступны: %s)",
+	"--group requires a name":                                 "--group требует имя",
+	"--group cannot be combined with %v":                      "--group нельзя использовать вместе с %v",
+	"--watch-docker cannot be combined with --dry-run":        "--watch-docker нельзя использовать вместе с --dry-run",
+	"--path requires a value":                                 "--path требует значение",
+	"--from requires a backup number or file":                 "--from требует номер резервной копии или файл",
+	"--clear does not take a hostname":                        "--clear не принимает имя хоста",
+	"--on-auto-forward requires a value":                      "--on-auto-forward требует значение",
+	"invalid --on-auto-forward %q (use notify, openBrowser, openPreview, silent or ignore)": "неверный --on-auto-forward %q (используйте notify, openBrowser, openPreview, silent или ignore)",
+	"--allow-lan cannot be combined with --remove":                                          "--allow-lan нельзя использовать вместе с --remove",
+	"invalid --source %q (expected a CIDR such as 192.168.1.0/24)":                          "неверный --source %q (ожидается CIDR, например 192.168.1.0/24)",
+	"--convert-store requires a target backend (%s or %s)":                                  "--convert-store требует целевое хранилище (%s или %s)",
+	"invalid --since value: %s (e.g., 7d, 12h)":                                             "неверное значение --since: %s (например, 7d, 12h)",
+	"invalid glob %q: %w":                       "неверный glob-шаблон %q: %w",
+	"invalid port: %s (must be 1-65535)":        "неверный порт: %s (должен быть 1-65535)",
+	"invalid port number: %s (must be 1-65535)": "неверный номер порта: %s (должен быть 1-65535)",
+	"invalid $%s value: %s (must be 1-65535)":   "неверное значение $%s: %s (должно быть 1-65535)",
+	"invalid directory %s: %w":                  "неверная директория %s: %w",
+	"confirmation required; re-run with --yes":  "требуется подтверждение; запустите повторно с --yes",
+
+	"usage: port-selector alias set NAME | clear | list":                           "использование: port-selector alias set NAME | clear | list",
+	"usage: port-selector config get KEY | set KEY VALUE | edit | validate | path": "использование: port-selector config get KEY | set KEY VALUE | edit | validate | path",
+	"usage: port-selector group NAME [--format table|dotenv|json]":                 "использование: port-selector group NAME [--format table|dotenv|json]",
+	"usage: port-selector --move [PORT | --name NAME] DIR":                         "использование: port-selector --move [PORT | --name NAME] DIR",
+	"usage: port-selector note PORT [TEXT]":                                        "использование: port-selector note PORT [TEXT]",
+	"usage: port-selector --rename OLD NEW":                                        "использование: port-selector --rename OLD NEW",
+	"usage: port-selector session end ID | session list":                           "использование: port-selector session end ID | session list",
+	"usage: port-selector session end ID":                                          "использование: port-selector session end ID",
+	"usage: port-selector swap PORT1 PORT2":                                        "использование: port-selector swap PORT1 PORT2",
+	"usage: port-selector tunnel user@host [--name NAME] [--print]":                "использование: port-selector tunnel user@host [--name NAME] [--print]",
+	"usage: port-selector %s CONFIG_DIR":                                           "использование: port-selector %s CONFIG_DIR",
+	"apply requires a manifest file (e.g., port-selector apply services.yaml)":     "apply требует файл манифеста (например, port-selector apply services.yaml)",
+	"init requires a framework (%s)":                                               "init требует фреймворк (%s)",
+	"unknown framework %q (available: %s)":                                         "неизвестный фреймворк %q (доступны: %s)",
+	"unknown config command: %s (use get, set, edit, validate or path)":            "неизвестная команда config: %s (используйте get, set, edit, validate или path)",
+	"config %s takes %d argument(s), got %d":                                       "config %s принимает аргументов: %d, получено %d",
+	"unknown session command: %s (use end or list)":                                "неизвестная команда session: %s (используйте end или list)",
+
+	"failed to load config: %w":           "не удалось загрузить конфигурацию: %w",
+	"failed to get config dir: %w":        "не удалось определить директорию конфигурации: %w",
+	"failed to load allocations: %w":      "не удалось загрузить аллокации: %w",
+	"failed to get working directory: %w": "не удалось определить рабочую директорию: %w",
+	"failed to find free port: %w":        "не удалось найти свободный порт: %w",
+	"no free ports in range %d-%d":        "нет свободных портов в диапазоне %d-%d",
+	"all ports in range are busy":         "все порты диапазона заняты",
+	"port search timed out":               "поиск порта превысил время ожидания",
+	"%w after %v with %d ports checked; port checks are slow (see checkTimeoutMs)": "%w через %v, проверено портов: %d; проверки портов медленные (см. checkTimeoutMs)",
+	"process %d is not running": "процесс %d не запущен",
+	"process %d not found":      "процесс %d не найден",
+	"liveness checks are off":   "проверки занятости портов отключены",
+	"%s needs liveness checks, which are off (--offline or checks: off)":                        "%s требует проверки занятости портов, а они отключены (--offline или checks: off)",
+	"port %d is in use by %s; stop the service first":                                           "порт %d занят процессом %s; сначала остановите сервис",
+	"port %d is in use by unknown process":                                                      "порт %d занят неизвестным процессом",
+	"port %d is locked by %s\n       use --lock %d --force to reassign it to current directory": "порт %d заблокирован для %s\n       используйте --lock %d --force, чтобы переназначить его текущей директории",
+	"port %d is outside configured range %d-%d":                                                 "порт %d вне настроенного диапазона %d-%d",
+	"no allocation found for port %d":                                                           "аллокация для порта %d не найдена",
+	"no allocation found for %s with name '%s'":                                                 "аллокация для %s с именем '%s' не найдена",
+	"no allocation found for %s with name '%s' (run port-selector first)":                       "аллокация для %s с именем '%s' не найдена (сначала запустите port-selector)",
+	"no allocation for %s ('%s')":                                                               "нет аллокации для %s ('%s')",
+	"no allocations for %s (run port-selector first)":                                           "нет аллокаций для %s (сначала запустите port-selector)",
+	"no allocations in group '%s'":                                                              "нет аллокаций в группе '%s'",
+	"internal error: allocation for port %d disappeared unexpectedly":                           "внутренняя ошибка: аллокация порта %d неожиданно исчезла",
+	"internal error: allocation for %s with name '%s' disappeared unexpectedly":                 "внутренняя ошибка: аллокация для %s с именем '%s' неожиданно исчезла",
+	"internal error: failed to lock port %d":                                                    "внутренняя ошибка: не удалось заблокировать порт %d",
+	"internal error: failed to lock port %d after reassignment":                                 "внутренняя ошибка: не удалось заблокировать порт %d после переназначения",
+	"internal error: failed to lock port %d after allocation":                                   "внутренняя ошибка: не удалось заблокировать порт %d после выделения",
+	"internal error: failed to lock port %d for service %s":                                     "внутренняя ошибка: не удалось заблокировать порт %d для сервиса %s",
+	"store is already %s":                                                                       "хранилище уже %s",
+	"%s is not a directory":                                                                     "%s не является директорией",
+	"port %d is an external allocation and cannot be moved":                                     "порт %d — внешняя аллокация, её нельзя перенести",
+	"port %d is an external allocation and cannot be swapped":                                   "порт %d — внешняя аллокация, её нельзя обменять",
+	"%s already has an allocation named '%s' (port %d)":                                         "у %s уже есть аллокация с именем '%s' (порт %d)",
+	"allocation name cannot be empty":                                                           "имя аллокации не может быть пустым",
+	"note must be a single line":                                                                "заметка должна быть одной строкой",
+	"port %d ('%s') has no lease (use --renew --lease D to add one)":                            "у порта %d ('%s') нет аренды (добавьте её через --renew --lease D)",
+	"'%s' has no sticky port in %s":                                                             "у '%s' нет закреплённого порта в %s",
+	"port %d is already sticky for '%s' in %s (run --unsticky there first)":                     "порт %d уже закреплён за '%s' в %s (сначала выполните там --unsticky)",
+	"cannot swap port %d with itself":                                                           "нельзя обменять порт %d с самим собой",
+	"port %d is locked (unlock it with 'port-selector --unlock %d' first)":                      "порт %d заблокирован (сначала разблокируйте его: 'port-selector --unlock %d')",
+	"port %d is in use; stop the service before swapping":                                       "порт %d занят; остановите сервис перед обменом",
+	"cannot hold port %d: %w":                                                                   "не удалось удержать порт %d: %w",
+	"timed out after %s waiting for port %d to be %s":                                           "время ожидания %s истекло, порт %d так и не стал %s",
+	"kernel kept assigning allocated ports (%d attempts)":                                       "ядро продолжало выдавать выделенные порты (попыток: %d)",
+	"%s, where outbound connections take ports transiently; move portStart/portEnd out of it (or set ephemeralOverlap: warn)": "%s, где исходящие соединения временно занимают порты; вынесите portStart/portEnd за его пределы (или задайте ephemeralOverlap: warn)",
+	"cannot read ephemeral port range: %w":                  "не удалось прочитать диапазон эфемерных портов: %w",
+	"unexpected content of %s: %q":                          "неожиданное содержимое %s: %q",
+	"cannot get an ephemeral port: liveness checks are off": "не удалось получить эфемерный порт: проверки занятости портов отключены",
+	"cannot get an ephemeral port: %w":                      "не удалось получить эфемерный порт: %w",
+	"cannot parse /proc/%d/stat":                            "не удалось разобрать /proc/%d/stat",
+	"unknown port check %q (use bind or strict)":            "неизвестная проверка портов %q (используйте bind или strict)",
+	"unknown socket source %q (use auto, netlink or proc)":  "неизвестный источник сокетов %q (используйте auto, netlink или proc)",
+	"sock_diag netlink is only available on Linux":          "sock_diag netlink доступен только в Linux",
+	"port conflict": "конфликт порта",
+	"%w: port %d of %s ('%s') is taken by %s (onConflict: fail)": "%w: порт %d для %s ('%s') занят процессом %s (onConflict: fail)",
+	"check failed": "проверка не пройдена",
+	"%w: no allocation for %s with name '%s'":                       "%w: нет аллокации для %s с именем '%s'",
+	"%w: port %d ('%s'): %s":                                        "%w: порт %d ('%s'): %s",
+	"release refused":                                               "освобождение отклонено",
+	"%w: port %d ('%s') is locked (use --unlock first or --forget)": "%w: порт %d ('%s') заблокирован (сначала используйте --unlock или --forget)",
+	"%w: port %d ('%s') is in use":                                  "%w: порт %d ('%s') занят",
+	"invalid label %q (use key=value; keys may contain letters, digits, '.', '_', '/' and '-')": "неверная метка %q (используйте key=value; ключ может содержать буквы, цифры, '.', '_', '/' и '-')",
+	"invalid label %q (values may not contain ',' or newlines)":                                 "неверная метка %q (значение не может содержать ',' и переводы строк)",
+	"invalid alias %q (use letters, digits, '.', '_' and '-')":                                  "неверный псевдоним %q (используйте буквы, цифры, '.', '_' и '-')",
+	"unknown alias @%s (see 'port-selector alias list')":                                        "неизвестный псевдоним @%s (см. 'port-selector alias list')",
+	"alias @%s is already used by %s":                                                           "псевдоним @%s уже используется для %s",
+
+	"allocations file corrupted": "файл аллокаций повреждён",
+	"%w (use 'port-selector repair' to salvage it or 'port-selector restore' to recover from a backup)": "%w (используйте 'port-selector repair', чтобы спасти его, или 'port-selector restore', чтобы восстановить из резервной копии)",
+	"allocations file is not corrupted":        "файл аллокаций не повреждён",
+	"repair supports the %s store only":        "repair поддерживает только хранилище %s",
+	"failed to quarantine corrupted file: %w":  "не удалось переместить повреждённый файл в карантин: %w",
+	"cannot read allocations file: %w":         "не удалось прочитать файл аллокаций: %w",
+	"failed to marshal store: %w":              "не удалось сериализовать хранилище: %w",
+	"%w: checksum verification failed":         "%w: контрольная сумма не совпадает",
+	"invalid port %d":                          "неверный порт %d",
+	"port %d has no valid directory":           "у порта %d нет корректной директории",
+	"store %s requires remoteURL in config":    "хранилище %s требует remoteURL в конфигурации",
+	"unknown store backend %q (use %s or %s)":  "неизвестное хранилище %q (используйте %s или %s)",
+	"failed to write backup: %w":               "не удалось записать резервную копию: %w",
+	"cannot read backup: %w":                   "не удалось прочитать резервную копию: %w",
+	"cannot restore from %s: %w":               "не удалось восстановить из %s: %w",
+	"failed to read hosts directory: %w":       "не удалось прочитать директорию хостов: %w",
+	"host %s: %w":                              "хост %s: %w",
+	"nothing to undo":                          "нечего отменять",
+	"allocations changed since the operation":  "аллокации изменились после операции",
+	"%w: port %d (use --force to overwrite)":   "%w: порт %d (используйте --force, чтобы перезаписать)",
+	"failed to read undo journal: %w":          "не удалось прочитать журнал отмены: %w",
+	"failed to parse undo journal: %w":         "не удалось разобрать журнал отмены: %w",
+	"failed to remove undo journal: %w":        "не удалось удалить журнал отмены: %w",
+	"failed to marshal undo journal: %w":       "не удалось сериализовать журнал отмены: %w",
+	"failed to open lock file: %w":             "не удалось открыть файл блокировки: %w",
+	"failed to acquire lock: %w":               "не удалось получить блокировку: %w",
+	"failed to read legacy history file: %w":   "не удалось прочитать устаревший файл истории: %w",
+	"failed to read legacy last-used file: %w": "не удалось прочитать устаревший файл last-used: %w",
+	"store is read-only":                       "хранилище доступно только для чтения",
+	"%w: cannot %s":                            "%w: нельзя выполнить %s",
+	"%w: operation would change %d allocation(s) (remove --read-only or readOnly: true to allow it)": "%w: операция изменила бы аллокаций: %d (уберите --read-only или readOnly: true, чтобы разрешить её)",
+	"remote store conflict":                                           "конфликт удалённого хранилища",
+	"remote store changed since it was read":                          "удалённое хранилище изменилось после чтения",
+	"cannot read remote store: %w":                                    "не удалось прочитать удалённое хранилище: %w",
+	"cannot read remote store cache: %w":                              "не удалось прочитать кэш удалённого хранилища: %w",
+	"%w: store kept changing while saving, try again":                 "%w: хранилище менялось во время сохранения, попробуйте ещё раз",
+	"%w: port %d was changed by someone else":                         "%w: порт %d изменён кем-то другим",
+	"%w: %s (%s) was allocated ports %d and %d":                       "%w: для %s (%s) выделены порты %d и %d",
+	"%w: sticky port %d was changed by someone else":                  "%w: закреплённый порт %d изменён кем-то другим",
+	"failed to read remote store: %w":                                 "не удалось прочитать удалённое хранилище: %w",
+	"invalid remote store URL: %w":                                    "неверный URL удалённого хранилища: %w",
+	"%w: remote store: %v":                                            "%w: удалённое хранилище: %v",
+	"failed to marshal allocation for port %d: %w":                    "не удалось сериализовать аллокацию порта %d: %w",
+	"failed to create state directory: %w":                            "не удалось создать директорию состояния: %w",
+	"failed to copy store to state directory: %w":                     "не удалось скопировать хранилище в директорию состояния: %w",
+	"refresh external allocations: isPortFree function cannot be nil": "обновление внешних аллокаций: функция isPortFree не может быть nil",
+	"failed to create temp file: %w":                                  "не удалось создать временный файл: %w",
+	"failed to write temp file: %w":                                   "не удалось записать временный файл: %w",
+	"failed to sync temp file: %w":                                    "не удалось синхронизировать временный файл: %w",
+	"failed to close temp file: %w":                                   "не удалось закрыть временный файл: %w",
+	"failed to set temp file permissions: %w":                         "не удалось установить права временного файла: %w",
+	"failed to rename temp file: %w":                                  "не удалось переименовать временный файл: %w",
+	"file ends inside the checksum header":                            "файл обрывается внутри заголовка контрольной суммы",
+	"file has %d of %d bytes, probably truncated by a crash":          "в файле %d из %d байт, вероятно, он обрезан при сбое",
+	"file was changed after it was written":                           "файл изменён после записи",
+
+	"portStart must be positive":                                            "portStart должен быть положительным",
+	"portEnd must be positive":                                              "portEnd должен быть положительным",
+	"portStart (%d) must be less than portEnd (%d)":                         "portStart (%d) должен быть меньше portEnd (%d)",
+	"portStart (%d) must be between 1 and 65535":                            "portStart (%d) должен быть от 1 до 65535",
+	"portEnd (%d) must be between 1 and 65535":                              "portEnd (%d) должен быть от 1 до 65535",
+	"invalid freezePeriod: %w":                                              "неверный freezePeriod: %w",
+	"invalid allocationTTL: %w":                                             "неверный allocationTTL: %w",
+	"invalid expiryWarning: %w":                                             "неверный expiryWarning: %w",
+	"freezeRules[%d]: name or directory is required":                        "freezeRules[%d]: требуется name или directory",
+	"freezeRules[%d]: invalid freezePeriod: %w":                             "freezeRules[%d]: неверный freezePeriod: %w",
+	"freezeRules[%d]: invalid directory pattern: %w":                        "freezeRules[%d]: неверный шаблон директории: %w",
+	"excludedPorts[%d]: port %d must be between 1 and 65535":                "excludedPorts[%d]: порт %d должен быть от 1 до 65535",
+	"excludedRanges[%d]: %w":                                                "excludedRanges[%d]: %w",
+	"advertiseNames[%d]: name must not be empty":                            "advertiseNames[%d]: имя не может быть пустым",
+	"invalid logFormat %q (must be text or json)":                           "неверный logFormat %q (допустимо text или json)",
+	"invalid logTarget %q (must be file, syslog or journald)":               "неверный logTarget %q (допустимо file, syslog или journald)",
+	"invalid store %q (must be yaml or remote)":                             "неверный store %q (допустимо yaml или remote)",
+	"invalid remoteURL %q (must be an http:// or https:// URL)":             "неверный remoteURL %q (нужен URL http:// или https://)",
+	"store remote requires remoteURL":                                       "store remote требует remoteURL",
+	"perHost cannot be used with store remote, which is shared on purpose":  "perHost нельзя использовать со store remote, которое намеренно общее",
+	"invalid containerRuntime %q (must be auto, docker, podman or nerdctl)": "неверный containerRuntime %q (допустимо auto, docker, podman или nerdctl)",
+	"invalid socketSource %q (must be auto, netlink or proc)":               "неверный socketSource %q (допустимо auto, netlink или proc)",
+	"composeBlockSize (%d) must be between 0 and the range size (%d)":       "composeBlockSize (%d) должен быть от 0 до размера диапазона (%d)",
+	"invalid portCheck %q (must be bind or strict)":                         "неверный portCheck %q (допустимо bind или strict)",
+	"checkTimeoutMs (%d) must not be negative":                              "checkTimeoutMs (%d) не может быть отрицательным",
+	"invalid checks %q (must be on or off)":                                 "неверный checks %q (допустимо on или off)",
+	"invalid language %q (must be auto, en or ru)":                          "неверный language %q (допустимо auto, en или ru)",
+	"invalid rootDetection %q (must be git, config or none)":                "неверный rootDetection %q (допустимо git, config или none)",
+	"invalid symlinks %q (must be resolve or keep)":                         "неверный symlinks %q (допустимо resolve или keep)",
+	"invalid pathCase %q (must be auto, sensitive or insensitive)":          "неверный pathCase %q (допустимо auto, sensitive или insensitive)",
+	"invalid ephemeralOverlap %q (must be warn, fail or ignore)":            "неверный ephemeralOverlap %q (допустимо warn, fail или ignore)",
+	"backups (%d) must be between 0 and %d":                                 "backups (%d) должен быть от 0 до %d",
+	"invalid onConflict %q (must be reuse, fail or reallocate)":             "неверный onConflict %q (допустимо reuse, fail или reallocate)",
+	"cannot parse duration: %s (use format like 30d, 720h, 24h30m)":         "не удалось разобрать длительность: %s (используйте формат вроде 30d, 720h, 24h30m)",
+	"invalid port range %q (use START-END, e.g. 5000-5010)":                 "неверный диапазон портов %q (используйте START-END, например 5000-5010)",
+	"invalid profile name %q (use letters, digits, '.', '_' and '-')":       "неверное имя профиля %q (используйте буквы, цифры, '.', '_' и '-')",
+	"failed to get user config dir: %w":                                     "не удалось определить директорию конфигурации пользователя: %w",
+	"failed to get home dir: %w":                                            "не удалось определить домашнюю директорию: %w",
+	"failed to create config directory: %w":                                 "не удалось создать директорию конфигурации: %w",
+	"failed to marshal config: %w":                                          "не удалось сериализовать конфигурацию: %w",
+	"failed to parse config file: %w":                                       "не удалось разобрать файл конфигурации: %w",
+	"failed to read config file: %w":                                        "не удалось прочитать файл конфигурации: %w",
+	"failed to write config file: %w":                                       "не удалось записать файл конфигурации: %w",
+	"invalid config: %w":                                                    "неверная конфигурация: %w",
+	"%s is invalid: %w (run 'port-selector config edit' to fix)":            "%s некорректен: %w (исправьте через 'port-selector config edit')",
+	"editor %s failed: %w":                                                  "ошибка редактора %s: %w",
+	"%s is a list; edit it with 'port-selector config edit'":                "%s — список; редактируйте его через 'port-selector config edit'",
+	"unknown config key %q (available: %s)":                                 "неизвестный ключ конфигурации %q (доступны: %s)",
+	"%s must be a number, got %q":                                           "%s должен быть числом, получено %q",
+	"%s must be true or false, got %q":                                      "%s должен быть true или false, получено %q",
+	"failed to parse %s: %w":                                                "не удалось разобрать %s: %w",
+	"%s: preferred port %d has an empty name":                               "%s: у предпочтительного порта %d пустое имя",
+	"%s: preferred port for %q (%d) must be between 1 and 65535":            "%s: предпочтительный порт для %q (%d) должен быть от 1 до 65535",
+	"%s: name must be a single line":                                        "%s: имя должно быть одной строкой",
+	"%s: invalid monorepo pattern %q (use a relative path like packages/*)": "%s: неверный шаблон monorepo %q (используйте относительный путь, например packages/*)",
+	"%s: monorepo pattern %q needs a name (e.g., %s)":                       "%s: шаблону monorepo %q нужно имя (например, %s)",
+	"failed to list profiles: %w":                                           "не удалось получить список профилей: %w",
+
+	"nothing to advertise: list allocation names in advertiseNames (e.g., advertiseNames: [web])": "нечего анонсировать: перечислите имена аллокаций в advertiseNames (например, advertiseNames: [web])",
+	"failed to get hostname: %w":              "не удалось определить имя хоста: %w",
+	"failed to list network addresses: %w":    "не удалось получить сетевые адреса: %w",
+	"no IPv4 network address to advertise on": "нет IPv4-адреса для анонса",
+	"failed to join mDNS group: %w":           "не удалось присоединиться к группе mDNS: %w",
+	"failed to read manifest: %w":             "не удалось прочитать манифест: %w",
+	"failed to parse manifest: %w":            "не удалось разобрать манифест: %w",
+	"manifest %s has no services":             "в манифесте %s нет сервисов",
+	"manifest service #%d has no name":        "у сервиса №%d в манифесте нет имени",
+	"manifest service %q is listed twice":     "сервис %q указан в манифесте дважды",
+	"manifest service %q: %w":                 "сервис манифеста %q: %w",
+	"service %s: %w":                          "сервис %s: %w",
+	"bench writes to a temporary store and cannot run with --dry-run or --read-only": "bench пишет во временное хранилище и не работает с --dry-run или --read-only",
+	"bench does not support store %s":                                                "bench не поддерживает хранилище %s",
+	"port range %d-%d is too small for %d workers":                                   "диапазон портов %d-%d слишком мал для исполнителей: %d",
+	"failed to create temporary store: %w":                                           "не удалось создать временное хранилище: %w",
+	"store holds %d allocations, expected %d (lost updates)":                         "в хранилище аллокаций: %d, ожидалось %d (потерянные обновления)",
+	"%d of %d allocations failed":                                                    "не удалось выполнить аллокаций: %d из %d",
+	"failed to load bench store: %w":                                                 "не удалось загрузить хранилище bench: %w",
+	"neither ufw nor firewalld found (use --tool ufw|firewalld)":                     "не найден ни ufw, ни firewalld (используйте --tool ufw|firewalld)",
+	"no private IPv4 network found":                                                  "не найдена частная IPv4-сеть",
+	"%s failed: %w":                                                                  "ошибка %s: %w",
+	"unknown firewall tool %q (use ufw or firewalld)":                                "неизвестный инструмент файрвола %q (используйте ufw или firewalld)",
+	"%w (use --source CIDR)":                                                         "%w (используйте --source CIDR)",
+	"history reads the log file, but events go to %s (logTarget); use journalctl or your syslog instead": "history читает файл лога, а события пишутся в %s (logTarget); используйте journalctl или syslog",
+	"logging is disabled; set 'log' in the config to record history":                                     "логирование отключено; задайте 'log' в конфигурации, чтобы записывать историю",
+	"failed to read log: %w": "не удалось прочитать лог: %w",
+	"invalid hostname %q":    "неверное имя хоста %q",
+	"invalid hostname %q (use letters, digits and dashes separated by dots)": "неверное имя хоста %q (используйте буквы, цифры и дефисы, разделённые точками)",
+	"hostname %s is already used by %s ('%s', port %d)":                      "имя хоста %s уже используется для %s ('%s', порт %d)",
+	"failed to read %s: %w": "не удалось прочитать %s: %w",
+	"cannot write %s: permission denied (try: sudo --preserve-env=HOME port-selector hosts --write)": "не удалось записать %s: доступ запрещён (попробуйте: sudo --preserve-env=HOME port-selector hosts --write)",
+	"failed to write %s: %w":                                                                             "не удалось записать %s: %w",
+	"%s already exists (use --force to overwrite)":                                                       "%s уже существует (используйте --force, чтобы перезаписать)",
+	"failed to open browser (%s): %w":                                                                    "не удалось открыть браузер (%s): %w",
+	"failed to create output directory: %w":                                                              "не удалось создать выходную директорию: %w",
+	"ssh -R %d:localhost:%d failed: %w":                                                                  "ошибка ssh -R %d:localhost:%d: %w",
+	"failed to allocate a port on %s (is port-selector installed there?): %w":                            "не удалось выделить порт на %s (установлен ли там port-selector?): %w",
+	"unexpected output from port-selector on %s: %q":                                                     "неожиданный вывод port-selector на %s: %q",
+	"--accept requires HOST:PORT":                                                                        "--accept требует HOST:PORT",
+	"--accept cannot be combined with user@host or --print":                                              "--accept нельзя сочетать с user@host или --print",
+	"invalid --accept value %q (expected HOST:PORT)":                                                     "неверное значение --accept %q (ожидается HOST:PORT)",
+	"failed to create .vscode directory: %w":                                                             "не удалось создать директорию .vscode: %w",
+	"not plain JSON (%v); remove comments or add the inputs by hand (see 'port-selector vscode --json')": "не чистый JSON (%v); удалите комментарии или добавьте inputs вручную (см. 'port-selector vscode --json')",
+	"expected a JSON object":                                                                             "ожидался JSON-объект",
+	"\"inputs\" is not a list":                                                                           "\"inputs\" не является списком",
+	"no Docker or Podman API socket found (set DOCKER_HOST=unix://...)":                                  "не найден сокет API Docker или Podman (задайте DOCKER_HOST=unix://...)",
+	"unknown container runtime %q (use auto, docker, podman or nerdctl)":                                 "неизвестная среда контейнеров %q (используйте auto, docker, podman или nerdctl)",
+	"failed to decode container list: %w":                                                                "не удалось разобрать список контейнеров: %w",
+	"event stream ended: %w":                                                                             "поток событий завершился: %w",
+	"desktop notifications are not supported on %s":                                                      "уведомления на рабочем столе не поддерживаются в %s",
+	"%s not found: %w":                                                                                   "%s не найден: %w",
+	"text too long for a QR code (max 213 bytes)":                                                        "текст слишком длинный для QR-кода (максимум 213 байт)",
+	"failed to fetch latest release: %w":                                                                 "не удалось получить последний релиз: %w",
+	"failed to fetch latest release: %s":                                                                 "не удалось получить последний релиз: %s",
+	"failed to parse latest release: %w":                                                                 "не удалось разобрать последний релиз: %w",
+	"latest release has no tag":                                                                          "у последнего релиза нет тега",
+
+	"unknown log format %q (use %s or %s)":     "неизвестный формат лога %q (используйте %s или %s)",
+	"unknown log target %q (use %s, %s or %s)": "неизвестное назначение лога %q (используйте %s, %s или %s)",
+	"log directory does not exist: %s":         "директория лога не существует: %s",
+	"failed to stat log directory: %w":         "не удалось проверить директорию лога: %w",
+	"log path parent is not a directory: %s":   "родитель пути лога не является директорией: %s",
+	"cannot write to log file: %w":             "не удалось записать в файл лога: %w",
+	"failed to expand home directory: %w":      "не удалось раскрыть домашнюю директорию: %w",
+	"invalid JSON log line: %w":                "неверная JSON-строка лога: %w",
+	"invalid timestamp %q: %w":                 "неверная метка времени %q: %w",
+	"invalid log line: %q":                     "неверная строка лога: %q",
+	"unterminated quote in log line: %q":       "незакрытая кавычка в строке лога: %q",
+	"failed to read log file: %w":              "не удалось прочитать файл лога: %w",
+	"journald is not available: %w":            "journald недоступен: %w",
+	"syslog is not available: %w":              "syslog недоступен: %w",
+}
@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// untranslated lists formats that are left in English on purpose: protocol
+// and kernel interface details that mean nothing in translation.
+var untranslated = map[string]bool{
+	"GET %s: %s":               true,
+	"PUT %s: %s":               true,
+	"GET /containers/json: %s": true,
+	"GET /events: %s":          true,
+	"no socket":                true,
+	"malformed DNS message":    true,
+	"mDNS socket closed":       true,
+	"netlink socket: %w":       true,
+	"netlink timeout: %w":      true,
+	"netlink send: %w":         true,
+	"netlink receive: %w":      true,
+	"netlink parse: %w":        true,
+	"sock_diag: %w":            true,
+}
+
+// TestCatalogCoversSources checks that every message format in the sources
+// (stderrf, i18n.Sprintf, i18n.Fprintf, fmt.Errorf and errors.New) has a
+// Russian translation, except formats made only of verbs and separators.
+func TestCatalogCoversSources(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, root := range []string{"../../cmd", "../../internal"} {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				format, ok := messageFormat(n)
+				if !ok || untranslated[format] {
+					return true
+				}
+				if _, ok := russian[format]; ok {
+					return true
+				}
+				if _, ok := compilePattern(format, format); !ok && verbPattern.MatchString(format) {
+					return true
+				}
+				t.Errorf("%s: %q has no Russian translation", fset.Position(n.Pos()), format)
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// messageFormat returns the format literal of a call that prints or creates a message.
+func messageFormat(n ast.Node) (string, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	name := ""
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		name = fn.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := fn.X.(*ast.Ident); ok {
+			name = pkg.Name + "." + fn.Sel.Name
+		}
+	}
+	arg := 0
+	switch name {
+	case "stderrf", "i18n.Sprintf", "i18n.T", "fmt.Errorf", "errors.New":
+	case "i18n.Fprintf":
+		arg = 1
+	default:
+		return "", false
+	}
+	if len(call.Args) <= arg {
+		return "", false
+	}
+	lit, ok := call.Args[arg].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	format, err := strconv.Unquote(lit.Value)
+	return format, err == nil
+}
@@ -2,12 +2,18 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/dapi/port-selector/internal/i18n"
 )
 
 // Event types for logging.
@@ -20,19 +26,56 @@ const (
 	AllocExpire    = "ALLOC_EXPIRE"
 	AllocExternal  = "ALLOC_EXTERNAL" // For registering external ports
 	AllocRefresh   = "ALLOC_REFRESH"  // For refresh operations
+	AllocMigrate   = "ALLOC_MIGRATE"  // For one-time migration of legacy files
+	AllocUndo      = "ALLOC_UNDO"     // For restoring allocations with undo
+	AllocRestore   = "ALLOC_RESTORE"  // For restoring the store from a backup
+	AllocRepair    = "ALLOC_REPAIR"   // For salvaging a corrupted store with repair
+	AllocRecover   = "ALLOC_RECOVER"  // For replacing a store that failed verification with a backup
+	ConfigSet      = "CONFIG_SET"     // For config changes via `config set`
+)
+
+// Log formats accepted by SetFormat and the `logFormat` config option.
+const (
+	FormatText = "text" // 2026-01-03T15:04:05Z ALLOC_ADD port=3001 dir=/path (default)
+	FormatJSON = "json" // {"ts":"...","event":"ALLOC_ADD","fields":{"port":"3001","dir":"/path"}}
 )
 
-// Logger handles writing events to a log file.
+// Logger handles writing events to a log file or a system logger.
 type Logger struct {
-	path string
-	mu   sync.Mutex
+	path   string
+	target string   // TargetFile if empty
+	conn   net.Conn // socket of the system logger
+	mu     sync.Mutex
 }
 
 var (
 	globalLogger *Logger
+	globalFormat = FormatText
 	globalMu     sync.Mutex
 )
 
+// jsonEntry is a single line of the JSON log format.
+type jsonEntry struct {
+	TS     string            `json:"ts"`
+	Event  string            `json:"event"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// SetFormat selects the log line format. Empty format selects text.
+func SetFormat(format string) error {
+	switch format {
+	case "":
+		format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q (use %s or %s)", format, FormatText, FormatJSON)
+	}
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalFormat = format
+	return nil
+}
+
 // Init initializes the global logger with the given path.
 // If path is empty, logging is disabled.
 func Init(path string) error {
@@ -44,13 +87,9 @@ func Init(path string) error {
 		return nil
 	}
 
-	// Expand ~ to home directory
-	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to expand home directory: %w", err)
-		}
-		path = filepath.Join(home, path[2:])
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
 	}
 
 	// Check if directory exists
@@ -75,41 +114,90 @@ func Init(path string) error {
 	return nil
 }
 
+// ExpandPath expands a leading ~/ in a log path to the home directory.
+func ExpandPath(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand home directory: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	return path, nil
+}
+
+// invokingUser returns the name of the user running port-selector, used as
+// the "by" field of every event. Empty if it cannot be determined.
+var invokingUser = sync.OnceValue(func() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+})
+
 // Log writes an event to the log file.
 // If logger is not initialized, this is a no-op.
 func Log(event string, fields ...string) {
 	globalMu.Lock()
 	logger := globalLogger
+	format := globalFormat
 	globalMu.Unlock()
 
 	if logger == nil {
 		return
 	}
 
-	logger.log(event, fields...)
+	logger.log(format, event, fields...)
 }
 
-func (l *Logger) log(event string, fields ...string) {
+func (l *Logger) log(format, event string, fields ...string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	timestamp := time.Now().UTC().Format(time.RFC3339)
-	line := fmt.Sprintf("%s %s", timestamp, event)
+	if by := invokingUser(); by != "" {
+		fields = append(fields[:len(fields):len(fields)], Field("by", by))
+	}
 
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	message := event
 	if len(fields) > 0 {
-		line += " " + strings.Join(fields, " ")
+		message += " " + strings.Join(fields, " ")
+	}
+
+	// System loggers add their own timestamps
+	switch l.target {
+	case TargetSyslog:
+		if format == FormatJSON {
+			message = formatJSON(timestamp, event, fields)
+		}
+		if err := l.writeSyslog(message); err != nil {
+			i18n.Fprintf(os.Stderr, "warning: failed to write to syslog: %v\n", err)
+		}
+		return
+	case TargetJournald:
+		if err := l.writeJournald(message, event, fields); err != nil {
+			i18n.Fprintf(os.Stderr, "warning: failed to write to journald: %v\n", err)
+		}
+		return
+	}
+
+	var line string
+	if format == FormatJSON {
+		line = formatJSON(timestamp, event, fields)
+	} else {
+		line = timestamp + " " + message
 	}
 	line += "\n"
 
 	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to open log file: %v\n", err)
+		i18n.Fprintf(os.Stderr, "warning: failed to open log file: %v\n", err)
 		return
 	}
 	defer f.Close()
 
 	if _, err := f.WriteString(line); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to write to log file: %v\n", err)
+		i18n.Fprintf(os.Stderr, "warning: failed to write to log file: %v\n", err)
 	}
 }
 
@@ -122,3 +210,25 @@ func Field(key string, value interface{}) string {
 	}
 	return fmt.Sprintf("%s=%s", key, str)
 }
+
+// formatJSON renders an event as a single-line JSON object.
+// Fields created by Field are split back into key and (unquoted) value.
+func formatJSON(timestamp, event string, fields []string) string {
+	entry := jsonEntry{TS: timestamp, Event: event}
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]string, len(fields))
+		for _, f := range fields {
+			key, value, _ := strings.Cut(f, "=")
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			entry.Fields[key] = value
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		// Unreachable for string maps; fall back to the event name only
+		return fmt.Sprintf(`{"ts":%q,"event":%q}`, timestamp, event)
+	}
+	return string(data)
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -271,3 +272,46 @@ func TestLog_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected %d log lines, got %d", expectedLines, len(lines))
 	}
 }
+
+func TestLog_JSONFormat(t *testing.T) {
+	globalLogger = nil
+	defer SetFormat(FormatText)
+
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	if err := Init(logPath); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatalf("SetFormat() error = %v", err)
+	}
+
+	Log(AllocAdd, Field("port", 3000), Field("dir", "/test/my dir"))
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	var entry jsonEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatalf("log line is not valid JSON: %v\n%s", err, content)
+	}
+	if entry.Event != AllocAdd {
+		t.Errorf("expected event %s, got %s", AllocAdd, entry.Event)
+	}
+	if entry.Fields["port"] != "3000" || entry.Fields["dir"] != "/test/my dir" {
+		t.Errorf("unexpected fields: %v", entry.Fields)
+	}
+	if _, err := time.Parse(time.RFC3339, entry.TS); err != nil {
+		t.Errorf("ts should be RFC3339, got %q", entry.TS)
+	}
+}
+
+func TestSetFormat_Invalid(t *testing.T) {
+	if err := SetFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if err := SetFormat(""); err != nil {
+		t.Errorf("empty format should select text, got %v", err)
+	}
+}
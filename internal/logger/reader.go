@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single parsed log event.
+type Entry struct {
+	Time   time.Time
+	Event  string
+	Fields map[string]string
+}
+
+// ParseLine parses a log line written in either the text or the JSON format.
+func ParseLine(line string) (Entry, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var je jsonEntry
+		if err := json.Unmarshal([]byte(line), &je); err != nil {
+			return Entry{}, fmt.Errorf("invalid JSON log line: %w", err)
+		}
+		ts, err := time.Parse(time.RFC3339, je.TS)
+		if err != nil {
+			return Entry{}, fmt.Errorf("invalid timestamp %q: %w", je.TS, err)
+		}
+		if je.Fields == nil {
+			je.Fields = map[string]string{}
+		}
+		return Entry{Time: ts, Event: je.Event, Fields: je.Fields}, nil
+	}
+
+	tokens, err := splitFields(line)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(tokens) < 2 {
+		return Entry{}, fmt.Errorf("invalid log line: %q", line)
+	}
+	ts, err := time.Parse(time.RFC3339, tokens[0])
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid timestamp %q: %w", tokens[0], err)
+	}
+
+	entry := Entry{Time: ts, Event: tokens[1], Fields: make(map[string]string, len(tokens)-2)}
+	for _, tok := range tokens[2:] {
+		key, value, _ := strings.Cut(tok, "=")
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		entry.Fields[key] = value
+	}
+	return entry, nil
+}
+
+// splitFields splits a text log line on spaces, keeping quoted values (as written
+// by Field) intact.
+func splitFields(line string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	inQuotes := false
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ' ' && !inQuotes:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in log line: %q", line)
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens, nil
+}
+
+// ReadEntries reads all events from the log file at path.
+// Lines that cannot be parsed are skipped; their count is returned.
+func ReadEntries(path string) ([]Entry, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	skipped := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		entry, err := ParseLine(scanner.Text())
+		if err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return entries, skipped, nil
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantEvent  string
+		wantFields map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "text",
+			line:       "2026-01-03T15:04:05Z ALLOC_ADD port=3001 dir=/home/user/project1",
+			wantEvent:  AllocAdd,
+			wantFields: map[string]string{"port": "3001", "dir": "/home/user/project1"},
+		},
+		{
+			name:       "text with quoted value",
+			line:       `2026-01-03T15:04:05Z ALLOC_DELETE port=3002 dir="/home/user/my \"project\"" by=alice`,
+			wantEvent:  AllocDelete,
+			wantFields: map[string]string{"port": "3002", "dir": `/home/user/my "project"`, "by": "alice"},
+		},
+		{
+			name:       "json",
+			line:       `{"ts":"2026-01-03T15:04:05Z","event":"ALLOC_LOCK","fields":{"port":"3001","locked":"true"}}`,
+			wantEvent:  AllocLock,
+			wantFields: map[string]string{"port": "3001", "locked": "true"},
+		},
+		{name: "garbage", line: "not a log line", wantErr: true},
+		{name: "unterminated quote", line: `2026-01-03T15:04:05Z ALLOC_ADD dir="/x`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ParseLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if entry.Event != tt.wantEvent {
+				t.Errorf("Event = %s, want %s", entry.Event, tt.wantEvent)
+			}
+			for k, v := range tt.wantFields {
+				if entry.Fields[k] != v {
+					t.Errorf("Fields[%s] = %q, want %q", k, entry.Fields[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestReadEntries_RoundTrip(t *testing.T) {
+	globalLogger = nil
+	defer SetFormat(FormatText)
+
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	if err := Init(logPath); err != nil {
+		t.Fatal(err)
+	}
+
+	Log(AllocAdd, Field("port", 3000), Field("dir", "/a dir"))
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	Log(AllocDelete, Field("port", 3000))
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("garbage\n")
+	f.Close()
+
+	entries, skipped, err := ReadEntries(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || skipped != 1 {
+		t.Fatalf("expected 2 entries and 1 skipped, got %d and %d", len(entries), skipped)
+	}
+	if entries[0].Fields["dir"] != "/a dir" || entries[1].Event != AllocDelete {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Fields["by"] == "" {
+		t.Error("expected the invoking user to be recorded in the by field")
+	}
+}
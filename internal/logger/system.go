@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log targets accepted by InitTarget and the `logTarget` config option.
+const (
+	TargetFile     = "file"     // the log file (default)
+	TargetSyslog   = "syslog"   // the local syslog daemon
+	TargetJournald = "journald" // the systemd journal, with fields as metadata
+)
+
+// identifier tags port-selector messages in syslog and the journal.
+const identifier = "port-selector"
+
+// syslogPriority is facility user (1) with severity info (6).
+const syslogPriority = 1*8 + 6
+
+// journaldPriority is severity info.
+const journaldPriority = "6"
+
+// Local sockets of the system loggers (variables for tests).
+var (
+	syslogSockets  = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+	journaldSocket = "/run/systemd/journal/socket"
+)
+
+// InitTarget sends events to a system logger (TargetSyslog or TargetJournald)
+// instead of a file. Returns an error if the logger's socket is not available.
+func InitTarget(target string) error {
+	var conn net.Conn
+	var err error
+	switch target {
+	case TargetSyslog:
+		conn, err = dialSyslog()
+	case TargetJournald:
+		conn, err = net.Dial("unixgram", journaldSocket)
+		if err != nil {
+			err = fmt.Errorf("journald is not available: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown log target %q (use %s, %s or %s)", target, TargetFile, TargetSyslog, TargetJournald)
+	}
+	if err != nil {
+		return err
+	}
+
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalLogger = &Logger{target: target, conn: conn}
+	return nil
+}
+
+// dialSyslog connects to the first local syslog socket that accepts a connection.
+func dialSyslog() (net.Conn, error) {
+	var lastErr error
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+	}
+	return nil, fmt.Errorf("syslog is not available: %w", lastErr)
+}
+
+// writeSyslog sends message to syslog in the local (RFC 3164) format.
+func (l *Logger) writeSyslog(message string) error {
+	_, err := fmt.Fprintf(l.conn, "<%d>%s %s[%d]: %s\n",
+		syslogPriority, time.Now().Format(time.Stamp), identifier, os.Getpid(), message)
+	return err
+}
+
+// writeJournald sends an event to the journal using its native protocol: the
+// text line is MESSAGE, and the event and every field become PORT_SELECTOR_*
+// metadata (e.g. PORT_SELECTOR_PORT=3001), usable in `journalctl PORT_SELECTOR_PORT=3001`.
+func (l *Logger) writeJournald(message, event string, fields []string) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", message)
+	writeJournalField(&buf, "PRIORITY", journaldPriority)
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", identifier)
+	writeJournalField(&buf, "PORT_SELECTOR_EVENT", event)
+	for _, f := range fields {
+		key, value, _ := strings.Cut(f, "=")
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		writeJournalField(&buf, "PORT_SELECTOR_"+journalFieldName(key), value)
+	}
+	_, err := l.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournalField appends one field; values with newlines use the binary form.
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+		return
+	}
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName turns a field key into a journal field name: upper case
+// letters, digits and underscores.
+func journalFieldName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
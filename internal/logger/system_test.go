@@ -0,0 +1,104 @@
+//go:build unix
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenGram starts a unixgram socket standing in for a system logger.
+func listenGram(t *testing.T) (string, *net.UnixConn) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets not available: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return path, conn
+}
+
+func readGram(t *testing.T, conn *net.UnixConn) []byte {
+	t.Helper()
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("no message received: %v", err)
+	}
+	return buf[:n]
+}
+
+func TestInitTarget_Journald(t *testing.T) {
+	path, conn := listenGram(t)
+	old := journaldSocket
+	journaldSocket = path
+	t.Cleanup(func() {
+		journaldSocket = old
+		Init("")
+	})
+
+	if err := InitTarget(TargetJournald); err != nil {
+		t.Fatal(err)
+	}
+	Log(AllocAdd, Field("port", 3001), Field("dir", "/test/my dir"), Field("note", "a\nb"))
+
+	msg := readGram(t, conn)
+	for _, want := range []string{
+		"MESSAGE=ALLOC_ADD port=3001 dir=\"/test/my dir\"",
+		"SYSLOG_IDENTIFIER=port-selector\n",
+		"PORT_SELECTOR_EVENT=ALLOC_ADD\n",
+		"PORT_SELECTOR_PORT=3001\n",
+		"PORT_SELECTOR_DIR=/test/my dir\n",
+	} {
+		if !bytes.Contains(msg, []byte(want)) {
+			t.Errorf("journal message lacks %q:\n%s", want, msg)
+		}
+	}
+
+	// Values with newlines use the binary form: NAME\n<le64 length><value>\n
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], 3)
+	if !bytes.Contains(msg, append(append([]byte("PORT_SELECTOR_NOTE\n"), size[:]...), "a\nb\n"...)) {
+		t.Errorf("expected binary-encoded note field:\n%q", msg)
+	}
+}
+
+func TestInitTarget_Syslog(t *testing.T) {
+	path, conn := listenGram(t)
+	old := syslogSockets
+	syslogSockets = []string{filepath.Join(t.TempDir(), "missing"), path}
+	t.Cleanup(func() {
+		syslogSockets = old
+		Init("")
+	})
+
+	if err := InitTarget(TargetSyslog); err != nil {
+		t.Fatal(err)
+	}
+	Log(AllocDelete, Field("port", 3002))
+
+	msg := string(readGram(t, conn))
+	if !strings.HasPrefix(msg, "<14>") || !strings.Contains(msg, " port-selector[") || !strings.Contains(msg, "]: ALLOC_DELETE port=3002") {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+}
+
+func TestInitTarget_Unavailable(t *testing.T) {
+	old := journaldSocket
+	journaldSocket = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() { journaldSocket = old })
+
+	if err := InitTarget(TargetJournald); err == nil {
+		t.Error("expected error for a missing journald socket")
+	}
+	if err := InitTarget("kafka"); err == nil {
+		t.Error("expected error for an unknown target")
+	}
+}
@@ -0,0 +1,320 @@
+// Package mdns advertises services on the local network via multicast DNS (RFC 6762)
+// and DNS-based service discovery (RFC 6763).
+package mdns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// groupAddr is the IPv4 mDNS multicast group.
+var groupAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// DNS constants used by the responder.
+const (
+	typeA   = 1
+	typePTR = 12
+	typeTXT = 16
+	typeSRV = 33
+	typeANY = 255
+
+	classIN    = 1
+	cacheFlush = 0x8000 // set on records unique to this host
+
+	flagResponse = 0x8400 // QR and AA bits
+
+	hostTTL    = 120  // SRV, TXT and A records
+	serviceTTL = 4500 // PTR records
+)
+
+// servicesName is the DNS-SD meta-query name that enumerates service types.
+var servicesName = []string{"_services", "_dns-sd", "_udp", "local"}
+
+// Service is a single advertised service instance.
+type Service struct {
+	Instance string   // instance label, e.g. "alice's shop-api (web)"
+	Type     string   // service type, e.g. "_http._tcp"
+	Port     int      // TCP port
+	TXT      []string // key=value attributes
+}
+
+// Host is the machine the services run on.
+type Host struct {
+	Name string   // host label without .local, e.g. "laptop"
+	IPs  []net.IP // IPv4 addresses
+}
+
+// typeName returns the labels of the service type in the local domain.
+func (s Service) typeName() []string {
+	return append(strings.Split(s.Type, "."), "local")
+}
+
+// instanceName returns the labels of the service instance. The instance label
+// may contain dots and spaces, so it is never split.
+func (s Service) instanceName() []string {
+	return append([]string{s.Instance}, s.typeName()...)
+}
+
+// hostName returns the labels of the host name in the local domain.
+func (h Host) hostName() []string {
+	return []string{h.Name, "local"}
+}
+
+// record is a resource record ready to be encoded.
+type record struct {
+	name  []string
+	rtype uint16
+	class uint16
+	ttl   uint32
+	data  []byte
+}
+
+// appendName appends labels in uncompressed wire format. Labels are truncated to 63 bytes.
+func appendName(b []byte, labels []string) []byte {
+	for _, l := range labels {
+		if len(l) > 63 {
+			l = l[:63]
+		}
+		b = append(b, byte(len(l)))
+		b = append(b, l...)
+	}
+	return append(b, 0)
+}
+
+// records returns all records advertising services on host. With goodbye, every
+// TTL is zero, which tells caches to drop the records.
+func records(host Host, services []Service, goodbye bool) []record {
+	var rs []record
+	seenType := make(map[string]bool)
+	for _, s := range services {
+		if !seenType[s.Type] {
+			seenType[s.Type] = true
+			rs = append(rs, record{servicesName, typePTR, classIN, serviceTTL, appendName(nil, s.typeName())})
+		}
+		rs = append(rs, record{s.typeName(), typePTR, classIN, serviceTTL, appendName(nil, s.instanceName())})
+
+		srv := make([]byte, 6)
+		binary.BigEndian.PutUint16(srv[4:], uint16(s.Port))
+		rs = append(rs, record{s.instanceName(), typeSRV, classIN | cacheFlush, hostTTL, appendName(srv, host.hostName())})
+
+		var txt []byte
+		for _, t := range s.TXT {
+			if len(t) > 255 {
+				t = t[:255]
+			}
+			txt = append(txt, byte(len(t)))
+			txt = append(txt, t...)
+		}
+		if len(txt) == 0 {
+			txt = []byte{0}
+		}
+		rs = append(rs, record{s.instanceName(), typeTXT, classIN | cacheFlush, hostTTL, txt})
+	}
+	for _, ip := range host.IPs {
+		if ip4 := ip.To4(); ip4 != nil {
+			rs = append(rs, record{host.hostName(), typeA, classIN | cacheFlush, hostTTL, []byte(ip4)})
+		}
+	}
+	if goodbye {
+		for i := range rs {
+			rs[i].ttl = 0
+		}
+	}
+	return rs
+}
+
+// encodeResponse builds an unsolicited mDNS response carrying rs as answers.
+func encodeResponse(rs []record) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[2:], flagResponse)
+	binary.BigEndian.PutUint16(b[6:], uint16(len(rs)))
+	for _, r := range rs {
+		b = appendName(b, r.name)
+		var fixed [10]byte
+		binary.BigEndian.PutUint16(fixed[0:], r.rtype)
+		binary.BigEndian.PutUint16(fixed[2:], r.class)
+		binary.BigEndian.PutUint32(fixed[4:], r.ttl)
+		binary.BigEndian.PutUint16(fixed[8:], uint16(len(r.data)))
+		b = append(b, fixed[:]...)
+		b = append(b, r.data...)
+	}
+	return b
+}
+
+// errMalformed is returned for packets that cannot be parsed.
+var errMalformed = errors.New("malformed DNS message")
+
+// readName reads a possibly compressed name at off and returns it (lowercase,
+// dot-separated) and the offset after it.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")), end, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(msg) || jumps > 10 {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+			jumps++
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+// parseQuestions returns the question names of a query. Responses yield no questions.
+func parseQuestions(msg []byte) ([]string, error) {
+	if len(msg) < 12 {
+		return nil, errMalformed
+	}
+	if binary.BigEndian.Uint16(msg[2:])&0x8000 != 0 {
+		return nil, nil
+	}
+	count := int(binary.BigEndian.Uint16(msg[4:]))
+	off := 12
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(msg) {
+			return nil, errMalformed
+		}
+		qtype := binary.BigEndian.Uint16(msg[next:])
+		off = next + 4
+		switch qtype {
+		case typeA, typePTR, typeTXT, typeSRV, typeANY:
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// joinName returns the lowercase dot-separated form of labels, as returned by readName.
+func joinName(labels []string) string {
+	return strings.ToLower(strings.Join(labels, "."))
+}
+
+// answers reports whether a query for any of names concerns the advertised records.
+func answers(names []string, host Host, services []Service) bool {
+	ours := map[string]bool{joinName(servicesName): true, joinName(host.hostName()): true}
+	for _, s := range services {
+		ours[joinName(s.typeName())] = true
+		ours[joinName(s.instanceName())] = true
+	}
+	for _, n := range names {
+		if ours[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// Advertise announces the services returned by list and answers queries for them
+// until ctx is canceled. list is called again every refresh, so services may come
+// and go. On return, a goodbye packet withdraws the last announced services.
+func Advertise(ctx context.Context, host Host, refresh time.Duration, list func() []Service) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return fmt.Errorf("failed to join mDNS group: %w", err)
+	}
+	defer conn.Close()
+
+	send := func(services []Service, goodbye bool) {
+		if len(services) == 0 {
+			return
+		}
+		if _, err := conn.WriteToUDP(encodeResponse(records(host, services, goodbye)), groupAddr); err != nil {
+			debug.Printf("mdns", "failed to send announcement: %v", err)
+		}
+	}
+
+	queries := make(chan []string)
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				close(queries)
+				return
+			}
+			names, err := parseQuestions(buf[:n])
+			if err != nil {
+				debug.Printf("mdns", "ignoring packet: %v", err)
+				continue
+			}
+			if len(names) > 0 {
+				select {
+				case queries <- names:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	services := list()
+	send(services, false)
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			send(services, true)
+			return nil
+		case <-ticker.C:
+			current := list()
+			send(withdrawn(services, current), true)
+			services = current
+			send(services, false)
+		case names, ok := <-queries:
+			if !ok {
+				return fmt.Errorf("mDNS socket closed")
+			}
+			if answers(names, host, services) {
+				debug.Printf("mdns", "answering query for %v", names)
+				send(services, false)
+			}
+		}
+	}
+}
+
+// withdrawn returns the services of old that are not in current.
+func withdrawn(old, current []Service) []Service {
+	keep := make(map[string]bool, len(current))
+	for _, s := range current {
+		keep[joinName(s.instanceName())] = true
+	}
+	var gone []Service
+	for _, s := range old {
+		if !keep[joinName(s.instanceName())] {
+			gone = append(gone, s)
+		}
+	}
+	return gone
+}
@@ -0,0 +1,96 @@
+package mdns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// query builds an mDNS query for name with the given type.
+func query(name []string, qtype uint16) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[4:], 1)
+	b = appendName(b, name)
+	return append(b, byte(qtype>>8), byte(qtype), 0, classIN)
+}
+
+func TestParseQuestionsAndAnswers(t *testing.T) {
+	host := Host{Name: "laptop", IPs: []net.IP{net.IPv4(192, 168, 1, 20)}}
+	services := []Service{{Instance: "alice's shop.api (web)", Type: "_http._tcp", Port: 3014}}
+
+	for _, tc := range []struct {
+		name []string
+		want bool
+	}{
+		{[]string{"_http", "_tcp", "local"}, true},
+		{[]string{"_HTTP", "_tcp", "local"}, true},
+		{[]string{"alice's shop.api (web)", "_http", "_tcp", "local"}, true},
+		{[]string{"laptop", "local"}, true},
+		{servicesName, true},
+		{[]string{"_ipp", "_tcp", "local"}, false},
+	} {
+		names, err := parseQuestions(query(tc.name, typePTR))
+		if err != nil {
+			t.Fatalf("parseQuestions(%v) error = %v", tc.name, err)
+		}
+		if got := answers(names, host, services); got != tc.want {
+			t.Errorf("answers(%v) = %v, want %v", names, got, tc.want)
+		}
+	}
+
+	// A compressed name pointing back at the first question
+	msg := query([]string{"_http", "_tcp", "local"}, typePTR)
+	binary.BigEndian.PutUint16(msg[4:], 2)
+	msg = append(msg, 0xC0, 12, 0, typeSRV, 0, classIN)
+	names, err := parseQuestions(msg)
+	if err != nil || len(names) != 2 || names[1] != "_http._tcp.local" {
+		t.Errorf("parseQuestions(compressed) = %v, %v", names, err)
+	}
+
+	if _, err := parseQuestions([]byte{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 5, 'a'}); err == nil {
+		t.Error("expected error for a truncated question")
+	}
+	response := encodeResponse(records(host, services, false))
+	if names, err := parseQuestions(response); err != nil || names != nil {
+		t.Errorf("parseQuestions(response) = %v, %v, want nothing", names, err)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	host := Host{Name: "laptop", IPs: []net.IP{net.IPv4(192, 168, 1, 20), net.ParseIP("fe80::1")}}
+	services := []Service{
+		{Instance: "alice's shop-api (web)", Type: "_http._tcp", Port: 3014, TXT: []string{"name=web"}},
+		{Instance: "alice's shop-api (api)", Type: "_http._tcp", Port: 3015},
+	}
+
+	rs := records(host, services, false)
+	// one service type PTR, then PTR+SRV+TXT per service, then one A record (IPv6 is skipped)
+	if len(rs) != 1+3*2+1 {
+		t.Fatalf("records() returned %d records", len(rs))
+	}
+	srv := rs[2]
+	if srv.rtype != typeSRV || binary.BigEndian.Uint16(srv.data[4:]) != 3014 {
+		t.Errorf("SRV record = %+v", srv)
+	}
+	if !bytes.Equal(srv.data[6:], appendName(nil, []string{"laptop", "local"})) {
+		t.Errorf("SRV target = %q", srv.data[6:])
+	}
+	if txt := rs[3]; txt.rtype != typeTXT || string(txt.data) != "\x08name=web" {
+		t.Errorf("TXT record = %+v", txt)
+	}
+	if a := rs[len(rs)-1]; a.rtype != typeA || !bytes.Equal(a.data, []byte{192, 168, 1, 20}) {
+		t.Errorf("A record = %+v", a)
+	}
+
+	for _, r := range records(host, services, true) {
+		if r.ttl != 0 {
+			t.Errorf("goodbye record %v has TTL %d", r.name, r.ttl)
+		}
+	}
+
+	gone := withdrawn(services, services[1:])
+	if len(gone) != 1 || gone[0].Port != 3014 {
+		t.Errorf("withdrawn() = %+v, want the web service", gone)
+	}
+}
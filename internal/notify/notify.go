@@ -0,0 +1,44 @@
+// Package notify sends desktop notifications via notify-send (Linux) or osascript (macOS).
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// command returns the notifier command and arguments for the given OS.
+// Returns an empty name if desktop notifications are not supported.
+func command(goos, title, message string) (string, []string) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+		return "osascript", []string{"-e", script}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=port-selector", title, message}
+	default:
+		return "", nil
+	}
+}
+
+// Send shows a desktop notification. Errors are returned but callers usually
+// ignore them: a missing notifier must never break port allocation.
+func Send(title, message string) error {
+	name, args := command(runtime.GOOS, title, message)
+	if name == "" {
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		debug.Printf("notify", "%s not found: %v", name, err)
+		return fmt.Errorf("%s not found: %w", name, err)
+	}
+
+	debug.Printf("notify", "sending notification: %s: %s", title, message)
+	if err := exec.Command(name, args...).Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		goos     string
+		wantName string
+		wantArg  string
+	}{
+		{"linux", "notify-send", "port 3000 is busy"},
+		{"darwin", "osascript", `display notification "port 3000 is busy" with title "port-selector"`},
+		{"windows", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			name, args := command(tt.goos, "port-selector", "port 3000 is busy")
+			if name != tt.wantName {
+				t.Fatalf("command(%q) name = %q, want %q", tt.goos, name, tt.wantName)
+			}
+			if tt.wantArg != "" && !strings.Contains(strings.Join(args, " "), tt.wantArg) {
+				t.Errorf("command(%q) args = %v, want to contain %q", tt.goos, args, tt.wantArg)
+			}
+		})
+	}
+}
+
+func TestCommand_QuotesMessage(t *testing.T) {
+	_, args := command("darwin", "title", `say "hi"`)
+	if !strings.Contains(args[1], `"say \"hi\""`) {
+		t.Errorf("expected message to be quoted for AppleScript, got %q", args[1])
+	}
+}
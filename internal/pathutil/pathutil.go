@@ -2,7 +2,9 @@
 package pathutil
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -26,3 +28,106 @@ func ShortenHomePath(path string) string {
 
 	return path
 }
+
+// ExpandHome replaces a leading ~ with the user's home directory.
+// If the home directory cannot be determined, the path is returned unchanged.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// IsWithin reports whether path is dir or is located inside dir.
+func IsWithin(path, dir string) bool {
+	path = filepath.Clean(path)
+	dir = filepath.Clean(dir)
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// ResolveSymlinks returns path with all symlinks resolved, or path itself if it
+// cannot be resolved (e.g., it no longer exists).
+func ResolveSymlinks(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+// CanonicalCase returns the absolute path with each existing component spelled
+// as it is stored on disk, so that /users/me/project and /Users/me/Project on a
+// case-insensitive filesystem become the same string. Components that cannot
+// be listed are kept as given; relative paths are returned unchanged.
+func CanonicalCase(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	path = filepath.Clean(path)
+	volume := filepath.VolumeName(path)
+	current := volume + string(filepath.Separator)
+	parts := strings.Split(strings.TrimPrefix(path[len(volume):], string(filepath.Separator)), string(filepath.Separator))
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		entries, err := os.ReadDir(current)
+		if err != nil {
+			return filepath.Join(append([]string{current}, parts[i:]...)...)
+		}
+		name := part
+		for _, e := range entries {
+			if e.Name() == part {
+				name = part
+				break
+			}
+			if name == part && strings.EqualFold(e.Name(), part) {
+				name = e.Name()
+			}
+		}
+		current = filepath.Join(current, name)
+	}
+	return current
+}
+
+// WriteFileAtomic writes data to a temp file next to path, syncs it, sets perm
+// and renames it over path, so a crash mid-write never leaves a truncated file behind.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to set temp file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
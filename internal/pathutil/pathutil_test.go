@@ -3,6 +3,7 @@ package pathutil
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -58,3 +59,103 @@ func TestShortenHomePath(t *testing.T) {
 		})
 	}
 }
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		dir      string
+		expected bool
+	}{
+		{name: "same directory", path: "/code/shop", dir: "/code/shop", expected: true},
+		{name: "subdirectory", path: "/code/shop/web", dir: "/code/shop", expected: true},
+		{name: "trailing slash", path: "/code/shop/web", dir: "/code/shop/", expected: true},
+		{name: "sibling with common prefix", path: "/code/shop2", dir: "/code/shop", expected: false},
+		{name: "parent", path: "/code", dir: "/code/shop", expected: false},
+		{name: "root dir", path: "/code", dir: "/", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsWithin(tt.path, tt.dir); result != tt.expected {
+				t.Errorf("IsWithin(%q, %q) = %v, want %v", tt.path, tt.dir, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("home directory not available")
+	}
+
+	tests := []struct {
+		input, want string
+	}{
+		{"~", home},
+		{"~/.config/work", filepath.Join(home, ".config/work")},
+		{"/tmp/x", "/tmp/x"},
+		{"~other/x", "~other/x"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExpandHome(tt.input); got != tt.want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	realDir := filepath.Join(dir, "real")
+	if err := os.Mkdir(realDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(realDir, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	want, err := filepath.EvalSymlinks(realDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ResolveSymlinks(link); got != want {
+		t.Errorf("ResolveSymlinks(%s) = %s, want %s", link, got, want)
+	}
+	missing := filepath.Join(dir, "gone")
+	if got := ResolveSymlinks(missing); got != missing {
+		t.Errorf("ResolveSymlinks(%s) = %s, want the path unchanged", missing, got)
+	}
+}
+
+func TestCanonicalCase(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Code", "Project")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{strings.ToLower(dir), dir},
+		{dir, dir},
+		{strings.ToLower(dir) + "/missing/Sub", dir + "/missing/Sub"},
+		{"relative/Path", "relative/Path"},
+	} {
+		if got := CanonicalCase(tc.path); got != tc.want {
+			t.Errorf("CanonicalCase(%s) = %s, want %s", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	if !Writable(dir) {
+		t.Errorf("Writable(%s) = false, want true", dir)
+	}
+	if missing := filepath.Join(dir, "a", "b"); !Writable(missing) {
+		t.Errorf("Writable(%s) = false for a missing dir under a writable one", missing)
+	}
+}
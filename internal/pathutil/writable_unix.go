@@ -0,0 +1,32 @@
+//go:build unix
+
+package pathutil
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+)
+
+// accessWriteOK is W_OK of access(2).
+const accessWriteOK = 0x2
+
+// Writable reports whether files can be created in dir, without creating any.
+// A missing dir is writable if its nearest existing parent is, since it can
+// be created there.
+func Writable(dir string) bool {
+	for {
+		err := syscall.Access(dir, accessWriteOK)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, syscall.ENOENT) {
+			return false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
@@ -2,9 +2,16 @@
 package port
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/dapi/port-selector/internal/debug"
 )
@@ -12,8 +19,114 @@ import (
 // ErrAllPortsBusy is returned when all ports in the range are busy.
 var ErrAllPortsBusy = errors.New("all ports in range are busy")
 
-// IsPortFree checks if a port is available for binding.
+// ErrSearchTimeout is returned when a port search runs out of time because checks are slow.
+var ErrSearchTimeout = errors.New("port search timed out")
+
+// DefaultCheckTimeout bounds a single port check unless SetCheckTimeout changes it.
+const DefaultCheckTimeout = time.Second
+
+// checkTimeout is the timeout set with SetCheckTimeout.
+var checkTimeout = DefaultCheckTimeout
+
+// searchTimeout bounds a whole port search (variable for tests).
+var searchTimeout = 10 * time.Second
+
+// SetCheckTimeout sets how long a single port check may take before the port is
+// reported busy. d <= 0 restores DefaultCheckTimeout.
+func SetCheckTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultCheckTimeout
+	}
+	checkTimeout = d
+}
+
+// offline is set with SetOffline.
+var offline bool
+
+// SetOffline turns liveness checks off (or back on). Offline, every port is
+// reported free and no process listens on it: no bind, no /proc, no container
+// runtime. Allocation then relies on the store alone, for sandboxes that
+// restrict network syscalls.
+func SetOffline(off bool) {
+	offline = off
+}
+
+// IsOffline reports whether liveness checks are off.
+func IsOffline() bool {
+	return offline
+}
+
+// Check strategies accepted by SetCheckStrategy.
+const (
+	CheckBind   = "bind"   // listen on the wildcard address (with SO_REUSEADDR, Go's default)
+	CheckStrict = "strict" // listen on 127.0.0.1, 0.0.0.0 and :: without SO_REUSEADDR
+)
+
+// checkStrategy is the strategy set with SetCheckStrategy.
+var checkStrategy = CheckBind
+
+// SetCheckStrategy selects how IsPortFree tests a port ("" means bind).
+func SetCheckStrategy(name string) error {
+	switch name {
+	case "", CheckBind:
+		checkStrategy = CheckBind
+	case CheckStrict:
+		checkStrategy = CheckStrict
+	default:
+		return fmt.Errorf("unknown port check %q (use bind or strict)", name)
+	}
+	return nil
+}
+
+// flapWindow is how long a port found busy counts as recently busy.
+const flapWindow = 10 * time.Second
+
+// seenBusy holds when IsPortFree last found each port busy during this run.
+var seenBusy = struct {
+	sync.Mutex
+	ports map[int]time.Time
+}{ports: make(map[int]time.Time)}
+
+// IsPortFree checks if a port is available for binding. Under WSL2, a port that
+// a Windows process listens on is busy too.
 func IsPortFree(port int) bool {
+	if offline {
+		return true
+	}
+	free := checkPort(port)
+	if free && windowsPortBusy(port) {
+		debug.Printf("port", "port %d is free in Linux but a Windows process listens on it", port)
+		free = false
+	}
+	if !free {
+		seenBusy.Lock()
+		seenBusy.ports[port] = time.Now()
+		seenBusy.Unlock()
+	}
+	return free
+}
+
+// checkPort implements IsPortFree. Binding normally takes microseconds, but some
+// firewall setups stall it; a check that outlasts checkTimeout reports the port busy.
+func checkPort(port int) bool {
+	done := make(chan bool, 1)
+	go func() { done <- bindPort(port) }()
+	timer := time.NewTimer(checkTimeout)
+	defer timer.Stop()
+	select {
+	case free := <-done:
+		return free
+	case <-timer.C:
+		debug.Printf("port", "check of port %d timed out after %v, treating it as busy", port, checkTimeout)
+		return false
+	}
+}
+
+// bindPort tests a port with the selected strategy.
+func bindPort(port int) bool {
+	if checkStrategy == CheckStrict {
+		return isPortFreeStrict(port)
+	}
 	addr := fmt.Sprintf(":%d", port)
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -23,6 +136,103 @@ func IsPortFree(port int) bool {
 	return true
 }
 
+// isCandidateFree reports whether a search candidate is free and stable. A port
+// that flaps between busy and free within one run (e.g., test suites churning
+// through connections in TIME_WAIT) would likely be taken again right after it
+// is handed out, wasting a freeze period, so it is skipped: a port found busy
+// within flapWindow is rejected, and a free port is checked a second time.
+func isCandidateFree(port int) bool {
+	seenBusy.Lock()
+	busyAt, wasBusy := seenBusy.ports[port]
+	seenBusy.Unlock()
+	wasBusy = wasBusy && time.Since(busyAt) < flapWindow
+
+	if !IsPortFree(port) {
+		return false
+	}
+	if wasBusy {
+		debug.Printf("port", "port %d was busy %s ago, skipping flapping port", port, time.Since(busyAt).Round(time.Millisecond))
+		return false
+	}
+	if !IsPortFree(port) {
+		debug.Printf("port", "port %d turned busy right after a free check, skipping flapping port", port)
+		return false
+	}
+	return true
+}
+
+// FindFreeBlock finds size consecutive available ports in [start, end], trying block
+// starts from lastUsed+1 and wrapping around. Returns the first port of the block.
+// Ports in excluded are unavailable.
+func FindFreeBlock(start, end, lastUsed, size int, excluded map[int]bool) (int, error) {
+	last := end - size + 1 // last possible block start
+	if size <= 0 || last < start {
+		return 0, ErrAllPortsBusy
+	}
+	first := start
+	if lastUsed >= start && lastUsed < last {
+		first = lastUsed + 1
+	}
+
+	deadline := time.Now().Add(searchTimeout)
+	checked := 0
+	scan := func(from, to int) (int, bool) {
+		for b := from; b <= to && time.Now().Before(deadline); {
+			ok := true
+			for p := b; p < b+size; p++ {
+				checked++
+				if excluded[p] || !isCandidateFree(p) {
+					b = p + 1 // no block can contain p
+					ok = false
+					break
+				}
+			}
+			if ok {
+				return b, true
+			}
+		}
+		return 0, false
+	}
+
+	if b, ok := scan(first, last); ok {
+		debug.Printf("port", "found free block %d-%d", b, b+size-1)
+		return b, nil
+	}
+	if b, ok := scan(start, min(first-1, last)); ok {
+		debug.Printf("port", "found free block %d-%d", b, b+size-1)
+		return b, nil
+	}
+	if !time.Now().Before(deadline) {
+		return 0, searchTimedOut(checked)
+	}
+	debug.Printf("port", "no block of %d free ports in %d-%d", size, start, end)
+	return 0, ErrAllPortsBusy
+}
+
+// isPortFreeStrict listens on the loopback and wildcard addresses one after another
+// without SO_REUSEADDR, so ports in TIME_WAIT and ports bound to a single interface
+// are reported busy. The IPv6 wildcard is skipped when IPv6 is unavailable.
+func isPortFreeStrict(port int) bool {
+	lc := net.ListenConfig{Control: disableReuseAddr}
+	for _, addr := range []struct{ network, host string }{
+		{"tcp4", "127.0.0.1"},
+		{"tcp4", "0.0.0.0"},
+		{"tcp6", "::"},
+	} {
+		ln, err := lc.Listen(context.Background(), addr.network, net.JoinHostPort(addr.host, strconv.Itoa(port)))
+		if err != nil {
+			if addr.network == "tcp6" && !errors.Is(err, syscall.EADDRINUSE) {
+				debug.Printf("port", "skipping IPv6 check of port %d: %v", port, err)
+				continue
+			}
+			debug.Printf("port", "port %d busy on %s: %v", port, addr.host, err)
+			return false
+		}
+		ln.Close()
+	}
+	return true
+}
+
 // FindFreePort finds the first available port in the given range.
 // It starts searching from lastUsed+1 and wraps around to start if needed.
 // Returns ErrAllPortsBusy if no ports are available.
@@ -46,6 +256,7 @@ func FindFreePortWithExclusions(start, end, lastUsed int, frozenPorts map[int]bo
 
 	debug.Printf("port", "searching from %d to %d (wrap at %d)", startFrom, end, start)
 
+	deadline := time.Now().Add(searchTimeout)
 	checked := 0
 
 	// First pass: from startFrom to end
@@ -54,8 +265,11 @@ func FindFreePortWithExclusions(start, end, lastUsed int, frozenPorts map[int]bo
 			debug.Printf("port", "port %d is frozen, skipping", port)
 			continue // Skip frozen port
 		}
+		if !time.Now().Before(deadline) {
+			return 0, searchTimedOut(checked)
+		}
 		checked++
-		if IsPortFree(port) {
+		if isCandidateFree(port) {
 			debug.Printf("port", "port %d is free (checked %d ports)", port, checked)
 			return port, nil
 		}
@@ -70,8 +284,11 @@ func FindFreePortWithExclusions(start, end, lastUsed int, frozenPorts map[int]bo
 				debug.Printf("port", "port %d is frozen, skipping", port)
 				continue // Skip frozen port
 			}
+			if !time.Now().Before(deadline) {
+				return 0, searchTimedOut(checked)
+			}
 			checked++
-			if IsPortFree(port) {
+			if isCandidateFree(port) {
 				debug.Printf("port", "port %d is free (checked %d ports)", port, checked)
 				return port, nil
 			}
@@ -82,3 +299,48 @@ func FindFreePortWithExclusions(start, end, lastUsed int, frozenPorts map[int]bo
 	debug.Printf("port", "no free ports found after checking %d ports", checked)
 	return 0, ErrAllPortsBusy
 }
+
+// searchTimedOut returns the error of a search that ran out of time after checked ports.
+func searchTimedOut(checked int) error {
+	debug.Printf("port", "search timed out after %v (%d ports checked)", searchTimeout, checked)
+	return fmt.Errorf("%w after %v with %d ports checked; port checks are slow (see checkTimeoutMs)", ErrSearchTimeout, searchTimeout, checked)
+}
+
+// localPortRangePath holds the kernel's ephemeral port range on Linux (variable for tests).
+var localPortRangePath = "/proc/sys/net/ipv4/ip_local_port_range"
+
+// KernelEphemeralRange returns the range the kernel picks source ports of outbound
+// connections from. Returns an error where it can't be read (non-Linux systems).
+func KernelEphemeralRange() (start, end int, err error) {
+	data, err := os.ReadFile(localPortRangePath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read ephemeral port range: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 2 {
+		start, err = strconv.Atoi(fields[0])
+		if err == nil {
+			end, err = strconv.Atoi(fields[1])
+		}
+		if err == nil && start <= end {
+			return start, end, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unexpected content of %s: %q", localPortRangePath, strings.TrimSpace(string(data)))
+}
+
+// EphemeralPort asks the kernel for a free port by listening on port 0 and
+// returns the port it assigned (from the system ephemeral range).
+func EphemeralPort() (int, error) {
+	if offline {
+		return 0, fmt.Errorf("cannot get an ephemeral port: liveness checks are off")
+	}
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("cannot get an ephemeral port: %w", err)
+	}
+	defer ln.Close()
+	p := ln.Addr().(*net.TCPAddr).Port
+	debug.Printf("port", "kernel assigned ephemeral port %d", p)
+	return p, nil
+}
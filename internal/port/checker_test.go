@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestIsPortFree_FreePort(t *testing.T) {
@@ -287,3 +291,187 @@ func TestFindFreePortWithExclusions_BusyAndFrozen(t *testing.T) {
 		t.Errorf("port %d not in expected range 51302-51310", port)
 	}
 }
+
+func TestIsPortFree_StrictTimeWait(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("TIME_WAIT bind semantics are Linux specific")
+	}
+
+	// The side that closes first keeps the port in TIME_WAIT
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	server.Close()
+	buf := make([]byte, 1)
+	client.Read(buf) // wait for FIN
+	client.Close()
+
+	if !IsPortFree(port) {
+		t.Skipf("port %d not reusable with SO_REUSEADDR on this system", port)
+	}
+	if err := SetCheckStrategy(CheckStrict); err != nil {
+		t.Fatal(err)
+	}
+	defer SetCheckStrategy(CheckBind)
+	if IsPortFree(port) {
+		t.Errorf("strict IsPortFree(%d) = true for a port in TIME_WAIT", port)
+	}
+}
+
+func TestSetCheckStrategy(t *testing.T) {
+	defer SetCheckStrategy(CheckBind)
+	for name, wantErr := range map[string]bool{"": false, "bind": false, "strict": false, "dial": true} {
+		if err := SetCheckStrategy(name); (err != nil) != wantErr {
+			t.Errorf("SetCheckStrategy(%q) error = %v, wantErr %v", name, err, wantErr)
+		}
+	}
+}
+
+func TestFindFreeBlock(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+
+	// The busy port splits the range; the block must start after it
+	start, err := FindFreeBlock(busy-2, busy+4, 0, 3, map[int]bool{busy + 4: true})
+	if err != nil {
+		t.Fatalf("FindFreeBlock() error = %v", err)
+	}
+	if start != busy+1 {
+		t.Errorf("FindFreeBlock() = %d, want %d", start, busy+1)
+	}
+
+	if _, err := FindFreeBlock(busy-1, busy+1, 0, 2, nil); !errors.Is(err, ErrAllPortsBusy) {
+		t.Errorf("expected ErrAllPortsBusy, got %v", err)
+	}
+}
+
+func TestEphemeralPort(t *testing.T) {
+	p, err := EphemeralPort()
+	if err != nil {
+		t.Fatalf("EphemeralPort() error = %v", err)
+	}
+	if p <= 0 || p > 65535 {
+		t.Errorf("EphemeralPort() = %d, want a valid port", p)
+	}
+	if !IsPortFree(p) {
+		t.Errorf("port %d should be free after EphemeralPort returns", p)
+	}
+}
+
+func TestKernelEphemeralRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip_local_port_range")
+	old := localPortRangePath
+	localPortRangePath = path
+	t.Cleanup(func() { localPortRangePath = old })
+
+	if err := os.WriteFile(path, []byte("32768\t60999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start, end, err := KernelEphemeralRange()
+	if err != nil || start != 32768 || end != 60999 {
+		t.Errorf("KernelEphemeralRange() = %d, %d, %v; want 32768, 60999", start, end, err)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := KernelEphemeralRange(); err == nil {
+		t.Error("expected error for malformed content")
+	}
+
+	localPortRangePath = filepath.Join(t.TempDir(), "missing")
+	if _, _, err := KernelEphemeralRange(); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestFindFreePort_SkipsFlappingPort(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	flapping := ln.Addr().(*net.TCPAddr).Port
+
+	if IsPortFree(flapping) {
+		t.Fatalf("expected port %d to be busy", flapping)
+	}
+	ln.Close()
+
+	if isCandidateFree(flapping) {
+		t.Errorf("port %d was busy a moment ago and must be skipped", flapping)
+	}
+	if !IsPortFree(flapping) {
+		t.Errorf("IsPortFree(%d) must still report the closed port as free", flapping)
+	}
+
+	seenBusy.Lock()
+	seenBusy.ports[flapping] = seenBusy.ports[flapping].Add(-flapWindow)
+	seenBusy.Unlock()
+	if !isCandidateFree(flapping) {
+		t.Errorf("port %d was busy longer than flapWindow ago and must be accepted", flapping)
+	}
+}
+
+func TestFindFreePort_SearchTimeout(t *testing.T) {
+	old := searchTimeout
+	searchTimeout = 0
+	defer func() { searchTimeout = old }()
+
+	if _, err := FindFreePortWithExclusions(40000, 40100, 0, nil); !errors.Is(err, ErrSearchTimeout) {
+		t.Errorf("FindFreePortWithExclusions() error = %v, want ErrSearchTimeout", err)
+	}
+	if _, err := FindFreeBlock(40000, 40100, 0, 5, nil); !errors.Is(err, ErrSearchTimeout) {
+		t.Errorf("FindFreeBlock() error = %v, want ErrSearchTimeout", err)
+	}
+}
+
+func TestSetCheckTimeout(t *testing.T) {
+	defer SetCheckTimeout(0)
+	SetCheckTimeout(250 * time.Millisecond)
+	if checkTimeout != 250*time.Millisecond {
+		t.Errorf("checkTimeout = %v, want 250ms", checkTimeout)
+	}
+	SetCheckTimeout(0)
+	if checkTimeout != DefaultCheckTimeout {
+		t.Errorf("checkTimeout = %v, want default %v", checkTimeout, DefaultCheckTimeout)
+	}
+}
+
+func TestSetOffline(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+
+	SetOffline(true)
+	defer SetOffline(false)
+	if !IsPortFree(busy) {
+		t.Errorf("offline IsPortFree(%d) = false, want true without a bind", busy)
+	}
+	if info := GetPortProcess(busy); info != nil {
+		t.Errorf("offline GetPortProcess(%d) = %+v, want nil", busy, info)
+	}
+	if !ProcessAlive(999999, 0) {
+		t.Error("offline ProcessAlive() = false, want true")
+	}
+	if _, err := EphemeralPort(); err == nil {
+		t.Error("offline EphemeralPort() expected error")
+	}
+}
@@ -0,0 +1,160 @@
+package port
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// KubeForward describes a `kubectl port-forward` process.
+type KubeForward struct {
+	Context   string // kubeconfig context (may be empty if unknown)
+	Namespace string
+	Target    string // e.g., svc/web, pod/api-0
+}
+
+// String returns "context/namespace target".
+func (k *KubeForward) String() string {
+	return fmt.Sprintf("%s/%s %s", k.Context, k.Namespace, k.Target)
+}
+
+// kubectlValueFlags are the kubectl flags that take a separate value argument.
+var kubectlValueFlags = map[string]bool{
+	"--context": true, "--namespace": true, "-n": true, "--kubeconfig": true,
+	"--address": true, "--pod-running-timeout": true, "--cluster": true,
+	"--user": true, "-s": true, "--server": true, "--as": true, "--token": true,
+	"--request-timeout": true, "-v": true,
+}
+
+// parseKubectlPortForward parses the argv of a kubectl process.
+// Returns nil if it is not a port-forward. Context and namespace are left empty
+// when not given on the command line; flags holds their values by name.
+func parseKubectlPortForward(argv []string) (kf *KubeForward, flags map[string]string) {
+	if len(argv) == 0 || !strings.HasPrefix(filepath.Base(argv[0]), "kubectl") {
+		return nil, nil
+	}
+
+	flags = make(map[string]string)
+	var positional []string
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && kubectlValueFlags[name] && i+1 < len(argv) {
+			i++
+			value = argv[i]
+		}
+		flags[name] = value
+	}
+
+	if len(positional) < 2 || positional[0] != "port-forward" {
+		return nil, nil
+	}
+	target := positional[1]
+	if !strings.Contains(target, "/") {
+		target = "pod/" + target
+	}
+
+	namespace := flags["--namespace"]
+	if namespace == "" {
+		namespace = flags["-n"]
+	}
+	return &KubeForward{Context: flags["--context"], Namespace: namespace, Target: target}, flags
+}
+
+// kubeconfig is the part of a kubeconfig file used to resolve the current context.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// resolveKubeDefaults fills a missing context and namespace from the kubeconfig at path.
+// The namespace falls back to "default".
+func resolveKubeDefaults(kf *KubeForward, path string) {
+	if path != "" && (kf.Context == "" || kf.Namespace == "") {
+		if data, err := os.ReadFile(path); err == nil {
+			var cfg kubeconfig
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				debug.Printf("port", "cannot parse kubeconfig %s: %v", path, err)
+			} else {
+				if kf.Context == "" {
+					kf.Context = cfg.CurrentContext
+				}
+				for _, c := range cfg.Contexts {
+					if c.Name == kf.Context && kf.Namespace == "" {
+						kf.Namespace = c.Context.Namespace
+					}
+				}
+			}
+		}
+	}
+	if kf.Namespace == "" {
+		kf.Namespace = "default"
+	}
+}
+
+// kubeconfigPath returns the kubeconfig used by a kubectl process: --kubeconfig,
+// then the first entry of its $KUBECONFIG, then ~/.kube/config.
+func kubeconfigPath(flags map[string]string, environ map[string]string) string {
+	if path := flags["--kubeconfig"]; path != "" {
+		return path
+	}
+	if list := environ["KUBECONFIG"]; list != "" {
+		return filepath.SplitList(list)[0]
+	}
+	if home := environ["HOME"]; home != "" {
+		return filepath.Join(home, ".kube", "config")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".kube", "config")
+	}
+	return ""
+}
+
+// getKubeForward returns port-forward details of the kubectl process pid, or nil.
+func getKubeForward(pid int) *KubeForward {
+	procDir := fmt.Sprintf("/proc/%d", pid)
+	data, err := os.ReadFile(filepath.Join(procDir, "cmdline"))
+	if err != nil {
+		return nil
+	}
+	kf, flags := parseKubectlPortForward(splitNul(data))
+	if kf == nil {
+		return nil
+	}
+
+	environ := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(procDir, "environ")); err == nil {
+		for _, kv := range splitNul(data) {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				environ[k] = v
+			}
+		}
+	}
+	resolveKubeDefaults(kf, kubeconfigPath(flags, environ))
+	debug.Printf("port", "kubectl port-forward: %s", kf)
+	return kf
+}
+
+// splitNul splits a NUL-separated /proc file (cmdline, environ).
+func splitNul(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\x00")
+}
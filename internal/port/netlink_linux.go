@@ -0,0 +1,88 @@
+//go:build linux
+
+package port
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+)
+
+// sock_diag constants from linux/sock_diag.h and linux/inet_diag.h.
+const (
+	netlinkSockDiag   = 4  // NETLINK_SOCK_DIAG
+	sockDiagByFamily  = 20 // SOCK_DIAG_BY_FAMILY
+	tcpListen         = 10 // TCP_LISTEN state
+	inetDiagReqLen    = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgMinLen = 72 // sizeof(struct inet_diag_msg)
+)
+
+// netlinkListeningSockets dumps the listening TCP (or TCP6) sockets through
+// NETLINK_SOCK_DIAG, keyed by local port.
+func netlinkListeningSockets(ipv6 bool) (map[int]socketInfo, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, netlinkSockDiag)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	tv := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		return nil, fmt.Errorf("netlink timeout: %w", err)
+	}
+
+	// nlmsghdr followed by inet_diag_req_v2 (zero socket id matches all sockets)
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqLen)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], 1) // sequence number
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = syscall.AF_INET
+	if ipv6 {
+		body[0] = syscall.AF_INET6
+	}
+	body[1] = syscall.IPPROTO_TCP
+	binary.NativeEndian.PutUint32(body[4:8], 1<<tcpListen)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("netlink send: %w", err)
+	}
+
+	sockets := make(map[int]socketInfo)
+	buf := make([]byte, 32*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("netlink receive: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("netlink parse: %w", err)
+		}
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return sockets, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+						return nil, fmt.Errorf("sock_diag: %w", syscall.Errno(-errno))
+					}
+				}
+				return sockets, nil
+			}
+			if len(m.Data) < inetDiagMsgMinLen {
+				continue
+			}
+			// inet_diag_msg: family, state, timer, retrans, id{sport(be16), dport, src, dst, if, cookie},
+			// expires, rqueue, wqueue, uid, inode
+			port := int(binary.BigEndian.Uint16(m.Data[4:6]))
+			uid := int(binary.NativeEndian.Uint32(m.Data[64:68]))
+			inode := uint64(binary.NativeEndian.Uint32(m.Data[68:72]))
+			if _, seen := sockets[port]; !seen {
+				sockets[port] = socketInfo{Inode: inode, UID: uid}
+			}
+		}
+	}
+}
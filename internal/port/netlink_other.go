@@ -0,0 +1,10 @@
+//go:build !linux
+
+package port
+
+import "errors"
+
+// netlinkListeningSockets is only available on Linux.
+func netlinkListeningSockets(ipv6 bool) (map[int]socketInfo, error) {
+	return nil, errors.New("sock_diag netlink is only available on Linux")
+}
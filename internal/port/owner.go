@@ -0,0 +1,49 @@
+package port
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProcessStartTime returns the start time of pid in clock ticks after boot
+// (field 22 of /proc/PID/stat). Together with the PID it identifies a process
+// even after the PID is reused.
+func ProcessStartTime(pid int) (uint64, error) {
+	if offline {
+		return 0, fmt.Errorf("liveness checks are off")
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, fmt.Errorf("process %d not found", pid)
+	}
+	// The command name in parentheses may contain spaces; fields start after it
+	s := string(data)
+	end := strings.LastIndexByte(s, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("cannot parse /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("cannot parse /proc/%d/stat", pid)
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// ProcessAlive reports whether pid is running and, if startTime is non-zero,
+// is still the process that had that start time. Without /proc (non-Linux)
+// liveness is unknown and the process is assumed to be alive, as it is offline.
+func ProcessAlive(pid int, startTime uint64) bool {
+	if offline {
+		return true
+	}
+	if _, err := os.Stat("/proc/self"); err != nil {
+		return true
+	}
+	current, err := ProcessStartTime(pid)
+	if err != nil {
+		return false
+	}
+	return startTime == 0 || current == startTime
+}
@@ -0,0 +1,31 @@
+package port
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProcessAlive(t *testing.T) {
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("/proc not available")
+	}
+
+	pid := os.Getpid()
+	start, err := ProcessStartTime(pid)
+	if err != nil || start == 0 {
+		t.Fatalf("ProcessStartTime(self) = %d, %v", start, err)
+	}
+	if !ProcessAlive(pid, start) || !ProcessAlive(pid, 0) {
+		t.Error("expected the test process to be alive")
+	}
+	// Same PID with another start time is a reused PID
+	if ProcessAlive(pid, start+1) {
+		t.Error("expected a start time mismatch to count as exited")
+	}
+	if _, err := ProcessStartTime(1 << 30); err == nil {
+		t.Error("expected error for a nonexistent PID")
+	}
+	if ProcessAlive(1<<30, 0) {
+		t.Error("expected a nonexistent PID to be reported as exited")
+	}
+}
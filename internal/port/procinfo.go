@@ -11,16 +11,19 @@ import (
 
 	"github.com/dapi/port-selector/internal/debug"
 	"github.com/dapi/port-selector/internal/docker"
+	"github.com/dapi/port-selector/internal/i18n"
 )
 
 // ProcessInfo contains information about a process using a port.
 type ProcessInfo struct {
 	PID         int
 	Name        string
-	Cwd         string // working directory
-	Cmdline     string // command line (truncated)
-	ContainerID string // Docker container ID (if applicable)
-	User        string // socket owner username (from /proc/net/tcp UID)
+	Cwd         string       // working directory
+	Cmdline     string       // command line (truncated)
+	ContainerID string       // Container ID (if applicable)
+	Service     string       // compose service of the container (if applicable)
+	User        string       // socket owner username (from /proc/net/tcp UID)
+	Kube        *KubeForward // kubectl port-forward details (if applicable)
 }
 
 // socketInfo contains socket inode and owner UID from /proc/net/tcp.
@@ -45,6 +48,10 @@ func (p *ProcessInfo) String() string {
 		parts = append(parts, fmt.Sprintf("container=%s", p.ContainerID))
 	}
 
+	if p.Kube != nil {
+		parts = append(parts, fmt.Sprintf("k8s=%s", p.Kube))
+	}
+
 	if p.Cwd != "" {
 		parts = append(parts, fmt.Sprintf("cwd=%s", p.Cwd))
 	}
@@ -52,20 +59,76 @@ func (p *ProcessInfo) String() string {
 	return strings.Join(parts, ", ")
 }
 
+// Socket table sources accepted by SetSocketSource.
+const (
+	SocketSourceAuto    = "auto"    // sock_diag netlink, falling back to /proc/net text
+	SocketSourceNetlink = "netlink" // like auto, but warns when netlink is unavailable
+	SocketSourceProc    = "proc"    // always parse /proc/net/tcp(6)
+)
+
+// socketSource is the source set with SetSocketSource.
+var socketSource = SocketSourceAuto
+
+// netlinkWarned is set once the netlink fallback warning was printed.
+var netlinkWarned bool
+
+// SetSocketSource selects how listening sockets are read ("" means auto).
+func SetSocketSource(name string) error {
+	switch name {
+	case "", SocketSourceAuto:
+		socketSource = SocketSourceAuto
+	case SocketSourceNetlink, SocketSourceProc:
+		socketSource = name
+	default:
+		return fmt.Errorf("unknown socket source %q (use auto, netlink or proc)", name)
+	}
+	return nil
+}
+
+// socketTable is a cached socket table read (or the error reading it).
+type socketTable struct {
+	sockets map[int]socketInfo
+	err     error
+}
+
+// Snapshot caches the parsed socket tables and the socket inode → PID map of /proc,
+// so that commands looking up many ports (--list, --scan) read them once.
+// It reflects the state at first use; create one per command run.
+type Snapshot struct {
+	sockets map[string]socketTable // listening sockets by port, per /proc/net file
+	owners  map[uint64]int         // socket inode → PID
+}
+
+// NewSnapshot returns an empty snapshot; tables are read on first lookup.
+func NewSnapshot() *Snapshot {
+	return &Snapshot{sockets: make(map[string]socketTable)}
+}
+
 // GetPortProcess returns information about the process using the given port.
-// If the process is docker-proxy, it attempts to resolve the actual project
-// directory from the Docker container.
+// It reads /proc afresh; use Snapshot.GetPortProcess for many ports.
+func GetPortProcess(port int) *ProcessInfo {
+	var uncached *Snapshot
+	return uncached.GetPortProcess(port)
+}
+
+// GetPortProcess returns information about the process using the given port.
+// If the process is a container port proxy (docker-proxy, rootlessport, ...), it attempts
+// to resolve the actual project directory from the container.
 // Returns nil if the port is not in use.
 // If the process cannot be fully determined (e.g., permission denied),
 // returns partial info with at least the User field populated.
-func GetPortProcess(port int) *ProcessInfo {
+// A nil snapshot reads /proc without caching. Offline, it always returns nil.
+func (s *Snapshot) GetPortProcess(port int) *ProcessInfo {
+	if offline {
+		return nil
+	}
 	debug.Printf("port", "getting process info for port %d", port)
 
 	// Try both IPv4 and IPv6
 	var info *ProcessInfo
-	if info = getPortProcessFromProc(port, "/proc/net/tcp"); info == nil {
+	if info = s.getPortProcessFromProc(port, "/proc/net/tcp"); info == nil {
 		debug.Printf("port", "not found in /proc/net/tcp, trying tcp6")
-		info = getPortProcessFromProc(port, "/proc/net/tcp6")
+		info = s.getPortProcessFromProc(port, "/proc/net/tcp6")
 	}
 
 	if info == nil {
@@ -75,29 +138,32 @@ func GetPortProcess(port int) *ProcessInfo {
 
 	debug.Printf("port", "found process: pid=%d, name=%s, user=%s", info.PID, info.Name, info.User)
 
-	// Check if this is a docker-proxy process
-	if docker.IsDockerProxy(info.Name) {
-		debug.Printf("port", "detected docker-proxy, enriching with container info")
+	// Check if this is a container port proxy process
+	if docker.IsContainerProxy(info.Name) {
+		debug.Printf("port", "detected %s, enriching with container info", info.Name)
 		enrichWithDocker(info, port)
 	} else if info.PID == 0 && info.User == "root" {
 		// Without sudo we can't get process name, but if it's root-owned,
 		// try Docker detection as a fallback (docker-proxy runs as root)
 		debug.Printf("port", "root-owned process without PID, trying Docker fallback")
 		enrichWithDocker(info, port)
+	} else if strings.HasPrefix(info.Name, "kubectl") {
+		info.Kube = getKubeForward(info.PID)
 	}
 
 	return info
 }
 
-// enrichWithDocker enhances ProcessInfo with Docker container information.
+// enrichWithDocker enhances ProcessInfo with container information.
 // It replaces the useless "/" cwd with the actual project directory.
 func enrichWithDocker(info *ProcessInfo, port int) {
-	containerInfo := docker.GetContainerInfo(port)
+	containerInfo := docker.GetContainerInfo(port, info.Name)
 	if containerInfo == nil {
 		return
 	}
 
 	info.ContainerID = containerInfo.ContainerID
+	info.Service = containerInfo.Service
 
 	// Replace useless "/" with actual project directory
 	if containerInfo.ProjectDir != "" {
@@ -108,8 +174,8 @@ func enrichWithDocker(info *ProcessInfo, port int) {
 // getPortProcessFromProc parses /proc/net/tcp or /proc/net/tcp6 to find the inode and UID,
 // then searches /proc/*/fd/ to find which process owns that socket.
 // If the process cannot be determined but the socket exists, returns partial info with User.
-func getPortProcessFromProc(port int, procNetFile string) *ProcessInfo {
-	sockInfo := findSocketInfo(port, procNetFile)
+func (s *Snapshot) getPortProcessFromProc(port int, procNetFile string) *ProcessInfo {
+	sockInfo := s.socketInfo(port, procNetFile)
 	if sockInfo == nil {
 		return nil
 	}
@@ -117,7 +183,7 @@ func getPortProcessFromProc(port int, procNetFile string) *ProcessInfo {
 	// Resolve UID to username
 	username := resolveUID(sockInfo.UID)
 
-	pid := findProcessByInode(sockInfo.Inode)
+	pid := s.processByInode(sockInfo.Inode)
 	if pid == 0 {
 		// Could not find process (permission denied or race condition),
 		// but we know the socket exists - return partial info with username
@@ -131,22 +197,83 @@ func getPortProcessFromProc(port int, procNetFile string) *ProcessInfo {
 	return info
 }
 
-// findSocketInfo searches /proc/net/tcp(6) for a listening socket on the given port.
-// Returns socket info (inode and UID) or nil if not found.
-func findSocketInfo(port int, procNetFile string) *socketInfo {
+// table returns the listening sockets of procNetFile, read once per snapshot.
+func (s *Snapshot) table(procNetFile string) (map[int]socketInfo, error) {
+	if t, ok := s.sockets[procNetFile]; ok {
+		return t.sockets, t.err
+	}
+	sockets, err := listeningSockets(procNetFile)
+	s.sockets[procNetFile] = socketTable{sockets, err}
+	debug.Printf("port", "cached %d listening sockets for %s", len(sockets), procNetFile)
+	return sockets, err
+}
+
+// socketInfo returns the listening socket on port from procNetFile, or nil.
+// With a snapshot the whole table is read once and reused.
+func (s *Snapshot) socketInfo(port int, procNetFile string) *socketInfo {
+	var sockets map[int]socketInfo
+	if s == nil {
+		sockets, _ = listeningSockets(procNetFile)
+	} else {
+		sockets, _ = s.table(procNetFile)
+	}
+	if info, ok := sockets[port]; ok {
+		return &info
+	}
+	return nil
+}
+
+// IsListening reports whether a TCP socket listens on port. When no socket table
+// can be read (e.g., not on Linux), it falls back to a bind check.
+func (s *Snapshot) IsListening(port int) bool {
+	readable := false
+	for _, procNetFile := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		sockets, err := s.table(procNetFile)
+		if err != nil {
+			continue
+		}
+		readable = true
+		if _, ok := sockets[port]; ok {
+			return true
+		}
+	}
+	if !readable {
+		return !IsPortFree(port)
+	}
+	return false
+}
+
+// listeningSockets returns the listening sockets of procNetFile (/proc/net/tcp or tcp6)
+// by port, dumped through sock_diag netlink unless the source is "proc".
+// If netlink fails, the text file is parsed instead.
+func listeningSockets(procNetFile string) (map[int]socketInfo, error) {
+	if socketSource != SocketSourceProc {
+		sockets, err := netlinkListeningSockets(procNetFile == "/proc/net/tcp6")
+		if err == nil {
+			return sockets, nil
+		}
+		debug.Printf("port", "sock_diag failed, reading %s: %v", procNetFile, err)
+		if socketSource == SocketSourceNetlink && !netlinkWarned {
+			netlinkWarned = true
+			i18n.Fprintf(os.Stderr, "warning: socketSource netlink is unavailable (%v), reading /proc instead\n", err)
+		}
+	}
+	return procListeningSockets(procNetFile)
+}
+
+// procListeningSockets parses the listening sockets from /proc/net/tcp(6).
+func procListeningSockets(procNetFile string) (map[int]socketInfo, error) {
 	file, err := os.Open(procNetFile)
 	if err != nil {
 		// Permission denied and file not exist are expected in some cases
 		if !os.IsNotExist(err) && !os.IsPermission(err) {
-			fmt.Fprintf(os.Stderr, "warning: cannot read %s: %v\n", procNetFile, err)
+			i18n.Fprintf(os.Stderr, "warning: cannot read %s: %v\n", procNetFile, err)
 		}
-		return nil
+		return nil, err
 	}
 	defer file.Close()
 
-	// Port in hex (network byte order for local port)
-	portHex := fmt.Sprintf("%04X", port)
-
+	sockets := make(map[int]socketInfo)
 	scanner := bufio.NewScanner(file)
 	scanner.Scan() // skip header line
 
@@ -157,6 +284,11 @@ func findSocketInfo(port int, procNetFile string) *socketInfo {
 			continue
 		}
 
+		// Field 3 is state: 0A = LISTEN
+		if fields[3] != "0A" {
+			continue
+		}
+
 		// Field 1 is local_address (hex_ip:hex_port)
 		localAddr := fields[1]
 		parts := strings.Split(localAddr, ":")
@@ -164,13 +296,8 @@ func findSocketInfo(port int, procNetFile string) *socketInfo {
 			continue
 		}
 
-		localPort := parts[1]
-		if localPort != portHex {
-			continue
-		}
-
-		// Field 3 is state: 0A = LISTEN
-		if fields[3] != "0A" {
+		localPort, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
 			continue
 		}
 
@@ -186,17 +313,16 @@ func findSocketInfo(port int, procNetFile string) *socketInfo {
 			continue
 		}
 
-		return &socketInfo{
-			Inode: inode,
-			UID:   uid,
+		if _, seen := sockets[int(localPort)]; !seen {
+			sockets[int(localPort)] = socketInfo{Inode: inode, UID: uid}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: error reading %s: %v\n", procNetFile, err)
+		i18n.Fprintf(os.Stderr, "warning: error reading %s: %v\n", procNetFile, err)
 	}
 
-	return nil
+	return sockets, nil
 }
 
 // resolveUID converts a numeric UID to a username.
@@ -212,14 +338,50 @@ func resolveUID(uid int) string {
 	return u.Username
 }
 
+// processByInode returns the PID owning the socket inode, or 0.
+// With a snapshot /proc/*/fd is walked once for all sockets.
+func (s *Snapshot) processByInode(inode uint64) int {
+	if s == nil {
+		return findProcessByInode(inode)
+	}
+	if s.owners == nil {
+		s.owners = make(map[uint64]int)
+		walkSocketFDs(func(pid int, link string) bool {
+			var ino uint64
+			if _, err := fmt.Sscanf(link, "socket:[%d]", &ino); err == nil {
+				if _, seen := s.owners[ino]; !seen {
+					s.owners[ino] = pid
+				}
+			}
+			return true
+		})
+		debug.Printf("port", "cached owners of %d sockets", len(s.owners))
+	}
+	return s.owners[inode]
+}
+
 // findProcessByInode searches /proc/*/fd/ for a socket with the given inode.
 // Returns the PID or 0 if not found.
 func findProcessByInode(inode uint64) int {
 	socketLink := fmt.Sprintf("socket:[%d]", inode)
 
+	found := 0
+	walkSocketFDs(func(pid int, link string) bool {
+		if link == socketLink {
+			found = pid
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// walkSocketFDs calls fn with the PID and link target of every socket fd in /proc/*/fd
+// until fn returns false. Processes that cannot be read are skipped.
+func walkSocketFDs(fn func(pid int, link string) bool) {
 	procDirs, err := os.ReadDir("/proc")
 	if err != nil {
-		return 0
+		return
 	}
 
 	for _, entry := range procDirs {
@@ -241,17 +403,15 @@ func findProcessByInode(inode uint64) int {
 
 		for _, fd := range fds {
 			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
-			if err != nil {
+			if err != nil || !strings.HasPrefix(link, "socket:") {
 				continue
 			}
 
-			if link == socketLink {
-				return pid
+			if !fn(pid, link) {
+				return
 			}
 		}
 	}
-
-	return 0
 }
 
 // getProcessInfo reads process information from /proc/[pid]/.
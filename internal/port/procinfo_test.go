@@ -161,3 +161,117 @@ func TestResolveUID_RootUser(t *testing.T) {
 		t.Errorf("resolveUID(0) = %q, want \"root\"", result)
 	}
 }
+
+func TestParseKubectlPortForward(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want *KubeForward
+	}{
+		{[]string{"kubectl", "port-forward", "svc/web", "8080:80"}, &KubeForward{Target: "svc/web"}},
+		{[]string{"/usr/local/bin/kubectl", "--context", "kind-dev", "-n", "shop", "port-forward", "api-0", "9000"},
+			&KubeForward{Context: "kind-dev", Namespace: "shop", Target: "pod/api-0"}},
+		{[]string{"kubectl", "port-forward", "--namespace=db", "--address", "0.0.0.0", "deploy/pg", "5432"},
+			&KubeForward{Namespace: "db", Target: "deploy/pg"}},
+		{[]string{"kubectl", "get", "pods"}, nil},
+		{[]string{"node", "server.js"}, nil},
+	}
+	for _, tt := range tests {
+		got, _ := parseKubectlPortForward(tt.argv)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("parseKubectlPortForward(%q) = %+v, want %+v", tt.argv, got, tt.want)
+		}
+	}
+}
+
+func TestResolveKubeDefaults(t *testing.T) {
+	path := t.TempDir() + "/config"
+	kubeconfig := `current-context: kind-dev
+contexts:
+- name: kind-dev
+  context:
+    cluster: kind-dev
+    namespace: shop
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kf := &KubeForward{Target: "svc/web"}
+	resolveKubeDefaults(kf, path)
+	if kf.Context != "kind-dev" || kf.Namespace != "shop" {
+		t.Errorf("resolveKubeDefaults() = %+v, want kind-dev/shop", kf)
+	}
+
+	kf = &KubeForward{Context: "prod", Target: "svc/web"}
+	resolveKubeDefaults(kf, path)
+	if kf.Context != "prod" || kf.Namespace != "default" {
+		t.Errorf("resolveKubeDefaults() = %+v, want prod/default", kf)
+	}
+
+	if got := kubeconfigPath(map[string]string{}, map[string]string{"KUBECONFIG": "/a:/b"}); got != "/a" {
+		t.Errorf("kubeconfigPath() = %q, want /a", got)
+	}
+}
+
+func TestSnapshot_GetPortProcess(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("GetPortProcess only works on Linux")
+	}
+
+	var ports []int
+	for i := 0; i < 2; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to start listener: %v", err)
+		}
+		defer ln.Close()
+		ports = append(ports, ln.Addr().(*net.TCPAddr).Port)
+	}
+
+	procs := NewSnapshot()
+	for _, p := range ports {
+		info := procs.GetPortProcess(p)
+		if info == nil || info.PID != os.Getpid() {
+			t.Errorf("GetPortProcess(%d) = %+v, want own PID %d", p, info, os.Getpid())
+		}
+	}
+	if procs.GetPortProcess(59999) != nil {
+		t.Error("GetPortProcess returned non-nil for unused port")
+	}
+	if len(procs.owners) == 0 {
+		t.Error("expected socket owners to be cached")
+	}
+}
+
+func TestNetlinkListeningSockets(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sock_diag is Linux only")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	sockets, err := netlinkListeningSockets(false)
+	if err != nil {
+		t.Skipf("sock_diag not available: %v", err)
+	}
+	fromProc, err := procListeningSockets("/proc/net/tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sockets[port] != fromProc[port] || sockets[port].Inode == 0 {
+		t.Errorf("netlink socket %+v, /proc socket %+v", sockets[port], fromProc[port])
+	}
+
+	procs := NewSnapshot()
+	if !procs.IsListening(port) {
+		t.Errorf("IsListening(%d) = false for a listening port", port)
+	}
+	if procs.IsListening(59999) {
+		t.Error("IsListening(59999) = true for an unused port")
+	}
+}
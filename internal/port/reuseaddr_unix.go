@@ -0,0 +1,17 @@
+//go:build unix
+
+package port
+
+import "syscall"
+
+// disableReuseAddr clears SO_REUSEADDR, which Go sets on listeners by default.
+func disableReuseAddr(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 0)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package port
+
+import "syscall"
+
+// disableReuseAddr is a no-op: Go does not set SO_REUSEADDR on Windows listeners.
+func disableReuseAddr(network, address string, c syscall.RawConn) error {
+	return nil
+}
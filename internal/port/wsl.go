@@ -0,0 +1,115 @@
+package port
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+)
+
+// osReleasePath holds the kernel release, which names the WSL2 kernel (variable for tests).
+var osReleasePath = "/proc/sys/kernel/osrelease"
+
+// windowsCommands list the ways to read the listening ports of Windows from WSL,
+// fastest first. netstat.exe is found through the interop PATH or under /mnt/c
+// (variable for tests).
+var windowsCommands = [][]string{
+	{"netstat.exe", "-ano"},
+	{"/mnt/c/Windows/System32/netstat.exe", "-ano"},
+	{"powershell.exe", "-NoProfile", "-NonInteractive", "-Command",
+		"Get-NetTCPConnection -State Listen | Select-Object -ExpandProperty LocalPort"},
+}
+
+// windowsQueryTimeout bounds one Windows command; powershell.exe takes a second to start.
+const windowsQueryTimeout = 5 * time.Second
+
+// windowsCacheTTL is how long a read of the Windows listening ports is reused,
+// so that a port search runs the Windows command once.
+const windowsCacheTTL = 2 * time.Second
+
+// wsl caches WSL detection and the last read of the Windows listening ports.
+var wsl struct {
+	sync.Mutex
+	detected bool
+	isWSL2   bool
+	readAt   time.Time
+	ports    map[int]bool
+}
+
+// IsWSL2 reports whether port-selector runs under WSL2. WSL2 runs Linux in a VM
+// whose localhost is forwarded to Windows, so a port free inside Linux may still be
+// bound by a Windows process. (Under WSL1 both share one network stack and a bind
+// check already sees Windows listeners.)
+func IsWSL2() bool {
+	wsl.Lock()
+	defer wsl.Unlock()
+	if !wsl.detected {
+		wsl.detected = true
+		data, err := os.ReadFile(osReleasePath)
+		wsl.isWSL2 = err == nil && strings.Contains(strings.ToLower(string(data)), "microsoft-standard")
+		debug.Printf("port", "WSL2: %v", wsl.isWSL2)
+	}
+	return wsl.isWSL2
+}
+
+// windowsPortBusy reports whether a Windows process listens on port. Outside WSL2,
+// or when Windows cannot be queried, it reports false.
+func windowsPortBusy(port int) bool {
+	if !IsWSL2() {
+		return false
+	}
+	wsl.Lock()
+	defer wsl.Unlock()
+	if wsl.ports == nil || time.Since(wsl.readAt) > windowsCacheTTL {
+		wsl.ports = readWindowsListeners()
+		wsl.readAt = time.Now()
+	}
+	return wsl.ports[port]
+}
+
+// readWindowsListeners runs the first working windowsCommands entry and returns
+// the ports Windows listens on (empty if none works).
+func readWindowsListeners() map[int]bool {
+	for _, argv := range windowsCommands {
+		ctx, cancel := context.WithTimeout(context.Background(), windowsQueryTimeout)
+		out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).Output()
+		cancel()
+		if err != nil {
+			debug.Printf("port", "cannot read Windows ports with %s: %v", argv[0], err)
+			continue
+		}
+		ports := parseWindowsListeners(string(out))
+		debug.Printf("port", "Windows listens on %d port(s) (%s)", len(ports), argv[0])
+		return ports
+	}
+	return map[int]bool{}
+}
+
+// parseWindowsListeners extracts listening TCP ports from `netstat -ano` output
+// (lines with a foreign address of port 0, which does not depend on the localized
+// state column) or from a list of ports, one per line, as printed by PowerShell.
+func parseWindowsListeners(out string) map[int]bool {
+	ports := make(map[int]bool)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			if p, err := strconv.Atoi(fields[0]); err == nil && p > 0 && p <= 65535 {
+				ports[p] = true
+			}
+		case len(fields) >= 4 && strings.EqualFold(fields[0], "TCP") && strings.HasSuffix(fields[2], ":0"):
+			local := fields[1]
+			if p, err := strconv.Atoi(local[strings.LastIndexByte(local, ':')+1:]); err == nil {
+				ports[p] = true
+			}
+		}
+	}
+	return ports
+}
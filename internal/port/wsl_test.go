@@ -0,0 +1,81 @@
+package port
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParseWindowsListeners(t *testing.T) {
+	netstat := `
+Active Connections
+
+  Proto  Local Address          Foreign Address        State           PID
+  TCP    0.0.0.0:135            0.0.0.0:0              LISTENING       1052
+  TCP    127.0.0.1:3000         0.0.0.0:0              ABHÖREN         4242
+  TCP    127.0.0.1:3000         127.0.0.1:51234        ESTABLISHED     4242
+  TCP    [::]:8080              [::]:0                 LISTENING       5000
+  UDP    0.0.0.0:5353           *:*                                    2120
+`
+	got := parseWindowsListeners(netstat)
+	for _, p := range []int{135, 3000, 8080} {
+		if !got[p] {
+			t.Errorf("netstat port %d not found in %v", p, got)
+		}
+	}
+	if got[51234] || got[5353] || len(got) != 3 {
+		t.Errorf("parseWindowsListeners(netstat) = %v, want 135, 3000 and 8080", got)
+	}
+
+	got = parseWindowsListeners("3000\r\n8080\r\n\r\n")
+	if !got[3000] || !got[8080] || len(got) != 2 {
+		t.Errorf("parseWindowsListeners(powershell) = %v, want 3000 and 8080", got)
+	}
+}
+
+// setWSL points WSL detection at a fake kernel release and Windows command.
+func setWSL(t *testing.T, release, windowsOutput string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "osrelease")
+	if err := os.WriteFile(path, []byte(release), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldPath, oldCommands := osReleasePath, windowsCommands
+	osReleasePath = path
+	windowsCommands = [][]string{{"sh", "-c", "printf '" + windowsOutput + "'"}}
+	reset := func() {
+		wsl.detected, wsl.isWSL2, wsl.ports = false, false, nil
+	}
+	reset()
+	t.Cleanup(func() {
+		osReleasePath, windowsCommands = oldPath, oldCommands
+		reset()
+	})
+}
+
+func TestIsPortFree_WindowsListener(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	setWSL(t, "5.15.153.1-microsoft-standard-WSL2\n", strconv.Itoa(p)+"\\n")
+	if !IsWSL2() {
+		t.Fatal("IsWSL2() = false for a WSL2 kernel")
+	}
+	if IsPortFree(p) {
+		t.Errorf("IsPortFree(%d) = true, want false while Windows listens on it", p)
+	}
+
+	setWSL(t, "6.8.0-45-generic\n", strconv.Itoa(p)+"\\n")
+	if IsWSL2() {
+		t.Error("IsWSL2() = true for a regular kernel")
+	}
+	if !IsPortFree(p) {
+		t.Errorf("IsPortFree(%d) = false outside WSL2", p)
+	}
+}
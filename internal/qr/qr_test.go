@@ -0,0 +1,160 @@
+package qr
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, from the ISO/IEC 18004 walkthrough at thonky.com
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomon() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	want := []string{
+		"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+		"100010111111001", "100000011001110", "100111110010111", "100101010100000",
+	}
+	for mask, w := range want {
+		if got := strconv.FormatInt(int64(formatBits(mask)), 2); got != w {
+			t.Errorf("formatBits(%d) = %s, want %s", mask, got, w)
+		}
+	}
+}
+
+// decode reads the text back from c, undoing the mask, the zigzag placement and
+// the block interleaving.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	version := (c.Size - 17) / 4
+
+	var format int
+	for i := 0; i <= 5; i++ {
+		if c.modules[i][8] {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m)&0x3F == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("no mask matches format bits %06b", format)
+	}
+	c.applyMask(mask)
+	defer c.applyMask(mask)
+
+	var bits []bool
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !c.function[y][x] {
+					bits = append(bits, c.modules[y][x])
+				}
+			}
+		}
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i := range codewords {
+		for j := 0; j < 8; j++ {
+			if bits[i*8+j] {
+				codewords[i] |= 1 << (7 - j)
+			}
+		}
+	}
+
+	l := layouts[version]
+	blocks := make([][]byte, l.count1+l.count2)
+	k := 0
+	for i := 0; i <= l.data1; i++ {
+		for b := range blocks {
+			if i < l.data1 || b >= l.count1 {
+				blocks[b] = append(blocks[b], codewords[k])
+				k++
+			}
+		}
+	}
+	for b, block := range blocks {
+		for i := 0; i < l.ecPerBlock; i++ {
+			if ec := reedSolomon(block, l.ecPerBlock); codewords[k+i*len(blocks)+b] != ec[i] {
+				t.Fatalf("block %d: error correction codeword %d does not match", b, i)
+			}
+		}
+	}
+	data := bytes.Join(blocks, nil)
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode = %x, want byte mode", data[0]>>4)
+	}
+	// Skip the 4-bit mode; the count and the bytes are shifted by half a byte
+	shifted := make([]byte, len(data)-1)
+	for i := range shifted {
+		shifted[i] = data[i]<<4 | data[i+1]>>4
+	}
+	if countBits(version) == 16 {
+		n := int(shifted[0])<<8 | int(shifted[1])
+		return string(shifted[2 : 2+n])
+	}
+	return string(shifted[1 : 1+int(shifted[0])])
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		size int
+	}{
+		{"http://192.168.1.20:3000", 25},                               // version 2
+		{"http://192.168.100.200:3000/" + strings.Repeat("a", 80), 45}, // version 7, with version information
+		{strings.Repeat("x", 213), 57},                                 // version 10, 16-bit count
+	} {
+		c, err := Encode(tc.text)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes) error = %v", len(tc.text), err)
+		}
+		if c.Size != tc.size {
+			t.Errorf("Encode(%d bytes) size = %d, want %d", len(tc.text), c.Size, tc.size)
+		}
+		for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+			if !c.Dark(corner[0], corner[1]) || !c.Dark(corner[0]+3, corner[1]+3) || c.Dark(corner[0]+1, corner[1]+1) {
+				t.Errorf("no finder pattern at %v", corner)
+			}
+		}
+		if got := decode(t, c); got != tc.text {
+			t.Errorf("decoded %q, want %q", got, tc.text)
+		}
+	}
+
+	if _, err := Encode(strings.Repeat("x", 214)); err != ErrTooLong {
+		t.Errorf("Encode(214 bytes) error = %v, want ErrTooLong", err)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	c, err := Encode("http://192.168.1.20:3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(2), "\n"), "\n")
+	if len(lines) != (c.Size+4+1)/2 {
+		t.Errorf("Terminal(2) has %d lines, want %d", len(lines), (c.Size+4+1)/2)
+	}
+	if !strings.Contains(lines[1], "█") {
+		t.Errorf("second line %q has no full block of the finder pattern", lines[1])
+	}
+}
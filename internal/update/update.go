@@ -0,0 +1,145 @@
+// Package update checks GitHub releases for a newer port-selector version.
+// Results are cached in the config directory so the network is hit at most once per interval.
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapi/port-selector/internal/debug"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	cacheFileName = "update-check.yaml"
+
+	// Interval is the minimum time between two release checks.
+	Interval = 24 * time.Hour
+
+	requestTimeout = 5 * time.Second
+)
+
+// latestURL is the GitHub API endpoint for the latest release (replaced in tests).
+var latestURL = "https://api.github.com/repos/dapi/port-selector/releases/latest"
+
+// cache is the content of update-check.yaml.
+type cache struct {
+	CheckedAt time.Time `yaml:"checked_at"`
+	Latest    string    `yaml:"latest,omitempty"`
+}
+
+func cachePath(configDir string) string {
+	return filepath.Join(configDir, cacheFileName)
+}
+
+// readCache returns the cached check result; a missing or broken cache reads as empty.
+func readCache(configDir string) cache {
+	var c cache
+	data, err := os.ReadFile(cachePath(configDir))
+	if err != nil {
+		return c
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		debug.Printf("update", "ignoring broken cache: %v", err)
+		return cache{}
+	}
+	return c
+}
+
+func writeCache(configDir string, c cache) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath(configDir), data, 0644)
+}
+
+// Notice returns a one-line notice if the cached latest release is newer than
+// current, or an empty string. It never touches the network.
+func Notice(configDir, current string) string {
+	latest := readCache(configDir).Latest
+	if latest == "" || !Newer(latest, current) {
+		return ""
+	}
+	return fmt.Sprintf("port-selector: new version %s available (you have %s)", latest, current)
+}
+
+// Due reports whether the last check is older than Interval.
+func Due(configDir string, now time.Time) bool {
+	return now.Sub(readCache(configDir).CheckedAt) >= Interval
+}
+
+// MarkChecked records a check attempt at now, keeping the known latest version.
+// Marking before fetching keeps failed or slow checks rate-limited too.
+func MarkChecked(configDir string, now time.Time) error {
+	c := readCache(configDir)
+	c.CheckedAt = now.UTC()
+	return writeCache(configDir, c)
+}
+
+// Refresh fetches the latest release tag and stores it in the cache.
+func Refresh(configDir string) error {
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Get(latestURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch latest release: %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return fmt.Errorf("failed to parse latest release: %w", err)
+	}
+	if release.TagName == "" {
+		return fmt.Errorf("latest release has no tag")
+	}
+
+	debug.Printf("update", "latest release is %s", release.TagName)
+	return writeCache(configDir, cache{CheckedAt: time.Now().UTC(), Latest: release.TagName})
+}
+
+// Newer reports whether version a is newer than b. Versions look like v1.2.3;
+// a missing "v" and pre-release suffixes (-rc1) are ignored. Unparseable versions are never newer.
+func Newer(a, b string) bool {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] > pb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion splits "v1.2.3" into its numeric parts.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
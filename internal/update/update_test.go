@@ -0,0 +1,74 @@
+package update
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"v0.11.0", "v0.10.0", true},
+		{"v0.10.1", "v0.10.0", true},
+		{"v1.0.0", "0.99.99", true},
+		{"v0.10.0", "v0.10.0", false},
+		{"v0.9.0", "v0.10.0", false},
+		{"v0.11.0-rc1", "v0.10.0", true},
+		{"v0.11.0", "dev", false},
+		{"garbage", "v0.10.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDueAndMarkChecked(t *testing.T) {
+	configDir := t.TempDir()
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	if !Due(configDir, now) {
+		t.Error("expected check to be due without a cache")
+	}
+	if err := MarkChecked(configDir, now); err != nil {
+		t.Fatal(err)
+	}
+	if Due(configDir, now.Add(time.Hour)) {
+		t.Error("expected no check one hour later")
+	}
+	if !Due(configDir, now.Add(Interval)) {
+		t.Error("expected check to be due after the interval")
+	}
+}
+
+func TestRefreshAndNotice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v0.12.0", "name": "v0.12.0"}`))
+	}))
+	defer server.Close()
+
+	oldURL := latestURL
+	latestURL = server.URL
+	t.Cleanup(func() { latestURL = oldURL })
+
+	configDir := t.TempDir()
+	if notice := Notice(configDir, "v0.10.0"); notice != "" {
+		t.Errorf("expected no notice before a check, got %q", notice)
+	}
+
+	if err := Refresh(configDir); err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if notice := Notice(configDir, "v0.10.0"); !strings.Contains(notice, "new version v0.12.0 available") {
+		t.Errorf("unexpected notice: %q", notice)
+	}
+	if notice := Notice(configDir, "v0.12.0"); notice != "" {
+		t.Errorf("expected no notice when up to date, got %q", notice)
+	}
+}
@@ -0,0 +1,280 @@
+// Command packaging generates package manager metadata from release artifacts:
+// a Homebrew formula, a Scoop manifest and nfpm configs for .deb/.rpm packages.
+//
+// Usage (after `make release-snapshot` or a goreleaser release):
+//
+//	go run ./scripts/packaging -version v0.11.0 -dist dist -out dist/packaging
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	binaryName  = "port-selector"
+	repo        = "dapi/port-selector"
+	homepage    = "https://github.com/" + repo
+	description = "Port allocator for parallel local dev environments"
+	license     = "MIT"
+)
+
+// platforms lists the release targets (see builds.targets in .goreleaser.yml).
+var platforms = []string{"linux-amd64", "linux-arm64", "darwin-amd64", "darwin-arm64", "windows-amd64"}
+
+// release describes the artifacts of one version.
+type release struct {
+	Version    string            // without the leading "v"
+	Tag        string            // with the leading "v"
+	Dist       string            // directory with the built binaries
+	Maintainer string            // package maintainer for deb/rpm ("Name <email>")
+	Checksums  map[string]string // artifact name -> sha256
+}
+
+// artifact returns the file name of the binary for a platform ("linux-amd64").
+func artifact(platform string) string {
+	return binaryName + "-" + platform
+}
+
+// URL returns the download URL of the binary for a platform.
+func (r release) URL(platform string) string {
+	return fmt.Sprintf("%s/releases/download/%s/%s", homepage, r.Tag, artifact(platform))
+}
+
+// SHA256 returns the checksum of the binary for a platform.
+func (r release) SHA256(platform string) string {
+	return r.Checksums[artifact(platform)]
+}
+
+// Binary returns the local path of the binary for a platform.
+func (r release) Binary(platform string) string {
+	return filepath.Join(r.Dist, artifact(platform))
+}
+
+// readChecksums reads goreleaser's checksums.txt ("<sha256>  <name>" per line).
+// Artifacts missing from the file are hashed directly.
+func readChecksums(dist string) (map[string]string, error) {
+	sums := make(map[string]string)
+	if f, err := os.Open(filepath.Join(dist, "checksums.txt")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 {
+				sums[fields[1]] = fields[0]
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, p := range platforms {
+		name := artifact(p)
+		if sums[name] != "" {
+			continue
+		}
+		sum, err := hashFile(filepath.Join(dist, name))
+		if err != nil {
+			return nil, fmt.Errorf("missing artifact %s: %w", name, err)
+		}
+		sums[name] = sum
+	}
+	return sums, nil
+}
+
+// hashFile returns the hex sha256 of a file.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var funcs = template.FuncMap{
+	"binaryName":  func() string { return binaryName },
+	"homepage":    func() string { return homepage },
+	"description": func() string { return description },
+	"license":     func() string { return license },
+}
+
+var homebrewTemplate = template.Must(template.New("formula").Funcs(funcs).Parse(`class PortSelector < Formula
+  desc "{{description}}"
+  homepage "{{homepage}}"
+  version "{{.Version}}"
+  license "{{license}}"
+
+  on_macos do
+    on_arm do
+      url "{{.URL "darwin-arm64"}}"
+      sha256 "{{.SHA256 "darwin-arm64"}}"
+    end
+    on_intel do
+      url "{{.URL "darwin-amd64"}}"
+      sha256 "{{.SHA256 "darwin-amd64"}}"
+    end
+  end
+
+  on_linux do
+    on_arm do
+      url "{{.URL "linux-arm64"}}"
+      sha256 "{{.SHA256 "linux-arm64"}}"
+    end
+    on_intel do
+      url "{{.URL "linux-amd64"}}"
+      sha256 "{{.SHA256 "linux-amd64"}}"
+    end
+  end
+
+  def install
+    bin.install Dir["{{binaryName}}-*"].first => "{{binaryName}}"
+  end
+
+  test do
+    assert_match version.to_s, shell_output("#{bin}/{{binaryName}} --version")
+  end
+end
+`))
+
+// The "#/name.exe" URL fragment makes Scoop save the download under that name.
+var scoopTemplate = template.Must(template.New("scoop").Funcs(funcs).Parse(`{
+  "version": "{{.Version}}",
+  "description": "{{description}}",
+  "homepage": "{{homepage}}",
+  "license": "{{license}}",
+  "architecture": {
+    "64bit": {
+      "url": "{{.URL "windows-amd64"}}#/{{binaryName}}.exe",
+      "hash": "{{.SHA256 "windows-amd64"}}"
+    }
+  },
+  "bin": "{{binaryName}}.exe",
+  "checkver": "github",
+  "autoupdate": {
+    "architecture": {
+      "64bit": {
+        "url": "{{homepage}}/releases/download/v$version/{{binaryName}}-windows-amd64#/{{binaryName}}.exe"
+      }
+    }
+  }
+}
+`))
+
+var nfpmTemplate = template.Must(template.New("nfpm").Funcs(funcs).Parse(`# nfpm config for {{binaryName}} {{.Release.Version}} ({{.Arch}})
+# Build packages with:
+#   nfpm pkg --packager deb --config {{.File}} --target {{.Release.Dist}}
+#   nfpm pkg --packager rpm --config {{.File}} --target {{.Release.Dist}}
+name: {{binaryName}}
+arch: {{.Arch}}
+platform: linux
+version: {{.Release.Version}}
+section: utils
+priority: optional
+maintainer: {{.Release.Maintainer}}
+description: {{description}}
+homepage: {{homepage}}
+license: {{license}}
+contents:
+  - src: {{.Release.Binary .Platform}}
+    dst: /usr/bin/{{binaryName}}
+    file_info:
+      mode: 0755
+`))
+
+// generate writes all metadata files into out and returns their paths.
+func generate(r release, out string) ([]string, error) {
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	write := func(name string, tmpl *template.Template, data any) error {
+		path := filepath.Join(out, name)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(f, data); err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		written = append(written, path)
+		return nil
+	}
+
+	if err := write(binaryName+".rb", homebrewTemplate, r); err != nil {
+		return nil, err
+	}
+	if err := write(binaryName+".json", scoopTemplate, r); err != nil {
+		return nil, err
+	}
+	for _, arch := range []string{"amd64", "arm64"} {
+		name := "nfpm-" + arch + ".yaml"
+		data := struct {
+			Release  release
+			Arch     string
+			Platform string
+			File     string
+		}{r, arch, "linux-" + arch, filepath.Join(out, name)}
+		if err := write(name, nfpmTemplate, data); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+func main() {
+	version := flag.String("version", "", "release version (e.g. v0.11.0)")
+	dist := flag.String("dist", "dist", "directory with the built binaries and checksums.txt")
+	maintainer := flag.String("maintainer", "Danil Pismenny", "deb/rpm package maintainer (\"Name <email>\")")
+	out := flag.String("out", filepath.Join("dist", "packaging"), "output directory")
+	flag.Parse()
+
+	if *version == "" {
+		fmt.Fprintln(os.Stderr, "error: -version is required")
+		os.Exit(2)
+	}
+
+	sums, err := readChecksums(*dist)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	r := release{
+		Version:    strings.TrimPrefix(*version, "v"),
+		Tag:        "v" + strings.TrimPrefix(*version, "v"),
+		Dist:       *dist,
+		Maintainer: *maintainer,
+		Checksums:  sums,
+	}
+	files, err := generate(r, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, f := range files {
+		fmt.Println(f)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerate(t *testing.T) {
+	dist := t.TempDir()
+	for _, p := range platforms {
+		if err := os.WriteFile(filepath.Join(dist, artifact(p)), []byte("binary "+p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// checksums.txt wins over hashing; missing entries are hashed
+	checksums := "1111111111111111111111111111111111111111111111111111111111111111  port-selector-darwin-arm64\n"
+	if err := os.WriteFile(filepath.Join(dist, "checksums.txt"), []byte(checksums), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sums, err := readChecksums(dist)
+	if err != nil {
+		t.Fatalf("readChecksums() error: %v", err)
+	}
+	if len(sums) != len(platforms) {
+		t.Fatalf("expected %d checksums, got %d", len(platforms), len(sums))
+	}
+
+	r := release{Version: "0.11.0", Tag: "v0.11.0", Dist: dist, Maintainer: "Jane Doe <jane@example.com>", Checksums: sums}
+	out := filepath.Join(dist, "packaging")
+	files, err := generate(r, out)
+	if err != nil {
+		t.Fatalf("generate() error: %v", err)
+	}
+	if len(files) != 4 {
+		t.Errorf("expected 4 files, got %v", files)
+	}
+
+	formula, err := os.ReadFile(filepath.Join(out, "port-selector.rb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`version "0.11.0"`,
+		"https://github.com/dapi/port-selector/releases/download/v0.11.0/port-selector-darwin-arm64",
+		`sha256 "1111111111111111111111111111111111111111111111111111111111111111"`,
+		`sha256 "` + sums["port-selector-linux-amd64"] + `"`,
+	} {
+		if !strings.Contains(string(formula), want) {
+			t.Errorf("formula doesn't contain %q:\n%s", want, formula)
+		}
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(out, "port-selector.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scoop struct {
+		Version      string
+		Architecture map[string]struct{ URL, Hash string }
+	}
+	if err := json.Unmarshal(manifest, &scoop); err != nil {
+		t.Fatalf("invalid Scoop manifest: %v\n%s", err, manifest)
+	}
+	if scoop.Version != "0.11.0" || scoop.Architecture["64bit"].Hash != sums["port-selector-windows-amd64"] {
+		t.Errorf("unexpected Scoop manifest: %+v", scoop)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "nfpm-arm64.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nfpm struct {
+		Arch       string
+		Version    string
+		Maintainer string
+		Contents   []struct{ Src, Dst string }
+	}
+	if err := yaml.Unmarshal(data, &nfpm); err != nil {
+		t.Fatalf("invalid nfpm config: %v\n%s", err, data)
+	}
+	if nfpm.Arch != "arm64" || nfpm.Version != "0.11.0" || nfpm.Maintainer != "Jane Doe <jane@example.com>" {
+		t.Errorf("unexpected nfpm config: %+v", nfpm)
+	}
+	if len(nfpm.Contents) != 1 || nfpm.Contents[0].Src != filepath.Join(dist, "port-selector-linux-arm64") || nfpm.Contents[0].Dst != "/usr/bin/port-selector" {
+		t.Errorf("unexpected nfpm contents: %+v", nfpm.Contents)
+	}
+}
+
+func TestReadChecksums_MissingArtifact(t *testing.T) {
+	if _, err := readChecksums(t.TempDir()); err == nil {
+		t.Error("expected error for missing artifacts")
+	}
+}